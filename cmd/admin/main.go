@@ -11,7 +11,10 @@ import (
 	"time"
 	"fmt"
 	"log" // https://golang.org/pkg/log/
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strconv"
 
 	// Third-party packages
 	"github.com/pkg/errors"
@@ -21,7 +24,10 @@ import (
 	"github.com/deezone/HydroBytes-BaseStation/internal/platform/auth"
 	"github.com/deezone/HydroBytes-BaseStation/internal/platform/conf"
 	"github.com/deezone/HydroBytes-BaseStation/internal/platform/database"
+	"github.com/deezone/HydroBytes-BaseStation/internal/report"
 	"github.com/deezone/HydroBytes-BaseStation/internal/schema"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_log"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
 )
 
 // Main entry point for program.
@@ -87,6 +93,14 @@ func run() error {
 		err = keygen(cfg.Args.Num(1))
 	case "migrate":
 		err = migrate(dbConfig)
+	case "purge":
+		// days
+		err = purge(dbConfig, cfg.Args.Num(1))
+	case "prune-logs":
+		err = pruneLogs(dbConfig)
+	case "report":
+		// file, week
+		err = weeklyReport(dbConfig, cfg.Args.Num(1), cfg.Args.Num(2))
 	case "seed":
 		err = seed(dbConfig)
 	default:
@@ -206,3 +220,98 @@ func seed(cfg database.Config) error {
 	fmt.Println("Seed data complete")
 	return nil
 }
+
+// purge permanently removes stations, station types and accounts that have
+// been archived for longer than the given number of days.
+func purge(cfg database.Config, days string) error {
+	n, err := strconv.Atoi(days)
+	if err != nil || n < 0 {
+		return errors.New("purge command must be called with the number of days archived records are kept")
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	before := time.Now().AddDate(0, 0, -n)
+
+	stations, types, err := station_type.Purge(context.Background(), db, before, time.Now())
+	if err != nil {
+		return err
+	}
+
+	accounts, err := account.Purge(context.Background(), db, before, time.Now())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Purged %d stations, %d station types and %d accounts archived before %s\n", stations, types, accounts, before.Format(time.RFC3339))
+	return nil
+}
+
+// pruneLogs removes station log lines older than the log retention of their
+// station.
+func pruneLogs(cfg database.Config) error {
+	db, err := database.Open(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	n, err := station_log.Prune(context.Background(), db, time.Now())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Pruned %d station log lines\n", n)
+	return nil
+}
+
+// weeklyReport writes the report of an ISO 8601 week such as 2021-W23, or of
+// last week when no week is given, to a file. Files ending in .md get the
+// Markdown report and any other file the HTML report.
+func weeklyReport(cfg database.Config, path, week string) error {
+	if path == "" {
+		return errors.New("report command must be called with the file to write the report to")
+	}
+
+	now := time.Now()
+
+	from := report.LastWeek(now)
+	if week != "" {
+		var err error
+		if from, err = report.ParseWeek(week); err != nil {
+			return err
+		}
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	w, err := report.Generate(context.Background(), db, from, now)
+	if err != nil {
+		return err
+	}
+
+	var content []byte
+	if filepath.Ext(path) == ".md" {
+		content, err = w.Markdown()
+	} else {
+		content, err = w.HTML()
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		return errors.Wrap(err, "writing report")
+	}
+
+	fmt.Printf("Report of week %s written to %s\n", w.Week, path)
+	return nil
+}
@@ -4,13 +4,17 @@ import (
 	// Core packages
 	"context"
 	"net/http"
+	"strconv"
+	"time"
 
 	// Internal packages
 	"github.com/deezone/HydroBytes-BaseStation/internal/platform/auth"
 	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
 	"github.com/deezone/HydroBytes-BaseStation/internal/account"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
 
 	// Third-party packages
+	"github.com/go-chi/chi"
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 	"go.opencensus.io/trace"
@@ -61,3 +65,68 @@ func (a *Account) Token(ctx context.Context, w http.ResponseWriter, r *http.Requ
 
 	return web.Respond(ctx, w, tkn, http.StatusOK)
 }
+
+// Delete removes an account identified by an ID in the request URL. An account
+// that still owns stations is refused with a 409 listing the stations unless
+// the request has one of the query parameters:
+//
+//   transfer_to={id}   transfer the stations to another account first
+//   cascade=true       archive the account and its stations instead
+func (a *Account) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Account.Delete")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	var d account.DeleteAccount
+	d.TransferTo = r.URL.Query().Get("transfer_to")
+	if v := r.URL.Query().Get("cascade"); v != "" {
+		var err error
+		if d.Cascade, err = strconv.ParseBool(v); err != nil {
+			return web.NewRequestError(errors.Wrap(err, "cascade must be true or false"), http.StatusBadRequest)
+		}
+	}
+
+	if err := account.Delete(ctx, a.db, id, d, time.Now()); err != nil {
+		switch err {
+		case account.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case account.ErrInvalidID, account.ErrTransferNotFound, account.ErrTransferToSelf:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		case account.ErrHasStations:
+			stations, err := station_type.ListAccountStations(ctx, a.db, id)
+			if err != nil {
+				return errors.Wrapf(err, "getting stations of account %q", id)
+			}
+			return respondStationsConflict(ctx, w, account.ErrHasStations, stations)
+		default:
+			return errors.Wrapf(err, "deleting account %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Restore brings back an archived account identified by an ID in the request
+// URL along with the stations archived with it.
+func (a *Account) Restore(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Account.Restore")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := account.Restore(ctx, a.db, id, time.Now()); err != nil {
+		switch err {
+		case account.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case account.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "restoring account %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
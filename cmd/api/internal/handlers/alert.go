@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	// Core packages
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/alert"
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/auth"
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
+
+	// Third party packages
+	"github.com/go-chi/chi"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Alert holds handlers for alert rules and the alerts they raise.
+type Alert struct {
+	db  *sqlx.DB
+	log *log.Logger
+}
+
+// ListRules gets all alert rules.
+func (a *Alert) ListRules(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Alert.ListRules")
+	defer span.End()
+
+	list, err := alert.ListRules(ctx, a.db)
+	if err != nil {
+		return errors.Wrap(err, "getting alert rule list")
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// RetrieveRule gets the alert rule identified by an ID in the request URL.
+func (a *Alert) RetrieveRule(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Alert.RetrieveRule")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	rule, err := alert.GetRule(ctx, a.db, id)
+	if err != nil {
+		switch err {
+		case alert.ErrRuleNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case alert.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting alert rule %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, rule, http.StatusOK)
+}
+
+// CreateRule decodes the body of a request to create a new alert rule.
+func (a *Alert) CreateRule(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Alert.CreateRule")
+	defer span.End()
+
+	var nr alert.NewRule
+	if err := web.Decode(r, &nr); err != nil {
+		return errors.Wrap(err, "decoding new alert rule")
+	}
+
+	rule, err := alert.CreateRule(ctx, a.db, nr, time.Now())
+	if err != nil {
+		switch err {
+		case alert.ErrInvalidRule, alert.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "creating alert rule: %+v", nr)
+		}
+	}
+
+	return web.Respond(ctx, w, rule, http.StatusCreated)
+}
+
+// DeleteRule removes the alert rule identified by an ID in the request URL
+// along with its alerts.
+func (a *Alert) DeleteRule(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Alert.DeleteRule")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := alert.DeleteRule(ctx, a.db, id); err != nil {
+		switch err {
+		case alert.ErrRuleNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case alert.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "deleting alert rule %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// List gets the pending and firing alerts, or the alerts with the status
+// query parameter. They may be limited to a rule or station with the rule_id
+// and station_id query parameters.
+func (a *Alert) List(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Alert.List")
+	defer span.End()
+
+	query := r.URL.Query()
+
+	filter := alert.Filter{
+		Status:    query.Get("status"),
+		RuleId:    query.Get("rule_id"),
+		StationId: query.Get("station_id"),
+	}
+
+	list, err := alert.List(ctx, a.db, filter)
+	if err != nil {
+		switch err {
+		case alert.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrap(err, "getting alert list")
+		}
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// Retrieve gets the alert identified by an ID in the request URL.
+func (a *Alert) Retrieve(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Alert.Retrieve")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	al, err := alert.Get(ctx, a.db, id)
+	if err != nil {
+		switch err {
+		case alert.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case alert.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting alert %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, al, http.StatusOK)
+}
+
+// Acknowledge marks the alert identified by an ID in the request URL as being
+// looked into by the account making the request.
+func (a *Alert) Acknowledge(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Alert.Acknowledge")
+	defer span.End()
+
+	claims, ok := ctx.Value(auth.Key).(auth.Claims)
+	if !ok {
+		return errors.New("claims missing from context")
+	}
+
+	id := chi.URLParam(r, "id")
+
+	al, err := alert.Acknowledge(ctx, a.db, id, claims.Subject, time.Now())
+	if err != nil {
+		switch err {
+		case alert.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case alert.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		case alert.ErrResolved:
+			return web.NewRequestError(err, http.StatusConflict)
+		default:
+			return errors.Wrapf(err, "acknowledging alert %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, al, http.StatusOK)
+}
+
+// Silence stops events for the alert identified by an ID in the request URL
+// until the time given in the request body.
+func (a *Alert) Silence(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Alert.Silence")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	var ns alert.NewSilence
+	if err := web.Decode(r, &ns); err != nil {
+		return errors.Wrap(err, "decoding silence")
+	}
+
+	al, err := alert.Silence(ctx, a.db, id, ns, time.Now())
+	if err != nil {
+		switch err {
+		case alert.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case alert.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		case alert.ErrResolved:
+			return web.NewRequestError(err, http.StatusConflict)
+		default:
+			return errors.Wrapf(err, "silencing alert %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, al, http.StatusOK)
+}
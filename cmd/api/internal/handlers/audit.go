@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	// Core packages
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/audit"
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
+
+	// Third party packages
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Audit holds handlers for the audit trail of changes to the garden.
+type Audit struct {
+	db  *sqlx.DB
+	log *log.Logger
+}
+
+// List gets audit entries with the optional query parameters:
+//
+//   actor_id=          changes made by the account
+//   action=station.    changes of the action, or of every action with the prefix
+//   target_id=         changes to the record
+//   since=, until=     changes made within the RFC 3339 times
+//   limit=n            at most n entries, most recent first
+func (a *Audit) List(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Audit.List")
+	defer span.End()
+
+	query := r.URL.Query()
+
+	filter := audit.Filter{
+		ActorId:  query.Get("actor_id"),
+		Action:   query.Get("action"),
+		TargetId: query.Get("target_id"),
+	}
+
+	for name, t := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := query.Get(name); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return web.NewRequestError(errors.Wrapf(err, "%s must be an RFC 3339 time", name), http.StatusBadRequest)
+			}
+			*t = &parsed
+		}
+	}
+
+	if v := query.Get("limit"); v != "" {
+		var err error
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			return web.NewRequestError(errors.Wrap(err, "limit must be a number"), http.StatusBadRequest)
+		}
+	}
+
+	list, err := audit.List(ctx, a.db, filter)
+	if err != nil {
+		switch err {
+		case audit.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrap(err, "getting audit entries")
+		}
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	// Core packages
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/command"
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/auth"
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
+
+	// Third party packages
+	"github.com/go-chi/chi"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Command holds handlers for commands queued for stations.
+type Command struct {
+	db  *sqlx.DB
+	log *log.Logger
+}
+
+// List gets commands, optionally limited by the station_id and status query
+// parameters and to those scheduled between the RFC 3339 times from and to.
+func (c *Command) List(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Command.List")
+	defer span.End()
+
+	query := r.URL.Query()
+
+	filter := command.Filter{
+		StationId: query.Get("station_id"),
+		Status:    query.Get("status"),
+	}
+	for name, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := query.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return web.NewRequestError(errors.Wrapf(err, "%s must be an RFC 3339 time", name), http.StatusBadRequest)
+			}
+			*dst = &t
+		}
+	}
+
+	list, err := command.List(ctx, c.db, filter)
+	if err != nil {
+		switch err {
+		case command.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrap(err, "getting command list")
+		}
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// Retrieve gets the command identified by an ID in the request URL, including
+// the litres its flow meter measured while it ran.
+func (c *Command) Retrieve(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Command.Retrieve")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	cmd, err := command.Get(ctx, c.db, id)
+	if err != nil {
+		switch err {
+		case command.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case command.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting command %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, cmd, http.StatusOK)
+}
+
+// Create queues a command for the station identified by an ID in the request
+// URL.
+func (c *Command) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Command.Create")
+	defer span.End()
+
+	claims, ok := ctx.Value(auth.Key).(auth.Claims)
+	if !ok {
+		return errors.New("claims missing from context")
+	}
+
+	id := chi.URLParam(r, "id")
+
+	var nc command.NewCommand
+	if err := web.Decode(r, &nc); err != nil {
+		return errors.Wrap(err, "decoding new command")
+	}
+
+	cmd, err := command.Create(ctx, c.db, id, nc, &claims.Subject, time.Now())
+	if err != nil {
+		switch err {
+		case command.ErrStationNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case command.ErrInvalidID, command.ErrMeterNotFound:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "creating command for station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, cmd, http.StatusCreated)
+}
+
+// CreateForStations queues the same command for every station matching the
+// tag and group query parameters, as used by the station list.
+func (c *Command) CreateForStations(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Command.CreateForStations")
+	defer span.End()
+
+	claims, ok := ctx.Value(auth.Key).(auth.Claims)
+	if !ok {
+		return errors.New("claims missing from context")
+	}
+
+	filter, err := parseStationFilter(r)
+	if err != nil {
+		return web.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	var nc command.NewCommand
+	if err := web.Decode(r, &nc); err != nil {
+		return errors.Wrap(err, "decoding new command")
+	}
+
+	cmds, err := command.CreateForStations(ctx, c.db, filter, nc, &claims.Subject, time.Now())
+	if err != nil {
+		switch err {
+		case command.ErrInvalidID, command.ErrNoTarget, command.ErrMeterForTarget:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrap(err, "creating commands for stations")
+		}
+	}
+
+	return web.Respond(ctx, w, cmds, http.StatusCreated)
+}
+
+// Due gets the queued commands the station identified by an ID in the request
+// URL should run now.
+func (c *Command) Due(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Command.Due")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := checkStationAccount(ctx, c.db, id); err != nil {
+		return err
+	}
+
+	list, err := command.Due(ctx, c.db, id, time.Now())
+	if err != nil {
+		return errors.Wrapf(err, "getting due commands of station %q", id)
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// Report decodes the progress a station reports on the command identified by
+// an ID in the request URL.
+func (c *Command) Report(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Command.Report")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	cmd, err := command.Get(ctx, c.db, id)
+	if err != nil {
+		switch err {
+		case command.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case command.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting command %q", id)
+		}
+	}
+
+	if err := checkStationAccount(ctx, c.db, cmd.StationId); err != nil {
+		return err
+	}
+
+	var rep command.Report
+	if err := web.Decode(r, &rep); err != nil {
+		return errors.Wrap(err, "decoding command report")
+	}
+
+	cmd, err = command.Update(ctx, c.db, id, rep, time.Now())
+	if err != nil {
+		switch err {
+		case command.ErrInvalidTransition:
+			return web.NewRequestError(err, http.StatusConflict)
+		default:
+			return errors.Wrapf(err, "updating command %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, cmd, http.StatusOK)
+}
+
+// Cancel stops the queued command identified by an ID in the request URL from
+// running.
+func (c *Command) Cancel(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Command.Cancel")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	cmd, err := command.Cancel(ctx, c.db, id, time.Now())
+	if err != nil {
+		switch err {
+		case command.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case command.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		case command.ErrInvalidTransition:
+			return web.NewRequestError(err, http.StatusConflict)
+		default:
+			return errors.Wrapf(err, "cancelling command %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, cmd, http.StatusOK)
+}
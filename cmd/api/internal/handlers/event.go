@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	// Core packages
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/event"
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
+
+	// Third party packages
+	"github.com/go-chi/chi"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Event holds handlers for events raised by the base station.
+type Event struct {
+	db  *sqlx.DB
+	log *log.Logger
+}
+
+// List gets events with the optional query parameters:
+//
+//   type=water.leak    events of the type
+//   severity=warning   events of the severity or more severe
+//   station_id=        events of the station
+//   since=             events raised since the RFC 3339 time
+//   limit=n            at most n events, most recent first
+func (e *Event) List(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Event.List")
+	defer span.End()
+
+	query := r.URL.Query()
+
+	filter := event.Filter{
+		Type:      query.Get("type"),
+		Severity:  query.Get("severity"),
+		StationId: query.Get("station_id"),
+	}
+
+	if v := query.Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return web.NewRequestError(errors.Wrap(err, "since must be an RFC 3339 time"), http.StatusBadRequest)
+		}
+		filter.Since = &since
+	}
+
+	if v := query.Get("limit"); v != "" {
+		var err error
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			return web.NewRequestError(errors.Wrap(err, "limit must be a number"), http.StatusBadRequest)
+		}
+	}
+
+	list, err := event.List(ctx, e.db, filter)
+	if err != nil {
+		switch err {
+		case event.ErrInvalidID, event.ErrInvalidSeverity:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrap(err, "getting event list")
+		}
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// Retrieve gets the event identified by an ID in the request URL.
+func (e *Event) Retrieve(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Event.Retrieve")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	ev, err := event.Get(ctx, e.db, id)
+	if err != nil {
+		switch err {
+		case event.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case event.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting event %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, ev, http.StatusOK)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	// Core packages
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/garden"
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
+	"github.com/deezone/HydroBytes-BaseStation/internal/zone"
+
+	// Third party packages
+	"github.com/go-chi/chi"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Garden holds handlers for views of the whole garden.
+type Garden struct {
+	db  *sqlx.DB
+	log *log.Logger
+}
+
+// Map renders the zones and stations of the garden as an SVG image.
+func (g *Garden) Map(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Garden.Map")
+	defer span.End()
+
+	var m garden.Map
+	var err error
+
+	if m.Zones, err = zone.List(ctx, g.db); err != nil {
+		return errors.Wrap(err, "getting zone list")
+	}
+	if m.StationTypes, err = station_type.List(ctx, g.db, false); err != nil {
+		return errors.Wrap(err, "getting station type list")
+	}
+	if m.Stations, err = station_type.ListAllStations(ctx, g.db, station_type.StationFilter{}); err != nil {
+		return errors.Wrap(err, "getting station list")
+	}
+	if m.Status, err = garden.LoadStatus(ctx, g.db); err != nil {
+		return errors.Wrap(err, "getting station status")
+	}
+	m.Now = time.Now()
+
+	svg, err := m.SVG()
+	if err != nil {
+		return err
+	}
+
+	return web.RespondContent(ctx, w, svg, "image/svg+xml", http.StatusOK)
+}
+
+// Calendar renders the upcoming irrigation runs, planned refills and planting
+// milestones of the garden as an iCalendar feed. Calendar apps can not send a
+// bearer token so the request is authorized by the feed token of an account
+// in the token query parameter. Accounts only see their own stations unless
+// they are admins.
+func (g *Garden) Calendar(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Garden.Calendar")
+	defer span.End()
+
+	accountID, admin, err := garden.FeedAccount(ctx, g.db, r.URL.Query().Get("token"))
+	if err != nil {
+		switch err {
+		case garden.ErrInvalidToken:
+			return web.NewRequestError(err, http.StatusUnauthorized)
+		default:
+			return errors.Wrap(err, "checking feed token")
+		}
+	}
+
+	now := time.Now()
+
+	c, err := garden.LoadCalendar(ctx, g.db, accountID, admin, now)
+	if err != nil {
+		return errors.Wrapf(err, "getting calendar of account %q", accountID)
+	}
+
+	return web.RespondContent(ctx, w, c.ICS(now), "text/calendar; charset=utf-8", http.StatusOK)
+}
+
+// CreateFeedToken gives the account identified by an ID in the request URL a
+// new calendar feed token, replacing its current one. The token is only
+// returned in this response.
+func (g *Garden) CreateFeedToken(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Garden.CreateFeedToken")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := checkAccount(ctx, id); err != nil {
+		return err
+	}
+
+	t, err := garden.CreateFeedToken(ctx, g.db, id, time.Now())
+	if err != nil {
+		switch err {
+		case garden.ErrAccountNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case garden.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "creating feed token of account %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, t, http.StatusCreated)
+}
+
+// RevokeFeedToken removes the calendar feed token of the account identified
+// by an ID in the request URL.
+func (g *Garden) RevokeFeedToken(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Garden.RevokeFeedToken")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := checkAccount(ctx, id); err != nil {
+		return err
+	}
+
+	if err := garden.RevokeFeedToken(ctx, g.db, id); err != nil {
+		switch err {
+		case garden.ErrTokenNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case garden.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "revoking feed token of account %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
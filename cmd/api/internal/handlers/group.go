@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	// Core packages
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/group"
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
+
+	// Third party packages
+	"github.com/go-chi/chi"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Group holds handlers for dealing with station groups.
+type Group struct {
+	db  *sqlx.DB
+	log *log.Logger
+}
+
+// Create decodes the body of a request to create a new group. The full group
+// with generated fields is sent back in the response.
+func (g *Group) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Group.Create")
+	defer span.End()
+
+	var ng group.NewGroup
+	if err := web.Decode(r, &ng); err != nil {
+		return errors.Wrap(err, "decoding new group")
+	}
+
+	created, err := group.Create(ctx, g.db, ng, time.Now())
+	if err != nil {
+		return errors.Wrap(err, "creating new group")
+	}
+
+	return web.Respond(ctx, w, created, http.StatusCreated)
+}
+
+// Delete removes a single group identified by an ID in the request URL.
+func (g *Group) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Group.Delete")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := group.Delete(ctx, g.db, id); err != nil {
+		switch err {
+		case group.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "deleting group %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// List returns all of the station groups.
+func (g *Group) List(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Group.List")
+	defer span.End()
+
+	list, err := group.List(ctx, g.db)
+	if err != nil {
+		return errors.Wrap(err, "getting group list")
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// Retrieve finds a group identified by a group ID in the request URL.
+func (g *Group) Retrieve(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Group.Retrieve")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	found, err := group.Get(ctx, g.db, id)
+	if err != nil {
+		switch err {
+		case group.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case group.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting group %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, found, http.StatusOK)
+}
+
+// Update decodes the body of a request to update an existing group. The ID of
+// the group is part of the request URL.
+func (g *Group) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Group.Update")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	var update group.UpdateGroup
+	if err := web.Decode(r, &update); err != nil {
+		return errors.Wrap(err, "decoding group update")
+	}
+
+	if err := group.Update(ctx, g.db, id, update, time.Now()); err != nil {
+		switch err {
+		case group.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case group.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "updating group %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// ListStations gets all active stations in a group.
+func (g *Group) ListStations(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Group.ListStations")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	list, err := station_type.ListAllStations(ctx, g.db, station_type.StationFilter{GroupId: id})
+	if err != nil {
+		switch err {
+		case station_type.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting stations for group %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// AddStation puts the station identified in the request URL into the group.
+func (g *Group) AddStation(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Group.AddStation")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+	stationID := chi.URLParam(r, "station_id")
+
+	if err := group.AddStation(ctx, g.db, id, stationID); err != nil {
+		switch err {
+		case group.ErrNotFound, group.ErrStationNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case group.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "adding station %q to group %q", stationID, id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// RemoveStation takes the station identified in the request URL out of the
+// group.
+func (g *Group) RemoveStation(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Group.RemoveStation")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+	stationID := chi.URLParam(r, "station_id")
+
+	if err := group.RemoveStation(ctx, g.db, id, stationID); err != nil {
+		switch err {
+		case group.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "removing station %q from group %q", stationID, id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	// Core packages
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/heartbeat"
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
+
+	// Third party packages
+	"github.com/go-chi/chi"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Heartbeat holds handlers for station heartbeats and power budgets.
+type Heartbeat struct {
+	db  *sqlx.DB
+	log *log.Logger
+}
+
+// Record decodes a heartbeat sent by the station identified by an ID in the
+// request URL. The response tells the station to sleep longer when its power
+// budget is negative.
+func (h *Heartbeat) Record(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Heartbeat.Record")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := checkStationAccount(ctx, h.db, id); err != nil {
+		return err
+	}
+
+	var nh heartbeat.NewHeartbeat
+	if err := web.Decode(r, &nh); err != nil {
+		return errors.Wrap(err, "decoding heartbeat")
+	}
+
+	now := time.Now()
+
+	hb, err := heartbeat.Record(ctx, h.db, id, nh, now)
+	if err != nil {
+		return errors.Wrapf(err, "recording heartbeat of station %q", id)
+	}
+
+	budget, err := heartbeat.StationBudget(ctx, h.db, id, now)
+	if err != nil {
+		return errors.Wrapf(err, "getting power budget of station %q", id)
+	}
+
+	ack := heartbeat.Ack{
+		Heartbeat:     *hb,
+		SleepInterval: budget.RecommendedSleepInterval,
+	}
+
+	return web.Respond(ctx, w, ack, http.StatusCreated)
+}
+
+// List gets the heartbeats of the station identified by an ID in the request
+// URL from the last day, or since the RFC 3339 time in the since query
+// parameter.
+func (h *Heartbeat) List(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Heartbeat.List")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	since := time.Now().Add(-heartbeat.BudgetWindow)
+	if v := r.URL.Query().Get("since"); v != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, v); err != nil {
+			return web.NewRequestError(errors.Wrap(err, "since must be an RFC 3339 time"), http.StatusBadRequest)
+		}
+	}
+
+	list, err := heartbeat.List(ctx, h.db, id, since)
+	if err != nil {
+		switch err {
+		case heartbeat.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting heartbeats of station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// StationBudget gets the power budget of the station identified by an ID in
+// the request URL.
+func (h *Heartbeat) StationBudget(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Heartbeat.StationBudget")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	budget, err := heartbeat.StationBudget(ctx, h.db, id, time.Now())
+	if err != nil {
+		switch err {
+		case heartbeat.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting power budget of station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, budget, http.StatusOK)
+}
+
+// ListBudgets gets the power budgets of all stations that reported in the
+// last day. With negative=true only stations losing energy are listed.
+func (h *Heartbeat) ListBudgets(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Heartbeat.ListBudgets")
+	defer span.End()
+
+	var negative bool
+	if v := r.URL.Query().Get("negative"); v != "" {
+		var err error
+		if negative, err = strconv.ParseBool(v); err != nil {
+			return web.NewRequestError(errors.Wrap(err, "negative must be true or false"), http.StatusBadRequest)
+		}
+	}
+
+	list, err := heartbeat.ListBudgets(ctx, h.db, time.Now(), negative)
+	if err != nil {
+		return errors.Wrap(err, "getting power budgets")
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
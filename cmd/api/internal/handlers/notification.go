@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	// Core packages
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/notify"
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/auth"
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
+
+	// Third party packages
+	"github.com/go-chi/chi"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Notification holds handlers for the notification preferences of accounts
+// and the notifications sent to them.
+type Notification struct {
+	db  *sqlx.DB
+	log *log.Logger
+}
+
+// RetrievePreference gets the notification preference of the account
+// identified by an ID in the request URL.
+func (n *Notification) RetrievePreference(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Notification.RetrievePreference")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := checkAccount(ctx, id); err != nil {
+		return err
+	}
+
+	p, err := notify.GetPreference(ctx, n.db, id)
+	if err != nil {
+		switch err {
+		case notify.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case notify.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting notification preference of account %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, p, http.StatusOK)
+}
+
+// UpdatePreference decodes the body of a request to set the notification
+// preference of the account identified by an ID in the request URL.
+func (n *Notification) UpdatePreference(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Notification.UpdatePreference")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := checkAccount(ctx, id); err != nil {
+		return err
+	}
+
+	var up notify.UpdatePreference
+	if err := web.Decode(r, &up); err != nil {
+		return errors.Wrap(err, "decoding notification preference")
+	}
+
+	p, err := notify.SetPreference(ctx, n.db, id, up, time.Now())
+	if err != nil {
+		switch err {
+		case notify.ErrInvalidID, notify.ErrInvalidPreference, notify.ErrInvalidEventType:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "setting notification preference of account %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, p, http.StatusOK)
+}
+
+// List gets the most recent notifications sent to the account identified by
+// an ID in the request URL. At most limit notifications are returned when the
+// query parameter is given.
+func (n *Notification) List(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Notification.List")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := checkAccount(ctx, id); err != nil {
+		return err
+	}
+
+	var limit int
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil {
+			return web.NewRequestError(errors.Wrap(err, "limit must be a number"), http.StatusBadRequest)
+		}
+	}
+
+	list, err := notify.ListNotifications(ctx, n.db, id, limit)
+	if err != nil {
+		switch err {
+		case notify.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting notifications of account %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// checkAccount returns an error unless the claims of the request belong to
+// the account with the given ID or to an admin.
+func checkAccount(ctx context.Context, id string) error {
+	claims, ok := ctx.Value(auth.Key).(auth.Claims)
+	if !ok {
+		return errors.New("claims missing from context")
+	}
+
+	if !claims.HasRole(auth.RoleAdmin) && claims.Subject != id {
+		return web.NewRequestError(station_type.ErrForbidden, http.StatusForbidden)
+	}
+
+	return nil
+}
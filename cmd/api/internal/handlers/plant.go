@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	// Core packages
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/plant"
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
+
+	// Third party packages
+	"github.com/go-chi/chi"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Plant holds handlers for dealing with the plant catalog and plantings.
+type Plant struct {
+	db  *sqlx.DB
+	log *log.Logger
+}
+
+// Create decodes the body of a request to add a plant to the catalog. The
+// full plant with generated fields is sent back in the response.
+func (p *Plant) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Plant.Create")
+	defer span.End()
+
+	var np plant.NewPlant
+	if err := web.Decode(r, &np); err != nil {
+		return errors.Wrap(err, "decoding new plant")
+	}
+
+	created, err := plant.Create(ctx, p.db, np, time.Now())
+	if err != nil {
+		switch err {
+		case plant.ErrInvalidRange:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrap(err, "creating new plant")
+		}
+	}
+
+	return web.Respond(ctx, w, created, http.StatusCreated)
+}
+
+// Delete removes a single plant identified by an ID in the request URL from
+// the catalog.
+func (p *Plant) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Plant.Delete")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := plant.Delete(ctx, p.db, id); err != nil {
+		switch err {
+		case plant.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		case plant.ErrHasPlantings:
+			return web.NewRequestError(err, http.StatusConflict)
+		default:
+			return errors.Wrapf(err, "deleting plant %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// List returns all of the plants in the catalog.
+func (p *Plant) List(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Plant.List")
+	defer span.End()
+
+	list, err := plant.List(ctx, p.db)
+	if err != nil {
+		return errors.Wrap(err, "getting plant list")
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// Retrieve finds a plant identified by a plant ID in the request URL.
+func (p *Plant) Retrieve(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Plant.Retrieve")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	found, err := plant.Get(ctx, p.db, id)
+	if err != nil {
+		switch err {
+		case plant.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case plant.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting plant %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, found, http.StatusOK)
+}
+
+// Update decodes the body of a request to update an existing plant. The ID of
+// the plant is part of the request URL.
+func (p *Plant) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Plant.Update")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	var update plant.UpdatePlant
+	if err := web.Decode(r, &update); err != nil {
+		return errors.Wrap(err, "decoding plant update")
+	}
+
+	if err := plant.Update(ctx, p.db, id, update, time.Now()); err != nil {
+		switch err {
+		case plant.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case plant.ErrInvalidID, plant.ErrInvalidRange:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "updating plant %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// AddPlanting decodes the body of a request to plant at the station
+// identified by an ID in the request URL.
+func (p *Plant) AddPlanting(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Plant.AddPlanting")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	var np plant.NewPlanting
+	if err := web.Decode(r, &np); err != nil {
+		return errors.Wrap(err, "decoding new planting")
+	}
+
+	created, err := plant.AddPlanting(ctx, p.db, id, np, time.Now())
+	if err != nil {
+		switch err {
+		case plant.ErrNotFound, plant.ErrStationNotFound, plant.ErrZoneNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case plant.ErrInvalidID, plant.ErrInvalidRange:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "planting at station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, created, http.StatusCreated)
+}
+
+// RemovePlanting marks the planting identified by an ID in the request URL as
+// removed.
+func (p *Plant) RemovePlanting(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Plant.RemovePlanting")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := plant.RemovePlanting(ctx, p.db, id, time.Now()); err != nil {
+		switch err {
+		case plant.ErrPlantingNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case plant.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "removing planting %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// ListPlantings gets the planting history of the station identified by an ID
+// in the request URL.
+func (p *Plant) ListPlantings(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Plant.ListPlantings")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	list, err := plant.ListPlantings(ctx, p.db, id)
+	if err != nil {
+		switch err {
+		case plant.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting plantings of station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// StationThresholds gets the moisture range of the station identified by an
+// ID in the request URL as given by what is planted there.
+func (p *Plant) StationThresholds(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Plant.StationThresholds")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	t, err := plant.StationThresholds(ctx, p.db, id)
+	if err != nil {
+		switch err {
+		case plant.ErrPlantingNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case plant.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting thresholds of station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, t, http.StatusOK)
+}
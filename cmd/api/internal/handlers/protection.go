@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	// Core packages
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
+	"github.com/deezone/HydroBytes-BaseStation/internal/protection"
+
+	// Third party packages
+	"github.com/go-chi/chi"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Protection holds handlers for frost and heat protection.
+type Protection struct {
+	db  *sqlx.DB
+	log *log.Logger
+}
+
+// RetrieveSettings gets the frost and heat protection settings of the station
+// type identified by an ID in the request URL.
+func (p *Protection) RetrieveSettings(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Protection.RetrieveSettings")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	s, err := protection.GetSettings(ctx, p.db, id)
+	if err != nil {
+		switch err {
+		case protection.ErrStationTypeNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case protection.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting protection settings of station type %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, s, http.StatusOK)
+}
+
+// UpdateSettings decodes the body of a request to change the frost and heat
+// protection settings of the station type identified by an ID in the request
+// URL.
+func (p *Protection) UpdateSettings(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Protection.UpdateSettings")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	var us protection.UpdateSettings
+	if err := web.Decode(r, &us); err != nil {
+		return errors.Wrap(err, "decoding protection settings")
+	}
+
+	s, err := protection.Update(ctx, p.db, id, us, time.Now())
+	if err != nil {
+		switch err {
+		case protection.ErrStationTypeNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case protection.ErrInvalidID, protection.ErrInvalidSettings:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "updating protection settings of station type %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, s, http.StatusOK)
+}
+
+// List gets the pending and active frost and heat conditions, or the
+// conditions with the status query parameter. They may be limited to a
+// station with the station_id query parameter.
+func (p *Protection) List(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Protection.List")
+	defer span.End()
+
+	query := r.URL.Query()
+
+	filter := protection.Filter{
+		Status:    query.Get("status"),
+		StationId: query.Get("station_id"),
+	}
+
+	list, err := protection.List(ctx, p.db, filter)
+	if err != nil {
+		switch err {
+		case protection.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrap(err, "getting protection condition list")
+		}
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	// Core packages
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/anomaly"
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
+	"github.com/deezone/HydroBytes-BaseStation/internal/reading"
+
+	// Third party packages
+	"github.com/go-chi/chi"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Reading holds handlers for station sensor readings.
+type Reading struct {
+	db  *sqlx.DB
+	log *log.Logger
+}
+
+// Record decodes sensor readings sent by the station identified by an ID in
+// the request URL.
+func (rd *Reading) Record(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Reading.Record")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := checkStationAccount(ctx, rd.db, id); err != nil {
+		return err
+	}
+
+	var nr reading.NewReadings
+	if err := web.Decode(r, &nr); err != nil {
+		return errors.Wrap(err, "decoding readings")
+	}
+
+	readings, err := reading.Record(ctx, rd.db, id, nr, time.Now())
+	if err != nil {
+		return errors.Wrapf(err, "recording readings of station %q", id)
+	}
+
+	return web.Respond(ctx, w, readings, http.StatusCreated)
+}
+
+// List gets the readings of the station identified by an ID in the request
+// URL, optionally of the sensor query parameter only and read between the
+// RFC 3339 times since and until.
+func (rd *Reading) List(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Reading.List")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+	query := r.URL.Query()
+
+	q := reading.Query{Sensor: query.Get("sensor")}
+
+	for name, t := range map[string]**time.Time{"since": &q.Since, "until": &q.Until} {
+		if v := query.Get(name); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return web.NewRequestError(errors.Wrapf(err, "%s must be an RFC 3339 time", name), http.StatusBadRequest)
+			}
+			*t = &parsed
+		}
+	}
+
+	list, err := reading.List(ctx, rd.db, id, q)
+	if err != nil {
+		switch err {
+		case reading.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting readings of station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// SensorStats gets the streaming statistics used to detect anomalies in the
+// readings of each sensor of the station identified by an ID in the request
+// URL.
+func (rd *Reading) SensorStats(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Reading.SensorStats")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	states, err := anomaly.ListStates(ctx, rd.db, id)
+	if err != nil {
+		switch err {
+		case anomaly.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting sensor stats of station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, states, http.StatusOK)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	// Core packages
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
+	"github.com/deezone/HydroBytes-BaseStation/internal/report"
+
+	// Third party packages
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Report holds handlers for garden reports.
+type Report struct {
+	db  *sqlx.DB
+	log *log.Logger
+}
+
+// Weekly renders the report of the ISO 8601 week in the week query parameter,
+// such as 2021-W23, or of last week when it is not given. The report is HTML
+// unless the format query parameter is markdown.
+func (rp *Report) Weekly(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Report.Weekly")
+	defer span.End()
+
+	query := r.URL.Query()
+	now := time.Now()
+
+	from := report.LastWeek(now)
+	if v := query.Get("week"); v != "" {
+		var err error
+		if from, err = report.ParseWeek(v); err != nil {
+			return web.NewRequestError(err, http.StatusBadRequest)
+		}
+	}
+
+	format := query.Get("format")
+	if format != "" && format != "html" && format != "markdown" {
+		return web.NewRequestError(errors.New("format must be html or markdown"), http.StatusBadRequest)
+	}
+
+	weekly, err := report.Generate(ctx, rp.db, from, now)
+	if err != nil {
+		return errors.Wrapf(err, "generating report of week %s", report.FormatWeek(from))
+	}
+
+	if format == "markdown" {
+		md, err := weekly.Markdown()
+		if err != nil {
+			return err
+		}
+		return web.RespondContent(ctx, w, md, "text/markdown; charset=utf-8", http.StatusOK)
+	}
+
+	html, err := weekly.HTML()
+	if err != nil {
+		return err
+	}
+
+	return web.RespondContent(ctx, w, html, "text/html; charset=utf-8", http.StatusOK)
+}
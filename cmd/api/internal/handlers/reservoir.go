@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	// Core packages
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
+	"github.com/deezone/HydroBytes-BaseStation/internal/reservoir"
+
+	// Third party packages
+	"github.com/go-chi/chi"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Reservoir holds handlers for water station reservoirs and their refills.
+type Reservoir struct {
+	db  *sqlx.DB
+	log *log.Logger
+}
+
+// Forecast gets when the reservoir of the station identified by an ID in the
+// request URL is expected to run dry.
+func (rs *Reservoir) Forecast(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Reservoir.Forecast")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	f, err := reservoir.StationForecast(ctx, rs.db, id, time.Now())
+	if err != nil {
+		switch err {
+		case reservoir.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case reservoir.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "forecasting reservoir of station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, f, http.StatusOK)
+}
+
+// ListForecasts gets the forecasts of every reservoir.
+func (rs *Reservoir) ListForecasts(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Reservoir.ListForecasts")
+	defer span.End()
+
+	list, err := reservoir.ListForecasts(ctx, rs.db, time.Now())
+	if err != nil {
+		return errors.Wrap(err, "forecasting reservoirs")
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// Update decodes the capacity of the reservoir of the station identified by an
+// ID in the request URL, setting the reservoir up if needed.
+func (rs *Reservoir) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Reservoir.Update")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	var ur reservoir.UpdateReservoir
+	if err := web.Decode(r, &ur); err != nil {
+		return errors.Wrap(err, "decoding reservoir")
+	}
+
+	res, err := reservoir.Set(ctx, rs.db, id, ur, time.Now())
+	if err != nil {
+		switch err {
+		case reservoir.ErrStationNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case reservoir.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "setting reservoir of station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, res, http.StatusOK)
+}
+
+// ListRefills gets the upcoming refills of the reservoir of the station
+// identified by an ID in the request URL.
+func (rs *Reservoir) ListRefills(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Reservoir.ListRefills")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	list, err := reservoir.ListRefills(ctx, rs.db, id, time.Now())
+	if err != nil {
+		switch err {
+		case reservoir.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting refills of station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// AddRefill plans a refill of the reservoir of the station identified by an
+// ID in the request URL.
+func (rs *Reservoir) AddRefill(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Reservoir.AddRefill")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	var nr reservoir.NewRefill
+	if err := web.Decode(r, &nr); err != nil {
+		return errors.Wrap(err, "decoding new refill")
+	}
+
+	refill, err := reservoir.AddRefill(ctx, rs.db, id, nr, time.Now())
+	if err != nil {
+		switch err {
+		case reservoir.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case reservoir.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "planning refill of station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, refill, http.StatusCreated)
+}
+
+// DeleteRefill removes the planned refill identified by an ID in the request
+// URL.
+func (rs *Reservoir) DeleteRefill(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Reservoir.DeleteRefill")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := reservoir.DeleteRefill(ctx, rs.db, id); err != nil {
+		switch err {
+		case reservoir.ErrRefillNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case reservoir.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "deleting refill %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
@@ -31,6 +31,24 @@ func API(shutdown chan os.Signal, db *sqlx.DB, log *log.Logger, authenticator *a
 		// Register account handlers.
 		a := Account{db: db, authenticator: authenticator}
 		app.Handle(http.MethodGet, "/v1/account/token", a.Token)
+		app.Handle(http.MethodDelete, "/v1/account/{id}", a.Delete,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodPost, "/v1/account/{id}/restore", a.Restore,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+	}
+
+	{
+		// Register Notification handlers. Accounts may only see and change their
+		// own preference unless they are admins.
+		n := Notification{db: db, log: log}
+
+		app.Handle(http.MethodGet, "/v1/account/{id}/notification-preference", n.RetrievePreference, mid.Authenticate(authenticator))
+		app.Handle(http.MethodPut, "/v1/account/{id}/notification-preference", n.UpdatePreference,   mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet, "/v1/account/{id}/notifications",           n.List,               mid.Authenticate(authenticator))
 	}
 
 	{
@@ -52,10 +70,18 @@ func API(shutdown chan os.Signal, db *sqlx.DB, log *log.Logger, authenticator *a
 			mid.Authenticate(authenticator),
 			mid.HasRole(auth.RoleAdmin),
 		)
+		app.Handle(http.MethodPost,   "/v1/station-type/{id}/restore", st.Restore,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
 
 		// Station
+		app.Handle(http.MethodGet,    "/v1/stations",                   st.ListAllStations, mid.Authenticate(authenticator))
 		app.Handle(http.MethodGet,    "/v1/station-type/{id}/stations", st.ListStations,    mid.Authenticate(authenticator))
 		app.Handle(http.MethodGet,    "/v1/station/{id}",               st.RetrieveStation, mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/station/{id}/nearest",       st.NearestStation,  mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/station/{id}/locations",     st.ListStationLocations,    mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/station/{id}/location",      st.RetrieveStationLocation, mid.Authenticate(authenticator))
 		app.Handle(http.MethodPost,   "/v1/station-type/{id}/station",  st.AddStation,
 			mid.Authenticate(authenticator),
 			mid.HasRole(auth.RoleAdmin),
@@ -67,6 +93,314 @@ func API(shutdown chan os.Signal, db *sqlx.DB, log *log.Logger, authenticator *a
 			mid.Authenticate(authenticator),
 			mid.HasRole(auth.RoleAdmin),
 		)
+		app.Handle(http.MethodPost,   "/v1/station/{id}/restore",       st.RestoreStation,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+
+		app.Handle(http.MethodPost,   "/v1/station/{id}/clone",         st.CloneStation,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+
+		// Station templates
+		app.Handle(http.MethodGet,    "/v1/station-templates",     st.ListTemplates,    mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/station-template/{id}", st.RetrieveTemplate, mid.Authenticate(authenticator))
+		app.Handle(http.MethodPost,   "/v1/station-template",      st.CreateTemplate,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodDelete, "/v1/station-template/{id}", st.DeleteTemplate,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+
+		// Station links
+		app.Handle(http.MethodGet,    "/v1/station/{id}/links",   st.ListStationLinks, mid.Authenticate(authenticator))
+		app.Handle(http.MethodPost,   "/v1/station/{id}/link",    st.AddStationLink,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodDelete, "/v1/station-link/{id}",    st.DeleteStationLink,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+	}
+
+	{
+		// Register StationLog handlers. Ensure all routes are authenticated.
+		sl := StationLog{db: db, log: log}
+
+		app.Handle(http.MethodGet,  "/v1/station/{id}/logs",           sl.Search,            mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,  "/v1/station/{id}/logs/tail",      sl.Tail,              mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,  "/v1/station/{id}/logs/retention", sl.RetrieveRetention, mid.Authenticate(authenticator))
+		app.Handle(http.MethodPost, "/v1/station/{id}/logs",           sl.Ingest,            mid.Authenticate(authenticator))
+		app.Handle(http.MethodPut,  "/v1/station/{id}/logs/retention", sl.UpdateRetention,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+	}
+
+	{
+		// Register Heartbeat handlers. Ensure all routes are authenticated.
+		h := Heartbeat{db: db, log: log}
+
+		app.Handle(http.MethodGet,  "/v1/power",                   h.ListBudgets,   mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,  "/v1/station/{id}/power",      h.StationBudget, mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,  "/v1/station/{id}/heartbeats", h.List,          mid.Authenticate(authenticator))
+		app.Handle(http.MethodPost, "/v1/station/{id}/heartbeat",  h.Record,        mid.Authenticate(authenticator))
+	}
+
+	{
+		// Register Command handlers. Ensure all routes are authenticated.
+		c := Command{db: db, log: log}
+
+		app.Handle(http.MethodGet,    "/v1/commands",                   c.List,     mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/command/{id}",               c.Retrieve, mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/station/{id}/commands/due",  c.Due,      mid.Authenticate(authenticator))
+		app.Handle(http.MethodPut,    "/v1/command/{id}",               c.Report,   mid.Authenticate(authenticator))
+		app.Handle(http.MethodPost,   "/v1/station/{id}/command",       c.Create,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodDelete, "/v1/command/{id}",               c.Cancel,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodPost,   "/v1/commands",                   c.CreateForStations,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+	}
+
+	{
+		// Register Water handlers. Ensure all routes are authenticated.
+		wa := Water{db: db, log: log}
+
+		app.Handle(http.MethodGet,    "/v1/water/usage",         wa.Usage,         mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/station/{id}/meters", wa.ListMeters,    mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/meter/{id}",          wa.RetrieveMeter, mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/meter/{id}/readings", wa.ListReadings,  mid.Authenticate(authenticator))
+		app.Handle(http.MethodPost,   "/v1/meter/{id}/readings", wa.RecordFlow,    mid.Authenticate(authenticator))
+		app.Handle(http.MethodPost,   "/v1/station/{id}/meter",  wa.AddMeter,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodPut,    "/v1/meter/{id}",          wa.UpdateMeter,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodDelete, "/v1/meter/{id}",          wa.DeleteMeter,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+	}
+
+	{
+		// Register Reading handlers. Ensure all routes are authenticated.
+		rd := Reading{db: db, log: log}
+
+		app.Handle(http.MethodGet,  "/v1/station/{id}/readings",     rd.List,        mid.Authenticate(authenticator))
+		app.Handle(http.MethodPost, "/v1/station/{id}/readings",     rd.Record,      mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,  "/v1/station/{id}/sensor-stats", rd.SensorStats, mid.Authenticate(authenticator))
+	}
+
+	{
+		// Register Reservoir handlers. Ensure all routes are authenticated.
+		rs := Reservoir{db: db, log: log}
+
+		app.Handle(http.MethodGet,    "/v1/reservoirs",             rs.ListForecasts, mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/station/{id}/reservoir", rs.Forecast,      mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/station/{id}/refills",   rs.ListRefills,   mid.Authenticate(authenticator))
+		app.Handle(http.MethodPut,    "/v1/station/{id}/reservoir", rs.Update,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodPost,   "/v1/station/{id}/refill",    rs.AddRefill,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodDelete, "/v1/refill/{id}",            rs.DeleteRefill,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+	}
+
+	{
+		// Register Alert handlers. Ensure all routes are authenticated.
+		al := Alert{db: db, log: log}
+
+		app.Handle(http.MethodGet,    "/v1/alert-rules",            al.ListRules,    mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/alert-rule/{id}",        al.RetrieveRule, mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/alerts",                 al.List,         mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/alert/{id}",             al.Retrieve,     mid.Authenticate(authenticator))
+		app.Handle(http.MethodPost,   "/v1/alert/{id}/acknowledge", al.Acknowledge,  mid.Authenticate(authenticator))
+		app.Handle(http.MethodPost,   "/v1/alert/{id}/silence",     al.Silence,      mid.Authenticate(authenticator))
+		app.Handle(http.MethodPost,   "/v1/alert-rule",             al.CreateRule,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodDelete, "/v1/alert-rule/{id}",        al.DeleteRule,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+	}
+
+	{
+		// Register Protection handlers. Ensure all routes are authenticated.
+		p := Protection{db: db, log: log}
+
+		app.Handle(http.MethodGet, "/v1/protection",                   p.List,             mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet, "/v1/station-type/{id}/protection", p.RetrieveSettings, mid.Authenticate(authenticator))
+		app.Handle(http.MethodPut, "/v1/station-type/{id}/protection", p.UpdateSettings,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+	}
+
+	{
+		// Register Event handlers. Ensure all routes are authenticated.
+		e := Event{db: db, log: log}
+
+		app.Handle(http.MethodGet, "/v1/events",     e.List,     mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet, "/v1/event/{id}", e.Retrieve, mid.Authenticate(authenticator))
+	}
+
+	{
+		// Register Audit handlers. Only admins may read the audit trail.
+		au := Audit{db: db, log: log}
+
+		app.Handle(http.MethodGet, "/v1/audit", au.List,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+	}
+
+	{
+		// Register Webhook handlers. Subscriptions hold secrets so all routes
+		// are restricted to admins.
+		wh := Webhook{db: db, log: log}
+
+		app.Handle(http.MethodGet,    "/v1/webhooks",                 wh.List,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodGet,    "/v1/webhook/{id}",             wh.Retrieve,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodGet,    "/v1/webhook/{id}/deliveries",  wh.ListDeliveries,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodPost,   "/v1/webhook",                  wh.Create,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodDelete, "/v1/webhook/{id}",             wh.Delete,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+	}
+
+	{
+		// Register Zone handlers. Ensure all routes are authenticated.
+		z := Zone{db: db, log: log}
+
+		app.Handle(http.MethodGet,    "/v1/zones",              z.List,         mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/zone/{id}",          z.Retrieve,     mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/zone/{id}/stations", z.ListStations, mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/zone/{id}/readings", z.Readings,     mid.Authenticate(authenticator))
+		app.Handle(http.MethodPost,   "/v1/zone",               z.Create,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodPut,    "/v1/zone/{id}",          z.Update,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodDelete, "/v1/zone/{id}",          z.Delete,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+	}
+
+	{
+		// Register Group handlers. Ensure all routes are authenticated.
+		g := Group{db: db, log: log}
+
+		app.Handle(http.MethodGet,    "/v1/groups",                          g.List,         mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/group/{id}",                      g.Retrieve,     mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/group/{id}/stations",             g.ListStations, mid.Authenticate(authenticator))
+		app.Handle(http.MethodPost,   "/v1/group",                           g.Create,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodPut,    "/v1/group/{id}",                      g.Update,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodDelete, "/v1/group/{id}",                      g.Delete,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodPut,    "/v1/group/{id}/station/{station_id}", g.AddStation,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodDelete, "/v1/group/{id}/station/{station_id}", g.RemoveStation,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+	}
+
+	{
+		// Register Plant handlers. Ensure all routes are authenticated.
+		p := Plant{db: db, log: log}
+
+		app.Handle(http.MethodGet,    "/v1/plants",                  p.List,              mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/plant/{id}",              p.Retrieve,          mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/station/{id}/plantings",  p.ListPlantings,     mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/station/{id}/thresholds", p.StationThresholds, mid.Authenticate(authenticator))
+		app.Handle(http.MethodPost,   "/v1/plant",                   p.Create,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodPut,    "/v1/plant/{id}",              p.Update,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodDelete, "/v1/plant/{id}",              p.Delete,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodPost,   "/v1/station/{id}/planting",   p.AddPlanting,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+		app.Handle(http.MethodDelete, "/v1/planting/{id}",           p.RemovePlanting,
+			mid.Authenticate(authenticator),
+			mid.HasRole(auth.RoleAdmin),
+		)
+	}
+
+	{
+		// Register Garden handlers. The calendar feed is authorized by the feed
+		// token in its URL rather than a bearer token.
+		gd := Garden{db: db, log: log}
+
+		app.Handle(http.MethodGet,    "/v1/garden/map.svg",              gd.Map,             mid.Authenticate(authenticator))
+		app.Handle(http.MethodGet,    "/v1/garden/calendar.ics",         gd.Calendar)
+		app.Handle(http.MethodPost,   "/v1/account/{id}/calendar-token", gd.CreateFeedToken, mid.Authenticate(authenticator))
+		app.Handle(http.MethodDelete, "/v1/account/{id}/calendar-token", gd.RevokeFeedToken, mid.Authenticate(authenticator))
+	}
+
+	{
+		// Register Report handlers. Reports cover the stations of every account
+		// so they are only for admins.
+		rp := Report{db: db, log: log}
+
+		app.Handle(http.MethodGet, "/v1/reports/weekly", rp.Weekly, mid.Authenticate(authenticator), mid.HasRole(auth.RoleAdmin))
 	}
 
 	return app
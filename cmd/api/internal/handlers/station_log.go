@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	// Core packages
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_log"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
+
+	// Third party packages
+	"github.com/go-chi/chi"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// maxTailWait is the longest a request to tail a station log waits for new
+// lines. It is kept below the default write timeout of the API server.
+const maxTailWait = 4 * time.Second
+
+// StationLog holds handlers for dealing with the device logs of stations.
+type StationLog struct {
+	db  *sqlx.DB
+	log *log.Logger
+}
+
+// Ingest decodes a batch of log lines uploaded by the station identified by an
+// ID in the request URL. Only the account of the station or an admin may
+// upload its logs.
+func (sl *StationLog) Ingest(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.StationLog.Ingest")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := checkStationAccount(ctx, sl.db, id); err != nil {
+		return err
+	}
+
+	var nl station_log.NewLogLines
+	if err := web.Decode(r, &nl); err != nil {
+		return errors.Wrap(err, "decoding log lines")
+	}
+
+	lines, err := station_log.Ingest(ctx, sl.db, id, nl.Lines, time.Now())
+	if err != nil {
+		return errors.Wrapf(err, "storing logs of station %q", id)
+	}
+
+	return web.Respond(ctx, w, lines, http.StatusCreated)
+}
+
+// Search finds log lines of the station identified by an ID in the request
+// URL with the optional query parameters:
+//
+//   q=text             lines containing text in their message or module
+//   level=warn         lines of the level or more severe
+//   since=, until=     lines logged in the RFC 3339 time window
+//   limit=n            at most n lines, most recent first
+//
+// Only the account of the station or an admin may search its logs.
+func (sl *StationLog) Search(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.StationLog.Search")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+	query := r.URL.Query()
+
+	if err := checkStationAccount(ctx, sl.db, id); err != nil {
+		return err
+	}
+
+	q := station_log.Query{
+		Text:  query.Get("q"),
+		Level: query.Get("level"),
+	}
+
+	for name, t := range map[string]**time.Time{"since": &q.Since, "until": &q.Until} {
+		if v := query.Get(name); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return web.NewRequestError(errors.Wrapf(err, "%s must be an RFC 3339 time", name), http.StatusBadRequest)
+			}
+			*t = &parsed
+		}
+	}
+
+	if v := query.Get("limit"); v != "" {
+		var err error
+		if q.Limit, err = strconv.Atoi(v); err != nil {
+			return web.NewRequestError(errors.Wrap(err, "limit must be a number"), http.StatusBadRequest)
+		}
+	}
+
+	lines, err := station_log.Search(ctx, sl.db, id, q)
+	if err != nil {
+		switch err {
+		case station_log.ErrInvalidID, station_log.ErrInvalidLevel:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "searching logs of station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, lines, http.StatusOK)
+}
+
+// Tail gets the log lines of the station identified by an ID in the request
+// URL that arrived after the line given by the after query parameter. When
+// there are none the request waits up to wait seconds for new lines. Clients
+// follow a log by repeating the request with the id of the last line received.
+// Only the account of the station or an admin may tail its logs.
+func (sl *StationLog) Tail(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.StationLog.Tail")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+	query := r.URL.Query()
+
+	if err := checkStationAccount(ctx, sl.db, id); err != nil {
+		return err
+	}
+
+	var after int64
+	if v := query.Get("after"); v != "" {
+		var err error
+		if after, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return web.NewRequestError(errors.Wrap(err, "after must be a log line id"), http.StatusBadRequest)
+		}
+	}
+
+	wait := maxTailWait
+	if v := query.Get("wait"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds < 0 {
+			return web.NewRequestError(errors.New("wait must be a number of seconds"), http.StatusBadRequest)
+		}
+		if d := time.Duration(seconds) * time.Second; d < wait {
+			wait = d
+		}
+	}
+
+	lines, err := station_log.Tail(ctx, sl.db, id, after, wait)
+	if err != nil {
+		switch err {
+		case station_log.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "tailing logs of station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, lines, http.StatusOK)
+}
+
+// RetrieveRetention gets how many days the logs of the station identified by
+// an ID in the request URL are kept. Only the account of the station or an
+// admin may see it.
+func (sl *StationLog) RetrieveRetention(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.StationLog.RetrieveRetention")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := checkStationAccount(ctx, sl.db, id); err != nil {
+		return err
+	}
+
+	retention, err := station_log.GetRetention(ctx, sl.db, id)
+	if err != nil {
+		switch err {
+		case station_log.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting log retention of station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, retention, http.StatusOK)
+}
+
+// UpdateRetention changes how many days the logs of the station identified by
+// an ID in the request URL are kept.
+func (sl *StationLog) UpdateRetention(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.StationLog.UpdateRetention")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if _, err := station_type.GetStation(ctx, sl.db, id); err != nil {
+		switch err {
+		case station_type.ErrStationNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case station_type.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting station %q", id)
+		}
+	}
+
+	var update station_log.UpdateRetention
+	if err := web.Decode(r, &update); err != nil {
+		return errors.Wrap(err, "decoding log retention")
+	}
+
+	if err := station_log.SetRetention(ctx, sl.db, id, update.Days); err != nil {
+		return errors.Wrapf(err, "setting log retention of station %q", id)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
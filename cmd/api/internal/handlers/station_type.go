@@ -3,11 +3,15 @@ package handlers
 import (
 	// Core packages
 	"context"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/mid"
 	"github.com/deezone/HydroBytes-BaseStation/internal/platform/auth"
 	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
 	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
@@ -45,7 +49,12 @@ func (st *StationType) Create(ctx context.Context, w http.ResponseWriter, r *htt
 	return web.Respond(ctx, w, &stationType, http.StatusCreated)
 }
 
-// Delete removes a single station type identified by an ID in the request URL.
+// Delete archives a single station type identified by an ID in the request URL.
+// A station type that still has stations is refused with a 409 listing the
+// stations unless the request has one of the query parameters:
+//
+//   reassign_to={id}   move the stations to another station type first
+//   cascade=true       archive the stations as well
 func (p *StationType) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 
 	ctx, span := trace.StartSpan(ctx, "handlers.StationType.Delete")
@@ -53,10 +62,25 @@ func (p *StationType) Delete(ctx context.Context, w http.ResponseWriter, r *http
 
 	id := chi.URLParam(r, "id")
 
-	if err := station_type.Delete(ctx, p.db, id); err != nil {
+	var d station_type.DeleteStationType
+	d.ReassignTo = r.URL.Query().Get("reassign_to")
+	if v := r.URL.Query().Get("cascade"); v != "" {
+		var err error
+		if d.Cascade, err = strconv.ParseBool(v); err != nil {
+			return web.NewRequestError(errors.Wrap(err, "cascade must be true or false"), http.StatusBadRequest)
+		}
+	}
+
+	if err := station_type.Delete(ctx, p.db, id, d, time.Now()); err != nil {
 		switch err {
-		case station_type.ErrInvalidID:
+		case station_type.ErrInvalidID, station_type.ErrReassignNotFound, station_type.ErrReassignToSelf:
 			return web.NewRequestError(err, http.StatusBadRequest)
+		case station_type.ErrHasStations:
+			stations, err := station_type.ListStations(ctx, p.db, id, false)
+			if err != nil {
+				return errors.Wrapf(err, "getting stations of station type %q", id)
+			}
+			return respondStationsConflict(ctx, w, station_type.ErrHasStations, stations)
 		default:
 			return errors.Wrapf(err, "deleting station type %q", id)
 		}
@@ -65,6 +89,43 @@ func (p *StationType) Delete(ctx context.Context, w http.ResponseWriter, r *http
 	return web.Respond(ctx, w, nil, http.StatusNoContent)
 }
 
+// Restore brings back an archived station type identified by an ID in the
+// request URL.
+func (st *StationType) Restore(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.StationType.Restore")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := station_type.Restore(ctx, st.db, id, time.Now()); err != nil {
+		switch err {
+		case station_type.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case station_type.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "restoring station type %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// respondStationsConflict refuses a request because the stations listed in the
+// response still depend on the record being changed.
+func respondStationsConflict(ctx context.Context, w http.ResponseWriter, err error, stations []station_type.Station) error {
+	conflict := struct {
+		Error    string                 `json:"error"`
+		Stations []station_type.Station `json:"stations"`
+	}{
+		Error:    err.Error(),
+		Stations: stations,
+	}
+
+	return web.Respond(ctx, w, conflict, http.StatusConflict)
+}
+
 /**
  * List StationTypes is a basic HTTP Handler that lists all of the station types in the HydroByte Automated Garden system.
  * A Handler is also know as a "controller" in the MVC pattern.
@@ -80,7 +141,12 @@ func (st *StationType) List(ctx context.Context, w http.ResponseWriter, r *http.
 	ctx, span := trace.StartSpan(ctx, "handlers.Product.List")
 	defer span.End()
 
-	list, err := station_type.List(ctx, st.db)
+	archived, err := includeArchived(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	list, err := station_type.List(ctx, st.db, archived)
 	if err != nil {
 		return errors.Wrap(err, "getting station type list")
 	}
@@ -163,12 +229,137 @@ func (st *StationType) AddStation(ctx context.Context, w http.ResponseWriter, r
 
 	station, err := station_type.AddStation(ctx, st.db, claims, ns, stationTypeId, time.Now())
 	if err != nil {
-		return errors.Wrap(err, "adding new sale")
+		switch err {
+		case station_type.ErrZoneNotFound, station_type.ErrTemplateMismatch:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		case station_type.ErrTemplateNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return errors.Wrap(err, "adding new station")
+		}
+	}
+
+	return web.Respond(ctx, w, station, http.StatusCreated)
+}
+
+// CloneStation decodes the body of a request to copy the station identified by
+// an ID in the request URL to a new location.
+func (st *StationType) CloneStation(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Station.CloneStation")
+	defer span.End()
+
+	claims, ok := ctx.Value(auth.Key).(auth.Claims)
+	if !ok {
+		return errors.New("claims missing from context")
+	}
+
+	id := chi.URLParam(r, "id")
+
+	var nc station_type.NewStationClone
+	if err := web.Decode(r, &nc); err != nil {
+		return errors.Wrap(err, "decoding station clone")
+	}
+
+	station, err := station_type.CloneStation(ctx, st.db, claims, id, nc, time.Now())
+	if err != nil {
+		switch err {
+		case station_type.ErrStationNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case station_type.ErrInvalidID, station_type.ErrZoneNotFound:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "cloning station %q", id)
+		}
 	}
 
 	return web.Respond(ctx, w, station, http.StatusCreated)
 }
 
+// CreateTemplate decodes the body of a request to create a new station
+// template.
+func (st *StationType) CreateTemplate(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Station.CreateTemplate")
+	defer span.End()
+
+	var nt station_type.NewStationTemplate
+	if err := web.Decode(r, &nt); err != nil {
+		return errors.Wrap(err, "decoding new station template")
+	}
+
+	template, err := station_type.CreateTemplate(ctx, st.db, nt, time.Now())
+	if err != nil {
+		switch err {
+		case station_type.ErrNotFound, station_type.ErrZoneNotFound:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrap(err, "creating new station template")
+		}
+	}
+
+	return web.Respond(ctx, w, template, http.StatusCreated)
+}
+
+// DeleteTemplate removes the station template identified by an ID in the
+// request URL.
+func (st *StationType) DeleteTemplate(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Station.DeleteTemplate")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := station_type.DeleteTemplate(ctx, st.db, id, time.Now()); err != nil {
+		switch err {
+		case station_type.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "deleting station template %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// ListTemplates returns all of the station templates.
+func (st *StationType) ListTemplates(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Station.ListTemplates")
+	defer span.End()
+
+	list, err := station_type.ListTemplates(ctx, st.db)
+	if err != nil {
+		return errors.Wrap(err, "getting station template list")
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// RetrieveTemplate finds the station template identified by an ID in the
+// request URL.
+func (st *StationType) RetrieveTemplate(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Station.RetrieveTemplate")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	template, err := station_type.GetTemplate(ctx, st.db, id)
+	if err != nil {
+		switch err {
+		case station_type.ErrTemplateNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case station_type.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting station template %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, template, http.StatusOK)
+}
+
 // AdjustStation decodes the body of a request to update an existing station. The ID
 // of the station is part of the request URL.
 func (st *StationType) AdjustStation(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
@@ -196,6 +387,8 @@ func (st *StationType) AdjustStation(ctx context.Context, w http.ResponseWriter,
 			return web.NewRequestError(err, http.StatusBadRequest)
 		case station_type.ErrForbidden:
 			return web.NewRequestError(err, http.StatusForbidden)
+		case station_type.ErrZoneNotFound:
+			return web.NewRequestError(err, http.StatusBadRequest)
 		default:
 			return errors.Wrapf(err, "updating station %q", id)
 		}
@@ -204,7 +397,7 @@ func (st *StationType) AdjustStation(ctx context.Context, w http.ResponseWriter,
 	return web.Respond(ctx, w, nil, http.StatusNoContent)
 }
 
-// DeleteStation removes a single station identified by an ID in the request URL.
+// DeleteStation archives a single station identified by an ID in the request URL.
 func (p *StationType) DeleteStation(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 
 	ctx, span := trace.StartSpan(ctx, "handlers.Station.DeleteStation")
@@ -212,7 +405,7 @@ func (p *StationType) DeleteStation(ctx context.Context, w http.ResponseWriter,
 
 	id := chi.URLParam(r, "id")
 
-	if err := station_type.DeleteStation(ctx, p.db, id); err != nil {
+	if err := station_type.DeleteStation(ctx, p.db, id, time.Now()); err != nil {
 		switch err {
 		case station_type.ErrInvalidID:
 			return web.NewRequestError(err, http.StatusBadRequest)
@@ -224,6 +417,31 @@ func (p *StationType) DeleteStation(ctx context.Context, w http.ResponseWriter,
 	return web.Respond(ctx, w, nil, http.StatusNoContent)
 }
 
+// RestoreStation brings back an archived station identified by an ID in the
+// request URL.
+func (st *StationType) RestoreStation(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Station.RestoreStation")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := station_type.RestoreStation(ctx, st.db, id, time.Now()); err != nil {
+		switch err {
+		case station_type.ErrStationNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case station_type.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		case station_type.ErrStationTypeArchived, station_type.ErrAccountArchived:
+			return web.NewRequestError(err, http.StatusConflict)
+		default:
+			return errors.Wrapf(err, "restoring station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
 // ListStations gets all sales for a particular station type.
 func (st *StationType) ListStations(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 
@@ -232,7 +450,12 @@ func (st *StationType) ListStations(ctx context.Context, w http.ResponseWriter,
 
 	id := chi.URLParam(r, "id")
 
-	list, err := station_type.ListStations(ctx, st.db, id)
+	archived, err := includeArchived(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	list, err := station_type.ListStations(ctx, st.db, id, archived)
 	if err != nil {
 		return errors.Wrap(err, "getting sales list")
 	}
@@ -260,5 +483,324 @@ func (st *StationType) RetrieveStation(ctx context.Context, w http.ResponseWrite
 		}
 	}
 
+	if station.Links, err = station_type.ListStationLinks(ctx, st.db, id, "", ""); err != nil {
+		return errors.Wrapf(err, "getting links of station %q", id)
+	}
+
+	return web.Respond(ctx, w, station, http.StatusOK)
+}
+
+// ListStationLinks gets the links of a station identified by an ID in the
+// request URL. The optional direction query parameter limits the links to
+// those "from" or "to" the station and the optional type parameter to one
+// type of link.
+func (st *StationType) ListStationLinks(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Station.ListStationLinks")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+	direction := r.URL.Query().Get("direction")
+	linkType := r.URL.Query().Get("type")
+
+	switch direction {
+	case "", station_type.LinksFrom, station_type.LinksTo:
+	default:
+		return web.NewRequestError(errors.New("direction must be from or to"), http.StatusBadRequest)
+	}
+
+	links, err := station_type.ListStationLinks(ctx, st.db, id, direction, linkType)
+	if err != nil {
+		switch err {
+		case station_type.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting links of station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, links, http.StatusOK)
+}
+
+// AddStationLink decodes the body of a request to link the station identified
+// by an ID in the request URL to another station.
+func (st *StationType) AddStationLink(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Station.AddStationLink")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	var nl station_type.NewStationLink
+	if err := web.Decode(r, &nl); err != nil {
+		return errors.Wrap(err, "decoding new station link")
+	}
+
+	link, err := station_type.AddStationLink(ctx, st.db, id, nl, time.Now())
+	if err != nil {
+		switch err {
+		case station_type.ErrStationNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case station_type.ErrInvalidID, station_type.ErrLinkNotAllowed:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		case station_type.ErrLinkExists:
+			return web.NewRequestError(err, http.StatusConflict)
+		default:
+			return errors.Wrapf(err, "linking station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, link, http.StatusCreated)
+}
+
+// DeleteStationLink removes the station link identified by an ID in the
+// request URL.
+func (st *StationType) DeleteStationLink(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Station.DeleteStationLink")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := station_type.DeleteStationLink(ctx, st.db, id, time.Now()); err != nil {
+		switch err {
+		case station_type.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "deleting station link %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// ListAllStations gets all stations, optionally limited to an area of the
+// garden grid with the query parameters:
+//
+//   near=x,y&radius=r    stations within r grid units of x,y, closest first
+//   bbox=x1,y1,x2,y2     stations inside the box with corners x1,y1 and x2,y2
+func (st *StationType) ListAllStations(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Station.ListAllStations")
+	defer span.End()
+
+	filter, err := parseStationFilter(r)
+	if err != nil {
+		return web.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	if filter.IncludeArchived, err = includeArchived(ctx, r); err != nil {
+		return err
+	}
+
+	list, err := station_type.ListAllStations(ctx, st.db, filter)
+	if err != nil {
+		switch err {
+		case station_type.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrap(err, "getting station list")
+		}
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// NearestStation finds the station of the station type given by the
+// station_type_id query parameter closest to the station identified by an ID
+// in the request URL.
+func (st *StationType) NearestStation(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Station.NearestStation")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+	stationTypeID := r.URL.Query().Get("station_type_id")
+
+	station, err := station_type.NearestStation(ctx, st.db, id, stationTypeID)
+	if err != nil {
+		switch err {
+		case station_type.ErrStationNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case station_type.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting station nearest to %q", id)
+		}
+	}
+
 	return web.Respond(ctx, w, station, http.StatusOK)
 }
+
+// parseStationFilter reads the near, radius, bbox, tag and group query
+// parameters. The tag parameter may be repeated and is either a key, matching
+// stations that have the tag with any value, or key:value.
+func parseStationFilter(r *http.Request) (station_type.StationFilter, error) {
+	var filter station_type.StationFilter
+	query := r.URL.Query()
+
+	if v := query.Get("near"); v != "" {
+		n, err := parseInts(v, 2)
+		if err != nil {
+			return filter, errors.Wrap(err, "near must be x,y")
+		}
+		radius, err := strconv.Atoi(query.Get("radius"))
+		if err != nil || radius <= 0 || radius > station_type.MaxRadius {
+			return filter, errors.Errorf("radius must be a positive integer up to %d when near is set", station_type.MaxRadius)
+		}
+		filter.Near = &station_type.Point{X: n[0], Y: n[1]}
+		filter.Radius = radius
+	} else if query.Get("radius") != "" {
+		return filter, errors.New("radius can only be set along with near")
+	}
+
+	if v := query.Get("bbox"); v != "" {
+		b, err := parseInts(v, 4)
+		if err != nil {
+			return filter, errors.Wrap(err, "bbox must be x1,y1,x2,y2")
+		}
+		filter.BBox = &station_type.BBox{MinX: b[0], MinY: b[1], MaxX: b[2], MaxY: b[3]}
+		if filter.BBox.MinX > filter.BBox.MaxX {
+			filter.BBox.MinX, filter.BBox.MaxX = filter.BBox.MaxX, filter.BBox.MinX
+		}
+		if filter.BBox.MinY > filter.BBox.MaxY {
+			filter.BBox.MinY, filter.BBox.MaxY = filter.BBox.MaxY, filter.BBox.MinY
+		}
+	}
+
+	for _, v := range query["tag"] {
+		tf, err := station_type.ParseTagFilter(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.Tags = append(filter.Tags, tf)
+	}
+
+	filter.GroupId = query.Get("group")
+
+	return filter, nil
+}
+
+// parseInts splits a comma separated list of exactly n integers.
+func parseInts(v string, n int) ([]int, error) {
+	parts := strings.Split(v, ",")
+	if len(parts) != n {
+		return nil, fmt.Errorf("expected %d values, got %d", n, len(parts))
+	}
+
+	ints := make([]int, n)
+	for i, p := range parts {
+		var err error
+		if ints[i], err = strconv.Atoi(strings.TrimSpace(p)); err != nil {
+			return nil, err
+		}
+	}
+
+	return ints, nil
+}
+
+// ListStationLocations gets the location history of a station identified by an
+// ID in the request URL.
+func (st *StationType) ListStationLocations(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Station.ListStationLocations")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	list, err := station_type.ListStationLocations(ctx, st.db, id)
+	if err != nil {
+		switch err {
+		case station_type.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting locations of station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// RetrieveStationLocation finds where the station identified by an ID in the
+// request URL was at the RFC 3339 time given by the at query parameter. The
+// current location is returned when at is not provided.
+func (st *StationType) RetrieveStationLocation(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Station.RetrieveStationLocation")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	at := time.Now()
+	if v := r.URL.Query().Get("at"); v != "" {
+		var err error
+		if at, err = time.Parse(time.RFC3339, v); err != nil {
+			return web.NewRequestError(errors.Wrap(err, "at must be an RFC 3339 time"), http.StatusBadRequest)
+		}
+	}
+
+	location, err := station_type.StationLocationAt(ctx, st.db, id, at)
+	if err != nil {
+		switch err {
+		case station_type.ErrLocationNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case station_type.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting location of station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, location, http.StatusOK)
+}
+
+// includeArchived reads the include_archived query parameter. Only admins may
+// list archived records.
+func includeArchived(ctx context.Context, r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("include_archived")
+	if v == "" {
+		return false, nil
+	}
+
+	archived, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, web.NewRequestError(errors.Wrap(err, "include_archived must be true or false"), http.StatusBadRequest)
+	}
+
+	claims, ok := ctx.Value(auth.Key).(auth.Claims)
+	if !ok {
+		return false, errors.New("claims missing from context")
+	}
+	if archived && !claims.HasRole(auth.RoleAdmin) {
+		return false, mid.ErrForbidden
+	}
+
+	return archived, nil
+}
+
+// checkStationAccount confirms the station identified by id exists and belongs
+// to the account making the request. Admins may act for any station.
+func checkStationAccount(ctx context.Context, db *sqlx.DB, id string) error {
+	claims, ok := ctx.Value(auth.Key).(auth.Claims)
+	if !ok {
+		return errors.New("claims missing from context")
+	}
+
+	s, err := station_type.GetStation(ctx, db, id)
+	if err != nil {
+		switch err {
+		case station_type.ErrStationNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case station_type.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting station %q", id)
+		}
+	}
+
+	if !claims.HasRole(auth.RoleAdmin) && s.AccountId != claims.Subject {
+		return web.NewRequestError(station_type.ErrForbidden, http.StatusForbidden)
+	}
+
+	return nil
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	// Core packages
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
+	"github.com/deezone/HydroBytes-BaseStation/internal/water"
+
+	// Third party packages
+	"github.com/go-chi/chi"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Water holds handlers for flow meters and water usage.
+type Water struct {
+	db  *sqlx.DB
+	log *log.Logger
+}
+
+// ListMeters gets the flow meters of the station identified by an ID in the
+// request URL.
+func (wa *Water) ListMeters(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Water.ListMeters")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	list, err := water.ListMeters(ctx, wa.db, id)
+	if err != nil {
+		switch err {
+		case water.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting flow meters of station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// RetrieveMeter gets the flow meter identified by an ID in the request URL.
+func (wa *Water) RetrieveMeter(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Water.RetrieveMeter")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	m, err := water.GetMeter(ctx, wa.db, id)
+	if err != nil {
+		switch err {
+		case water.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case water.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting flow meter %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, m, http.StatusOK)
+}
+
+// AddMeter adds a flow meter to the station identified by an ID in the request
+// URL.
+func (wa *Water) AddMeter(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Water.AddMeter")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	var nm water.NewFlowMeter
+	if err := web.Decode(r, &nm); err != nil {
+		return errors.Wrap(err, "decoding new flow meter")
+	}
+
+	m, err := water.AddMeter(ctx, wa.db, id, nm, time.Now())
+	if err != nil {
+		switch err {
+		case water.ErrStationNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case water.ErrInvalidID, water.ErrZoneNotFound:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "adding flow meter to station %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, m, http.StatusCreated)
+}
+
+// UpdateMeter decodes the body of a request to update an existing flow meter.
+// The ID of the flow meter is part of the request URL.
+func (wa *Water) UpdateMeter(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Water.UpdateMeter")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	var um water.UpdateFlowMeter
+	if err := web.Decode(r, &um); err != nil {
+		return errors.Wrap(err, "decoding flow meter update")
+	}
+
+	if err := water.UpdateMeter(ctx, wa.db, id, um, time.Now()); err != nil {
+		switch err {
+		case water.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case water.ErrInvalidID, water.ErrZoneNotFound:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "updating flow meter %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// DeleteMeter removes the flow meter identified by an ID in the request URL
+// along with its readings.
+func (wa *Water) DeleteMeter(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Water.DeleteMeter")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := water.DeleteMeter(ctx, wa.db, id); err != nil {
+		switch err {
+		case water.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case water.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "deleting flow meter %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// RecordFlow decodes pulse counts sent by a station for the flow meter
+// identified by an ID in the request URL.
+func (wa *Water) RecordFlow(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Water.RecordFlow")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	m, err := water.GetMeter(ctx, wa.db, id)
+	if err != nil {
+		switch err {
+		case water.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case water.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting flow meter %q", id)
+		}
+	}
+
+	if err := checkStationAccount(ctx, wa.db, m.StationId); err != nil {
+		return err
+	}
+
+	var nr water.NewFlowReadings
+	if err := web.Decode(r, &nr); err != nil {
+		return errors.Wrap(err, "decoding flow readings")
+	}
+
+	readings, err := water.RecordFlow(ctx, wa.db, id, nr, time.Now())
+	if err != nil {
+		return errors.Wrapf(err, "recording flow of meter %q", id)
+	}
+
+	return web.Respond(ctx, w, readings, http.StatusCreated)
+}
+
+// ListReadings gets the readings of the flow meter identified by an ID in the
+// request URL from the last day, or since the RFC 3339 time in the since query
+// parameter.
+func (wa *Water) ListReadings(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Water.ListReadings")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	since := time.Now().Add(-24 * time.Hour)
+	if v := r.URL.Query().Get("since"); v != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, v); err != nil {
+			return web.NewRequestError(errors.Wrap(err, "since must be an RFC 3339 time"), http.StatusBadRequest)
+		}
+	}
+
+	list, err := water.ListReadings(ctx, wa.db, id, since)
+	if err != nil {
+		switch err {
+		case water.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting readings of flow meter %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// Usage gets the litres of water used per day, week or season as given by the
+// period query parameter (day when not set). The usage is grouped by station,
+// zone or meter with the group query parameter and may be limited with
+// station_id, zone_id and the RFC 3339 times from and to.
+func (wa *Water) Usage(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Water.Usage")
+	defer span.End()
+
+	query := r.URL.Query()
+
+	filter := water.UsageFilter{
+		Period:    query.Get("period"),
+		GroupBy:   query.Get("group"),
+		StationId: query.Get("station_id"),
+		ZoneId:    query.Get("zone_id"),
+	}
+	if filter.Period == "" {
+		filter.Period = water.PeriodDay
+	}
+	for name, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := query.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return web.NewRequestError(errors.Wrapf(err, "%s must be an RFC 3339 time", name), http.StatusBadRequest)
+			}
+			*dst = &t
+		}
+	}
+
+	list, err := water.ListUsage(ctx, wa.db, filter)
+	if err != nil {
+		switch err {
+		case water.ErrInvalidID, water.ErrInvalidUsage:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrap(err, "getting water usage")
+		}
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
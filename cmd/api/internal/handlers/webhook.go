@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	// Core packages
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
+	"github.com/deezone/HydroBytes-BaseStation/internal/webhook"
+
+	// Third party packages
+	"github.com/go-chi/chi"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Webhook holds handlers for webhook subscriptions.
+type Webhook struct {
+	db  *sqlx.DB
+	log *log.Logger
+}
+
+// List gets all webhook subscriptions.
+func (wh *Webhook) List(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Webhook.List")
+	defer span.End()
+
+	list, err := webhook.List(ctx, wh.db)
+	if err != nil {
+		return errors.Wrap(err, "getting webhook subscription list")
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// Retrieve gets the webhook subscription identified by an ID in the request
+// URL.
+func (wh *Webhook) Retrieve(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Webhook.Retrieve")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	s, err := webhook.Get(ctx, wh.db, id)
+	if err != nil {
+		switch err {
+		case webhook.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case webhook.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting webhook subscription %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, s, http.StatusOK)
+}
+
+// Create decodes the body of a request to create a new webhook subscription.
+// The response holds the secret deliveries are signed with. It is not
+// returned again.
+func (wh *Webhook) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Webhook.Create")
+	defer span.End()
+
+	var ns webhook.NewSubscription
+	if err := web.Decode(r, &ns); err != nil {
+		return errors.Wrap(err, "decoding new webhook subscription")
+	}
+
+	s, err := webhook.Create(ctx, wh.db, ns, time.Now())
+	if err != nil {
+		switch err {
+		case webhook.ErrInvalidEventType:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "creating webhook subscription: %s", ns.Url)
+		}
+	}
+
+	return web.Respond(ctx, w, s, http.StatusCreated)
+}
+
+// Delete removes the webhook subscription identified by an ID in the request
+// URL along with its deliveries.
+func (wh *Webhook) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Webhook.Delete")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := webhook.Delete(ctx, wh.db, id); err != nil {
+		switch err {
+		case webhook.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case webhook.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "deleting webhook subscription %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// ListDeliveries gets the most recent deliveries of the webhook subscription
+// identified by an ID in the request URL. At most limit deliveries are
+// returned when the query parameter is given.
+func (wh *Webhook) ListDeliveries(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Webhook.ListDeliveries")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	var limit int
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil {
+			return web.NewRequestError(errors.Wrap(err, "limit must be a number"), http.StatusBadRequest)
+		}
+	}
+
+	list, err := webhook.ListDeliveries(ctx, wh.db, id, limit)
+	if err != nil {
+		switch err {
+		case webhook.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case webhook.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting deliveries of webhook subscription %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
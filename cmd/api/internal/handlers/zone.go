@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	// Core packages
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
+	"github.com/deezone/HydroBytes-BaseStation/internal/reading"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
+	"github.com/deezone/HydroBytes-BaseStation/internal/zone"
+
+	// Third party packages
+	"github.com/go-chi/chi"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Zone holds handlers for dealing with garden zones.
+type Zone struct {
+	db  *sqlx.DB
+	log *log.Logger
+}
+
+// Create decodes the body of a request to create a new zone. The full zone
+// with generated fields is sent back in the response.
+func (z *Zone) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Zone.Create")
+	defer span.End()
+
+	var nz zone.NewZone
+	if err := web.Decode(r, &nz); err != nil {
+		return errors.Wrap(err, "decoding new zone")
+	}
+
+	created, err := zone.Create(ctx, z.db, nz, time.Now())
+	if err != nil {
+		return errors.Wrap(err, "creating new zone")
+	}
+
+	return web.Respond(ctx, w, created, http.StatusCreated)
+}
+
+// Delete removes a single zone identified by an ID in the request URL.
+func (z *Zone) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Zone.Delete")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	if err := zone.Delete(ctx, z.db, id); err != nil {
+		switch err {
+		case zone.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "deleting zone %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// List returns all of the zones in the garden.
+func (z *Zone) List(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Zone.List")
+	defer span.End()
+
+	list, err := zone.List(ctx, z.db)
+	if err != nil {
+		return errors.Wrap(err, "getting zone list")
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// Retrieve finds a zone identified by a zone ID in the request URL.
+func (z *Zone) Retrieve(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Zone.Retrieve")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	found, err := zone.Get(ctx, z.db, id)
+	if err != nil {
+		switch err {
+		case zone.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case zone.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting zone %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, found, http.StatusOK)
+}
+
+// Update decodes the body of a request to update an existing zone. The ID of
+// the zone is part of the request URL.
+func (z *Zone) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Zone.Update")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	var update zone.UpdateZone
+	if err := web.Decode(r, &update); err != nil {
+		return errors.Wrap(err, "decoding zone update")
+	}
+
+	if err := zone.Update(ctx, z.db, id, update, time.Now()); err != nil {
+		switch err {
+		case zone.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case zone.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "updating zone %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// ListStations gets all stations assigned to a zone.
+func (z *Zone) ListStations(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Zone.ListStations")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	list, err := station_type.ListZoneStations(ctx, z.db, id)
+	if err != nil {
+		switch err {
+		case station_type.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting stations for zone %q", id)
+		}
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
+
+// Readings gives the minimum, average and maximum of each sensor over the
+// readings of the stations in a zone, optionally of the sensor query parameter
+// only and read between the RFC 3339 times since and until.
+func (z *Zone) Readings(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+	ctx, span := trace.StartSpan(ctx, "handlers.Zone.Readings")
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+	query := r.URL.Query()
+
+	if _, err := zone.Get(ctx, z.db, id); err != nil {
+		switch err {
+		case zone.ErrNotFound:
+			return web.NewRequestError(err, http.StatusNotFound)
+		case zone.ErrInvalidID:
+			return web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return errors.Wrapf(err, "getting zone %q", id)
+		}
+	}
+
+	q := reading.Query{Sensor: query.Get("sensor")}
+
+	for name, t := range map[string]**time.Time{"since": &q.Since, "until": &q.Until} {
+		if v := query.Get(name); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return web.NewRequestError(errors.Wrapf(err, "%s must be an RFC 3339 time", name), http.StatusBadRequest)
+			}
+			*t = &parsed
+		}
+	}
+
+	list, err := reading.ZoneAggregates(ctx, z.db, id, q)
+	if err != nil {
+		return errors.Wrapf(err, "getting readings of zone %q", id)
+	}
+
+	return web.Respond(ctx, w, list, http.StatusOK)
+}
@@ -16,10 +16,20 @@ import (
 	"time"
 
 	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/alert"
+	"github.com/deezone/HydroBytes-BaseStation/internal/anomaly"
+	"github.com/deezone/HydroBytes-BaseStation/internal/notify"
+	"github.com/deezone/HydroBytes-BaseStation/internal/outbox"
 	"github.com/deezone/HydroBytes-BaseStation/internal/platform/auth"
 	"github.com/deezone/HydroBytes-BaseStation/cmd/api/internal/handlers"
 	"github.com/deezone/HydroBytes-BaseStation/internal/platform/conf"
 	"github.com/deezone/HydroBytes-BaseStation/internal/platform/database"
+	"github.com/deezone/HydroBytes-BaseStation/internal/protection"
+	"github.com/deezone/HydroBytes-BaseStation/internal/report"
+	"github.com/deezone/HydroBytes-BaseStation/internal/reservoir"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_log"
+	"github.com/deezone/HydroBytes-BaseStation/internal/water"
+	"github.com/deezone/HydroBytes-BaseStation/internal/webhook"
 
 	// Third-party packages
 	"contrib.go.opencensus.io/exporter/zipkin"
@@ -78,6 +88,42 @@ func run() error {
 			PrivateKeyFile string `conf:"default:private.pem"`
 			Algorithm      string `conf:"default:RS256"`
 		}
+		Water struct {
+			LeakCheckInterval      time.Duration `conf:"default:1m"`
+			CloseValveOnLeak       bool          `conf:"default:false"`
+			ReservoirCheckInterval time.Duration `conf:"default:1h"`
+		}
+		Alert struct {
+			EvaluateInterval time.Duration `conf:"default:1m"`
+		}
+		Anomaly struct {
+			ScanInterval time.Duration `conf:"default:1m"`
+		}
+		Protection struct {
+			EvaluateInterval time.Duration `conf:"default:5m"`
+		}
+		Outbox struct {
+			RelayInterval time.Duration `conf:"default:5s"`
+		}
+		Webhook struct {
+			DeliverInterval time.Duration `conf:"default:10s"`
+			Timeout         time.Duration `conf:"default:10s"`
+		}
+		SMTP struct {
+			Host             string        // email notifications are disabled when empty
+			Port             int           `conf:"default:25"`
+			Username         string
+			Password         string        `conf:"noprint"`
+			From             string        `conf:"default:base-station@localhost"`
+			DispatchInterval time.Duration `conf:"default:1m"`
+		}
+		Report struct {
+			Dir           string        // weekly reports are not written when empty
+			CheckInterval time.Duration `conf:"default:1h"`
+		}
+		Log struct {
+			PruneInterval time.Duration `conf:"default:1h"`
+		}
 		Trace struct {
 			URL         string  `conf:"default:http://localhost:9411/api/v2/spans"`
 			Service     string  `conf:"default:station-api"`
@@ -165,6 +211,102 @@ func run() error {
 		log.Println("debug service closed", err)
 	}()
 
+	// =========================================================================
+	// Start Background Workers
+
+	// Workers stop when run returns.
+	workers, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	// Station and station type changes are relayed from the outbox to the
+	// event log.
+	go runEvery(workers, log, "relaying outbox", cfg.Outbox.RelayInterval, func(ctx context.Context, now time.Time) error {
+		_, err := outbox.Relay(ctx, db, outbox.ConsumerEvents, outbox.RecordEvents(db), now)
+		return err
+	})
+
+	// Flow readings received since the previous check are checked for leaks.
+	leakOpts := water.DefaultLeakOptions
+	leakOpts.CloseValve = cfg.Water.CloseValveOnLeak
+	leaksFrom := time.Now()
+	go runEvery(workers, log, "detecting leaks", cfg.Water.LeakCheckInterval, func(ctx context.Context, now time.Time) error {
+		if _, err := water.DetectLeaks(ctx, db, leaksFrom, now, leakOpts, now); err != nil {
+			return err
+		}
+		leaksFrom = now
+		return nil
+	})
+
+	go runEvery(workers, log, "evaluating alerts", cfg.Alert.EvaluateInterval, func(ctx context.Context, now time.Time) error {
+		_, err := alert.Evaluate(ctx, db, now)
+		return err
+	})
+
+	// Sensor readings received since the previous scan update the statistics
+	// of their sensor and are checked for spikes, flat lines and drift.
+	go runEvery(workers, log, "detecting anomalies", cfg.Anomaly.ScanInterval, func(ctx context.Context, now time.Time) error {
+		_, err := anomaly.Scan(ctx, db, anomaly.DefaultOptions, now)
+		return err
+	})
+
+	// Frost and heat conditions pause irrigation or queue extra watering as
+	// the protection settings of the station type ask.
+	go runEvery(workers, log, "protecting from frost and heat", cfg.Protection.EvaluateInterval, func(ctx context.Context, now time.Time) error {
+		_, err := protection.Evaluate(ctx, db, now)
+		return err
+	})
+
+	go runEvery(workers, log, "forecasting reservoirs", cfg.Water.ReservoirCheckInterval, func(ctx context.Context, now time.Time) error {
+		_, err := reservoir.Warn(ctx, db, now)
+		return err
+	})
+
+	// Station log lines older than the log retention of their station are
+	// removed.
+	go runEvery(workers, log, "pruning station logs", cfg.Log.PruneInterval, func(ctx context.Context, now time.Time) error {
+		_, err := station_log.Prune(ctx, db, now)
+		return err
+	})
+
+	// Events are queued for their webhook subscriptions and posted, retrying
+	// failed deliveries.
+	hooks := &http.Client{Timeout: cfg.Webhook.Timeout}
+	go runEvery(workers, log, "delivering webhooks", cfg.Webhook.DeliverInterval, func(ctx context.Context, now time.Time) error {
+		if _, err := webhook.Enqueue(ctx, db, now); err != nil {
+			return err
+		}
+		_, err := webhook.Deliver(ctx, db, hooks, now)
+		return err
+	})
+
+	// Events are emailed to the accounts that asked to be notified of them.
+	if cfg.SMTP.Host != "" {
+		mailer := notify.NewMailer(notify.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.SMTP.From,
+		})
+		go runEvery(workers, log, "sending notifications", cfg.SMTP.DispatchInterval, func(ctx context.Context, now time.Time) error {
+			_, err := notify.Dispatch(ctx, db, mailer, now)
+			return err
+		})
+	}
+
+	// The report of each week is written once the week has ended, early on
+	// the Monday after it.
+	if cfg.Report.Dir != "" {
+		go runEvery(workers, log, "writing weekly reports", cfg.Report.CheckInterval, func(ctx context.Context, now time.Time) error {
+			from := report.LastWeek(now)
+			written, err := report.Save(ctx, db, cfg.Report.Dir, from, now)
+			if written {
+				log.Printf("main : wrote report of week %s to %s", report.FormatWeek(from), cfg.Report.Dir)
+			}
+			return err
+		})
+	}
+
 	// =========================================================================
 	// Start API Service
 
@@ -244,6 +386,24 @@ func run() error {
 	return nil
 }
 
+// runEvery calls fn every interval until ctx is cancelled. Errors are logged
+// and fn is tried again at the next interval.
+func runEvery(ctx context.Context, log *log.Logger, name string, interval time.Duration, fn func(ctx context.Context, now time.Time) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := fn(ctx, now); err != nil {
+				log.Printf("main : %s : %v", name, err)
+			}
+		}
+	}
+}
+
 func createAuth(privateKeyFile, keyID, algorithm string) (*auth.Authenticator, error) {
 
 	keyContents, err := ioutil.ReadFile(privateKeyFile)
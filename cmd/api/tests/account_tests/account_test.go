@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	// Internal packages
@@ -28,6 +29,9 @@ func TestAccount(t *testing.T) {
 	t.Run("TokenDenyUnknown", ut.TokenDenyUnknown)
 	t.Run("TokenDenyBadPassword", ut.TokenDenyBadPassword)
 	t.Run("TokenSuccess", ut.TokenSuccess)
+	t.Run("DeleteWithStations", ut.DeleteWithStations)
+	t.Run("DeleteCascadeRestore", ut.DeleteCascadeRestore)
+	t.Run("DeleteWithArchivedStations", ut.DeleteWithArchivedStations)
 }
 
 // AccountTests holds methods for each account subtest. This type allows passing
@@ -104,3 +108,134 @@ func (at *AccountTests) TokenSuccess(t *testing.T) {
 		t.Fatal("token was not in response")
 	}
 }
+
+// DeleteWithStations ensures an account that owns stations is only deleted when
+// its stations are transferred or archived along with it.
+func (at *AccountTests) DeleteWithStations(t *testing.T) {
+
+	// "Station 0001" owns Plant Station 0001 in the seed data.
+	url := "/v1/account/" + tests.AccountOneId
+
+	{ // Refused while the account has stations.
+		req := httptest.NewRequest("DELETE", url, nil)
+		req.Header.Set("Authorization", "Bearer " + at.adminToken)
+		resp := httptest.NewRecorder()
+
+		at.app.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusConflict {
+			t.Fatalf("deleting: expected status code %v, got %v", http.StatusConflict, resp.Code)
+		}
+
+		var conflict struct {
+			Error    string                   `json:"error"`
+			Stations []map[string]interface{} `json:"stations"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&conflict); err != nil {
+			t.Fatalf("decoding: %s", err)
+		}
+
+		if len(conflict.Stations) != 1 || conflict.Stations[0]["id"] != "d58f6d32-6332-11eb-ae93-0242ac130002" {
+			t.Fatalf("expected Plant Station 0001 to be listed as a dependant, got %v", conflict.Stations)
+		}
+	}
+
+	{ // Transferring the stations to the account itself is refused.
+		req := httptest.NewRequest("DELETE", url + "?transfer_to=" + tests.AccountOneId, nil)
+		req.Header.Set("Authorization", "Bearer " + at.adminToken)
+		resp := httptest.NewRecorder()
+
+		at.app.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusBadRequest {
+			t.Fatalf("deleting: expected status code %v, got %v", http.StatusBadRequest, resp.Code)
+		}
+	}
+
+	{ // Transfer the stations to the admin account.
+		req := httptest.NewRequest("DELETE", url + "?transfer_to=" + tests.AdminId, nil)
+		req.Header.Set("Authorization", "Bearer " + at.adminToken)
+		resp := httptest.NewRecorder()
+
+		at.app.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusNoContent {
+			t.Fatalf("deleting: expected status code %v, got %v", http.StatusNoContent, resp.Code)
+		}
+
+		req = httptest.NewRequest("GET", "/v1/station/d58f6d32-6332-11eb-ae93-0242ac130002", nil)
+		req.Header.Set("Authorization", "Bearer " + at.adminToken)
+		resp = httptest.NewRecorder()
+
+		at.app.ServeHTTP(resp, req)
+
+		var station map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&station); err != nil {
+			t.Fatalf("decoding: %s", err)
+		}
+		if !strings.EqualFold(tests.AdminId, station["account_id"].(string)) {
+			t.Fatalf("expected station to be transferred to %v, got %v", tests.AdminId, station["account_id"])
+		}
+	}
+}
+
+// DeleteCascadeRestore ensures a cascading delete archives the account along
+// with its stations so both can be restored.
+func (at *AccountTests) DeleteCascadeRestore(t *testing.T) {
+
+	// The account afb7c618-6332-11eb-ae93-0242ac130002 owns Plant Station 0002
+	// in the seed data.
+	const station = "/v1/station/27356858-6333-11eb-ae93-0242ac130002"
+	url := "/v1/account/afb7c618-6332-11eb-ae93-0242ac130002"
+
+	for _, step := range []struct {
+		method, url string
+		exp         int
+	}{
+		{"DELETE", url + "?cascade=true", http.StatusNoContent},
+		{"GET", station, http.StatusNotFound},
+		{"POST", url + "/restore", http.StatusNoContent},
+		{"GET", station, http.StatusOK},
+		{"POST", url + "/restore", http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(step.method, step.url, nil)
+		req.Header.Set("Authorization", "Bearer " + at.adminToken)
+		resp := httptest.NewRecorder()
+
+		at.app.ServeHTTP(resp, req)
+
+		if resp.Code != step.exp {
+			t.Fatalf("%s %s: expected status code %v, got %v", step.method, step.url, step.exp, resp.Code)
+		}
+	}
+}
+
+// DeleteWithArchivedStations ensures an account whose stations are all
+// archived is deleted without transferring or archiving them, and that a
+// transfer is refused when the account to transfer to does not exist.
+func (at *AccountTests) DeleteWithArchivedStations(t *testing.T) {
+
+	// The account c08cfdf0-6332-11eb-ae93-0242ac130002 owns Plant Station 0003
+	// in the seed data.
+	url := "/v1/account/c08cfdf0-6332-11eb-ae93-0242ac130002"
+
+	for _, step := range []struct {
+		method, url string
+		exp         int
+	}{
+		{"DELETE", url + "?transfer_to=0b6e4d2c-3f8a-4c59-9e21-7d5a6b8c9f01", http.StatusBadRequest},
+		{"DELETE", "/v1/station/342c0d0a-6333-11eb-ae93-0242ac130002", http.StatusNoContent},
+		{"DELETE", url, http.StatusNoContent},
+		{"DELETE", url, http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(step.method, step.url, nil)
+		req.Header.Set("Authorization", "Bearer " + at.adminToken)
+		resp := httptest.NewRecorder()
+
+		at.app.ServeHTTP(resp, req)
+
+		if resp.Code != step.exp {
+			t.Fatalf("%s %s: expected status code %v, got %v", step.method, step.url, step.exp, resp.Code)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package garden_tests
+
+import (
+	// Core Packages
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/cmd/api/internal/handlers"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+)
+
+// TestGarden runs a series of tests to exercise the whole garden views from
+// the API level.
+func TestGarden(t *testing.T) {
+	test := tests.New(t)
+	defer test.Teardown()
+
+	shutdown := make(chan os.Signal, 1)
+	gardenTests := GardenTests{
+		app:        handlers.API(shutdown, test.Db, test.Log, test.Authenticator),
+		adminToken: test.Token("Admin", "gophers"),
+	}
+
+	t.Run("Map", gardenTests.Map)
+}
+
+// GardenTests holds methods for each garden subtest.
+type GardenTests struct {
+	app        http.Handler
+	adminToken string
+}
+
+func (gt *GardenTests) Map(t *testing.T) {
+	req := httptest.NewRequest("GET", "/v1/garden/map.svg", nil)
+	req.Header.Set("Authorization", "Bearer "+gt.adminToken)
+	resp := httptest.NewRecorder()
+
+	gt.app.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("getting: expected status code %v, got %v", http.StatusOK, resp.Code)
+	}
+
+	if exp, got := "image/svg+xml", resp.Header().Get("Content-Type"); exp != got {
+		t.Fatalf("expected content type %q, got %q", exp, got)
+	}
+
+	// Every seeded zone and station should be drawn.
+	body := resp.Body.String()
+	for _, name := range []string{"Tomato row", "Herb box", "Base Station one", "Water Station one", "Plant Station 0003"} {
+		if !strings.Contains(body, name) {
+			t.Errorf("expected map to contain %q", name)
+		}
+	}
+}
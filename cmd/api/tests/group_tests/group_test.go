@@ -0,0 +1,214 @@
+package group_tests
+
+import (
+	// Core Packages
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	// NOTE: Models should not be imported, we want to test the exact JSON. We
+	// make the comparison process easier using the go-cmp library.
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/cmd/api/internal/handlers"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+
+	// Third-party packages
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestGroup runs a series of tests to exercise Group behavior from the API
+// level. The subtests all share the same database and application so the
+// order the tests are run matters.
+func TestGroup(t *testing.T) {
+	test := tests.New(t)
+	defer test.Teardown()
+
+	shutdown := make(chan os.Signal, 1)
+	groupTests := GroupTests{
+		app:        handlers.API(shutdown, test.Db, test.Log, test.Authenticator),
+		adminToken: test.Token("Admin", "gophers"),
+	}
+
+	t.Run("ListStations", groupTests.ListStations)
+	t.Run("ListStationsByTag", groupTests.ListStationsByTag)
+	t.Run("GroupCRUD", groupTests.GroupCRUD)
+}
+
+// GroupTests holds methods for each group subtest.
+type GroupTests struct {
+	app        http.Handler
+	adminToken string
+}
+
+func (gt *GroupTests) ListStations(t *testing.T) {
+
+	// Get the stations in the "Seedlings" group as defined in the seed data
+	req := httptest.NewRequest("GET", "/v1/group/0b6f2a4e-93d1-4c58-8e27-6d1a5f3c9b70/stations", nil)
+	req.Header.Set("Authorization", "Bearer "+gt.adminToken)
+	resp := httptest.NewRecorder()
+
+	gt.app.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("getting: expected status code %v, got %v", http.StatusOK, resp.Code)
+	}
+
+	var list []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatalf("decoding: %s", err)
+	}
+
+	if exp, got := 2, len(list); exp != got {
+		t.Fatalf("expected %v stations, got %v", exp, got)
+	}
+}
+
+func (gt *GroupTests) ListStationsByTag(t *testing.T) {
+
+	// Tag Plant Station 0001
+	body := strings.NewReader(`{"tags":{"hardware":"v2"}}`)
+	req := httptest.NewRequest("PUT", "/v1/station/d58f6d32-6332-11eb-ae93-0242ac130002", body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+gt.adminToken)
+	resp := httptest.NewRecorder()
+
+	gt.app.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusNoContent {
+		t.Fatalf("tagging: expected status code %v, got %v", http.StatusNoContent, resp.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/v1/stations?tag=hardware:v2&group=0b6f2a4e-93d1-4c58-8e27-6d1a5f3c9b70", nil)
+	req.Header.Set("Authorization", "Bearer "+gt.adminToken)
+	resp = httptest.NewRecorder()
+
+	gt.app.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("getting: expected status code %v, got %v", http.StatusOK, resp.Code)
+	}
+
+	var list []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatalf("decoding: %s", err)
+	}
+
+	if exp, got := 1, len(list); exp != got {
+		t.Fatalf("expected %v stations, got %v", exp, got)
+	}
+	if diff := cmp.Diff(map[string]interface{}{"hardware": "v2"}, list[0]["tags"]); diff != "" {
+		t.Fatalf("tags did not match. Diff:\n%s", diff)
+	}
+
+	// An invalid group is a bad request.
+	req = httptest.NewRequest("GET", "/v1/stations?group=abc123", nil)
+	req.Header.Set("Authorization", "Bearer "+gt.adminToken)
+	resp = httptest.NewRecorder()
+
+	gt.app.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("getting: expected status code %v, got %v", http.StatusBadRequest, resp.Code)
+	}
+}
+
+func (gt *GroupTests) GroupCRUD(t *testing.T) {
+	var actual map[string]interface{}
+
+	{ // CREATE
+		body := strings.NewReader(`{"name":"group0","description":"Test description 0"}`)
+		req := httptest.NewRequest("POST", "/v1/group", body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+gt.adminToken)
+		resp := httptest.NewRecorder()
+
+		gt.app.ServeHTTP(resp, req)
+
+		if http.StatusCreated != resp.Code {
+			t.Fatalf("posting: expected status code %v, got %v", http.StatusCreated, resp.Code)
+		}
+
+		if err := json.NewDecoder(resp.Body).Decode(&actual); err != nil {
+			t.Fatalf("decoding: %s", err)
+		}
+
+		expected := map[string]interface{}{
+			"id":           actual["id"],
+			"date_created": actual["date_created"],
+			"date_updated": actual["date_updated"],
+			"name":         "group0",
+			"description":  "Test description 0",
+			"stations":     float64(0),
+		}
+
+		if diff := cmp.Diff(expected, actual); diff != "" {
+			t.Fatalf("Response did not match expected. Diff:\n%s", diff)
+		}
+	}
+
+	url := fmt.Sprintf("/v1/group/%s", actual["id"])
+
+	{ // ADD STATION
+		req := httptest.NewRequest("PUT", url+"/station/342c0d0a-6333-11eb-ae93-0242ac130002", nil)
+		req.Header.Set("Authorization", "Bearer "+gt.adminToken)
+		resp := httptest.NewRecorder()
+
+		gt.app.ServeHTTP(resp, req)
+
+		if http.StatusNoContent != resp.Code {
+			t.Fatalf("adding station: expected status code %v, got %v", http.StatusNoContent, resp.Code)
+		}
+
+		req = httptest.NewRequest("GET", url, nil)
+		req.Header.Set("Authorization", "Bearer "+gt.adminToken)
+		resp = httptest.NewRecorder()
+
+		gt.app.ServeHTTP(resp, req)
+
+		var fetched map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&fetched); err != nil {
+			t.Fatalf("decoding: %s", err)
+		}
+		if exp, got := float64(1), fetched["stations"]; exp != got {
+			t.Fatalf("expected %v stations, got %v", exp, got)
+		}
+	}
+
+	{ // REMOVE STATION
+		req := httptest.NewRequest("DELETE", url+"/station/342c0d0a-6333-11eb-ae93-0242ac130002", nil)
+		req.Header.Set("Authorization", "Bearer "+gt.adminToken)
+		resp := httptest.NewRecorder()
+
+		gt.app.ServeHTTP(resp, req)
+
+		if http.StatusNoContent != resp.Code {
+			t.Fatalf("removing station: expected status code %v, got %v", http.StatusNoContent, resp.Code)
+		}
+	}
+
+	{ // DELETE
+		req := httptest.NewRequest("DELETE", url, nil)
+		req.Header.Set("Authorization", "Bearer "+gt.adminToken)
+		resp := httptest.NewRecorder()
+
+		gt.app.ServeHTTP(resp, req)
+
+		if http.StatusNoContent != resp.Code {
+			t.Fatalf("deleting: expected status code %v, got %v", http.StatusNoContent, resp.Code)
+		}
+
+		req = httptest.NewRequest("GET", url, nil)
+		req.Header.Set("Authorization", "Bearer "+gt.adminToken)
+		resp = httptest.NewRecorder()
+
+		gt.app.ServeHTTP(resp, req)
+
+		if http.StatusNotFound != resp.Code {
+			t.Fatalf("retrieving: expected status code %v, got %v", http.StatusNotFound, resp.Code)
+		}
+	}
+}
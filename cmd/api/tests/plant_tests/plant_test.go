@@ -0,0 +1,148 @@
+package plant_tests
+
+import (
+	// Core Packages
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	// NOTE: Models should not be imported, we want to test the exact JSON. We
+	// make the comparison process easier using the go-cmp library.
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/cmd/api/internal/handlers"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+
+	// Third-party packages
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestPlant runs a series of tests to exercise Plant behavior from the API
+// level. The subtests all share the same database and application so the
+// order the tests are run matters.
+func TestPlant(t *testing.T) {
+	test := tests.New(t)
+	defer test.Teardown()
+
+	shutdown := make(chan os.Signal, 1)
+	plantTests := PlantTests{
+		app:        handlers.API(shutdown, test.Db, test.Log, test.Authenticator),
+		adminToken: test.Token("Admin", "gophers"),
+	}
+
+	t.Run("CreateRequiresFields", plantTests.CreateRequiresFields)
+	t.Run("DeletePlanted", plantTests.DeletePlanted)
+	t.Run("Planting", plantTests.Planting)
+}
+
+// PlantTests holds methods for each plant subtest.
+type PlantTests struct {
+	app        http.Handler
+	adminToken string
+}
+
+func (pt *PlantTests) CreateRequiresFields(t *testing.T) {
+	body := strings.NewReader(`{}`)
+	req := httptest.NewRequest("POST", "/v1/plant", body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+pt.adminToken)
+	resp := httptest.NewRecorder()
+
+	pt.app.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("posting: expected status code %v, got %v", http.StatusBadRequest, resp.Code)
+	}
+}
+
+func (pt *PlantTests) DeletePlanted(t *testing.T) {
+
+	// The seeded tomatoes are planted at the plant stations.
+	req := httptest.NewRequest("DELETE", "/v1/plant/6e3c1a9d-52b7-4f08-b1d4-7a2e9c0f5b36", nil)
+	req.Header.Set("Authorization", "Bearer "+pt.adminToken)
+	resp := httptest.NewRecorder()
+
+	pt.app.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusConflict {
+		t.Fatalf("deleting: expected status code %v, got %v", http.StatusConflict, resp.Code)
+	}
+}
+
+func (pt *PlantTests) Planting(t *testing.T) {
+	url := "/v1/station/342c0d0a-6333-11eb-ae93-0242ac130002"
+
+	var current map[string]interface{}
+
+	{ // The thresholds come from the seeded tomatoes.
+		req := httptest.NewRequest("GET", url+"/thresholds", nil)
+		req.Header.Set("Authorization", "Bearer "+pt.adminToken)
+		resp := httptest.NewRecorder()
+
+		pt.app.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusOK {
+			t.Fatalf("getting: expected status code %v, got %v", http.StatusOK, resp.Code)
+		}
+
+		if err := json.NewDecoder(resp.Body).Decode(&current); err != nil {
+			t.Fatalf("decoding: %s", err)
+		}
+
+		expected := map[string]interface{}{
+			"station_id":   "342c0d0a-6333-11eb-ae93-0242ac130002",
+			"planting_id":  current["planting_id"],
+			"plant_id":     "6e3c1a9d-52b7-4f08-b1d4-7a2e9c0f5b36",
+			"moisture_min": float64(40),
+			"moisture_max": float64(70),
+		}
+
+		if diff := cmp.Diff(expected, current); diff != "" {
+			t.Fatalf("Response did not match expected. Diff:\n%s", diff)
+		}
+	}
+
+	{ // Remove the tomatoes and plant basil.
+		req := httptest.NewRequest("DELETE", fmt.Sprintf("/v1/planting/%s", current["planting_id"]), nil)
+		req.Header.Set("Authorization", "Bearer "+pt.adminToken)
+		resp := httptest.NewRecorder()
+
+		pt.app.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusNoContent {
+			t.Fatalf("removing: expected status code %v, got %v", http.StatusNoContent, resp.Code)
+		}
+
+		body := strings.NewReader(`{"plant_id":"f1a7d3c5-0e94-4b62-8d3f-c59b2e7a1048"}`)
+		req = httptest.NewRequest("POST", url+"/planting", body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+pt.adminToken)
+		resp = httptest.NewRecorder()
+
+		pt.app.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusCreated {
+			t.Fatalf("planting: expected status code %v, got %v", http.StatusCreated, resp.Code)
+		}
+	}
+
+	{ // The station now has the moisture range of basil.
+		req := httptest.NewRequest("GET", url+"/thresholds", nil)
+		req.Header.Set("Authorization", "Bearer "+pt.adminToken)
+		resp := httptest.NewRecorder()
+
+		pt.app.ServeHTTP(resp, req)
+
+		var actual map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&actual); err != nil {
+			t.Fatalf("decoding: %s", err)
+		}
+
+		if exp, got := float64(50), actual["moisture_min"]; exp != got {
+			t.Fatalf("expected moisture_min %v, got %v", exp, got)
+		}
+	}
+}
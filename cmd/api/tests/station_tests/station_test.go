@@ -37,6 +37,10 @@ func TestStation(t *testing.T) {
 	}
 
 	t.Run("ListStations", productTests.ListStations)
+	t.Run("ListStationsNear", productTests.ListStationsNear)
+	t.Run("ListStationsBadArea", productTests.ListStationsBadArea)
+	t.Run("StationLogsNotFound", productTests.StationLogsNotFound)
+	t.Run("StationLinks", productTests.StationLinks)
 	t.Run("CreateRequiresFields", productTests.CreateRequiresFields)
 	t.Run("StationCRUD", productTests.StationCRUD)
 }
@@ -73,6 +77,7 @@ func (st *StationTests) ListStations(t *testing.T) {
 			"id":              "d58f6d32-6332-11eb-ae93-0242ac130002",
 			"station_type_id": "5c86bbaa-4ef8-11eb-ae93-0242ac130002",
 			"account_id":      "45b5fbd3-755f-4379-8f07-a58d4a30fa2f",
+			"zone_id":         "8a1f5c2e-7d3b-4e0a-9c61-2b4d8e6f0a13",
 			"name":            "Plant Station 0001",
 			"description":     "Some description of Plant Station one",
 			"location_x":      float64(3),
@@ -84,6 +89,7 @@ func (st *StationTests) ListStations(t *testing.T) {
 			"id":              "27356858-6333-11eb-ae93-0242ac130002",
 			"station_type_id": "5c86bbaa-4ef8-11eb-ae93-0242ac130002",
 			"account_id":      "afb7c618-6332-11eb-ae93-0242ac130002",
+			"zone_id":         "8a1f5c2e-7d3b-4e0a-9c61-2b4d8e6f0a13",
 			"name":            "Plant Station 0002",
 			"description":     "Some description of Plant Station two",
 			"location_x":      float64(4),
@@ -95,6 +101,7 @@ func (st *StationTests) ListStations(t *testing.T) {
 			"id":              "342c0d0a-6333-11eb-ae93-0242ac130002",
 			"station_type_id": "5c86bbaa-4ef8-11eb-ae93-0242ac130002",
 			"account_id":      "c08cfdf0-6332-11eb-ae93-0242ac130002",
+			"zone_id":         "8a1f5c2e-7d3b-4e0a-9c61-2b4d8e6f0a13",
 			"name":            "Plant Station 0003",
 			"description":     "Some description of Plant Station three",
 			"location_x":      float64(5),
@@ -109,6 +116,120 @@ func (st *StationTests) ListStations(t *testing.T) {
 	}
 }
 
+func (st *StationTests) ListStationsNear(t *testing.T) {
+
+	// Plant Station 0001 and 0002 are within one grid unit of 3,3 in the seed data
+	req := httptest.NewRequest("GET", "/v1/stations?near=3,3&radius=1", nil)
+	resp := httptest.NewRecorder()
+
+	req.Header.Set("Authorization", "Bearer " + st.adminToken)
+
+	st.app.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("getting: expected status code %v, got %v", http.StatusOK, resp.Code)
+	}
+
+	var list []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatalf("decoding: %s", err)
+	}
+
+	var ids []interface{}
+	for _, s := range list {
+		ids = append(ids, s["id"])
+	}
+
+	expected := []interface{}{
+		"d58f6d32-6332-11eb-ae93-0242ac130002",
+		"27356858-6333-11eb-ae93-0242ac130002",
+	}
+
+	if diff := cmp.Diff(expected, ids); diff != "" {
+		t.Fatalf("Response did not match expected. Diff:\n%s", diff)
+	}
+}
+
+func (st *StationTests) StationLinks(t *testing.T) {
+
+	// Plant Station 0002 is irrigated by channel 2 of the zone-2 valve of Water Station one
+	req := httptest.NewRequest("GET", "/v1/station/27356858-6333-11eb-ae93-0242ac130002", nil)
+	resp := httptest.NewRecorder()
+
+	req.Header.Set("Authorization", "Bearer " + st.adminToken)
+
+	st.app.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("getting: expected status code %v, got %v", http.StatusOK, resp.Code)
+	}
+
+	var station map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&station); err != nil {
+		t.Fatalf("decoding: %s", err)
+	}
+
+	expected := []interface{}{
+		map[string]interface{}{
+			"id":              "2d8f5c3b-9064-4ea1-b7f2-4c0e6d3a8f15",
+			"type":            "irrigates",
+			"from_station_id": "ee72a90c-590c-11eb-ae93-0242ac130002",
+			"to_station_id":   "27356858-6333-11eb-ae93-0242ac130002",
+			"valve":           "zone-2",
+			"channel":         float64(2),
+			"date_created":    "2021-01-01T00:00:05.000001Z",
+		},
+	}
+
+	if diff := cmp.Diff(expected, station["links"]); diff != "" {
+		t.Fatalf("Response did not match expected. Diff:\n%s", diff)
+	}
+
+	// A Water station can not be the backup of a Plant station
+	body := strings.NewReader(`{"type":"backup_of","to_station_id":"27356858-6333-11eb-ae93-0242ac130002"}`)
+	req = httptest.NewRequest("POST", "/v1/station/ee72a90c-590c-11eb-ae93-0242ac130002/link", body)
+	resp = httptest.NewRecorder()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer " + st.adminToken)
+
+	st.app.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("linking: expected status code %v, got %v", http.StatusBadRequest, resp.Code)
+	}
+}
+
+func (st *StationTests) ListStationsBadArea(t *testing.T) {
+	for _, query := range []string{"near=3&radius=1", "near=3,3", "bbox=1,2,3", "near=3,3&radius=0", "near=3,3&radius=-2", "near=3,3&radius=100000", "radius=5"} {
+		req := httptest.NewRequest("GET", "/v1/stations?" + query, nil)
+		resp := httptest.NewRecorder()
+
+		req.Header.Set("Authorization", "Bearer " + st.adminToken)
+
+		st.app.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusBadRequest {
+			t.Fatalf("getting %q: expected status code %v, got %v", query, http.StatusBadRequest, resp.Code)
+		}
+	}
+}
+
+func (st *StationTests) StationLogsNotFound(t *testing.T) {
+	for _, path := range []string{"logs", "logs/tail?wait=0", "logs/retention"} {
+		req := httptest.NewRequest("GET", "/v1/station/4e9b1a7c-0d2f-4c3b-9a8e-5f6d7c8b9a01/" + path, nil)
+		resp := httptest.NewRecorder()
+
+		req.Header.Set("Authorization", "Bearer " + st.adminToken)
+
+		st.app.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusNotFound {
+			t.Fatalf("getting %q: expected status code %v, got %v", path, http.StatusNotFound, resp.Code)
+		}
+	}
+}
+
 func (st *StationTests) CreateRequiresFields(t *testing.T) {
 	body := strings.NewReader(`{}`)
 	req := httptest.NewRequest("POST", "/v1/station-type/5c86bbaa-4ef8-11eb-ae93-0242ac130002/station", body)
@@ -161,6 +282,7 @@ func (st *StationTests) StationCRUD(t *testing.T) {
 			"id":           actual["id"],
 			"station_type_id": "72f8b983-3eb4-48db-9ed0-e45cc6bd716b",
 			"account_id":   tests.AdminId,
+			"zone_id":      nil,
 			"date_created": actual["date_created"],
 			"date_updated": actual["date_updated"],
 			"name":         "station0",
@@ -199,7 +321,7 @@ func (st *StationTests) StationCRUD(t *testing.T) {
 	}
 
 	{ // UPDATE
-		body := strings.NewReader(`{"name":"UPDATED station0","description":"UPDATED Test description 0", "location_x":456, "location_y": 123}`)
+		body := strings.NewReader(`{"name":"UPDATED station0","description":"UPDATED Test description 0", "location_x":456, "location_y": 123, "zone_id": "c4e9b7a0-1f62-4d85-a3e7-5b0c9d2f8e41"}`)
 		url := fmt.Sprintf("/v1/station/%s", actual["id"])
 		req := httptest.NewRequest("PUT", url, body)
 		req.Header.Set("Content-Type", "application/json")
@@ -237,6 +359,7 @@ func (st *StationTests) StationCRUD(t *testing.T) {
 			"description":     "UPDATED Test description 0",
 			"station_type_id": "72f8b983-3eb4-48db-9ed0-e45cc6bd716b",
 			"account_id":      "5cf37266-3473-4006-984f-9325122678b7",
+			"zone_id":         "c4e9b7a0-1f62-4d85-a3e7-5b0c9d2f8e41",
 			"location_x":      float64(456),
 			"location_y":      float64(123),
 		}
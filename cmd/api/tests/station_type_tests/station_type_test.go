@@ -70,6 +70,7 @@ func (st *StationTypeTests) List(t *testing.T) {
 		{
 			"id":           "5c86bbaa-4ef8-11eb-ae93-0242ac130002",
 			"name":         "Plant",
+			"kind":         "plant",
 			"description":  "Monitors and reports plant health.",
 			"stations":     float64(3),
 			"date_created": "2021-01-01T00:00:03.000001Z",
@@ -78,6 +79,7 @@ func (st *StationTypeTests) List(t *testing.T) {
 		{
 			"id":           "a2b0639f-2cc6-44b8-b97b-15d69dbb511e",
 			"name":         "Base",
+			"kind":         "base",
 			"description":  "Coordinator for all station types - monitor, command and control. Access point to public Internet.",
 			"stations":     float64(1),
 			"date_created": "2021-01-01T00:00:01.000001Z",
@@ -86,6 +88,7 @@ func (st *StationTypeTests) List(t *testing.T) {
 		{
 			"id":           "72f8b983-3eb4-48db-9ed0-e45cc6bd716b",
 			"name":         "Water",
+			"kind":         "water",
 			"description":  "Management of water resources. Controls water levels in reservoir and implements irrigation.",
 			"stations":     float64(1),
 			"date_created": "2021-01-01T00:00:02.000001Z",
@@ -150,6 +153,7 @@ func (st *StationTypeTests) StationTypeCRUD(t *testing.T) {
 			"date_created": actual["date_created"],
 			"date_updated": actual["date_updated"],
 			"name":         "stationtype0",
+			"kind":         "other",
 			"description":  "Test description 0",
 			"stations":     float64(0),
 		}
@@ -219,6 +223,7 @@ func (st *StationTypeTests) StationTypeCRUD(t *testing.T) {
 			"date_created": actual["date_created"],
 			"date_updated": updated["date_updated"],
 			"name":         "UPDATED stationtype0",
+			"kind":         "other",
 			"description":  "UPDATED Test description 0",
 			"stations":     float64(0),
 		}
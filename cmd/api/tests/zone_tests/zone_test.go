@@ -0,0 +1,188 @@
+package zone_tests
+
+import (
+	// Core Packages
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	// NOTE: Models should not be imported, we want to test the exact JSON. We
+	// make the comparison process easier using the go-cmp library.
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/cmd/api/internal/handlers"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+
+	// Third-party packages
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestZone runs a series of tests to exercise Zone behavior from the API
+// level. The subtests all share the same database and application so the
+// order the tests are run matters.
+func TestZone(t *testing.T) {
+	test := tests.New(t)
+	defer test.Teardown()
+
+	shutdown := make(chan os.Signal, 1)
+	zoneTests := ZoneTests{
+		app:        handlers.API(shutdown, test.Db, test.Log, test.Authenticator),
+		adminToken: test.Token("Admin", "gophers"),
+	}
+
+	t.Run("ListStations", zoneTests.ListStations)
+	t.Run("CreateRequiresFields", zoneTests.CreateRequiresFields)
+	t.Run("ZoneCRUD", zoneTests.ZoneCRUD)
+}
+
+// ZoneTests holds methods for each zone subtest.
+type ZoneTests struct {
+	app        http.Handler
+	adminToken string
+}
+
+func (zt *ZoneTests) ListStations(t *testing.T) {
+
+	// Get the stations in the "Tomato row" zone as defined in the seed data
+	req := httptest.NewRequest("GET", "/v1/zone/8a1f5c2e-7d3b-4e0a-9c61-2b4d8e6f0a13/stations", nil)
+	req.Header.Set("Authorization", "Bearer "+zt.adminToken)
+	resp := httptest.NewRecorder()
+
+	zt.app.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("getting: expected status code %v, got %v", http.StatusOK, resp.Code)
+	}
+
+	var list []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatalf("decoding: %s", err)
+	}
+
+	if exp, got := 3, len(list); exp != got {
+		t.Fatalf("expected %v stations, got %v", exp, got)
+	}
+	for _, s := range list {
+		if s["zone_id"] != "8a1f5c2e-7d3b-4e0a-9c61-2b4d8e6f0a13" {
+			t.Fatalf("station %v listed for the wrong zone %v", s["id"], s["zone_id"])
+		}
+	}
+}
+
+func (zt *ZoneTests) CreateRequiresFields(t *testing.T) {
+	body := strings.NewReader(`{}`)
+	req := httptest.NewRequest("POST", "/v1/zone", body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+zt.adminToken)
+	resp := httptest.NewRecorder()
+
+	zt.app.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("getting: expected status code %v, got %v", http.StatusBadRequest, resp.Code)
+	}
+}
+
+func (zt *ZoneTests) ZoneCRUD(t *testing.T) {
+	var actual map[string]interface{}
+
+	{ // CREATE
+		body := strings.NewReader(`{"name":"zone0","description":"Test description 0","soil_type":"Clay","location_x":1,"location_y":2,"width":3,"height":4}`)
+		req := httptest.NewRequest("POST", "/v1/zone", body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+zt.adminToken)
+		resp := httptest.NewRecorder()
+
+		zt.app.ServeHTTP(resp, req)
+
+		if http.StatusCreated != resp.Code {
+			t.Fatalf("posting: expected status code %v, got %v", http.StatusCreated, resp.Code)
+		}
+
+		if err := json.NewDecoder(resp.Body).Decode(&actual); err != nil {
+			t.Fatalf("decoding: %s", err)
+		}
+
+		if actual["id"] == "" || actual["id"] == nil {
+			t.Fatal("expected non-empty zone id")
+		}
+
+		expected := map[string]interface{}{
+			"id":           actual["id"],
+			"date_created": actual["date_created"],
+			"date_updated": actual["date_updated"],
+			"name":         "zone0",
+			"description":  "Test description 0",
+			"soil_type":    "Clay",
+			"location_x":   float64(1),
+			"location_y":   float64(2),
+			"width":        float64(3),
+			"height":       float64(4),
+			"stations":     float64(0),
+		}
+
+		if diff := cmp.Diff(expected, actual); diff != "" {
+			t.Fatalf("Response did not match expected. Diff:\n%s", diff)
+		}
+	}
+
+	url := fmt.Sprintf("/v1/zone/%s", actual["id"])
+
+	{ // UPDATE
+		body := strings.NewReader(`{"name":"UPDATED zone0","width":5}`)
+		req := httptest.NewRequest("PUT", url, body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+zt.adminToken)
+		resp := httptest.NewRecorder()
+
+		zt.app.ServeHTTP(resp, req)
+
+		if http.StatusNoContent != resp.Code {
+			t.Fatalf("updating: expected status code %v, got %v", http.StatusNoContent, resp.Code)
+		}
+
+		req = httptest.NewRequest("GET", url, nil)
+		req.Header.Set("Authorization", "Bearer "+zt.adminToken)
+		resp = httptest.NewRecorder()
+
+		zt.app.ServeHTTP(resp, req)
+
+		if http.StatusOK != resp.Code {
+			t.Fatalf("retrieving: expected status code %v, got %v", http.StatusOK, resp.Code)
+		}
+
+		var updated map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&updated); err != nil {
+			t.Fatalf("decoding: %s", err)
+		}
+
+		if updated["name"] != "UPDATED zone0" || updated["width"] != float64(5) {
+			t.Fatalf("zone was not updated: %v", updated)
+		}
+	}
+
+	{ // DELETE
+		req := httptest.NewRequest("DELETE", url, nil)
+		req.Header.Set("Authorization", "Bearer "+zt.adminToken)
+		resp := httptest.NewRecorder()
+
+		zt.app.ServeHTTP(resp, req)
+
+		if http.StatusNoContent != resp.Code {
+			t.Fatalf("deleting: expected status code %v, got %v", http.StatusNoContent, resp.Code)
+		}
+
+		req = httptest.NewRequest("GET", url, nil)
+		req.Header.Set("Authorization", "Bearer "+zt.adminToken)
+		resp = httptest.NewRecorder()
+
+		zt.app.ServeHTTP(resp, req)
+
+		if http.StatusNotFound != resp.Code {
+			t.Fatalf("retrieving: expected status code %v, got %v", http.StatusNotFound, resp.Code)
+		}
+	}
+}
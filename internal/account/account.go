@@ -7,7 +7,10 @@ import (
 	"time"
 
 	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/audit"
+	"github.com/deezone/HydroBytes-BaseStation/internal/outbox"
 	"github.com/deezone/HydroBytes-BaseStation/internal/platform/auth"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
 
 	// Third-party packages
 	"github.com/google/uuid"
@@ -20,6 +23,24 @@ import (
 var (
 	// ErrAuthenticationFailure occurs when an account attempts to authenticate but something goes wrong.
 	ErrAuthenticationFailure = errors.New("Authentication failed")
+
+	// ErrNotFound is used when a specific Account is requested but does not exist.
+	ErrNotFound = errors.New("account not found")
+
+	// ErrInvalidID is used when an invalid UUID is provided.
+	ErrInvalidID = errors.New("ID is not in its proper UUID format")
+
+	// ErrHasStations is used when an Account can not be deleted because it still
+	// owns Stations.
+	ErrHasStations = errors.New("account has stations")
+
+	// ErrTransferNotFound is used when the Account that Stations should be
+	// transferred to before a delete does not exist.
+	ErrTransferNotFound = errors.New("account to transfer stations to not found")
+
+	// ErrTransferToSelf is used when the Stations of an Account are to be
+	// transferred to the Account being deleted.
+	ErrTransferToSelf = errors.New("stations can not be transferred to the account being deleted")
 )
 
 /**
@@ -33,7 +54,7 @@ func Authenticate(ctx context.Context, db *sqlx.DB, now time.Time, name, passwor
 	ctx, span := trace.StartSpan(ctx, "internal.account.Authenticate")
 	defer span.End()
 
-	const q = `SELECT * FROM account WHERE name = $1`
+	const q = `SELECT * FROM account WHERE name = $1 AND date_deleted IS NULL`
 
 	var a Account
 	if err := db.GetContext(ctx, &a, q, name); err != nil {
@@ -79,10 +100,16 @@ func Create(ctx context.Context, db *sqlx.DB, n NewAccount, now time.Time) (*Acc
 		DateUpdated:  now.UTC(),
 	}
 
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
 	const q = `INSERT INTO account
 		(id, name, password_hash, roles, date_created, date_updated)
 		VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err = db.ExecContext(
+	_, err = tx.ExecContext(
 		ctx, q,
 		a.Id, a.Name,
 		a.PasswordHash, a.Roles,
@@ -92,5 +119,252 @@ func Create(ctx context.Context, db *sqlx.DB, n NewAccount, now time.Time) (*Acc
 		return nil, errors.Wrap(err, "inserting account")
 	}
 
+	if err := audit.Write(ctx, tx, "account.create", a.Id, nil, a, now); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "committing account")
+	}
+
 	return &a, nil
 }
+
+// Delete removes the account identified by a given ID. An account that still
+// owns active Stations is only removed when the Stations are either
+// transferred to another account with DeleteAccount.TransferTo or archived
+// along with it with DeleteAccount.Cascade. Otherwise ErrHasStations is
+// returned. A transfer moves archived Stations as well. The account to
+// transfer to must exist and not be archived, or ErrTransferNotFound is
+// returned.
+//
+// An account that is left with archived Stations, such as after a cascading
+// delete, is archived instead of removed since its Stations still refer to it.
+// Archived accounts can not authenticate and are kept until they are restored
+// with Restore or permanently removed by Purge.
+func Delete(ctx context.Context, db *sqlx.DB, id string, d DeleteAccount, now time.Time) error {
+
+	ctx, span := trace.StartSpan(ctx, "internal.account.Delete")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrInvalidID
+	}
+	if d.TransferTo != "" {
+		if _, err := uuid.Parse(d.TransferTo); err != nil {
+			return ErrInvalidID
+		}
+		if d.TransferTo == id {
+			return ErrTransferToSelf
+		}
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	var before []Account
+	const get = `
+		SELECT id, name, roles, password_hash, date_created, date_updated, date_deleted
+		FROM account WHERE id = $1 AND date_deleted IS NULL`
+	if err := sqlx.SelectContext(ctx, tx, &before, get, id); err != nil {
+		return errors.Wrapf(err, "selecting account %s", id)
+	}
+	if len(before) == 0 {
+		return ErrNotFound
+	}
+
+	if d.TransferTo != "" {
+		var exists bool
+		if err := tx.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM account WHERE id = $1 AND date_deleted IS NULL)`, d.TransferTo); err != nil {
+			return errors.Wrap(err, "checking account")
+		}
+		if !exists {
+			return ErrTransferNotFound
+		}
+	}
+
+	var stations struct {
+		Active   int `db:"active"`
+		Archived int `db:"archived"`
+	}
+	const count = `
+		SELECT
+			COUNT(*) FILTER (WHERE date_deleted IS NULL) AS active,
+			COUNT(*) FILTER (WHERE date_deleted IS NOT NULL) AS archived
+		FROM station WHERE account_id = $1`
+	if err := tx.GetContext(ctx, &stations, count, id); err != nil {
+		return errors.Wrap(err, "counting stations")
+	}
+
+	archive := false
+	if stations.Active > 0 || stations.Archived > 0 {
+		switch {
+		case d.TransferTo != "":
+			var moved []station_type.Station
+			const q = `UPDATE station SET account_id = $2 WHERE account_id = $1 RETURNING ` + station_type.StationColumns
+			if err := sqlx.SelectContext(ctx, tx, &moved, q, id, d.TransferTo); err != nil {
+				return errors.Wrapf(err, "transferring stations of account %s", id)
+			}
+
+			for _, s := range moved {
+				from := s
+				from.AccountId = id
+				if err := audit.Write(ctx, tx, "station.update", s.Id, from, s, now); err != nil {
+					return err
+				}
+				if err := outbox.Add(ctx, tx, station_type.EventStationUpdated, s.Id, s, now); err != nil {
+					return err
+				}
+			}
+
+		case d.Cascade:
+			var archived []station_type.Station
+			const q = `UPDATE station SET date_deleted = $2 WHERE account_id = $1 AND date_deleted IS NULL RETURNING ` + station_type.StationColumns
+			if err := sqlx.SelectContext(ctx, tx, &archived, q, id, now.UTC()); err != nil {
+				return errors.Wrapf(err, "archiving stations of account %s", id)
+			}
+
+			for _, s := range archived {
+				s.DateDeleted = nil
+				if err := audit.Write(ctx, tx, "station.delete", s.Id, s, nil, now); err != nil {
+					return err
+				}
+				if err := outbox.Add(ctx, tx, station_type.EventStationDeleted, s.Id, s, now); err != nil {
+					return err
+				}
+			}
+			archive = true
+
+		case stations.Active > 0:
+			return ErrHasStations
+
+		default:
+			archive = true
+		}
+	}
+
+	if archive {
+		const a = `UPDATE account SET date_deleted = $2 WHERE id = $1`
+		if _, err := tx.ExecContext(ctx, a, id, now.UTC()); err != nil {
+			return errors.Wrapf(err, "archiving account %s", id)
+		}
+	} else {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM account WHERE id = $1`, id); err != nil {
+			return errors.Wrapf(err, "deleting account %s", id)
+		}
+	}
+
+	if err := audit.Write(ctx, tx, "account.delete", id, before[0], nil, now); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrapf(err, "committing delete of account %s", id)
+	}
+
+	return nil
+}
+
+// Restore brings back an archived account along with the Stations that were
+// archived with it by a cascading Delete, unless their station type has since
+// been archived.
+func Restore(ctx context.Context, db *sqlx.DB, id string, now time.Time) error {
+
+	ctx, span := trace.StartSpan(ctx, "internal.account.Restore")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrInvalidID
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	var archived time.Time
+	const q = `SELECT date_deleted FROM account WHERE id = $1 AND date_deleted IS NOT NULL`
+	if err := tx.GetContext(ctx, &archived, q, id); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+
+		return errors.Wrapf(err, "selecting archived account %s", id)
+	}
+
+	const stations = `
+		UPDATE station SET date_deleted = NULL
+		WHERE account_id = $1 AND date_deleted = $2
+		  AND NOT EXISTS (SELECT 1 FROM station_type WHERE station_type.id = station.station_type_id AND station_type.date_deleted IS NOT NULL)
+		RETURNING id`
+	var restored []string
+	if err := sqlx.SelectContext(ctx, tx, &restored, stations, id, archived); err != nil {
+		return errors.Wrapf(err, "restoring stations of account %s", id)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE account SET date_deleted = NULL WHERE id = $1`, id); err != nil {
+		return errors.Wrapf(err, "restoring account %s", id)
+	}
+
+	before := map[string]interface{}{"date_deleted": archived}
+	for _, sid := range restored {
+		if err := audit.Write(ctx, tx, "station.restore", sid, before, nil, now); err != nil {
+			return err
+		}
+		if err := outbox.Add(ctx, tx, station_type.EventStationRestored, sid, map[string]string{"id": sid}, now); err != nil {
+			return err
+		}
+	}
+
+	if err := audit.Write(ctx, tx, "account.restore", id, before, nil, now); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrapf(err, "committing restore of account %s", id)
+	}
+
+	return nil
+}
+
+// Purge permanently removes accounts that were archived before the given time
+// and no longer own any Stations. Stations are purged first by
+// station_type.Purge. It returns the number of accounts removed.
+func Purge(ctx context.Context, db *sqlx.DB, before, now time.Time) (int64, error) {
+
+	ctx, span := trace.StartSpan(ctx, "internal.account.Purge")
+	defer span.End()
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	var purged []Account
+	const q = `
+		DELETE FROM account
+		WHERE date_deleted < $1
+		  AND NOT EXISTS (SELECT 1 FROM station WHERE station.account_id = account.id)
+		RETURNING id, name, roles, password_hash, date_created, date_updated, date_deleted`
+
+	if err := sqlx.SelectContext(ctx, tx, &purged, q, before.UTC()); err != nil {
+		return 0, errors.Wrap(err, "purging accounts")
+	}
+
+	for _, a := range purged {
+		if err := audit.Write(ctx, tx, "account.purge", a.Id, a, nil, now); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "committing purge of accounts")
+	}
+
+	return int64(len(purged)), nil
+}
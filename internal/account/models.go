@@ -14,6 +14,7 @@ type Account struct {
 	PasswordHash []byte         `db:"password_hash" json:"-"`
 	DateCreated  time.Time      `db:"date_created" json:"date_created"`
 	DateUpdated  time.Time      `db:"date_updated" json:"date_updated"`
+	DateDeleted  *time.Time     `db:"date_deleted" json:"date_deleted,omitempty"`
 }
 
 // NewAccount contains information needed to create a new Account.
@@ -23,3 +24,10 @@ type NewAccount struct {
 	Password        string   `json:"password" validate:"required"`
 	PasswordConfirm string   `json:"password_confirm" validate:"eqfield=Password"`
 }
+
+// DeleteAccount defines what happens to the Stations of an Account that is
+// deleted. Without either option an Account with Stations is not deleted.
+type DeleteAccount struct {
+	TransferTo string // ID of the Account the Stations are transferred to
+	Cascade    bool   // Stations are archived along with the Account
+}
@@ -0,0 +1,312 @@
+package alert
+
+import (
+	// Core packages
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/event"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Predefined errors identify expected failure conditions.
+var (
+	// ErrNotFound is used when a specific Alert is requested but does not exist.
+	ErrNotFound = errors.New("alert not found")
+
+	// ErrRuleNotFound is used when a specific Rule is requested but does not exist.
+	ErrRuleNotFound = errors.New("alert rule not found")
+
+	// ErrInvalidID is used when an invalid UUID is provided.
+	ErrInvalidID = errors.New("ID is not in its proper UUID format")
+
+	// ErrInvalidRule is used when a threshold Rule lacks a sensor or operator,
+	// or a scoped Rule lacks the value of its scope.
+	ErrInvalidRule = errors.New("threshold rules need a sensor and operator and scoped rules a scope value")
+
+	// ErrResolved is used when acknowledging or silencing a resolved Alert.
+	ErrResolved = errors.New("alert is resolved")
+)
+
+// CreateRule adds a Rule to the database.
+func CreateRule(ctx context.Context, db *sqlx.DB, nr NewRule, now time.Time) (*Rule, error) {
+
+	ctx, span := trace.StartSpan(ctx, "alert.CreateRule")
+	defer span.End()
+
+	if nr.Kind == KindThreshold && (nr.Sensor == "" || nr.Operator == "") {
+		return nil, ErrInvalidRule
+	}
+	if nr.Scope != ScopeAll && nr.ScopeValue == "" {
+		return nil, ErrInvalidRule
+	}
+	if nr.Scope == ScopeTag {
+		if _, err := station_type.ParseTagFilter(nr.ScopeValue); err != nil {
+			return nil, ErrInvalidRule
+		}
+	}
+	if nr.Scope != ScopeAll && nr.Scope != ScopeTag {
+		if _, err := uuid.Parse(nr.ScopeValue); err != nil {
+			return nil, ErrInvalidID
+		}
+	}
+
+	r := Rule{
+		Id:          uuid.New().String(),
+		Name:        nr.Name,
+		Kind:        nr.Kind,
+		Sensor:      nr.Sensor,
+		Operator:    nr.Operator,
+		Threshold:   nr.Threshold,
+		Hysteresis:  nr.Hysteresis,
+		For:         nr.For,
+		Severity:    nr.Severity,
+		Scope:       nr.Scope,
+		ScopeValue:  nr.ScopeValue,
+		DateCreated: now.UTC(),
+		DateUpdated: now.UTC(),
+	}
+	if r.Severity == "" {
+		r.Severity = event.SeverityWarning
+	}
+
+	const q = `
+		INSERT INTO alert_rule
+		  (id, name, kind, sensor, operator, threshold, hysteresis, for_seconds, severity, scope, scope_value, date_created, date_updated)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+
+	_, err := db.ExecContext(ctx, q,
+		r.Id,
+		r.Name,
+		r.Kind,
+		r.Sensor,
+		r.Operator,
+		r.Threshold,
+		r.Hysteresis,
+		r.For,
+		r.Severity,
+		r.Scope,
+		r.ScopeValue,
+		r.DateCreated,
+		r.DateUpdated,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "inserting alert rule")
+	}
+
+	return &r, nil
+}
+
+// ListRules gets all Rules from the database.
+func ListRules(ctx context.Context, db *sqlx.DB) ([]Rule, error) {
+
+	ctx, span := trace.StartSpan(ctx, "alert.ListRules")
+	defer span.End()
+
+	rules := []Rule{}
+
+	const q = `
+		SELECT id, name, kind, sensor, operator, threshold, hysteresis, for_seconds, severity, scope, scope_value, date_created, date_updated
+		FROM alert_rule
+		ORDER BY name`
+
+	if err := db.SelectContext(ctx, &rules, q); err != nil {
+		return nil, errors.Wrap(err, "selecting alert rules")
+	}
+
+	return rules, nil
+}
+
+// GetRule finds the Rule identified by a given ID.
+func GetRule(ctx context.Context, db *sqlx.DB, id string) (*Rule, error) {
+
+	ctx, span := trace.StartSpan(ctx, "alert.GetRule")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var r Rule
+
+	const q = `
+		SELECT id, name, kind, sensor, operator, threshold, hysteresis, for_seconds, severity, scope, scope_value, date_created, date_updated
+		FROM alert_rule
+		WHERE id = $1`
+
+	if err := db.GetContext(ctx, &r, q, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRuleNotFound
+		}
+
+		return nil, errors.Wrap(err, "selecting single alert rule")
+	}
+
+	return &r, nil
+}
+
+// DeleteRule removes a Rule along with its Alerts.
+func DeleteRule(ctx context.Context, db *sqlx.DB, id string) error {
+
+	ctx, span := trace.StartSpan(ctx, "alert.DeleteRule")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrInvalidID
+	}
+
+	const q = `DELETE FROM alert_rule WHERE id = $1`
+
+	res, err := db.ExecContext(ctx, q, id)
+	if err != nil {
+		return errors.Wrapf(err, "deleting alert rule %s", id)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return errors.Wrapf(err, "deleting alert rule %s", id)
+	} else if n == 0 {
+		return ErrRuleNotFound
+	}
+
+	return nil
+}
+
+// selectAlert selects Alerts.
+const selectAlert = `
+	SELECT
+		id, rule_id, station_id, status, value, date_started, date_firing, date_resolved,
+		acknowledged_by, date_acknowledged, silenced_until, date_updated
+	FROM alert`
+
+// List gets the Alerts matching the Filter, most recently started first.
+func List(ctx context.Context, db *sqlx.DB, filter Filter) ([]Alert, error) {
+
+	ctx, span := trace.StartSpan(ctx, "alert.List")
+	defer span.End()
+
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	var where []string
+	if filter.Status != "" {
+		where = append(where, "status = "+arg(filter.Status))
+	} else {
+		where = append(where, "status IN ('pending', 'firing')")
+	}
+	if filter.RuleId != "" {
+		if _, err := uuid.Parse(filter.RuleId); err != nil {
+			return nil, ErrInvalidID
+		}
+		where = append(where, "rule_id = "+arg(filter.RuleId))
+	}
+	if filter.StationId != "" {
+		if _, err := uuid.Parse(filter.StationId); err != nil {
+			return nil, ErrInvalidID
+		}
+		where = append(where, "station_id = "+arg(filter.StationId))
+	}
+
+	alerts := []Alert{}
+
+	q := selectAlert + ` WHERE ` + strings.Join(where, " AND ") + ` ORDER BY date_started DESC`
+	if err := db.SelectContext(ctx, &alerts, q, args...); err != nil {
+		return nil, errors.Wrap(err, "selecting alerts")
+	}
+
+	return alerts, nil
+}
+
+// Get finds the Alert identified by a given ID.
+func Get(ctx context.Context, db *sqlx.DB, id string) (*Alert, error) {
+
+	ctx, span := trace.StartSpan(ctx, "alert.Get")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var a Alert
+
+	if err := db.GetContext(ctx, &a, selectAlert+` WHERE id = $1`, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+
+		return nil, errors.Wrap(err, "selecting single alert")
+	}
+
+	return &a, nil
+}
+
+// Acknowledge marks an Alert as being looked into by an account.
+func Acknowledge(ctx context.Context, db *sqlx.DB, id, accountID string, now time.Time) (*Alert, error) {
+
+	ctx, span := trace.StartSpan(ctx, "alert.Acknowledge")
+	defer span.End()
+
+	a, err := Get(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+	if a.Status == StatusResolved {
+		return nil, ErrResolved
+	}
+
+	t := now.UTC()
+	a.AcknowledgedBy = &accountID
+	a.DateAcknowledged = &t
+	a.DateUpdated = t
+
+	const q = `UPDATE alert SET
+		"acknowledged_by" = $2,
+		"date_acknowledged" = $3,
+		"date_updated" = $3
+		WHERE id = $1`
+	if _, err := db.ExecContext(ctx, q, id, accountID, t); err != nil {
+		return nil, errors.Wrap(err, "acknowledging alert")
+	}
+
+	return a, nil
+}
+
+// Silence stops events being raised for an Alert until the given time.
+func Silence(ctx context.Context, db *sqlx.DB, id string, ns NewSilence, now time.Time) (*Alert, error) {
+
+	ctx, span := trace.StartSpan(ctx, "alert.Silence")
+	defer span.End()
+
+	a, err := Get(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+	if a.Status == StatusResolved {
+		return nil, ErrResolved
+	}
+
+	until := ns.Until.UTC()
+	a.SilencedUntil = &until
+	a.DateUpdated = now.UTC()
+
+	const q = `UPDATE alert SET
+		"silenced_until" = $2,
+		"date_updated" = $3
+		WHERE id = $1`
+	if _, err := db.ExecContext(ctx, q, id, a.SilencedUntil, a.DateUpdated); err != nil {
+		return nil, errors.Wrap(err, "silencing alert")
+	}
+
+	return a, nil
+}
@@ -0,0 +1,347 @@
+package alert
+
+import (
+	// Core packages
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/event"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Types of the Events raised as Alerts change.
+const (
+	EventFiring   = "alert.firing"
+	EventResolved = "alert.resolved"
+)
+
+// candidate is a Station in the scope of a Rule along with what the Rule is
+// evaluated on.
+type candidate struct {
+	StationId string     `db:"station_id"`
+	Name      string     `db:"name"`
+	Value     *float64   `db:"value"`
+	DateRead  *time.Time `db:"date_read"`
+	LastSeen  time.Time  `db:"last_seen"`
+}
+
+// Breached reports whether a value breaks the threshold of a Rule. A firing
+// Alert keeps firing until the value is back past the threshold by the
+// hysteresis of the Rule.
+func Breached(r Rule, value float64, firing bool) bool {
+	limit := r.Threshold
+
+	switch r.Operator {
+	case OperatorBelow:
+		if firing {
+			limit += r.Hysteresis
+		}
+		return value < limit
+	case OperatorAbove:
+		if firing {
+			limit -= r.Hysteresis
+		}
+		return value > limit
+	}
+
+	return false
+}
+
+// Evaluate checks every Rule against the Stations in its scope and moves their
+// Alerts through pending, firing and resolved. An Event is raised when an
+// Alert fires or resolves unless it is silenced. It returns the Alerts that
+// changed status.
+func Evaluate(ctx context.Context, db *sqlx.DB, now time.Time) ([]Alert, error) {
+
+	ctx, span := trace.StartSpan(ctx, "alert.Evaluate")
+	defer span.End()
+
+	rules, err := ListRules(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := []Alert{}
+	for _, r := range rules {
+		c, err := evaluateRule(ctx, db, r, now.UTC())
+		if err != nil {
+			return nil, errors.Wrapf(err, "evaluating alert rule %s", r.Id)
+		}
+		changed = append(changed, c...)
+	}
+
+	return changed, nil
+}
+
+// evaluateRule checks a Rule against the Stations in its scope.
+func evaluateRule(ctx context.Context, db *sqlx.DB, r Rule, now time.Time) ([]Alert, error) {
+	candidates, err := inScope(ctx, db, r)
+	if err != nil {
+		return nil, err
+	}
+
+	open, err := List(ctx, db, Filter{RuleId: r.Id})
+	if err != nil {
+		return nil, err
+	}
+	alerts := make(map[string]Alert, len(open))
+	for _, a := range open {
+		alerts[a.StationId] = a
+	}
+
+	hold := time.Duration(r.For) * time.Second
+
+	var changed []Alert
+	for _, c := range candidates {
+		a, isOpen := alerts[c.StationId]
+		delete(alerts, c.StationId)
+
+		var breach bool
+		var value float64
+		var started time.Time
+
+		switch r.Kind {
+		case KindThreshold:
+			if c.Value == nil {
+				continue
+			}
+			value = *c.Value
+			started = *c.DateRead
+			breach = Breached(r, value, isOpen && a.Status == StatusFiring)
+		case KindOffline:
+			value = now.Sub(c.LastSeen).Seconds()
+			started = c.LastSeen
+			breach = now.Sub(c.LastSeen) >= hold
+		}
+
+		switch {
+		case !isOpen && breach:
+			a = Alert{
+				Id:          uuid.New().String(),
+				RuleId:      r.Id,
+				StationId:   c.StationId,
+				Status:      StatusPending,
+				Value:       value,
+				DateStarted: started,
+				DateUpdated: now,
+			}
+			if now.Sub(started) >= hold {
+				a.Status = StatusFiring
+				a.DateFiring = &now
+			}
+			if err := insertAlert(ctx, db, a); err != nil {
+				return nil, err
+			}
+			changed = append(changed, a)
+
+		case isOpen && breach:
+			from := a.Status
+			a.Value = value
+			a.DateUpdated = now
+			if a.Status == StatusPending && now.Sub(a.DateStarted) >= hold {
+				a.Status = StatusFiring
+				a.DateFiring = &now
+			}
+			if err := updateAlert(ctx, db, a); err != nil {
+				return nil, err
+			}
+
+			// Events are only raised when the Alert changes status.
+			if a.Status == from {
+				continue
+			}
+			changed = append(changed, a)
+
+		case isOpen && !breach:
+			if a.Status == StatusPending {
+				// The condition did not hold for long enough so the Alert
+				// never happened.
+				if _, err := db.ExecContext(ctx, `DELETE FROM alert WHERE id = $1`, a.Id); err != nil {
+					return nil, errors.Wrap(err, "deleting pending alert")
+				}
+				continue
+			}
+			a.Value = value
+			a.Status = StatusResolved
+			a.DateResolved = &now
+			a.DateUpdated = now
+			if err := updateAlert(ctx, db, a); err != nil {
+				return nil, err
+			}
+			changed = append(changed, a)
+
+		default:
+			continue
+		}
+
+		if err := raise(ctx, db, r, c.Name, a, now); err != nil {
+			return nil, err
+		}
+	}
+
+	// Stations that left the scope of the Rule, for example because they were
+	// archived, resolve their Alerts.
+	for _, a := range alerts {
+		a.Status = StatusResolved
+		a.DateResolved = &now
+		a.DateUpdated = now
+		if err := updateAlert(ctx, db, a); err != nil {
+			return nil, err
+		}
+		changed = append(changed, a)
+
+		// Archived Stations keep their name.
+		var name string
+		if err := db.GetContext(ctx, &name, `SELECT name FROM station WHERE id = $1`, a.StationId); err != nil {
+			return nil, errors.Wrapf(err, "selecting name of station %s", a.StationId)
+		}
+
+		if err := raise(ctx, db, r, name, a, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return changed, nil
+}
+
+// inScope gets the Stations in the scope of a Rule along with the latest
+// value of the sensor of the Rule and when the Station was last heard from.
+func inScope(ctx context.Context, db *sqlx.DB, r Rule) ([]candidate, error) {
+	args := []interface{}{r.Sensor}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// Tags and groups select Stations the same way as the station list
+	// filters do.
+	var filter station_type.StationFilter
+	switch r.Scope {
+	case ScopeTag:
+		tf, err := station_type.ParseTagFilter(r.ScopeValue)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing tag scope")
+		}
+		filter.Tags = []station_type.TagFilter{tf}
+	case ScopeGroup:
+		filter.GroupId = r.ScopeValue
+	}
+
+	where, err := filter.Where(arg)
+	if err != nil {
+		return nil, errors.Wrap(err, "filtering stations in scope")
+	}
+
+	switch r.Scope {
+	case ScopeStation:
+		where = append(where, "station.id = "+arg(r.ScopeValue))
+	case ScopeZone:
+		where = append(where, "station.zone_id = "+arg(r.ScopeValue))
+	case ScopeStationType:
+		where = append(where, "station.station_type_id = "+arg(r.ScopeValue))
+	}
+
+	q := `
+		SELECT
+			station.id AS station_id,
+			station.name,
+			latest.value,
+			latest.date_read,
+			GREATEST(
+				station.date_created,
+				(SELECT MAX(date_received) FROM heartbeat WHERE heartbeat.station_id = station.id),
+				(SELECT MAX(date_received) FROM reading WHERE reading.station_id = station.id)
+			) AS last_seen
+		FROM station
+		LEFT JOIN LATERAL (
+			SELECT value, date_read FROM reading
+			WHERE reading.station_id = station.id AND reading.sensor = $1
+			ORDER BY date_read DESC
+			LIMIT 1
+		) AS latest ON TRUE
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY station.id`
+
+	var candidates []candidate
+	if err := db.SelectContext(ctx, &candidates, q, args...); err != nil {
+		return nil, errors.Wrap(err, "selecting stations in scope")
+	}
+
+	return candidates, nil
+}
+
+// insertAlert stores a new Alert.
+func insertAlert(ctx context.Context, db *sqlx.DB, a Alert) error {
+	const q = `
+		INSERT INTO alert
+		  (id, rule_id, station_id, status, value, date_started, date_firing, date_updated)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := db.ExecContext(ctx, q, a.Id, a.RuleId, a.StationId, a.Status, a.Value, a.DateStarted, a.DateFiring, a.DateUpdated)
+	if err != nil {
+		return errors.Wrap(err, "inserting alert")
+	}
+
+	return nil
+}
+
+// updateAlert stores the status and value of an Alert.
+func updateAlert(ctx context.Context, db *sqlx.DB, a Alert) error {
+	const q = `UPDATE alert SET
+		"status" = $2,
+		"value" = $3,
+		"date_firing" = $4,
+		"date_resolved" = $5,
+		"date_updated" = $6
+		WHERE id = $1`
+
+	_, err := db.ExecContext(ctx, q, a.Id, a.Status, a.Value, a.DateFiring, a.DateResolved, a.DateUpdated)
+	if err != nil {
+		return errors.Wrap(err, "updating alert")
+	}
+
+	return nil
+}
+
+// raise records the Event of an Alert that fired or resolved unless the Alert
+// is silenced.
+func raise(ctx context.Context, db *sqlx.DB, r Rule, station string, a Alert, now time.Time) error {
+	if a.SilencedUntil != nil && now.Before(*a.SilencedUntil) {
+		return nil
+	}
+
+	var ne event.NewEvent
+	switch a.Status {
+	case StatusFiring:
+		ne = event.NewEvent{
+			Type:     EventFiring,
+			Severity: r.Severity,
+			Message:  fmt.Sprintf("%s is firing for %s (value %.1f)", r.Name, station, a.Value),
+		}
+	case StatusResolved:
+		ne = event.NewEvent{
+			Type:     EventResolved,
+			Severity: event.SeverityInfo,
+			Message:  fmt.Sprintf("%s resolved for %s", r.Name, station),
+		}
+	default:
+		return nil
+	}
+	ne.StationId = &a.StationId
+	ne.Data = a
+
+	if _, err := event.Record(ctx, db, ne, now); err != nil {
+		return errors.Wrap(err, "recording alert event")
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package alert_test
+
+import (
+	// Core packages
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/alert"
+	"github.com/deezone/HydroBytes-BaseStation/internal/event"
+	"github.com/deezone/HydroBytes-BaseStation/internal/reading"
+	"github.com/deezone/HydroBytes-BaseStation/internal/schema"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+)
+
+func TestBreached(t *testing.T) {
+	below := alert.Rule{Operator: alert.OperatorBelow, Threshold: 25, Hysteresis: 5}
+	above := alert.Rule{Operator: alert.OperatorAbove, Threshold: 35, Hysteresis: 2}
+
+	tests := []struct {
+		name   string
+		rule   alert.Rule
+		value  float64
+		firing bool
+		exp    bool
+	}{
+		{"below threshold", below, 24, false, true},
+		{"at threshold", below, 25, false, false},
+		{"recovering within hysteresis", below, 28, true, true},
+		{"recovered past hysteresis", below, 30, true, false},
+		{"above threshold", above, 36, false, true},
+		{"cooling within hysteresis", above, 34, true, true},
+		{"cooled past hysteresis", above, 33, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := alert.Breached(tt.rule, tt.value, tt.firing); tt.exp != got {
+				t.Fatalf("expected breached %v, got %v", tt.exp, got)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	start := time.Date(2021, time.June, 2, 12, 0, 0, 0, time.UTC)
+
+	station := "d58f6d32-6332-11eb-ae93-0242ac130002"
+	moistureLow := "4c8e2a6f-1d93-4b57-a0e8-7f3b5d9c1e24"
+
+	// moisture reports a moisture reading minutes after start and evaluates
+	// the rules, returning the moisture alert of the station.
+	moisture := func(minutes int, value float64) *alert.Alert {
+		at := start.Add(time.Duration(minutes) * time.Minute)
+		nr := reading.NewReadings{Readings: []reading.NewReading{{Sensor: reading.SensorMoisture, Value: value, DateRead: &at}}}
+		if _, err := reading.Record(ctx, db, station, nr, at); err != nil {
+			t.Fatalf("recording reading: %s", err)
+		}
+		if _, err := alert.Evaluate(ctx, db, at); err != nil {
+			t.Fatalf("evaluating alerts: %s", err)
+		}
+
+		alerts, err := alert.List(ctx, db, alert.Filter{RuleId: moistureLow, StationId: station})
+		if err != nil {
+			t.Fatalf("listing alerts: %s", err)
+		}
+		if len(alerts) == 0 {
+			return nil
+		}
+		return &alerts[0]
+	}
+
+	if a := moisture(0, 22); a == nil || a.Status != alert.StatusPending {
+		t.Fatalf("expected a pending alert, got %+v", a)
+	}
+	if a := moisture(10, 27); a != nil {
+		t.Fatalf("expected the pending alert to be dropped, got %+v", a)
+	}
+
+	moisture(20, 22)
+	if a := moisture(30, 21); a == nil || a.Status != alert.StatusPending {
+		t.Fatalf("expected the alert to be pending for 20 minutes, got %+v", a)
+	}
+	firing := moisture(41, 20)
+	if firing == nil || firing.Status != alert.StatusFiring {
+		t.Fatalf("expected the alert to fire, got %+v", firing)
+	}
+
+	if _, err := alert.Acknowledge(ctx, db, firing.Id, "5cf37266-3473-4006-984f-9325122678b7", start); err != nil {
+		t.Fatalf("acknowledging alert: %s", err)
+	}
+
+	// Within the hysteresis band the alert keeps firing.
+	if a := moisture(50, 27); a == nil || a.Id != firing.Id || a.Status != alert.StatusFiring {
+		t.Fatalf("expected the alert to keep firing, got %+v", a)
+	}
+	if a := moisture(60, 31); a != nil {
+		t.Fatalf("expected the alert to resolve, got %+v", a)
+	}
+
+	resolved, err := alert.Get(ctx, db, firing.Id)
+	if err != nil {
+		t.Fatalf("getting alert: %s", err)
+	}
+	if exp, got := alert.StatusResolved, resolved.Status; exp != got {
+		t.Fatalf("expected the alert to be %v, got %v", exp, got)
+	}
+	if resolved.AcknowledgedBy == nil {
+		t.Fatal("expected the resolved alert to stay acknowledged")
+	}
+
+	// The station reported every time so only the moisture rule raised events.
+	for _, typ := range []string{alert.EventFiring, alert.EventResolved} {
+		events, err := event.List(ctx, db, event.Filter{Type: typ, StationId: station})
+		if err != nil {
+			t.Fatalf("listing events: %s", err)
+		}
+		if exp, got := 1, len(events); exp != got {
+			t.Fatalf("expected %v %v event, got %v", exp, typ, got)
+		}
+	}
+
+	// An alert of a station that leaves the scope of the rule is resolved
+	// under the name of the station.
+	moisture(70, 20)
+	if a := moisture(91, 20); a == nil || a.Status != alert.StatusFiring {
+		t.Fatalf("expected the alert to fire again, got %+v", a)
+	}
+
+	s, err := station_type.GetStation(ctx, db, station)
+	if err != nil {
+		t.Fatalf("getting station: %s", err)
+	}
+	archived := start.Add(92 * time.Minute)
+	if err := station_type.DeleteStation(ctx, db, station, archived); err != nil {
+		t.Fatalf("deleting station: %s", err)
+	}
+	if _, err := alert.Evaluate(ctx, db, archived); err != nil {
+		t.Fatalf("evaluating alerts: %s", err)
+	}
+
+	events, err := event.List(ctx, db, event.Filter{Type: alert.EventResolved, StationId: station})
+	if err != nil {
+		t.Fatalf("listing events: %s", err)
+	}
+	if exp, got := 2, len(events); exp != got {
+		t.Fatalf("expected %v %v events, got %v", exp, alert.EventResolved, got)
+	}
+	for _, e := range events {
+		if !strings.HasSuffix(e.Message, " for "+s.Name) {
+			t.Fatalf("expected the resolved event to name station %q, got %q", s.Name, e.Message)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package alert
+
+import (
+	// Core packages
+	"time"
+)
+
+// Kinds of Rule.
+const (
+	KindThreshold = "threshold"
+	KindOffline   = "offline"
+)
+
+// Operators of threshold Rules.
+const (
+	OperatorBelow = "below"
+	OperatorAbove = "above"
+)
+
+// Scopes of a Rule.
+const (
+	ScopeAll         = ""
+	ScopeStation     = "station"
+	ScopeZone        = "zone"
+	ScopeTag         = "tag"
+	ScopeGroup       = "group"
+	ScopeStationType = "station_type"
+)
+
+// Statuses of an Alert.
+const (
+	StatusPending  = "pending"
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// Rule defines when the Stations in its scope raise an Alert.
+//
+// A threshold Rule raises an Alert when the latest value of Sensor has been
+// below or above Threshold for at least For seconds, for example moisture below
+// 25 for 1200 seconds. A firing Alert only resolves once the value is back past
+// the Threshold by Hysteresis so a flapping sensor does not fire over and over.
+//
+// An offline Rule raises an Alert when a Station has not sent a heartbeat or
+// reading for For seconds.
+//
+// Scope limits the Rule to a station, zone, tag, group or station type
+// identified by ScopeValue. Tags are given as key or key:value. A Rule without
+// a Scope applies to every Station.
+type Rule struct {
+	Id          string    `db:"id"           json:"id"`
+	Name        string    `db:"name"         json:"name"`
+	Kind        string    `db:"kind"         json:"kind"`
+	Sensor      string    `db:"sensor"       json:"sensor,omitempty"`
+	Operator    string    `db:"operator"     json:"operator,omitempty"`
+	Threshold   float64   `db:"threshold"    json:"threshold"`
+	Hysteresis  float64   `db:"hysteresis"   json:"hysteresis"`
+	For         int       `db:"for_seconds"  json:"for"`
+	Severity    string    `db:"severity"     json:"severity"`
+	Scope       string    `db:"scope"        json:"scope,omitempty"`
+	ScopeValue  string    `db:"scope_value"  json:"scope_value,omitempty"`
+	DateCreated time.Time `db:"date_created" json:"date_created"`
+	DateUpdated time.Time `db:"date_updated" json:"date_updated"`
+}
+
+// NewRule is what we require from clients when adding a Rule. Severity
+// defaults to warning.
+type NewRule struct {
+	Name       string  `json:"name" validate:"required"`
+	Kind       string  `json:"kind" validate:"required,oneof=threshold offline"`
+	Sensor     string  `json:"sensor"`
+	Operator   string  `json:"operator" validate:"omitempty,oneof=below above"`
+	Threshold  float64 `json:"threshold"`
+	Hysteresis float64 `json:"hysteresis" validate:"gte=0"`
+	For        int     `json:"for" validate:"gte=0"`
+	Severity   string  `json:"severity" validate:"omitempty,oneof=info warning critical"`
+	Scope      string  `json:"scope" validate:"omitempty,oneof=station zone tag group station_type"`
+	ScopeValue string  `json:"scope_value"`
+}
+
+// Alert is a Rule that matched a Station. It is pending until the condition
+// of the Rule has held for long enough, then firing until it resolves. Only
+// one pending or firing Alert exists per Rule and Station.
+//
+// An acknowledged Alert keeps firing but someone is looking into it. No
+// events are raised for an Alert while it is silenced.
+type Alert struct {
+	Id               string     `db:"id"                json:"id"`
+	RuleId           string     `db:"rule_id"           json:"rule_id"`
+	StationId        string     `db:"station_id"        json:"station_id"`
+	Status           string     `db:"status"            json:"status"`
+	Value            float64    `db:"value"             json:"value"`
+	DateStarted      time.Time  `db:"date_started"      json:"date_started"`
+	DateFiring       *time.Time `db:"date_firing"       json:"date_firing,omitempty"`
+	DateResolved     *time.Time `db:"date_resolved"     json:"date_resolved,omitempty"`
+	AcknowledgedBy   *string    `db:"acknowledged_by"   json:"acknowledged_by,omitempty"`
+	DateAcknowledged *time.Time `db:"date_acknowledged" json:"date_acknowledged,omitempty"`
+	SilencedUntil    *time.Time `db:"silenced_until"    json:"silenced_until,omitempty"`
+	DateUpdated      time.Time  `db:"date_updated"      json:"date_updated"`
+}
+
+// NewSilence is what we require from clients when silencing an Alert.
+type NewSilence struct {
+	Until time.Time `json:"until" validate:"required"`
+}
+
+// Filter limits the Alerts returned by List. The zero value returns every
+// pending and firing Alert.
+type Filter struct {
+	Status    string
+	RuleId    string
+	StationId string
+}
@@ -0,0 +1,239 @@
+package anomaly
+
+import (
+	// Core packages
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/event"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Predefined errors identify expected failure conditions.
+var (
+	// ErrInvalidID is used when an invalid UUID is provided.
+	ErrInvalidID = errors.New("ID is not in its proper UUID format")
+)
+
+const (
+	// StartWindow is how far back readings of a sensor without a State are
+	// read the first time it is scanned.
+	StartWindow = 24 * time.Hour
+
+	// driftEvery is how often drift of the same sensor is reported.
+	driftEvery = 24 * time.Hour
+)
+
+// ListStates gets the States of the sensors of a Station.
+func ListStates(ctx context.Context, db *sqlx.DB, stationID string) ([]State, error) {
+
+	ctx, span := trace.StartSpan(ctx, "anomaly.ListStates")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	states := []State{}
+
+	const q = `
+		SELECT
+			station_id, sensor, mean, variance, count, flat_run, "window", date_last_received,
+			date_drift, date_updated
+		FROM sensor_state
+		WHERE station_id = $1
+		ORDER BY sensor`
+
+	if err := db.SelectContext(ctx, &states, q, stationID); err != nil {
+		return nil, errors.Wrap(err, "selecting sensor states")
+	}
+
+	return states, nil
+}
+
+// Scan feeds the readings received since the previous Scan through the
+// States of their sensors, then compares the sensors of Stations sharing a
+// Zone. Every Anomaly found is recorded as an Event and returned.
+func Scan(ctx context.Context, db *sqlx.DB, opts Options, now time.Time) ([]Anomaly, error) {
+
+	ctx, span := trace.StartSpan(ctx, "anomaly.Scan")
+	defer span.End()
+
+	var states []State
+
+	const qs = `
+		SELECT
+			station_id, sensor, mean, variance, count, flat_run, "window", date_last_received,
+			date_drift, date_updated
+		FROM sensor_state`
+
+	if err := db.SelectContext(ctx, &states, qs); err != nil {
+		return nil, errors.Wrap(err, "selecting sensor states")
+	}
+
+	byKey := make(map[[2]string]*State, len(states))
+	for i := range states {
+		byKey[[2]string{states[i].StationId, states[i].Sensor}] = &states[i]
+	}
+
+	var readings []struct {
+		StationId    string    `db:"station_id"`
+		Sensor       string    `db:"sensor"`
+		Value        float64   `db:"value"`
+		DateRead     time.Time `db:"date_read"`
+		DateReceived time.Time `db:"date_received"`
+	}
+
+	// Readings are fed in the order they were read. The State keeps track of
+	// when the last reading it saw was received.
+	const qr = `
+		SELECT reading.station_id, reading.sensor, reading.value, reading.date_read, reading.date_received
+		FROM reading
+		LEFT JOIN sensor_state USING (station_id, sensor)
+		WHERE reading.date_received > COALESCE(sensor_state.date_last_received, $1)
+		ORDER BY reading.station_id, reading.sensor, reading.date_read`
+
+	if err := db.SelectContext(ctx, &readings, qr, now.Add(-StartWindow).UTC()); err != nil {
+		return nil, errors.Wrap(err, "selecting new readings")
+	}
+
+	var found []Anomaly
+	changed := make(map[[2]string]bool)
+	for _, r := range readings {
+		key := [2]string{r.StationId, r.Sensor}
+		s, ok := byKey[key]
+		if !ok {
+			s = &State{StationId: r.StationId, Sensor: r.Sensor}
+			byKey[key] = s
+		}
+
+		found = append(found, s.Observe(Point{Value: r.Value, DateRead: r.DateRead}, opts)...)
+		if r.DateReceived.After(s.DateLastReceived) {
+			s.DateLastReceived = r.DateReceived
+		}
+		changed[key] = true
+	}
+
+	// Sensors of Stations in the same Zone are compared once their States are
+	// up to date. Stations that stopped reporting are left out.
+	var zones []struct {
+		Id     string `db:"id"`
+		ZoneId string `db:"zone_id"`
+	}
+	const qz = `SELECT id, zone_id FROM station WHERE zone_id IS NOT NULL AND date_deleted IS NULL`
+	if err := db.SelectContext(ctx, &zones, qz); err != nil {
+		return nil, errors.Wrap(err, "selecting station zones")
+	}
+
+	byZone := make(map[string][]State)
+	zoneOf := make(map[string]string, len(zones))
+	for _, z := range zones {
+		zoneOf[z.Id] = z.ZoneId
+	}
+	for _, s := range byKey {
+		if zone, ok := zoneOf[s.StationId]; ok && now.Sub(s.DateLastReceived) < StartWindow {
+			byZone[zone] = append(byZone[zone], *s)
+		}
+	}
+	for _, group := range byZone {
+		for _, a := range Drift(group, opts) {
+			key := [2]string{a.StationId, a.Sensor}
+			s := byKey[key]
+			if s.DateDrift != nil && now.Sub(*s.DateDrift) < driftEvery {
+				continue
+			}
+			t := now.UTC()
+			s.DateDrift = &t
+			changed[key] = true
+			found = append(found, a)
+		}
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	const upsert = `
+		INSERT INTO sensor_state
+		  (station_id, sensor, mean, variance, count, flat_run, "window", date_last_received, date_drift, date_updated)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (station_id, sensor) DO UPDATE SET
+			mean = EXCLUDED.mean,
+			variance = EXCLUDED.variance,
+			count = EXCLUDED.count,
+			flat_run = EXCLUDED.flat_run,
+			"window" = EXCLUDED."window",
+			date_last_received = EXCLUDED.date_last_received,
+			date_drift = EXCLUDED.date_drift,
+			date_updated = EXCLUDED.date_updated`
+
+	for key := range changed {
+		s := byKey[key]
+		s.DateUpdated = now.UTC()
+		_, err := tx.ExecContext(ctx, upsert,
+			s.StationId,
+			s.Sensor,
+			s.Mean,
+			s.Variance,
+			s.Count,
+			s.FlatRun,
+			s.Window,
+			s.DateLastReceived,
+			s.DateDrift,
+			s.DateUpdated,
+		)
+		if err != nil {
+			return nil, errors.Wrapf(err, "saving state of %s sensor of station %s", s.Sensor, s.StationId)
+		}
+	}
+
+	// The Events are recorded with the States so a Scan that fails does not
+	// report the same Anomalies again.
+	for _, a := range found {
+		stationID := a.StationId
+		ne := event.NewEvent{
+			Type:      EventAnomaly,
+			Severity:  event.SeverityWarning,
+			StationId: &stationID,
+			Message:   describe(a),
+			Data:      a,
+		}
+		if _, err := event.Record(ctx, tx, ne, now); err != nil {
+			return nil, errors.Wrap(err, "recording anomaly event")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "committing sensor states")
+	}
+
+	return found, nil
+}
+
+// describe writes an Anomaly as the message of its Event.
+func describe(a Anomaly) string {
+	switch a.Kind {
+	case KindSpike:
+		return fmt.Sprintf("%s reading %.1f is a spike from the usual %.1f (z-score %.1f)", a.Sensor, a.Value, a.Expected, a.Score)
+	case KindFlatLine:
+		return fmt.Sprintf("%s sensor read %.1f %d times in a row and may be stuck", a.Sensor, a.Value, int(a.Score))
+	case KindDrift:
+		dir := "above"
+		if a.Score < 0 {
+			dir = "below"
+		}
+		return fmt.Sprintf("%s averages %.1f, %.1f %s the other stations of its zone", a.Sensor, a.Value, math.Abs(a.Value-a.Expected), dir)
+	default:
+		return fmt.Sprintf("%s reading %.1f is unusual", a.Sensor, a.Value)
+	}
+}
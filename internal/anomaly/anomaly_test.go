@@ -0,0 +1,88 @@
+package anomaly_test
+
+import (
+	// Core packages
+	"context"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/anomaly"
+	"github.com/deezone/HydroBytes-BaseStation/internal/event"
+	"github.com/deezone/HydroBytes-BaseStation/internal/reading"
+	"github.com/deezone/HydroBytes-BaseStation/internal/schema"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+)
+
+func TestScan(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2021, time.June, 3, 0, 0, 0, 0, time.UTC)
+
+	station := "ee72a90c-590c-11eb-ae93-0242ac130002"
+
+	record := func(received time.Time, values ...float64) {
+		var nr reading.NewReadings
+		for i, v := range values {
+			read := received.Add(time.Duration(i-len(values)) * time.Minute)
+			nr.Readings = append(nr.Readings, reading.NewReading{Sensor: reading.SensorMoisture, Value: v, DateRead: &read})
+		}
+		if _, err := reading.Record(ctx, db, station, nr, received); err != nil {
+			t.Fatalf("recording readings: %s", err)
+		}
+	}
+
+	record(now.Add(-time.Hour), 40, 42, 41, 43, 40, 42, 41, 43, 40, 42, 41, 43)
+
+	found, err := anomaly.Scan(ctx, db, anomaly.DefaultOptions, now)
+	if err != nil {
+		t.Fatalf("scanning readings: %s", err)
+	}
+	if len(found) != 0 {
+		t.Fatalf("expected no anomalies in steady readings, got %+v", found)
+	}
+
+	states, err := anomaly.ListStates(ctx, db, station)
+	if err != nil {
+		t.Fatalf("listing sensor states: %s", err)
+	}
+	if exp, got := 1, len(states); exp != got {
+		t.Fatalf("expected %v sensor state, got %v", exp, got)
+	}
+	if exp, got := 12, states[0].Count; exp != got {
+		t.Fatalf("expected %v readings counted, got %v", exp, got)
+	}
+
+	// Readings already scanned are not counted again.
+	record(now.Add(time.Minute), 90)
+
+	found, err = anomaly.Scan(ctx, db, anomaly.DefaultOptions, now.Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("scanning readings: %s", err)
+	}
+	if len(found) != 1 || found[0].Kind != anomaly.KindSpike {
+		t.Fatalf("expected a spike, got %+v", found)
+	}
+
+	states, err = anomaly.ListStates(ctx, db, station)
+	if err != nil {
+		t.Fatalf("listing sensor states: %s", err)
+	}
+	if exp, got := 13, states[0].Count; exp != got {
+		t.Fatalf("expected %v readings counted, got %v", exp, got)
+	}
+
+	events, err := event.List(ctx, db, event.Filter{Type: anomaly.EventAnomaly})
+	if err != nil {
+		t.Fatalf("listing events: %s", err)
+	}
+	if exp, got := 1, len(events); exp != got {
+		t.Fatalf("expected %v anomaly event, got %v", exp, got)
+	}
+}
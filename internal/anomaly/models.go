@@ -0,0 +1,122 @@
+package anomaly
+
+import (
+	// Core packages
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/reading"
+
+	// Third-party packages
+	"github.com/pkg/errors"
+)
+
+// EventAnomaly is the type of the Event raised for every Anomaly.
+const EventAnomaly = "reading.anomaly"
+
+// Kinds of Anomaly.
+const (
+	KindFlatLine = "flat_line"
+	KindSpike    = "spike"
+	KindDrift    = "drift"
+)
+
+// State is the streaming statistics of a sensor of a Station. Mean and
+// Variance are exponentially weighted moving averages of the readings.
+// FlatRun counts the readings in a row equal to the one before them and
+// Window holds the most recent readings.
+type State struct {
+	StationId        string     `db:"station_id"         json:"station_id"`
+	Sensor           string     `db:"sensor"             json:"sensor"`
+	Mean             float64    `db:"mean"               json:"mean"`
+	Variance         float64    `db:"variance"           json:"variance"`
+	Count            int        `db:"count"              json:"count"`
+	FlatRun          int        `db:"flat_run"           json:"flat_run"`
+	Window           Window     `db:"window"             json:"window"`
+	DateLastReceived time.Time  `db:"date_last_received" json:"date_last_received"`
+	DateDrift        *time.Time `db:"date_drift"         json:"date_drift,omitempty"`
+	DateUpdated      time.Time  `db:"date_updated"       json:"date_updated"`
+}
+
+// Point is a reading in the Window of a State.
+type Point struct {
+	Value    float64   `json:"value"`
+	DateRead time.Time `json:"date_read"`
+}
+
+// Window is the most recent readings of a sensor, oldest first. It is stored
+// as a JSON array in the window column.
+type Window []Point
+
+// Scan implements the sql.Scanner interface to read a Window from the database.
+func (w *Window) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, w)
+	case string:
+		return json.Unmarshal([]byte(v), w)
+	case nil:
+		*w = nil
+		return nil
+	default:
+		return errors.Errorf("unsupported type %T for window", src)
+	}
+}
+
+// Value implements the driver.Valuer interface to write a Window to the database.
+func (w Window) Value() (driver.Value, error) {
+	if w == nil {
+		return []byte("[]"), nil
+	}
+
+	return json.Marshal(w)
+}
+
+// Anomaly is a reading, or for drift the recent readings, of a sensor that
+// does not fit its history or the other Stations of its Zone. Score is the
+// z-score of the reading, or the length of the run of a flat line. Window
+// holds the readings leading up to the Anomaly.
+type Anomaly struct {
+	Kind      string  `json:"kind"`
+	StationId string  `json:"station_id"`
+	Sensor    string  `json:"sensor"`
+	Value     float64 `json:"value"`
+	Score     float64 `json:"score"`
+	Expected  float64 `json:"expected"`
+	Window    Window  `json:"window"`
+}
+
+// Options tune the detection of Anomalies.
+//
+// Readings are spikes when their z-score against the moving average is above
+// SpikeZ, once a sensor has MinSamples readings. FlatReadings is the number of
+// equal readings in a row that make a flat line for each sensor; sensors
+// without an entry, such as light which reads 0 all night, are not checked.
+// A sensor drifts when its moving average is more than DriftZ spreads away
+// from the median of at least MinSiblings other Stations in its Zone.
+// Standard deviations and spreads below MinStdDev are raised to it so a
+// sensor that barely changed does not flag every small change.
+type Options struct {
+	Alpha        float64
+	SpikeZ       float64
+	MinSamples   int
+	FlatReadings map[string]int
+	WindowSize   int
+	DriftZ       float64
+	MinSiblings  int
+	MinStdDev    float64
+}
+
+// DefaultOptions are the Options used by the base station.
+var DefaultOptions = Options{
+	Alpha:        0.1,
+	SpikeZ:       4,
+	MinSamples:   10,
+	FlatReadings: map[string]int{reading.SensorMoisture: 24, reading.SensorTemperature: 12},
+	WindowSize:   20,
+	DriftZ:       3,
+	MinSiblings:  2,
+	MinStdDev:    0.5,
+}
@@ -0,0 +1,142 @@
+package anomaly
+
+import (
+	// Core packages
+	"math"
+	"sort"
+)
+
+// Observe adds a reading to the State of its sensor and returns the
+// Anomalies the reading shows. A flat line is only reported once, when the
+// run reaches the number of readings of the Options.
+func (s *State) Observe(p Point, opts Options) []Anomaly {
+	var found []Anomaly
+
+	window := func() Window {
+		return append(append(Window{}, s.Window...), p)
+	}
+
+	if s.Count >= opts.MinSamples {
+		sd := math.Max(math.Sqrt(s.Variance), opts.MinStdDev)
+		if z := (p.Value - s.Mean) / sd; math.Abs(z) > opts.SpikeZ {
+			found = append(found, Anomaly{
+				Kind:      KindSpike,
+				StationId: s.StationId,
+				Sensor:    s.Sensor,
+				Value:     p.Value,
+				Score:     z,
+				Expected:  s.Mean,
+				Window:    window(),
+			})
+		}
+	}
+
+	if s.Count > 0 && len(s.Window) > 0 && p.Value == s.Window[len(s.Window)-1].Value {
+		s.FlatRun++
+	} else {
+		s.FlatRun = 0
+	}
+	if n := opts.FlatReadings[s.Sensor]; n > 1 && s.FlatRun == n-1 {
+		found = append(found, Anomaly{
+			Kind:      KindFlatLine,
+			StationId: s.StationId,
+			Sensor:    s.Sensor,
+			Value:     p.Value,
+			Score:     float64(n),
+			Expected:  s.Mean,
+			Window:    window(),
+		})
+	}
+
+	// Exponentially weighted moving average and variance.
+	if s.Count == 0 {
+		s.Mean = p.Value
+		s.Variance = 0
+	} else {
+		diff := p.Value - s.Mean
+		incr := opts.Alpha * diff
+		s.Mean += incr
+		s.Variance = (1 - opts.Alpha) * (s.Variance + diff*incr)
+	}
+	s.Count++
+
+	s.Window = window()
+	if len(s.Window) > opts.WindowSize {
+		s.Window = s.Window[len(s.Window)-opts.WindowSize:]
+	}
+
+	return found
+}
+
+// Drift compares the moving averages of the same sensor of Stations in one
+// Zone and returns an Anomaly for every State that is far from the others.
+// States of other sensors are compared separately.
+func Drift(states []State, opts Options) []Anomaly {
+	bySensor := make(map[string][]State)
+	for _, s := range states {
+		if s.Count >= opts.MinSamples {
+			bySensor[s.Sensor] = append(bySensor[s.Sensor], s)
+		}
+	}
+
+	var found []Anomaly
+	for _, group := range bySensor {
+		if len(group) <= opts.MinSiblings {
+			continue
+		}
+
+		for i, s := range group {
+			others := make([]float64, 0, len(group)-1)
+			for j, o := range group {
+				if i != j {
+					others = append(others, o.Mean)
+				}
+			}
+
+			med := median(others)
+			spread := math.Max(stdDev(others), opts.MinStdDev)
+			if z := (s.Mean - med) / spread; math.Abs(z) > opts.DriftZ {
+				found = append(found, Anomaly{
+					Kind:      KindDrift,
+					StationId: s.StationId,
+					Sensor:    s.Sensor,
+					Value:     s.Mean,
+					Score:     z,
+					Expected:  med,
+					Window:    s.Window,
+				})
+			}
+		}
+	}
+
+	return found
+}
+
+// median gets the middle of a list of values.
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// stdDev gets the population standard deviation of a list of values.
+func stdDev(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+
+	return math.Sqrt(sq / float64(len(values)))
+}
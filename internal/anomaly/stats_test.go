@@ -0,0 +1,114 @@
+package anomaly_test
+
+import (
+	// Core packages
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/anomaly"
+)
+
+// observe feeds values to a State a minute apart and returns the Anomalies
+// of every reading.
+func observe(s *anomaly.State, values ...float64) []anomaly.Anomaly {
+	start := time.Date(2021, time.June, 2, 0, 0, 0, 0, time.UTC)
+
+	var found []anomaly.Anomaly
+	for _, v := range values {
+		p := anomaly.Point{Value: v, DateRead: start.Add(time.Duration(s.Count) * time.Minute)}
+		found = append(found, s.Observe(p, anomaly.DefaultOptions)...)
+	}
+
+	return found
+}
+
+func TestSpike(t *testing.T) {
+	s := anomaly.State{StationId: "a", Sensor: "moisture"}
+
+	if found := observe(&s, 40, 42, 41, 43, 40, 42, 41, 43, 40, 42, 41, 43); len(found) != 0 {
+		t.Fatalf("expected no anomalies in steady readings, got %+v", found)
+	}
+
+	found := observe(&s, 90)
+	if len(found) != 1 || found[0].Kind != anomaly.KindSpike {
+		t.Fatalf("expected a spike, got %+v", found)
+	}
+	if found[0].Score <= anomaly.DefaultOptions.SpikeZ {
+		t.Errorf("expected a z-score above %v, got %v", anomaly.DefaultOptions.SpikeZ, found[0].Score)
+	}
+	if last := found[0].Window[len(found[0].Window)-1]; last.Value != 90 {
+		t.Errorf("expected the window to end with the spike, got %v", last.Value)
+	}
+	if len(s.Window) != len(found[0].Window) {
+		t.Errorf("expected a window of %d readings, got %d", len(s.Window), len(found[0].Window))
+	}
+}
+
+func TestSpikeNeedsSamples(t *testing.T) {
+	s := anomaly.State{StationId: "a", Sensor: "moisture"}
+
+	if found := observe(&s, 40, 41, 90); len(found) != 0 {
+		t.Errorf("expected no spike before %d samples, got %+v", anomaly.DefaultOptions.MinSamples, found)
+	}
+}
+
+func TestFlatLine(t *testing.T) {
+	s := anomaly.State{StationId: "a", Sensor: "temperature"}
+
+	values := make([]float64, 11)
+	for i := range values {
+		values[i] = 18.5
+	}
+	if found := observe(&s, values...); len(found) != 0 {
+		t.Fatalf("expected no anomalies before 12 equal readings, got %+v", found)
+	}
+
+	found := observe(&s, 18.5)
+	if len(found) != 1 || found[0].Kind != anomaly.KindFlatLine {
+		t.Fatalf("expected a flat line, got %+v", found)
+	}
+
+	// The same run is only reported once.
+	if found := observe(&s, 18.5, 18.5); len(found) != 0 {
+		t.Errorf("expected the flat line to be reported once, got %+v", found)
+	}
+
+	// Sensors without a flat line limit are not checked.
+	r := anomaly.State{StationId: "a", Sensor: "reservoir_level"}
+	values = make([]float64, 30)
+	for i := range values {
+		values[i] = 60
+	}
+	if found := observe(&r, values...); len(found) != 0 {
+		t.Errorf("expected no flat line for reservoir_level, got %+v", found)
+	}
+}
+
+func TestDrift(t *testing.T) {
+	state := func(id, sensor string, mean float64) anomaly.State {
+		return anomaly.State{StationId: id, Sensor: sensor, Mean: mean, Count: anomaly.DefaultOptions.MinSamples}
+	}
+
+	states := []anomaly.State{
+		state("a", "moisture", 41),
+		state("b", "moisture", 40),
+		state("c", "moisture", 42),
+		state("d", "moisture", 12),
+		state("a", "temperature", 18),
+		state("b", "temperature", 19),
+	}
+
+	found := anomaly.Drift(states, anomaly.DefaultOptions)
+	if len(found) != 1 {
+		t.Fatalf("expected 1 drift, got %+v", found)
+	}
+	if found[0].StationId != "d" || found[0].Kind != anomaly.KindDrift || found[0].Expected != 41 {
+		t.Errorf("expected moisture of d to drift from 41, got %+v", found[0])
+	}
+
+	// Two stations are not enough to tell which one drifted.
+	if found := anomaly.Drift(states[2:4], anomaly.DefaultOptions); len(found) != 0 {
+		t.Errorf("expected no drift with one sibling, got %+v", found)
+	}
+}
@@ -0,0 +1,144 @@
+package audit
+
+import (
+	// Core packages
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/auth"
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Predefined errors identify expected failure conditions.
+var (
+	// ErrInvalidID is used when an invalid UUID is provided.
+	ErrInvalidID = errors.New("ID is not in its proper UUID format")
+)
+
+// DefaultLimit is the number of Entries returned by List when the Filter has
+// no Limit.
+const DefaultLimit = 100
+
+// Write records a change to the record identified by targetID. The actor is
+// taken from the claims of the request in ctx and the trace ID from its web
+// values, so changes made outside a request have neither. before and after
+// are stored as JSON; either may be nil. Pass the transaction of the change
+// as db so the Entry is only kept when the change is.
+func Write(ctx context.Context, db sqlx.ExtContext, action, targetID string, before, after interface{}, now time.Time) error {
+
+	ctx, span := trace.StartSpan(ctx, "audit.Write")
+	defer span.End()
+
+	e := Entry{
+		Id:          uuid.New().String(),
+		Action:      action,
+		TargetId:    targetID,
+		DateCreated: now.UTC(),
+	}
+
+	if claims, ok := ctx.Value(auth.Key).(auth.Claims); ok && claims.Subject != "" {
+		e.ActorId = &claims.Subject
+	}
+	if v, ok := ctx.Value(web.KeyValues).(*web.Values); ok {
+		e.TraceId = v.TraceID
+	}
+
+	var data [2][]byte
+	for i, v := range []interface{}{before, after} {
+		if v == nil {
+			continue
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return errors.Wrapf(err, "encoding %s audit data", action)
+		}
+		data[i] = b
+	}
+
+	const q = `
+		INSERT INTO audit
+		  (id, actor_id, action, target_id, before, after, trace_id, date_created)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := db.ExecContext(ctx, q,
+		e.Id,
+		e.ActorId,
+		e.Action,
+		e.TargetId,
+		data[0],
+		data[1],
+		e.TraceId,
+		e.DateCreated,
+	)
+	if err != nil {
+		return errors.Wrapf(err, "inserting %s audit entry", action)
+	}
+
+	return nil
+}
+
+// List gets the Entries matching the Filter, most recent first.
+func List(ctx context.Context, db *sqlx.DB, filter Filter) ([]Entry, error) {
+
+	ctx, span := trace.StartSpan(ctx, "audit.List")
+	defer span.End()
+
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := []string{"TRUE"}
+	if filter.ActorId != "" {
+		if _, err := uuid.Parse(filter.ActorId); err != nil {
+			return nil, ErrInvalidID
+		}
+		where = append(where, "actor_id = "+arg(filter.ActorId))
+	}
+	if filter.Action != "" {
+		if strings.HasSuffix(filter.Action, ".") {
+			where = append(where, "left(action, "+arg(len(filter.Action))+") = "+arg(filter.Action))
+		} else {
+			where = append(where, "action = "+arg(filter.Action))
+		}
+	}
+	if filter.TargetId != "" {
+		where = append(where, "target_id = "+arg(filter.TargetId))
+	}
+	if filter.Since != nil {
+		where = append(where, "date_created >= "+arg(filter.Since.UTC()))
+	}
+	if filter.Until != nil {
+		where = append(where, "date_created < "+arg(filter.Until.UTC()))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	q := `
+		SELECT id, actor_id, action, target_id, before, after, trace_id, date_created
+		FROM audit
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY date_created DESC
+		LIMIT ` + arg(limit)
+
+	entries := []Entry{}
+	if err := db.SelectContext(ctx, &entries, q, args...); err != nil {
+		return nil, errors.Wrap(err, "selecting audit entries")
+	}
+
+	return entries, nil
+}
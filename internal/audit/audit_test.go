@@ -0,0 +1,81 @@
+package audit_test
+
+import (
+	// Core packages
+	"context"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/audit"
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/auth"
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/web"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+)
+
+func TestAudit(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	now := time.Date(2021, time.June, 2, 6, 0, 0, 0, time.UTC)
+
+	// Changes made during a request are recorded with the account and trace
+	// of the request.
+	claims := auth.NewClaims(tests.AdminId, []string{auth.RoleAdmin}, now, time.Hour)
+	ctx := context.WithValue(context.Background(), auth.Key, claims)
+	ctx = context.WithValue(ctx, web.KeyValues, &web.Values{TraceID: "0af7651916cd43dd8448eb211c80319c", Start: now})
+
+	st, err := station_type.Create(ctx, db, station_type.NewStationType{Name: "Sensor"}, now)
+	if err != nil {
+		t.Fatalf("creating station type: %s", err)
+	}
+
+	name := "Soil sensor"
+	if err := station_type.Update(ctx, db, st.Id, station_type.UpdateStationType{Name: &name}, now.Add(time.Minute)); err != nil {
+		t.Fatalf("updating station type: %s", err)
+	}
+
+	entries, err := audit.List(ctx, db, audit.Filter{TargetId: st.Id})
+	if err != nil {
+		t.Fatalf("listing audit entries: %s", err)
+	}
+	if exp, got := 2, len(entries); exp != got {
+		t.Fatalf("expected %v audit entries, got %v", exp, got)
+	}
+
+	update := entries[0]
+	if exp, got := "station_type.update", update.Action; exp != got {
+		t.Fatalf("expected action %q, got %q", exp, got)
+	}
+	if update.ActorId == nil || *update.ActorId != tests.AdminId {
+		t.Fatalf("expected actor %q, got %v", tests.AdminId, update.ActorId)
+	}
+	if exp, got := "0af7651916cd43dd8448eb211c80319c", update.TraceId; exp != got {
+		t.Fatalf("expected trace %q, got %q", exp, got)
+	}
+
+	var before, after station_type.StationType
+	if err := update.Before.Unmarshal(&before); err != nil {
+		t.Fatalf("decoding before: %s", err)
+	}
+	if err := update.After.Unmarshal(&after); err != nil {
+		t.Fatalf("decoding after: %s", err)
+	}
+	if before.Name != "Sensor" || after.Name != name {
+		t.Fatalf("expected name changed from %q to %q, got %q to %q", "Sensor", name, before.Name, after.Name)
+	}
+
+	if entries[1].Before != nil {
+		t.Fatalf("create should have nothing before, got %s", *entries[1].Before)
+	}
+
+	// Prefixes match every action on a kind of record.
+	entries, err = audit.List(ctx, db, audit.Filter{Action: "station_type."})
+	if err != nil {
+		t.Fatalf("listing audit entries: %s", err)
+	}
+	if exp, got := 2, len(entries); exp != got {
+		t.Fatalf("expected %v station type entries, got %v", exp, got)
+	}
+}
@@ -0,0 +1,36 @@
+package audit
+
+import (
+	// Core packages
+	"time"
+
+	// Third-party packages
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Entry is the record of a change made to the garden. ActorId is the Account
+// that made the change; it is empty for changes made by the admin tools.
+// Before and After hold the changed record as JSON and are empty when there
+// was nothing before a create or after a delete.
+type Entry struct {
+	Id          string          `db:"id"           json:"id"`
+	ActorId     *string         `db:"actor_id"     json:"actor_id,omitempty"`
+	Action      string          `db:"action"       json:"action"`
+	TargetId    string          `db:"target_id"    json:"target_id,omitempty"`
+	Before      *types.JSONText `db:"before"       json:"before,omitempty"`
+	After       *types.JSONText `db:"after"        json:"after,omitempty"`
+	TraceId     string          `db:"trace_id"     json:"trace_id,omitempty"`
+	DateCreated time.Time       `db:"date_created" json:"date_created"`
+}
+
+// Filter limits the Entries returned by List. The zero value returns the most
+// recent Entries. Actions ending in "." match every action with that prefix,
+// for example "station." matches all changes to Stations.
+type Filter struct {
+	ActorId  string
+	Action   string
+	TargetId string
+	Since    *time.Time
+	Until    *time.Time
+	Limit    int
+}
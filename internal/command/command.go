@@ -0,0 +1,404 @@
+package command
+
+import (
+	// Core packages
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/event"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// EventFailed is the type of the Event raised when a Station reports a
+// Command failed.
+const EventFailed = "command.failed"
+
+// Predefined errors identify expected failure conditions.
+var (
+	// ErrNotFound is used when a specific Command is requested but does not exist.
+	ErrNotFound = errors.New("command not found")
+
+	// ErrInvalidID is used when an invalid UUID is provided.
+	ErrInvalidID = errors.New("ID is not in its proper UUID format")
+
+	// ErrStationNotFound is used when a Command is queued for a Station that does not exist.
+	ErrStationNotFound = errors.New("station not found")
+
+	// ErrMeterNotFound is used when a Command names a flow meter that is not
+	// part of its Station.
+	ErrMeterNotFound = errors.New("flow meter not found on station")
+
+	// ErrInvalidTransition is used when a Command is moved to a status it can
+	// not reach from its current status.
+	ErrInvalidTransition = errors.New("command can not move to that status")
+
+	// ErrNoTarget is used when Commands are queued for a tag or group but
+	// neither is given.
+	ErrNoTarget = errors.New("tag or group of the stations is required")
+
+	// ErrMeterForTarget is used when Commands queued for a tag or group name
+	// a flow meter, which only belongs to a single Station.
+	ErrMeterForTarget = errors.New("flow meter can only be set for a single station")
+)
+
+// transitions lists the statuses a Command may move to from each status.
+var transitions = map[string][]string{
+	StatusQueued:  {StatusRunning, StatusFailed, StatusCancelled},
+	StatusRunning: {StatusCompleted, StatusFailed},
+}
+
+// selectCommand selects Commands along with the litres measured while they ran.
+const selectCommand = `
+	SELECT
+		command.id,
+		command.station_id,
+		command.kind,
+		command.valve,
+		command.meter_id,
+		command.duration,
+		command.status,
+		command.error,
+		command.created_by,
+		(SELECT SUM(flow_reading.litres) FROM flow_reading WHERE flow_reading.command_id = command.id) AS litres,
+		command.date_scheduled,
+		command.date_started,
+		command.date_finished,
+		command.date_created
+	FROM command`
+
+// Create queues a Command for a Station. createdBy is the account queueing the
+// Command and is nil for Commands queued by the base station itself.
+func Create(ctx context.Context, db *sqlx.DB, stationID string, nc NewCommand, createdBy *string, now time.Time) (*Command, error) {
+
+	ctx, span := trace.StartSpan(ctx, "command.Create")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var exists bool
+	const check = `SELECT EXISTS (SELECT 1 FROM station WHERE id = $1 AND date_deleted IS NULL)`
+	if err := db.GetContext(ctx, &exists, check, stationID); err != nil {
+		return nil, errors.Wrap(err, "checking station")
+	}
+	if !exists {
+		return nil, ErrStationNotFound
+	}
+
+	if nc.MeterId != nil {
+		const meter = `SELECT EXISTS (SELECT 1 FROM flow_meter WHERE id = $1 AND station_id = $2)`
+		if err := db.GetContext(ctx, &exists, meter, *nc.MeterId, stationID); err != nil {
+			return nil, errors.Wrap(err, "checking flow meter")
+		}
+		if !exists {
+			return nil, ErrMeterNotFound
+		}
+	}
+
+	c := newCommand(stationID, nc, createdBy, now)
+	if err := insertCommand(ctx, db, c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// CreateForStations queues the same Command for every active Station matching
+// the filter, for example all Stations tagged seedlings. The filter must name
+// a tag or group so a Command is never sent to every Station by mistake.
+// Either all of the Commands are queued or none are.
+func CreateForStations(ctx context.Context, db *sqlx.DB, filter station_type.StationFilter, nc NewCommand, createdBy *string, now time.Time) ([]Command, error) {
+
+	ctx, span := trace.StartSpan(ctx, "command.CreateForStations")
+	defer span.End()
+
+	if len(filter.Tags) == 0 && filter.GroupId == "" {
+		return nil, ErrNoTarget
+	}
+	if nc.MeterId != nil {
+		return nil, ErrMeterForTarget
+	}
+
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	filter.IncludeArchived = false
+	where, err := filter.Where(arg)
+	if err != nil {
+		if err == station_type.ErrInvalidID {
+			return nil, ErrInvalidID
+		}
+		return nil, err
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	var ids []string
+	q := `SELECT station.id FROM station WHERE ` + strings.Join(where, " AND ") + ` ORDER BY station.date_created`
+	if err := tx.SelectContext(ctx, &ids, q, args...); err != nil {
+		return nil, errors.Wrap(err, "selecting target stations")
+	}
+
+	commands := make([]Command, 0, len(ids))
+	for _, id := range ids {
+		c := newCommand(id, nc, createdBy, now)
+		if err := insertCommand(ctx, tx, c); err != nil {
+			return nil, err
+		}
+		commands = append(commands, c)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "committing commands")
+	}
+
+	return commands, nil
+}
+
+// newCommand builds a queued Command for a Station.
+func newCommand(stationID string, nc NewCommand, createdBy *string, now time.Time) Command {
+	c := Command{
+		Id:            uuid.New().String(),
+		StationId:     stationID,
+		Kind:          nc.Kind,
+		Valve:         nc.Valve,
+		MeterId:       nc.MeterId,
+		Duration:      nc.Duration,
+		Status:        StatusQueued,
+		CreatedBy:     createdBy,
+		DateScheduled: now.UTC(),
+		DateCreated:   now.UTC(),
+	}
+	if nc.DateScheduled != nil {
+		c.DateScheduled = nc.DateScheduled.UTC()
+	}
+
+	return c
+}
+
+// insertCommand stores a new Command.
+func insertCommand(ctx context.Context, db sqlx.ExecerContext, c Command) error {
+	const q = `
+		INSERT INTO command
+		  (id, station_id, kind, valve, meter_id, duration, status, error, created_by, date_scheduled, date_created)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	_, err := db.ExecContext(ctx, q,
+		c.Id,
+		c.StationId,
+		c.Kind,
+		c.Valve,
+		c.MeterId,
+		c.Duration,
+		c.Status,
+		c.Error,
+		c.CreatedBy,
+		c.DateScheduled,
+		c.DateCreated,
+	)
+	if err != nil {
+		return errors.Wrap(err, "inserting command")
+	}
+
+	return nil
+}
+
+// Get finds the Command identified by a given ID.
+func Get(ctx context.Context, db *sqlx.DB, id string) (*Command, error) {
+
+	ctx, span := trace.StartSpan(ctx, "command.Get")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var c Command
+
+	if err := db.GetContext(ctx, &c, selectCommand+` WHERE command.id = $1`, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+
+		return nil, errors.Wrap(err, "selecting single command")
+	}
+
+	return &c, nil
+}
+
+// List gets the Commands matching the Filter ordered by when they are
+// scheduled.
+func List(ctx context.Context, db *sqlx.DB, filter Filter) ([]Command, error) {
+
+	ctx, span := trace.StartSpan(ctx, "command.List")
+	defer span.End()
+
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := []string{"TRUE"}
+	if filter.StationId != "" {
+		if _, err := uuid.Parse(filter.StationId); err != nil {
+			return nil, ErrInvalidID
+		}
+		where = append(where, "command.station_id = "+arg(filter.StationId))
+	}
+	if filter.Status != "" {
+		where = append(where, "command.status = "+arg(filter.Status))
+	}
+	if filter.From != nil {
+		where = append(where, "command.date_scheduled >= "+arg(filter.From.UTC()))
+	}
+	if filter.To != nil {
+		where = append(where, "command.date_scheduled < "+arg(filter.To.UTC()))
+	}
+
+	commands := []Command{}
+
+	q := selectCommand + ` WHERE ` + strings.Join(where, " AND ") + ` ORDER BY command.date_scheduled, command.date_created`
+	if err := db.SelectContext(ctx, &commands, q, args...); err != nil {
+		return nil, errors.Wrap(err, "selecting commands")
+	}
+
+	return commands, nil
+}
+
+// Due gets the queued Commands of a Station that are scheduled to run by now,
+// oldest first. Stations poll for these to find out what to do. Watering runs
+// are held back while irrigation is paused by an active protection condition
+// of the Station, or of a Station the valve of the run irrigates, until the
+// condition ends or the run is cancelled.
+func Due(ctx context.Context, db *sqlx.DB, stationID string, now time.Time) ([]Command, error) {
+
+	ctx, span := trace.StartSpan(ctx, "command.Due")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	commands := []Command{}
+
+	const where = `
+		WHERE command.station_id = $1 AND command.status = 'queued' AND command.date_scheduled <= $2
+		  AND NOT (command.kind = 'water' AND EXISTS (
+			SELECT 1 FROM protection_condition
+			WHERE protection_condition.status = 'active' AND protection_condition.response = 'pause_irrigation'
+			  AND (protection_condition.station_id = command.station_id OR EXISTS (
+				SELECT 1 FROM station_link
+				WHERE station_link.type = 'irrigates'
+				  AND station_link.from_station_id = command.station_id
+				  AND station_link.to_station_id = protection_condition.station_id
+				  AND (COALESCE(station_link.valve, '') = '' OR station_link.valve = command.valve)))))
+		ORDER BY command.date_scheduled`
+
+	if err := db.SelectContext(ctx, &commands, selectCommand+where, stationID, now.UTC()); err != nil {
+		return nil, errors.Wrap(err, "selecting due commands")
+	}
+
+	return commands, nil
+}
+
+// Update moves a Command to the status given in the Report of its Station. A
+// Command starts when it moves to running and finishes when it moves to
+// completed or failed.
+func Update(ctx context.Context, db *sqlx.DB, id string, r Report, now time.Time) (*Command, error) {
+
+	ctx, span := trace.StartSpan(ctx, "command.Update")
+	defer span.End()
+
+	return transition(ctx, db, id, r.Status, r.Error, now)
+}
+
+// Cancel stops a queued Command from running.
+func Cancel(ctx context.Context, db *sqlx.DB, id string, now time.Time) (*Command, error) {
+
+	ctx, span := trace.StartSpan(ctx, "command.Cancel")
+	defer span.End()
+
+	return transition(ctx, db, id, StatusCancelled, "", now)
+}
+
+// transition moves a Command to a new status if it may reach it from its
+// current status.
+func transition(ctx context.Context, db *sqlx.DB, id, status, errMsg string, now time.Time) (*Command, error) {
+
+	c, err := Get(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := false
+	for _, s := range transitions[c.Status] {
+		if s == status {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return nil, ErrInvalidTransition
+	}
+
+	from := c.Status
+
+	t := now.UTC()
+	if status == StatusRunning {
+		c.DateStarted = &t
+	} else {
+		c.DateFinished = &t
+	}
+	c.Status = status
+	c.Error = errMsg
+
+	// Only update the Command if no one else moved it in the meantime.
+	const q = `UPDATE command SET
+		"status" = $2,
+		"error" = $3,
+		"date_started" = $4,
+		"date_finished" = $5
+		WHERE id = $1 AND status = $6`
+	res, err := db.ExecContext(ctx, q, id, c.Status, c.Error, c.DateStarted, c.DateFinished, from)
+	if err != nil {
+		return nil, errors.Wrap(err, "updating command")
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, errors.Wrap(err, "updating command")
+	} else if n == 0 {
+		return nil, ErrInvalidTransition
+	}
+
+	if c.Status == StatusFailed {
+		ne := event.NewEvent{
+			Type:      EventFailed,
+			Severity:  event.SeverityWarning,
+			StationId: &c.StationId,
+			Message:   fmt.Sprintf("%s command on valve %q failed: %s", c.Kind, c.Valve, c.Error),
+			Data:      c,
+		}
+		if _, err := event.Record(ctx, db, ne, now); err != nil {
+			return nil, errors.Wrap(err, "recording command event")
+		}
+	}
+
+	return c, nil
+}
@@ -0,0 +1,94 @@
+package command_test
+
+import (
+	// Core packages
+	"context"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/command"
+	"github.com/deezone/HydroBytes-BaseStation/internal/schema"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+)
+
+func TestCommand(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2021, time.June, 2, 6, 0, 0, 0, time.UTC)
+
+	water := "ee72a90c-590c-11eb-ae93-0242ac130002"
+	later := now.Add(time.Hour)
+
+	first, err := command.Create(ctx, db, water, command.NewCommand{Kind: command.KindWater, Valve: "zone-2", Duration: 240}, nil, now)
+	if err != nil {
+		t.Fatalf("creating command: %s", err)
+	}
+	if _, err := command.Create(ctx, db, water, command.NewCommand{Kind: command.KindWater, Valve: "zone-2", Duration: 240, DateScheduled: &later}, nil, now); err != nil {
+		t.Fatalf("creating scheduled command: %s", err)
+	}
+
+	due, err := command.Due(ctx, db, water, now)
+	if err != nil {
+		t.Fatalf("getting due commands: %s", err)
+	}
+	if exp, got := 1, len(due); exp != got {
+		t.Fatalf("expected %v due command, got %v", exp, got)
+	}
+
+	if _, err := command.Update(ctx, db, first.Id, command.Report{Status: command.StatusCompleted}, now); err != command.ErrInvalidTransition {
+		t.Fatalf("completing a queued command should fail with %v, got %v", command.ErrInvalidTransition, err)
+	}
+
+	running, err := command.Update(ctx, db, first.Id, command.Report{Status: command.StatusRunning}, now)
+	if err != nil {
+		t.Fatalf("starting command: %s", err)
+	}
+	if running.DateStarted == nil {
+		t.Fatal("started command should have a start date")
+	}
+
+	if _, err := command.Cancel(ctx, db, first.Id, now); err != command.ErrInvalidTransition {
+		t.Fatalf("cancelling a running command should fail with %v, got %v", command.ErrInvalidTransition, err)
+	}
+
+	done, err := command.Update(ctx, db, first.Id, command.Report{Status: command.StatusCompleted}, now.Add(4*time.Minute))
+	if err != nil {
+		t.Fatalf("completing command: %s", err)
+	}
+	if done.DateFinished == nil {
+		t.Fatal("completed command should have a finish date")
+	}
+
+	list, err := command.List(ctx, db, command.Filter{StationId: water, Status: command.StatusQueued})
+	if err != nil {
+		t.Fatalf("listing commands: %s", err)
+	}
+	if exp, got := 1, len(list); exp != got {
+		t.Fatalf("expected %v queued command, got %v", exp, got)
+	}
+
+	// Commands for a group are queued for each of its members.
+	nc := command.NewCommand{Kind: command.KindWater, Duration: 120}
+	if _, err := command.CreateForStations(ctx, db, station_type.StationFilter{}, nc, nil, now); err != command.ErrNoTarget {
+		t.Fatalf("creating commands without a target should fail with %v, got %v", command.ErrNoTarget, err)
+	}
+	seedlings := station_type.StationFilter{GroupId: "0b6f2a4e-93d1-4c58-8e27-6d1a5f3c9b70"}
+	fanned, err := command.CreateForStations(ctx, db, seedlings, nc, nil, now)
+	if err != nil {
+		t.Fatalf("creating commands for group: %s", err)
+	}
+	if exp, got := 2, len(fanned); exp != got {
+		t.Fatalf("expected %v commands for the group, got %v", exp, got)
+	}
+	if exp, got := "d58f6d32-6332-11eb-ae93-0242ac130002", fanned[0].StationId; exp != got {
+		t.Fatalf("expected the first command for station %v, got %v", exp, got)
+	}
+}
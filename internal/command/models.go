@@ -0,0 +1,71 @@
+package command
+
+import (
+	// Core packages
+	"time"
+)
+
+// Command kinds.
+const (
+	KindWater      = "water"
+	KindCloseValve = "close_valve"
+)
+
+// Command statuses. A Command is queued until the Station picks it up and
+// reports it running, then ends as completed or failed. Queued Commands may be
+// cancelled.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Command is an instruction for a Station, such as a watering run. Valve and
+// MeterId identify the valve the Command opens or closes and the flow meter
+// measuring it. Duration is how many seconds a watering run lasts.
+//
+// Litres is the water measured by the flow meter while the Command ran. It is
+// only set for Commands with a MeterId.
+type Command struct {
+	Id            string     `db:"id"             json:"id"`
+	StationId     string     `db:"station_id"     json:"station_id"`
+	Kind          string     `db:"kind"           json:"kind"`
+	Valve         string     `db:"valve"          json:"valve,omitempty"`
+	MeterId       *string    `db:"meter_id"       json:"meter_id,omitempty"`
+	Duration      int        `db:"duration"       json:"duration"`
+	Status        string     `db:"status"         json:"status"`
+	Error         string     `db:"error"          json:"error,omitempty"`
+	CreatedBy     *string    `db:"created_by"     json:"created_by,omitempty"`
+	Litres        *float64   `db:"litres"         json:"litres,omitempty"`
+	DateScheduled time.Time  `db:"date_scheduled" json:"date_scheduled"`
+	DateStarted   *time.Time `db:"date_started"   json:"date_started,omitempty"`
+	DateFinished  *time.Time `db:"date_finished"  json:"date_finished,omitempty"`
+	DateCreated   time.Time  `db:"date_created"   json:"date_created"`
+}
+
+// NewCommand is what we require from clients when queueing a Command.
+// DateScheduled defaults to now.
+type NewCommand struct {
+	Kind          string     `json:"kind" validate:"required,oneof=water close_valve"`
+	Valve         string     `json:"valve"`
+	MeterId       *string    `json:"meter_id" validate:"omitempty,uuid"`
+	Duration      int        `json:"duration" validate:"gte=0"`
+	DateScheduled *time.Time `json:"date_scheduled"`
+}
+
+// Report is what a Station sends to move a Command through its statuses.
+type Report struct {
+	Status string `json:"status" validate:"required,oneof=running completed failed"`
+	Error  string `json:"error"`
+}
+
+// Filter limits the Commands returned by List. The zero value matches every
+// Command. From and To limit Commands to those scheduled in the time window.
+type Filter struct {
+	StationId string
+	Status    string
+	From      *time.Time
+	To        *time.Time
+}
@@ -0,0 +1,192 @@
+package event
+
+import (
+	// Core packages
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Predefined errors identify expected failure conditions.
+var (
+	// ErrNotFound is used when a specific Event is requested but does not exist.
+	ErrNotFound = errors.New("event not found")
+
+	// ErrInvalidID is used when an invalid UUID is provided.
+	ErrInvalidID = errors.New("ID is not in its proper UUID format")
+
+	// ErrInvalidSeverity is used when Events are filtered by an unknown severity.
+	ErrInvalidSeverity = errors.New("severity must be info, warning or critical")
+)
+
+// DefaultLimit is the number of Events returned by List when no limit is given.
+const DefaultLimit = 100
+
+// Record stores an Event. It takes a transaction as well so an Event can be
+// stored along with the change it is about.
+func Record(ctx context.Context, db sqlx.ExtContext, ne NewEvent, now time.Time) (*Event, error) {
+
+	ctx, span := trace.StartSpan(ctx, "event.Record")
+	defer span.End()
+
+	data, err := json.Marshal(ne.Data)
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding event data")
+	}
+
+	e := Event{
+		Id:          ne.Id,
+		Type:        ne.Type,
+		Severity:    ne.Severity,
+		StationId:   ne.StationId,
+		Message:     ne.Message,
+		Data:        data,
+		DateCreated: now.UTC(),
+	}
+	if e.Id == "" {
+		e.Id = uuid.New().String()
+	}
+
+	const q = `
+		INSERT INTO event
+		  (id, type, severity, station_id, message, data, date_created)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO NOTHING`
+
+	_, err = db.ExecContext(ctx, q,
+		e.Id,
+		e.Type,
+		e.Severity,
+		e.StationId,
+		e.Message,
+		e.Data,
+		e.DateCreated,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "inserting event")
+	}
+
+	return &e, nil
+}
+
+// Get finds the Event identified by a given ID.
+func Get(ctx context.Context, db *sqlx.DB, id string) (*Event, error) {
+
+	ctx, span := trace.StartSpan(ctx, "event.Get")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var e Event
+
+	const q = `
+		SELECT id, type, severity, station_id, message, data, date_created
+		FROM event
+		WHERE id = $1`
+
+	if err := db.GetContext(ctx, &e, q, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+
+		return nil, errors.Wrap(err, "selecting single event")
+	}
+
+	return &e, nil
+}
+
+// List gets the Events matching the Filter, most recent first.
+func List(ctx context.Context, db *sqlx.DB, filter Filter) ([]Event, error) {
+
+	ctx, span := trace.StartSpan(ctx, "event.List")
+	defer span.End()
+
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := []string{"TRUE"}
+	if filter.Type != "" {
+		where = append(where, "type = "+arg(filter.Type))
+	}
+	if filter.Severity != "" {
+		i := Rank(filter.Severity)
+		if i < 0 {
+			return nil, ErrInvalidSeverity
+		}
+		where = append(where, "severity = ANY("+arg(pq.Array(severities[i:]))+")")
+	}
+	if filter.StationId != "" {
+		if _, err := uuid.Parse(filter.StationId); err != nil {
+			return nil, ErrInvalidID
+		}
+		where = append(where, "station_id = "+arg(filter.StationId))
+	}
+	if filter.Since != nil {
+		where = append(where, "date_created >= "+arg(filter.Since.UTC()))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	events := []Event{}
+
+	q := `
+		SELECT id, type, severity, station_id, message, data, date_created
+		FROM event
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY date_created DESC
+		LIMIT ` + arg(limit)
+
+	if err := db.SelectContext(ctx, &events, q, args...); err != nil {
+		return nil, errors.Wrap(err, "selecting events")
+	}
+
+	return events, nil
+}
+
+// ValidTypes reports whether every one of types is listed in Types.
+func ValidTypes(types []string) bool {
+	for _, t := range types {
+		found := false
+		for _, known := range Types {
+			if t == known {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Rank orders severities from info, ranked 0, to critical. Unknown severities
+// are ranked -1.
+func Rank(severity string) int {
+	for i, s := range severities {
+		if s == severity {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,81 @@
+package event
+
+import (
+	// Core packages
+	"time"
+
+	// Third-party packages
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Event severities from least to most severe.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// severities lists the event severities from least to most severe.
+var severities = []string{SeverityInfo, SeverityWarning, SeverityCritical}
+
+// Types lists the Event types that webhooks and notifications can subscribe
+// to. The types are defined by the packages raising the Events, which import
+// this package, so they are repeated here.
+var Types = []string{
+	"station.created",
+	"station.updated",
+	"station.deleted",
+	"station.restored",
+	"station.purged",
+	"station_type.created",
+	"station_type.updated",
+	"station_type.deleted",
+	"station_type.restored",
+	"station_type.purged",
+	"reading.anomaly",
+	"frost.started",
+	"frost.ended",
+	"heat.started",
+	"heat.ended",
+	"alert.firing",
+	"alert.resolved",
+	"command.failed",
+	"water.leak",
+	"reservoir.dry",
+}
+
+// Event is something noteworthy the base station noticed about the garden,
+// such as a leaking valve. Data holds details that depend on the Type.
+type Event struct {
+	Id          string         `db:"id"           json:"id"`
+	Type        string         `db:"type"         json:"type"`
+	Severity    string         `db:"severity"     json:"severity"`
+	StationId   *string        `db:"station_id"   json:"station_id,omitempty"`
+	Message     string         `db:"message"      json:"message"`
+	Data        types.JSONText `db:"data"         json:"data,omitempty"`
+	DateCreated time.Time      `db:"date_created" json:"date_created"`
+}
+
+// NewEvent is what is required to record an Event. Data is stored as JSON.
+// When Id is set it is used as the ID of the Event and recording an Event
+// with the same Id again is a no-op, so Events relayed more than once are
+// only stored once.
+type NewEvent struct {
+	Id        string
+	Type      string
+	Severity  string
+	StationId *string
+	Message   string
+	Data      interface{}
+}
+
+// Filter limits the Events returned by List. The zero value returns the most
+// recent Events of every type. Severity returns Events of that severity or
+// more severe.
+type Filter struct {
+	Type      string
+	Severity  string
+	StationId string
+	Since     *time.Time
+	Limit     int
+}
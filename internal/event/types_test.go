@@ -0,0 +1,53 @@
+package event_test
+
+import (
+	// Core packages
+	"testing"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/alert"
+	"github.com/deezone/HydroBytes-BaseStation/internal/anomaly"
+	"github.com/deezone/HydroBytes-BaseStation/internal/command"
+	"github.com/deezone/HydroBytes-BaseStation/internal/event"
+	"github.com/deezone/HydroBytes-BaseStation/internal/protection"
+	"github.com/deezone/HydroBytes-BaseStation/internal/reservoir"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
+	"github.com/deezone/HydroBytes-BaseStation/internal/water"
+)
+
+// TestTypes checks the Event types defined by the packages raising Events are
+// all listed in event.Types.
+func TestTypes(t *testing.T) {
+	types := []string{
+		station_type.EventStationCreated,
+		station_type.EventStationUpdated,
+		station_type.EventStationDeleted,
+		station_type.EventStationRestored,
+		station_type.EventStationPurged,
+		station_type.EventStationTypeCreated,
+		station_type.EventStationTypeUpdated,
+		station_type.EventStationTypeDeleted,
+		station_type.EventStationTypeRestored,
+		station_type.EventStationTypePurged,
+		anomaly.EventAnomaly,
+		protection.EventFrostStarted,
+		protection.EventFrostEnded,
+		protection.EventHeatStarted,
+		protection.EventHeatEnded,
+		alert.EventFiring,
+		alert.EventResolved,
+		command.EventFailed,
+		water.EventLeak,
+		reservoir.EventDry,
+	}
+
+	for _, typ := range types {
+		if !event.ValidTypes([]string{typ}) {
+			t.Errorf("expected %q to be a valid event type", typ)
+		}
+	}
+
+	if event.ValidTypes([]string{alert.EventFiring, "alert.exploded"}) {
+		t.Error("expected an unknown event type to be invalid")
+	}
+}
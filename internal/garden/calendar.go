@@ -0,0 +1,113 @@
+package garden
+
+import (
+	// Core packages
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Categories of calendar Entry.
+const (
+	CategoryIrrigation = "Irrigation"
+	CategoryRefill     = "Refill"
+	CategoryPlanting   = "Planting"
+)
+
+// Entry is an event of the garden calendar. All day entries only use the date
+// of Start and last one day.
+type Entry struct {
+	Uid         string
+	Category    string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+}
+
+// Calendar is the data needed to export the garden calendar: the upcoming
+// irrigation runs, planned refills and planting milestones.
+type Calendar struct {
+	Name    string
+	Entries []Entry
+}
+
+// icsEscaper escapes the characters with a meaning in iCalendar text values.
+var icsEscaper = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// ICS renders the Calendar as an iCalendar (RFC 5545) document that calendar
+// apps can subscribe to. now is used as the time stamp of every event.
+func (c Calendar) ICS(now time.Time) []byte {
+	var buf bytes.Buffer
+
+	line := func(s string) {
+		buf.WriteString(fold(s))
+		buf.WriteString("\r\n")
+	}
+	stamp := func(t time.Time) string {
+		return t.UTC().Format("20060102T150405Z")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//HydroBytes//Base Station//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + icsEscaper.Replace(c.Name))
+
+	for _, e := range c.Entries {
+		line("BEGIN:VEVENT")
+		line("UID:" + e.Uid)
+		line("DTSTAMP:" + stamp(now))
+		if e.AllDay {
+			line("DTSTART;VALUE=DATE:" + e.Start.Format("20060102"))
+			line("DTEND;VALUE=DATE:" + e.Start.AddDate(0, 0, 1).Format("20060102"))
+		} else {
+			line("DTSTART:" + stamp(e.Start))
+			line("DTEND:" + stamp(e.End))
+		}
+		line("SUMMARY:" + icsEscaper.Replace(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION:" + icsEscaper.Replace(e.Description))
+		}
+		line("CATEGORIES:" + icsEscaper.Replace(e.Category))
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+
+	return buf.Bytes()
+}
+
+// fold splits a content line into lines of at most 75 octets as RFC 5545
+// asks, continuing each with a space. Lines are not split inside a UTF-8
+// character.
+func fold(s string) string {
+	const limit = 75
+
+	if len(s) <= limit {
+		return s
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		size := len(string(r))
+		if n+size > limit {
+			b.WriteString("\r\n ")
+			n = 1
+		}
+		b.WriteRune(r)
+		n += size
+	}
+
+	return b.String()
+}
+
+// uid gives the unique ID of an Entry of a kind of record.
+func uid(kind, id string) string {
+	return fmt.Sprintf("%s-%s@hydrobytes", kind, id)
+}
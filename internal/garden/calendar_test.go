@@ -0,0 +1,70 @@
+package garden_test
+
+import (
+	// Core packages
+	"strings"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/garden"
+)
+
+func TestCalendarICS(t *testing.T) {
+	start := time.Date(2021, time.June, 2, 6, 30, 0, 0, time.UTC)
+	now := time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	c := garden.Calendar{
+		Name: "Garden",
+		Entries: []garden.Entry{
+			{
+				Uid:         "run-1@hydrobytes",
+				Category:    garden.CategoryIrrigation,
+				Summary:     "Watering at Water Station one, valve zone-2",
+				Description: "300 second watering run; tomatoes\nand basil",
+				Start:       start,
+				End:         start.Add(5 * time.Minute),
+			},
+			{
+				Uid:      "harvest-1@hydrobytes",
+				Category: garden.CategoryPlanting,
+				Summary:  "Tomato at " + strings.Repeat("Plant Station ", 8) + "ready to harvest",
+				Start:    start,
+				AllDay:   true,
+			},
+		},
+	}
+
+	ics := string(c.ICS(now))
+
+	if !strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(ics, "END:VCALENDAR\r\n") {
+		t.Fatalf("expected a VCALENDAR with CRLF line endings, got:\n%s", ics)
+	}
+
+	for _, exp := range []string{
+		"DTSTAMP:20210601T120000Z\r\n",
+		"DTSTART:20210602T063000Z\r\n",
+		"DTEND:20210602T063500Z\r\n",
+		`DESCRIPTION:300 second watering run\; tomatoes\nand basil` + "\r\n",
+		"SUMMARY:Watering at Water Station one\\, valve zone-2\r\n",
+		"DTSTART;VALUE=DATE:20210602\r\n",
+		"DTEND;VALUE=DATE:20210603\r\n",
+	} {
+		if !strings.Contains(ics, exp) {
+			t.Errorf("expected %q in:\n%s", exp, ics)
+		}
+	}
+
+	// Long lines are folded at 75 octets.
+	for _, line := range strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+	if !strings.Contains(ics, "\r\n ") {
+		t.Errorf("expected the long summary to be folded, got:\n%s", ics)
+	}
+	if exp, got := 2, strings.Count(ics, "BEGIN:VEVENT"); exp != got {
+		t.Errorf("expected %v events, got %v", exp, got)
+	}
+}
@@ -0,0 +1,296 @@
+package garden
+
+import (
+	// Core packages
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/auth"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Predefined errors identify expected failure conditions.
+var (
+	// ErrInvalidID is used when an invalid UUID is provided.
+	ErrInvalidID = errors.New("ID is not in its proper UUID format")
+
+	// ErrAccountNotFound is used when a feed token is created for an account
+	// that does not exist.
+	ErrAccountNotFound = errors.New("account not found")
+
+	// ErrTokenNotFound is used when revoking the feed token of an account
+	// that has none.
+	ErrTokenNotFound = errors.New("feed token not found")
+
+	// ErrInvalidToken is used when a feed is requested with a token that does
+	// not belong to any account.
+	ErrInvalidToken = errors.New("feed token is not valid")
+)
+
+const (
+	// FeedSince is how long ago the entries of the calendar feed start.
+	FeedSince = 7 * 24 * time.Hour
+
+	// FeedUntil is how far ahead the entries of the calendar feed go.
+	FeedUntil = 90 * 24 * time.Hour
+)
+
+// FeedToken authorizes reading the calendar feed of an Account. Calendar apps
+// can not send an Authorization header so the token is given in the URL of
+// the feed. Only a hash of the token is stored and the token itself is only
+// returned when it is created.
+type FeedToken struct {
+	AccountId   string    `json:"account_id"`
+	Token       string    `json:"token"`
+	DateCreated time.Time `json:"date_created"`
+}
+
+// hashToken gives the hash of a feed token that is stored.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateFeedToken gives the Account identified by accountID a new feed token,
+// replacing any token it had.
+func CreateFeedToken(ctx context.Context, db *sqlx.DB, accountID string, now time.Time) (*FeedToken, error) {
+
+	ctx, span := trace.StartSpan(ctx, "garden.CreateFeedToken")
+	defer span.End()
+
+	if _, err := uuid.Parse(accountID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var exists bool
+	const check = `SELECT EXISTS (SELECT 1 FROM account WHERE id = $1 AND date_deleted IS NULL)`
+	if err := db.GetContext(ctx, &exists, check, accountID); err != nil {
+		return nil, errors.Wrap(err, "checking account")
+	}
+	if !exists {
+		return nil, ErrAccountNotFound
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, errors.Wrap(err, "generating feed token")
+	}
+
+	t := FeedToken{
+		AccountId:   accountID,
+		Token:       hex.EncodeToString(b),
+		DateCreated: now.UTC(),
+	}
+
+	const q = `
+		INSERT INTO calendar_feed_token (account_id, token_hash, date_created)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (account_id) DO UPDATE SET
+			token_hash = EXCLUDED.token_hash,
+			date_created = EXCLUDED.date_created`
+
+	if _, err := db.ExecContext(ctx, q, t.AccountId, hashToken(t.Token), t.DateCreated); err != nil {
+		return nil, errors.Wrapf(err, "saving feed token of account %s", accountID)
+	}
+
+	return &t, nil
+}
+
+// RevokeFeedToken removes the feed token of the Account identified by
+// accountID so its feed can no longer be read.
+func RevokeFeedToken(ctx context.Context, db *sqlx.DB, accountID string) error {
+
+	ctx, span := trace.StartSpan(ctx, "garden.RevokeFeedToken")
+	defer span.End()
+
+	if _, err := uuid.Parse(accountID); err != nil {
+		return ErrInvalidID
+	}
+
+	const q = `DELETE FROM calendar_feed_token WHERE account_id = $1`
+
+	res, err := db.ExecContext(ctx, q, accountID)
+	if err != nil {
+		return errors.Wrapf(err, "revoking feed token of account %s", accountID)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return errors.Wrapf(err, "revoking feed token of account %s", accountID)
+	} else if n == 0 {
+		return ErrTokenNotFound
+	}
+
+	return nil
+}
+
+// FeedAccount finds the Account a feed token belongs to. Admins see the
+// entries of every Station in their feed.
+func FeedAccount(ctx context.Context, db *sqlx.DB, token string) (accountID string, admin bool, err error) {
+
+	ctx, span := trace.StartSpan(ctx, "garden.FeedAccount")
+	defer span.End()
+
+	var a struct {
+		Id    string         `db:"id"`
+		Roles pq.StringArray `db:"roles"`
+	}
+
+	const q = `
+		SELECT account.id, account.roles
+		FROM calendar_feed_token
+		JOIN account ON account.id = calendar_feed_token.account_id
+		WHERE calendar_feed_token.token_hash = $1 AND account.date_deleted IS NULL`
+
+	if err := db.GetContext(ctx, &a, q, hashToken(token)); err != nil {
+		if err == sql.ErrNoRows {
+			return "", false, ErrInvalidToken
+		}
+
+		return "", false, errors.Wrap(err, "selecting feed token")
+	}
+
+	for _, r := range a.Roles {
+		if r == auth.RoleAdmin {
+			admin = true
+		}
+	}
+
+	return a.Id, admin, nil
+}
+
+// LoadCalendar gets the Calendar of the Stations of the Account identified by
+// accountID, or of every Station when all is set. It holds the queued and
+// running irrigation runs, planned refills and the planting and expected
+// harvest dates of current plantings between FeedSince before and FeedUntil
+// after now, in order.
+func LoadCalendar(ctx context.Context, db *sqlx.DB, accountID string, all bool, now time.Time) (*Calendar, error) {
+
+	ctx, span := trace.StartSpan(ctx, "garden.LoadCalendar")
+	defer span.End()
+
+	from, to := now.Add(-FeedSince).UTC(), now.Add(FeedUntil).UTC()
+
+	c := Calendar{Name: "HydroBytes garden", Entries: []Entry{}}
+
+	// Only Stations of the Account are included unless all is set.
+	const visible = `station.date_deleted IS NULL AND ($3::BOOLEAN OR station.account_id = $4::UUID)`
+
+	var runs []struct {
+		Id            string    `db:"id"`
+		Station       string    `db:"station"`
+		Valve         string    `db:"valve"`
+		Duration      int       `db:"duration"`
+		DateScheduled time.Time `db:"date_scheduled"`
+	}
+	const qr = `
+		SELECT command.id, station.name AS station, command.valve, command.duration, command.date_scheduled
+		FROM command
+		JOIN station ON station.id = command.station_id
+		WHERE command.kind = 'water' AND command.status IN ('queued', 'running')
+			AND command.date_scheduled BETWEEN $1 AND $2 AND ` + visible
+
+	if err := db.SelectContext(ctx, &runs, qr, from, to, all, accountID); err != nil {
+		return nil, errors.Wrap(err, "selecting irrigation runs")
+	}
+	for _, r := range runs {
+		summary := fmt.Sprintf("Watering at %s", r.Station)
+		if r.Valve != "" {
+			summary = fmt.Sprintf("Watering at %s, valve %s", r.Station, r.Valve)
+		}
+		c.Entries = append(c.Entries, Entry{
+			Uid:         uid("run", r.Id),
+			Category:    CategoryIrrigation,
+			Summary:     summary,
+			Description: fmt.Sprintf("%d second watering run", r.Duration),
+			Start:       r.DateScheduled,
+			End:         r.DateScheduled.Add(time.Duration(r.Duration) * time.Second),
+		})
+	}
+
+	var refills []struct {
+		Id          string    `db:"id"`
+		Station     string    `db:"station"`
+		Note        string    `db:"note"`
+		DatePlanned time.Time `db:"date_planned"`
+	}
+	const qf = `
+		SELECT reservoir_refill.id, station.name AS station, reservoir_refill.note, reservoir_refill.date_planned
+		FROM reservoir_refill
+		JOIN station ON station.id = reservoir_refill.station_id
+		WHERE reservoir_refill.date_planned BETWEEN $1 AND $2 AND ` + visible
+
+	if err := db.SelectContext(ctx, &refills, qf, from, to, all, accountID); err != nil {
+		return nil, errors.Wrap(err, "selecting planned refills")
+	}
+	for _, r := range refills {
+		c.Entries = append(c.Entries, Entry{
+			Uid:         uid("refill", r.Id),
+			Category:    CategoryRefill,
+			Summary:     fmt.Sprintf("Refill reservoir of %s", r.Station),
+			Description: r.Note,
+			Start:       r.DatePlanned,
+			End:         r.DatePlanned.Add(30 * time.Minute),
+		})
+	}
+
+	var plantings []struct {
+		Id             string    `db:"id"`
+		Station        string    `db:"station"`
+		Plant          string    `db:"plant"`
+		DaysToMaturity int       `db:"days_to_maturity"`
+		DatePlanted    time.Time `db:"date_planted"`
+	}
+	const qp = `
+		SELECT planting.id, station.name AS station, plant.common_name AS plant, plant.days_to_maturity, planting.date_planted
+		FROM planting
+		JOIN plant ON plant.id = planting.plant_id
+		JOIN station ON station.id = planting.station_id
+		WHERE planting.date_removed IS NULL
+			AND planting.date_planted <= $2
+			AND planting.date_planted + make_interval(days => plant.days_to_maturity) >= $1
+			AND ` + visible
+
+	if err := db.SelectContext(ctx, &plantings, qp, from, to, all, accountID); err != nil {
+		return nil, errors.Wrap(err, "selecting plantings")
+	}
+	for _, p := range plantings {
+		if !p.DatePlanted.Before(from) {
+			c.Entries = append(c.Entries, Entry{
+				Uid:      uid("planted", p.Id),
+				Category: CategoryPlanting,
+				Summary:  fmt.Sprintf("%s planted at %s", p.Plant, p.Station),
+				Start:    p.DatePlanted,
+				AllDay:   true,
+			})
+		}
+		harvest := p.DatePlanted.AddDate(0, 0, p.DaysToMaturity)
+		if p.DaysToMaturity > 0 && !harvest.Before(from) && !harvest.After(to) {
+			c.Entries = append(c.Entries, Entry{
+				Uid:         uid("harvest", p.Id),
+				Category:    CategoryPlanting,
+				Summary:     fmt.Sprintf("%s at %s ready to harvest", p.Plant, p.Station),
+				Description: fmt.Sprintf("%d days after planting on %s", p.DaysToMaturity, p.DatePlanted.Format("2 Jan 2006")),
+				Start:       harvest,
+				AllDay:      true,
+			})
+		}
+	}
+
+	sort.SliceStable(c.Entries, func(i, j int) bool {
+		return c.Entries[i].Start.Before(c.Entries[j].Start)
+	})
+
+	return &c, nil
+}
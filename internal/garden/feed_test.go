@@ -0,0 +1,91 @@
+package garden_test
+
+import (
+	// Core packages
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/command"
+	"github.com/deezone/HydroBytes-BaseStation/internal/garden"
+	"github.com/deezone/HydroBytes-BaseStation/internal/schema"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+)
+
+func TestFeed(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, _, err := garden.FeedAccount(ctx, db, "not a token"); err != garden.ErrInvalidToken {
+		t.Fatalf("expected %v, got %v", garden.ErrInvalidToken, err)
+	}
+
+	old, err := garden.CreateFeedToken(ctx, db, tests.AccountOneId, now)
+	if err != nil {
+		t.Fatalf("creating feed token: %s", err)
+	}
+	token, err := garden.CreateFeedToken(ctx, db, tests.AccountOneId, now)
+	if err != nil {
+		t.Fatalf("creating feed token: %s", err)
+	}
+
+	// A new token replaces the old one.
+	if _, _, err := garden.FeedAccount(ctx, db, old.Token); err != garden.ErrInvalidToken {
+		t.Fatalf("expected the old token to be replaced, got %v", err)
+	}
+	id, admin, err := garden.FeedAccount(ctx, db, token.Token)
+	if err != nil {
+		t.Fatalf("finding feed account: %s", err)
+	}
+	if id != tests.AccountOneId || admin {
+		t.Fatalf("expected account one without admin role, got %s %v", id, admin)
+	}
+
+	// The watering run of the Water station of the admin is only in the feed
+	// of admins.
+	nc := command.NewCommand{Kind: command.KindWater, Valve: "zone-2", Duration: 300}
+	if _, err := command.Create(ctx, db, "ee72a90c-590c-11eb-ae93-0242ac130002", nc, nil, now); err != nil {
+		t.Fatalf("queueing watering run: %s", err)
+	}
+
+	c, err := garden.LoadCalendar(ctx, db, tests.AccountOneId, false, now)
+	if err != nil {
+		t.Fatalf("loading calendar: %s", err)
+	}
+	if exp, got := 1, len(c.Entries); exp != got {
+		t.Fatalf("expected %v entry, got %v: %+v", exp, got, c.Entries)
+	}
+	if e := c.Entries[0]; e.Category != garden.CategoryPlanting || !strings.Contains(e.Summary, "Plant Station 0001") {
+		t.Fatalf("expected the harvest of the tomatoes of plant station one, got %+v", e)
+	}
+
+	c, err = garden.LoadCalendar(ctx, db, tests.AdminId, true, now)
+	if err != nil {
+		t.Fatalf("loading calendar: %s", err)
+	}
+	if exp, got := 4, len(c.Entries); exp != got {
+		t.Fatalf("expected %v entries, got %v: %+v", exp, got, c.Entries)
+	}
+	if exp, got := garden.CategoryIrrigation, c.Entries[0].Category; exp != got {
+		t.Fatalf("expected the watering run first, got %s", got)
+	}
+
+	if err := garden.RevokeFeedToken(ctx, db, tests.AccountOneId); err != nil {
+		t.Fatalf("revoking feed token: %s", err)
+	}
+	if _, _, err := garden.FeedAccount(ctx, db, token.Token); err != garden.ErrInvalidToken {
+		t.Fatalf("expected the token to be revoked, got %v", err)
+	}
+	if err := garden.RevokeFeedToken(ctx, db, tests.AccountOneId); err != garden.ErrTokenNotFound {
+		t.Fatalf("expected %v, got %v", garden.ErrTokenNotFound, err)
+	}
+}
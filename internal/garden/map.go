@@ -0,0 +1,193 @@
+package garden
+
+import (
+	// Core packages
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"text/template"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
+	"github.com/deezone/HydroBytes-BaseStation/internal/zone"
+
+	// Third-party packages
+	"github.com/pkg/errors"
+)
+
+// cell is the size in pixels of one unit of the station location grid.
+const cell = 40
+
+// typeColors are the colors of the kinds of station type. Station types of
+// any other kind are given a color from palette by their name.
+var typeColors = map[string]string{
+	station_type.KindBase:  "#6a4c93",
+	station_type.KindWater: "#1982c4",
+	station_type.KindPlant: "#8ac926",
+}
+
+var palette = []string{"#ff595e", "#ffca3a", "#f77f00", "#3a86ff", "#8338ec"}
+
+// Fill colors of stations by whether they are online, offline or never
+// reported.
+const (
+	colorOnline  = "#2a9d8f"
+	colorOffline = "#e63946"
+	colorUnknown = "#adb5bd"
+)
+
+// Map is the data needed to draw the garden: the zones and the stations placed
+// on the shared location grid. Status is keyed by Station id and decides
+// whether a Station is drawn as online at Now.
+type Map struct {
+	Zones        []zone.Zone
+	Stations     []station_type.Station
+	StationTypes []station_type.StationType
+	Status       map[string]StationStatus
+	Now          time.Time
+}
+
+// svgZone and svgStation are a Zone and Station translated to pixels.
+type svgZone struct {
+	X, Y, Width, Height int
+	Name                string
+	SoilType            string
+}
+
+type svgStation struct {
+	X, Y     int
+	Name     string
+	Type     string
+	Color    string
+	Fill     string
+	Status   string
+	Readings []string
+}
+
+// SVG renders the Map as a standalone SVG document. Everything needed to draw
+// the map is inline so it can be displayed without network access. Stations
+// are filled by whether they are online and outlined in the color of their
+// type. Hovering a zone or station shows its details, including the latest
+// reading of each sensor, through the SVG title element.
+//
+// The location grid has y increasing up the page so rows are flipped when
+// converted to pixels.
+func (m Map) SVG() ([]byte, error) {
+
+	// Find the extent of the grid that needs to be drawn.
+	maxX, maxY := 1, 1
+	for _, z := range m.Zones {
+		maxX = max(maxX, z.LocationX+z.Width)
+		maxY = max(maxY, z.LocationY+z.Height)
+	}
+	for _, s := range m.Stations {
+		maxX = max(maxX, s.LocationX+1)
+		maxY = max(maxY, s.LocationY+1)
+	}
+
+	types := make(map[string]station_type.StationType, len(m.StationTypes))
+	for _, st := range m.StationTypes {
+		types[st.Id] = st
+	}
+
+	data := struct {
+		Width, Height int
+		Radius        int
+		Zones         []svgZone
+		Stations      []svgStation
+	}{
+		Width:  (maxX + 1) * cell,
+		Height: (maxY + 1) * cell,
+		Radius: cell / 3,
+	}
+
+	for _, z := range m.Zones {
+		data.Zones = append(data.Zones, svgZone{
+			X:        z.LocationX * cell,
+			Y:        (maxY - z.LocationY - z.Height + 1) * cell,
+			Width:    z.Width * cell,
+			Height:   z.Height * cell,
+			Name:     z.Name,
+			SoilType: z.SoilType,
+		})
+	}
+
+	for _, s := range m.Stations {
+		t := types[s.StationTypeId]
+		st := m.Status[s.Id]
+		ss := svgStation{
+			X:      s.LocationX*cell + cell/2,
+			Y:      (maxY-s.LocationY)*cell + cell/2,
+			Name:   s.Name,
+			Type:   t.Name,
+			Color:  typeColor(t),
+			Fill:   colorUnknown,
+			Status: "never reported",
+		}
+		if st.LastSeen != nil {
+			ss.Fill, ss.Status = colorOffline, "offline"
+			if st.Online(m.Now) {
+				ss.Fill, ss.Status = colorOnline, "online"
+			}
+			ss.Status += ", last seen " + st.LastSeen.UTC().Format(time.RFC3339)
+		}
+		for _, r := range st.Readings {
+			ss.Readings = append(ss.Readings, fmt.Sprintf("%s: %s at %s",
+				r.Sensor, strconv.FormatFloat(r.Value, 'f', -1, 64), r.DateRead.UTC().Format(time.RFC3339)))
+		}
+		data.Stations = append(data.Stations, ss)
+	}
+
+	var buf bytes.Buffer
+	if err := mapTemplate.Execute(&buf, data); err != nil {
+		return nil, errors.Wrap(err, "rendering garden map")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// typeColor gives the color of a station type.
+func typeColor(st station_type.StationType) string {
+	if c, ok := typeColors[st.Kind]; ok {
+		return c
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(st.Name))
+	return palette[h.Sum32()%uint32(len(palette))]
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// mapTemplate is the SVG document. The html function escapes names so they
+// are safe to use as XML text and attribute values.
+var mapTemplate = template.Must(template.New("map").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" font-family="sans-serif" font-size="11">
+  <rect width="100%" height="100%" fill="#fdfcf7"/>
+  <g class="zones">
+{{- range .Zones}}
+    <g class="zone">
+      <title>{{html .Name}}{{if .SoilType}} ({{html .SoilType}}){{end}}</title>
+      <rect x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}" fill="#c9a66b" fill-opacity="0.35" stroke="#8d6e3f" stroke-width="2" rx="4"/>
+      <text x="{{.X}}" y="{{.Y}}" dx="4" dy="13" fill="#5c4424">{{html .Name}}</text>
+    </g>
+{{- end}}
+  </g>
+  <g class="stations">
+{{- range .Stations}}
+    <g class="station">
+      <title>{{html .Name}}{{if .Type}} - {{html .Type}}{{end}} ({{.Status}}){{range .Readings}}
+{{html .}}{{end}}</title>
+      <circle cx="{{.X}}" cy="{{.Y}}" r="{{$.Radius}}" fill="{{.Fill}}" stroke="{{.Color}}" stroke-width="3"/>
+    </g>
+{{- end}}
+  </g>
+</svg>
+`))
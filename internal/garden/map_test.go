@@ -0,0 +1,77 @@
+package garden_test
+
+import (
+	// Core packages
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/garden"
+	"github.com/deezone/HydroBytes-BaseStation/internal/reading"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
+	"github.com/deezone/HydroBytes-BaseStation/internal/zone"
+)
+
+func TestMapSVG(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	seen := now.Add(-10 * time.Minute)
+	stale := now.Add(-2 * time.Hour)
+
+	m := garden.Map{
+		Zones: []zone.Zone{
+			{Id: "z1", Name: "Herbs & <Greens>", SoilType: "Loam", LocationX: 0, LocationY: 0, Width: 2, Height: 2},
+		},
+		Stations: []station_type.Station{
+			{Id: "s1", StationTypeId: "t1", Name: "Plant Station 0001", LocationX: 1, LocationY: 1},
+			{Id: "s2", StationTypeId: "t2", Name: "Water Station one", LocationX: 3, LocationY: 0},
+			{Id: "s3", StationTypeId: "t1", Name: "Plant Station 0002", LocationX: 4, LocationY: 0},
+		},
+		StationTypes: []station_type.StationType{
+			{Id: "t1", Name: "Plant", Kind: station_type.KindPlant},
+			{Id: "t2", Name: "Water", Kind: station_type.KindWater},
+		},
+		Status: map[string]garden.StationStatus{
+			"s1": {LastSeen: &seen, Readings: []reading.Reading{
+				{StationId: "s1", Sensor: reading.SensorMoisture, Value: 31.5, DateRead: seen},
+			}},
+			"s2": {LastSeen: &stale},
+		},
+		Now: now,
+	}
+
+	svg, err := m.SVG()
+	if err != nil {
+		t.Fatalf("rendering map: %s", err)
+	}
+
+	// The document must be well formed XML even with markup in names.
+	d := xml.NewDecoder(bytes.NewReader(svg))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("map is not well formed XML: %s\n%s", err, svg)
+		}
+	}
+
+	for _, want := range []string{
+		"Herbs &amp; &lt;Greens&gt;",
+		"<title>Plant Station 0001 - Plant (online, last seen 2021-06-01T11:50:00Z)\nmoisture: 31.5 at 2021-06-01T11:50:00Z</title>",
+		"<title>Water Station one - Water (offline, last seen 2021-06-01T10:00:00Z)</title>",
+		"<title>Plant Station 0002 - Plant (never reported)</title>",
+		`fill="#2a9d8f" stroke="#8ac926"`,
+		`fill="#e63946" stroke="#1982c4"`,
+		`fill="#adb5bd" stroke="#8ac926"`,
+		`width="240" height="120"`,
+	} {
+		if !strings.Contains(string(svg), want) {
+			t.Errorf("expected map to contain %q:\n%s", want, svg)
+		}
+	}
+}
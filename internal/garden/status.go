@@ -0,0 +1,86 @@
+package garden
+
+import (
+	// Core packages
+	"context"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/reading"
+
+	// Third-party packages
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// OfflineAfter is how long a Station can go without a heartbeat or reading
+// before the map shows it as offline. It matches the seeded offline alert rule.
+const OfflineAfter = time.Hour
+
+// StationStatus is when a Station was last heard from and the latest reading
+// of each of its sensors. LastSeen is nil if the Station never reported.
+type StationStatus struct {
+	LastSeen *time.Time
+	Readings []reading.Reading
+}
+
+// Online reports whether the Station was heard from within OfflineAfter of
+// now.
+func (s StationStatus) Online(now time.Time) bool {
+	return s.LastSeen != nil && now.Sub(*s.LastSeen) < OfflineAfter
+}
+
+// LoadStatus gives the StationStatus of every active Station keyed by Station
+// id.
+func LoadStatus(ctx context.Context, db *sqlx.DB) (map[string]StationStatus, error) {
+
+	ctx, span := trace.StartSpan(ctx, "garden.LoadStatus")
+	defer span.End()
+
+	var seen []struct {
+		StationId string     `db:"station_id"`
+		LastSeen  *time.Time `db:"last_seen"`
+	}
+
+	const qs = `
+		SELECT
+			station.id AS station_id,
+			GREATEST(
+				(SELECT MAX(date_received) FROM heartbeat WHERE heartbeat.station_id = station.id),
+				(SELECT MAX(date_received) FROM reading WHERE reading.station_id = station.id)
+			) AS last_seen
+		FROM station
+		WHERE station.date_deleted IS NULL`
+
+	if err := db.SelectContext(ctx, &seen, qs); err != nil {
+		return nil, errors.Wrap(err, "selecting station last seen")
+	}
+
+	var latest []reading.Reading
+
+	const qr = `
+		SELECT DISTINCT ON (reading.station_id, reading.sensor)
+			reading.id, reading.station_id, reading.sensor, reading.value,
+			reading.date_read, reading.date_received
+		FROM reading
+		JOIN station ON station.id = reading.station_id
+		WHERE station.date_deleted IS NULL
+		ORDER BY reading.station_id, reading.sensor, reading.date_read DESC`
+
+	if err := db.SelectContext(ctx, &latest, qr); err != nil {
+		return nil, errors.Wrap(err, "selecting latest readings")
+	}
+
+	status := make(map[string]StationStatus, len(seen))
+	for _, s := range seen {
+		status[s.StationId] = StationStatus{LastSeen: s.LastSeen}
+	}
+	for _, r := range latest {
+		s := status[r.StationId]
+		s.Readings = append(s.Readings, r)
+		status[r.StationId] = s
+	}
+
+	return status, nil
+}
@@ -0,0 +1,228 @@
+package group
+
+import (
+	// Core packages
+	"context"
+	"database/sql"
+	"time"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Predefined errors identify expected failure conditions.
+var (
+	// ErrNotFound is used when a specific Group is requested but does not exist.
+	ErrNotFound = errors.New("group not found")
+
+	// ErrStationNotFound is used when a station added to a Group does not exist.
+	ErrStationNotFound = errors.New("station not found")
+
+	// ErrInvalidID is used when an invalid UUID is provided.
+	ErrInvalidID = errors.New("ID is not in its proper UUID format")
+)
+
+// Create adds a Group to the database. It returns the created Group with fields
+// like ID and DateCreated populated.
+func Create(ctx context.Context, db *sqlx.DB, ng NewGroup, now time.Time) (*Group, error) {
+
+	ctx, span := trace.StartSpan(ctx, "group.Create")
+	defer span.End()
+
+	g := Group{
+		Id:          uuid.New().String(),
+		Name:        ng.Name,
+		Description: ng.Description,
+		DateCreated: now.UTC(),
+		DateUpdated: now.UTC(),
+	}
+
+	const q = `
+		INSERT INTO station_group
+		  (id, name, description, date_created, date_updated)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	if _, err := db.ExecContext(ctx, q, g.Id, g.Name, g.Description, g.DateCreated, g.DateUpdated); err != nil {
+		return nil, errors.Wrap(err, "inserting group")
+	}
+
+	return &g, nil
+}
+
+// Delete removes the Group identified by a given ID. The stations in the group
+// are not changed.
+func Delete(ctx context.Context, db *sqlx.DB, id string) error {
+
+	ctx, span := trace.StartSpan(ctx, "group.Delete")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrInvalidID
+	}
+
+	const q = `DELETE FROM station_group WHERE id = $1`
+
+	if _, err := db.ExecContext(ctx, q, id); err != nil {
+		return errors.Wrapf(err, "deleting group %s", id)
+	}
+
+	return nil
+}
+
+// List gets all Groups from the database.
+func List(ctx context.Context, db *sqlx.DB) ([]Group, error) {
+
+	ctx, span := trace.StartSpan(ctx, "group.List")
+	defer span.End()
+
+	groups := []Group{}
+
+	const q = `
+		SELECT
+			station_group.id,
+			station_group.name,
+			station_group.description,
+			COUNT(station.id) AS stations,
+			station_group.date_created,
+			station_group.date_updated
+		FROM station_group
+		  LEFT JOIN station_group_member ON station_group.id = station_group_member.group_id
+		  LEFT JOIN station ON station.id = station_group_member.station_id AND station.date_deleted IS NULL
+		GROUP BY station_group.id
+		ORDER BY station_group.name`
+
+	if err := db.SelectContext(ctx, &groups, q); err != nil {
+		return nil, errors.Wrap(err, "selecting groups")
+	}
+
+	return groups, nil
+}
+
+// Get finds the Group identified by a given ID.
+func Get(ctx context.Context, db *sqlx.DB, id string) (*Group, error) {
+
+	ctx, span := trace.StartSpan(ctx, "group.Get")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var g Group
+
+	const q = `
+		SELECT
+			station_group.id,
+			station_group.name,
+			station_group.description,
+			COUNT(station.id) AS stations,
+			station_group.date_created,
+			station_group.date_updated
+		FROM station_group
+		  LEFT JOIN station_group_member ON station_group.id = station_group_member.group_id
+		  LEFT JOIN station ON station.id = station_group_member.station_id AND station.date_deleted IS NULL
+		WHERE station_group.id = $1
+		GROUP BY station_group.id`
+
+	if err := db.GetContext(ctx, &g, q, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+
+		return nil, errors.Wrap(err, "selecting single group")
+	}
+
+	return &g, nil
+}
+
+// Update modifies data about a Group. It will error if the specified ID is
+// invalid or does not reference an existing Group.
+func Update(ctx context.Context, db *sqlx.DB, id string, update UpdateGroup, now time.Time) error {
+
+	ctx, span := trace.StartSpan(ctx, "group.Update")
+	defer span.End()
+
+	g, err := Get(ctx, db, id)
+	if err != nil {
+		return err
+	}
+
+	if update.Name != nil {
+		g.Name = *update.Name
+	}
+	if update.Description != nil {
+		g.Description = *update.Description
+	}
+	g.DateUpdated = now
+
+	const q = `UPDATE station_group SET
+		"name" = $2,
+		"description" = $3,
+		"date_updated" = $4
+		WHERE id = $1`
+	if _, err := db.ExecContext(ctx, q, id, g.Name, g.Description, g.DateUpdated); err != nil {
+		return errors.Wrap(err, "updating group")
+	}
+
+	return nil
+}
+
+// AddStation makes a station a member of the Group. Adding a station that is
+// already a member is not an error.
+func AddStation(ctx context.Context, db *sqlx.DB, id, stationID string) error {
+
+	ctx, span := trace.StartSpan(ctx, "group.AddStation")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return ErrInvalidID
+	}
+	if _, err := Get(ctx, db, id); err != nil {
+		return err
+	}
+
+	var exists bool
+	const check = `SELECT EXISTS (SELECT 1 FROM station WHERE id = $1 AND date_deleted IS NULL)`
+	if err := db.GetContext(ctx, &exists, check, stationID); err != nil {
+		return errors.Wrap(err, "checking station")
+	}
+	if !exists {
+		return ErrStationNotFound
+	}
+
+	const q = `
+		INSERT INTO station_group_member (group_id, station_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`
+
+	if _, err := db.ExecContext(ctx, q, id, stationID); err != nil {
+		return errors.Wrapf(err, "adding station %s to group %s", stationID, id)
+	}
+
+	return nil
+}
+
+// RemoveStation takes a station out of the Group.
+func RemoveStation(ctx context.Context, db *sqlx.DB, id, stationID string) error {
+
+	ctx, span := trace.StartSpan(ctx, "group.RemoveStation")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrInvalidID
+	}
+	if _, err := uuid.Parse(stationID); err != nil {
+		return ErrInvalidID
+	}
+
+	const q = `DELETE FROM station_group_member WHERE group_id = $1 AND station_id = $2`
+
+	if _, err := db.ExecContext(ctx, q, id, stationID); err != nil {
+		return errors.Wrapf(err, "removing station %s from group %s", stationID, id)
+	}
+
+	return nil
+}
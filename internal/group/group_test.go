@@ -0,0 +1,138 @@
+package group_test
+
+import (
+	// Core packages
+	"context"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/group"
+	"github.com/deezone/HydroBytes-BaseStation/internal/schema"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+
+	// Third-party packages
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGroup(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ng := group.NewGroup{
+		Name:        "Shade loving",
+		Description: "Plants that prefer the north side of the balcony.",
+	}
+	now := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	g0, err := group.Create(ctx, db, ng, now)
+	if err != nil {
+		t.Fatalf("creating group g0: %s", err)
+	}
+
+	g1, err := group.Get(ctx, db, g0.Id)
+	if err != nil {
+		t.Fatalf("getting group g0: %s", err)
+	}
+
+	if diff := cmp.Diff(g1, g0); diff != "" {
+		t.Fatalf("fetched != created:\n%s", diff)
+	}
+
+	// Plant Station 0003 and the Water station
+	plant := "342c0d0a-6333-11eb-ae93-0242ac130002"
+	water := "ee72a90c-590c-11eb-ae93-0242ac130002"
+
+	for _, id := range []string{plant, water, plant} {
+		if err := group.AddStation(ctx, db, g0.Id, id); err != nil {
+			t.Fatalf("adding station %s to group g0: %s", id, err)
+		}
+	}
+
+	if err := group.AddStation(ctx, db, g0.Id, "9bd4f1e2-0000-4000-8000-000000000000"); err != group.ErrStationNotFound {
+		t.Fatalf("adding unknown station: expected %v, got %v", group.ErrStationNotFound, err)
+	}
+
+	saved, err := group.Get(ctx, db, g0.Id)
+	if err != nil {
+		t.Fatalf("getting group g0: %s", err)
+	}
+	if exp, got := 2, saved.Stations; exp != got {
+		t.Fatalf("expected %v stations in group, got %v", exp, got)
+	}
+
+	// Stations can be filtered by group.
+	stations, err := station_type.ListAllStations(ctx, db, station_type.StationFilter{GroupId: g0.Id})
+	if err != nil {
+		t.Fatalf("listing stations in group g0: %s", err)
+	}
+	if exp, got := 2, len(stations); exp != got {
+		t.Fatalf("expected station list size %v, got %v", exp, got)
+	}
+
+	if err := group.RemoveStation(ctx, db, g0.Id, water); err != nil {
+		t.Fatalf("removing station from group g0: %s", err)
+	}
+
+	update := group.UpdateGroup{
+		Name: tests.StringPointer("Shady"),
+	}
+	updatedTime := time.Date(2019, time.January, 1, 1, 1, 1, 0, time.UTC)
+
+	if err := group.Update(ctx, db, g0.Id, update, updatedTime); err != nil {
+		t.Fatalf("updating group g0: %s", err)
+	}
+
+	saved, err = group.Get(ctx, db, g0.Id)
+	if err != nil {
+		t.Fatalf("getting group g0: %s", err)
+	}
+
+	want := *g0
+	want.Name = "Shady"
+	want.Stations = 1
+	want.DateUpdated = updatedTime
+
+	if diff := cmp.Diff(want, *saved); diff != "" {
+		t.Fatalf("updated record did not match:\n%s", diff)
+	}
+
+	if err := group.Delete(ctx, db, g0.Id); err != nil {
+		t.Fatalf("deleting group g0: %s", err)
+	}
+
+	if _, err := group.Get(ctx, db, g0.Id); err != group.ErrNotFound {
+		t.Fatalf("getting deleted group g0: expected %v, got %v", group.ErrNotFound, err)
+	}
+
+	// Deleting the group leaves its stations alone.
+	if _, err := station_type.GetStation(ctx, db, plant); err != nil {
+		t.Fatalf("getting station of deleted group: %s", err)
+	}
+}
+
+func TestGroupList(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := group.List(context.Background(), db)
+	if err != nil {
+		t.Fatalf("listing groups: %s", err)
+	}
+	if exp, got := 1, len(groups); exp != got {
+		t.Fatalf("expected group list size %v, got %v", exp, got)
+	}
+	if exp, got := 2, groups[0].Stations; exp != got {
+		t.Fatalf("expected %v stations in %q, got %v", exp, groups[0].Name, got)
+	}
+}
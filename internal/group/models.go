@@ -0,0 +1,32 @@
+package group
+
+import (
+	// Core packages
+	"time"
+)
+
+// Group is a named collection of stations such as "seedlings" or
+// "shade-loving". Unlike a zone a group is not tied to a location and a station
+// can belong to any number of groups.
+type Group struct {
+	Id          string    `db:"id"           json:"id"`
+	Name        string    `db:"name"         json:"name"`
+	Description string    `db:"description"  json:"description"`
+	Stations    int       `db:"stations"     json:"stations"`
+	DateCreated time.Time `db:"date_created" json:"date_created"`
+	DateUpdated time.Time `db:"date_updated" json:"date_updated"`
+}
+
+// NewGroup is what we require from clients when adding a Group.
+type NewGroup struct {
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description"`
+}
+
+// UpdateGroup defines what information may be provided to modify an existing
+// Group. All fields are optional so clients can send just the fields they want
+// changed.
+type UpdateGroup struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
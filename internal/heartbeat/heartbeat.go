@@ -0,0 +1,149 @@
+package heartbeat
+
+import (
+	// Core packages
+	"context"
+	"time"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// ErrInvalidID is used when an invalid UUID is provided.
+var ErrInvalidID = errors.New("ID is not in its proper UUID format")
+
+// BudgetWindow is how far back Heartbeats are used to work out a PowerBudget.
+const BudgetWindow = 24 * time.Hour
+
+// Record stores a Heartbeat of a Station.
+func Record(ctx context.Context, db *sqlx.DB, stationID string, nh NewHeartbeat, now time.Time) (*Heartbeat, error) {
+
+	ctx, span := trace.StartSpan(ctx, "heartbeat.Record")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	hb := Heartbeat{
+		Id:             uuid.New().String(),
+		StationId:      stationID,
+		BatteryVoltage: nh.BatteryVoltage,
+		BatteryCurrent: nh.BatteryCurrent,
+		SolarVoltage:   nh.SolarVoltage,
+		SleepInterval:  nh.SleepInterval,
+		DateSent:       now.UTC(),
+		DateReceived:   now.UTC(),
+	}
+	if nh.DateSent != nil {
+		hb.DateSent = nh.DateSent.UTC()
+	}
+
+	const q = `
+		INSERT INTO heartbeat
+		  (id, station_id, battery_voltage, battery_current, solar_voltage, sleep_interval, date_sent, date_received)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := db.ExecContext(ctx, q,
+		hb.Id,
+		hb.StationId,
+		hb.BatteryVoltage,
+		hb.BatteryCurrent,
+		hb.SolarVoltage,
+		hb.SleepInterval,
+		hb.DateSent,
+		hb.DateReceived,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "inserting heartbeat")
+	}
+
+	return &hb, nil
+}
+
+// List gets the Heartbeats of a Station sent since the given time, oldest
+// first.
+func List(ctx context.Context, db *sqlx.DB, stationID string, since time.Time) ([]Heartbeat, error) {
+
+	ctx, span := trace.StartSpan(ctx, "heartbeat.List")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	hbs := []Heartbeat{}
+
+	const q = `
+		SELECT
+			id, station_id, battery_voltage, battery_current, solar_voltage, sleep_interval,
+			date_sent, date_received
+		FROM heartbeat
+		WHERE station_id = $1 AND date_sent >= $2
+		ORDER BY date_sent`
+
+	if err := db.SelectContext(ctx, &hbs, q, stationID, since.UTC()); err != nil {
+		return nil, errors.Wrap(err, "selecting heartbeats")
+	}
+
+	return hbs, nil
+}
+
+// StationBudget works out the PowerBudget of a Station from its Heartbeats of
+// the last BudgetWindow.
+func StationBudget(ctx context.Context, db *sqlx.DB, stationID string, now time.Time) (*PowerBudget, error) {
+
+	ctx, span := trace.StartSpan(ctx, "heartbeat.StationBudget")
+	defer span.End()
+
+	hbs, err := List(ctx, db, stationID, now.Add(-BudgetWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	b := Budget(stationID, hbs)
+	return &b, nil
+}
+
+// ListBudgets works out the PowerBudget of every active Station that sent a
+// Heartbeat in the last BudgetWindow. When negativeOnly is set only Stations
+// losing energy are returned.
+func ListBudgets(ctx context.Context, db *sqlx.DB, now time.Time, negativeOnly bool) ([]PowerBudget, error) {
+
+	ctx, span := trace.StartSpan(ctx, "heartbeat.ListBudgets")
+	defer span.End()
+
+	var hbs []Heartbeat
+
+	const q = `
+		SELECT
+			heartbeat.id, heartbeat.station_id, heartbeat.battery_voltage, heartbeat.battery_current,
+			heartbeat.solar_voltage, heartbeat.sleep_interval, heartbeat.date_sent, heartbeat.date_received
+		FROM heartbeat
+		  JOIN station ON station.id = heartbeat.station_id AND station.date_deleted IS NULL
+		WHERE heartbeat.date_sent >= $1
+		ORDER BY heartbeat.station_id, heartbeat.date_sent`
+
+	if err := db.SelectContext(ctx, &hbs, q, now.Add(-BudgetWindow).UTC()); err != nil {
+		return nil, errors.Wrap(err, "selecting heartbeats")
+	}
+
+	budgets := []PowerBudget{}
+	for start := 0; start < len(hbs); {
+		end := start
+		for end < len(hbs) && hbs[end].StationId == hbs[start].StationId {
+			end++
+		}
+
+		b := Budget(hbs[start].StationId, hbs[start:end])
+		if b.Negative || !negativeOnly {
+			budgets = append(budgets, b)
+		}
+		start = end
+	}
+
+	return budgets, nil
+}
@@ -0,0 +1,70 @@
+package heartbeat_test
+
+import (
+	// Core packages
+	"context"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/heartbeat"
+	"github.com/deezone/HydroBytes-BaseStation/internal/schema"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+)
+
+func TestHeartbeat(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2021, time.June, 2, 0, 0, 0, 0, time.UTC)
+
+	draining := "d58f6d32-6332-11eb-ae93-0242ac130002"
+	charging := "27356858-6333-11eb-ae93-0242ac130002"
+
+	beats := []struct {
+		station string
+		current float64
+		ago     time.Duration
+	}{
+		{draining, -100, 12 * time.Hour},
+		{draining, -100, 0},
+		{charging, 80, 12 * time.Hour},
+		{charging, 80, 0},
+	}
+	for _, b := range beats {
+		sent := now.Add(-b.ago)
+		nh := heartbeat.NewHeartbeat{BatteryVoltage: 3.9, BatteryCurrent: b.current, SleepInterval: 300, DateSent: &sent}
+		if _, err := heartbeat.Record(ctx, db, b.station, nh, now); err != nil {
+			t.Fatalf("recording heartbeat: %s", err)
+		}
+	}
+
+	hbs, err := heartbeat.List(ctx, db, draining, now.Add(-heartbeat.BudgetWindow))
+	if err != nil {
+		t.Fatalf("listing heartbeats: %s", err)
+	}
+	if exp, got := 2, len(hbs); exp != got {
+		t.Fatalf("expected %v heartbeats, got %v", exp, got)
+	}
+
+	all, err := heartbeat.ListBudgets(ctx, db, now, false)
+	if err != nil {
+		t.Fatalf("listing budgets: %s", err)
+	}
+	if exp, got := 2, len(all); exp != got {
+		t.Fatalf("expected %v budgets, got %v", exp, got)
+	}
+
+	negative, err := heartbeat.ListBudgets(ctx, db, now, true)
+	if err != nil {
+		t.Fatalf("listing negative budgets: %s", err)
+	}
+	if len(negative) != 1 || negative[0].StationId != draining {
+		t.Fatalf("expected only station %v to have a negative budget, got %+v", draining, negative)
+	}
+}
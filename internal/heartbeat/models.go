@@ -0,0 +1,62 @@
+package heartbeat
+
+import (
+	// Core packages
+	"time"
+)
+
+// Heartbeat is the periodic status report of a Station. BatteryCurrent is in
+// milliamps, positive while the battery is charging and negative while it is
+// discharging. SleepInterval is how many seconds the Station sleeps between
+// heartbeats.
+type Heartbeat struct {
+	Id             string    `db:"id"              json:"id"`
+	StationId      string    `db:"station_id"      json:"station_id"`
+	BatteryVoltage float64   `db:"battery_voltage" json:"battery_voltage"`
+	BatteryCurrent float64   `db:"battery_current" json:"battery_current"`
+	SolarVoltage   float64   `db:"solar_voltage"   json:"solar_voltage"`
+	SleepInterval  int       `db:"sleep_interval"  json:"sleep_interval"`
+	DateSent       time.Time `db:"date_sent"       json:"date_sent"`
+	DateReceived   time.Time `db:"date_received"   json:"date_received"`
+}
+
+// NewHeartbeat is what we require from a Station when it reports in. DateSent
+// defaults to the time the heartbeat is received.
+type NewHeartbeat struct {
+	BatteryVoltage float64    `json:"battery_voltage" validate:"required,gt=0"`
+	BatteryCurrent float64    `json:"battery_current"`
+	SolarVoltage   float64    `json:"solar_voltage" validate:"gte=0"`
+	SleepInterval  int        `json:"sleep_interval" validate:"gte=0"`
+	DateSent       *time.Time `json:"date_sent"`
+}
+
+// Ack is the response to a Heartbeat. SleepInterval is set when the Station
+// should sleep longer between heartbeats to stay within its power budget.
+type Ack struct {
+	Heartbeat     Heartbeat `json:"heartbeat"`
+	SleepInterval *int      `json:"sleep_interval,omitempty"`
+}
+
+// PowerBudget is the power state of a Station worked out from its recent
+// Heartbeats.
+//
+// ChargePercent is estimated from the battery voltage. VoltageTrend is the
+// change in battery voltage per day. EnergyBalance is the energy in watt hours
+// the battery gains (positive) or loses (negative) per day. RuntimeHours is how
+// long the battery lasts at the current balance and is only set while the
+// balance is negative. RecommendedSleepInterval is the sleep interval in
+// seconds that would bring the balance back to zero.
+type PowerBudget struct {
+	StationId                string    `json:"station_id"`
+	BatteryVoltage           float64   `json:"battery_voltage"`
+	ChargePercent            float64   `json:"charge_percent"`
+	VoltageTrend             float64   `json:"voltage_trend"`
+	EnergyBalance            float64   `json:"energy_balance"`
+	RuntimeHours             *float64  `json:"runtime_hours,omitempty"`
+	Negative                 bool      `json:"negative"`
+	SleepInterval            int       `json:"sleep_interval"`
+	RecommendedSleepInterval *int      `json:"recommended_sleep_interval,omitempty"`
+	Heartbeats               int       `json:"heartbeats"`
+	DateFrom                 time.Time `json:"date_from"`
+	DateTo                   time.Time `json:"date_to"`
+}
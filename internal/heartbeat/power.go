@@ -0,0 +1,140 @@
+package heartbeat
+
+import (
+	// Core packages
+	"math"
+)
+
+const (
+	// CellCapacity is the capacity in milliamp hours of the 18650 cell of a
+	// Station.
+	CellCapacity = 2600
+
+	// CellNominalVoltage is the nominal voltage of the 18650 cell of a Station.
+	CellNominalVoltage = 3.7
+
+	// MaxSleepInterval is the longest sleep interval in seconds recommended to
+	// a Station.
+	MaxSleepInterval = 3600
+)
+
+// dischargeCurve maps the resting voltage of a lithium ion cell to its state
+// of charge in percent. Voltages in between are interpolated.
+var dischargeCurve = []struct {
+	volts   float64
+	percent float64
+}{
+	{3.00, 0},
+	{3.30, 3},
+	{3.50, 8},
+	{3.60, 15},
+	{3.70, 30},
+	{3.80, 50},
+	{3.90, 65},
+	{4.00, 80},
+	{4.10, 90},
+	{4.20, 100},
+}
+
+// ChargePercent estimates the state of charge of a lithium ion cell from its
+// voltage.
+func ChargePercent(volts float64) float64 {
+	if volts <= dischargeCurve[0].volts {
+		return 0
+	}
+	for i := 1; i < len(dischargeCurve); i++ {
+		lo, hi := dischargeCurve[i-1], dischargeCurve[i]
+		if volts <= hi.volts {
+			return lo.percent + (volts-lo.volts)/(hi.volts-lo.volts)*(hi.percent-lo.percent)
+		}
+	}
+	return 100
+}
+
+// Budget works out the PowerBudget of a Station from its Heartbeats ordered
+// oldest first. At least two Heartbeats are needed to see a trend, with fewer
+// only the battery state is filled in.
+func Budget(stationID string, hbs []Heartbeat) PowerBudget {
+	b := PowerBudget{
+		StationId:  stationID,
+		Heartbeats: len(hbs),
+	}
+	if len(hbs) == 0 {
+		return b
+	}
+
+	first, last := hbs[0], hbs[len(hbs)-1]
+	b.BatteryVoltage = last.BatteryVoltage
+	b.ChargePercent = round(ChargePercent(last.BatteryVoltage), 1)
+	b.SleepInterval = last.SleepInterval
+	b.DateFrom = first.DateSent
+	b.DateTo = last.DateSent
+
+	days := last.DateSent.Sub(first.DateSent).Hours() / 24
+	if len(hbs) < 2 || days <= 0 {
+		return b
+	}
+
+	// Integrate the power in and out of the battery between heartbeats, and
+	// fit a line through the voltages for the trend.
+	var charged, discharged float64
+	var sumT, sumV, sumTT, sumTV float64
+	for i, hb := range hbs {
+		t := hb.DateSent.Sub(first.DateSent).Hours() / 24
+		sumT += t
+		sumV += hb.BatteryVoltage
+		sumTT += t * t
+		sumTV += t * hb.BatteryVoltage
+
+		if i == 0 {
+			continue
+		}
+		prev := hbs[i-1]
+		hours := hb.DateSent.Sub(prev.DateSent).Hours()
+		watts := (prev.BatteryVoltage + hb.BatteryVoltage) / 2 * (prev.BatteryCurrent + hb.BatteryCurrent) / 2 / 1000
+		if watts > 0 {
+			charged += watts * hours
+		} else {
+			discharged -= watts * hours
+		}
+	}
+
+	n := float64(len(hbs))
+	if d := n*sumTT - sumT*sumT; d != 0 {
+		b.VoltageTrend = round((n*sumTV-sumT*sumV)/d, 3)
+	}
+
+	b.EnergyBalance = round((charged-discharged)/days, 3)
+	if b.EnergyBalance >= 0 {
+		return b
+	}
+	b.Negative = true
+
+	remaining := b.ChargePercent / 100 * CellCapacity / 1000 * CellNominalVoltage
+	runtime := round(remaining/(-b.EnergyBalance)*24, 1)
+	b.RuntimeHours = &runtime
+
+	// The energy used is taken to scale with how often the Station wakes up, so
+	// sleeping longer by the ratio of energy used to energy gained balances
+	// the budget.
+	if b.SleepInterval > 0 {
+		recommended := MaxSleepInterval
+		if charged > 0 {
+			recommended = int(math.Ceil(float64(b.SleepInterval) * discharged / charged))
+		}
+		if recommended > MaxSleepInterval {
+			recommended = MaxSleepInterval
+		}
+		if recommended > b.SleepInterval {
+			b.RecommendedSleepInterval = &recommended
+		}
+	}
+
+	return b
+}
+
+// round rounds f to the given number of decimal places.
+func round(f float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(f*p) / p
+}
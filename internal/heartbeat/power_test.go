@@ -0,0 +1,96 @@
+package heartbeat_test
+
+import (
+	// Core packages
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/heartbeat"
+)
+
+func TestChargePercent(t *testing.T) {
+	tt := []struct {
+		volts float64
+		want  float64
+	}{
+		{2.8, 0},
+		{3.0, 0},
+		{3.75, 40},
+		{4.0, 80},
+		{4.2, 100},
+		{4.3, 100},
+	}
+
+	for _, tc := range tt {
+		if got := heartbeat.ChargePercent(tc.volts); got != tc.want {
+			t.Errorf("ChargePercent(%v): expected %v, got %v", tc.volts, tc.want, got)
+		}
+	}
+}
+
+func TestBudget(t *testing.T) {
+	start := time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC)
+	station := "d58f6d32-6332-11eb-ae93-0242ac130002"
+
+	{ // A single heartbeat only gives the battery state.
+		b := heartbeat.Budget(station, []heartbeat.Heartbeat{{BatteryVoltage: 4.0, DateSent: start}})
+		if b.ChargePercent != 80 || b.Negative || b.RuntimeHours != nil {
+			t.Fatalf("expected only the battery state, got %+v", b)
+		}
+	}
+
+	{ // No sun for a day, drawing 100mA at 4V.
+		hbs := []heartbeat.Heartbeat{
+			{BatteryVoltage: 4.0, BatteryCurrent: -100, SleepInterval: 300, DateSent: start},
+			{BatteryVoltage: 4.0, BatteryCurrent: -100, SleepInterval: 300, DateSent: start.Add(24 * time.Hour)},
+		}
+		b := heartbeat.Budget(station, hbs)
+
+		if exp, got := -9.6, b.EnergyBalance; exp != got {
+			t.Fatalf("expected energy balance %v, got %v", exp, got)
+		}
+		if !b.Negative {
+			t.Fatal("expected a negative budget")
+		}
+		if b.RuntimeHours == nil || *b.RuntimeHours != 19.2 {
+			t.Fatalf("expected 19.2 hours of runtime, got %v", b.RuntimeHours)
+		}
+		if b.RecommendedSleepInterval == nil || *b.RecommendedSleepInterval != heartbeat.MaxSleepInterval {
+			t.Fatalf("expected the longest sleep interval, got %v", b.RecommendedSleepInterval)
+		}
+	}
+
+	{ // The panel gives back half of what is used.
+		hbs := []heartbeat.Heartbeat{
+			{BatteryVoltage: 3.9, BatteryCurrent: -100, SleepInterval: 300, DateSent: start},
+			{BatteryVoltage: 3.9, BatteryCurrent: -100, SleepInterval: 300, DateSent: start.Add(12 * time.Hour)},
+			{BatteryVoltage: 3.9, BatteryCurrent: 50, SleepInterval: 300, DateSent: start.Add(12*time.Hour + time.Second)},
+			{BatteryVoltage: 3.8, BatteryCurrent: 50, SleepInterval: 300, DateSent: start.Add(24*time.Hour + time.Second)},
+		}
+		b := heartbeat.Budget(station, hbs)
+
+		if !b.Negative {
+			t.Fatalf("expected a negative budget, got %+v", b)
+		}
+		if b.VoltageTrend >= 0 {
+			t.Fatalf("expected a falling voltage trend, got %v", b.VoltageTrend)
+		}
+		// Using about twice the energy gained means sleeping about twice as long.
+		if b.RecommendedSleepInterval == nil || *b.RecommendedSleepInterval < 600 || *b.RecommendedSleepInterval > 620 {
+			t.Fatalf("expected a sleep interval of about 600, got %v", b.RecommendedSleepInterval)
+		}
+	}
+
+	{ // Charging stations need no changes.
+		hbs := []heartbeat.Heartbeat{
+			{BatteryVoltage: 3.9, BatteryCurrent: 80, SleepInterval: 300, DateSent: start},
+			{BatteryVoltage: 4.0, BatteryCurrent: 80, SleepInterval: 300, DateSent: start.Add(6 * time.Hour)},
+		}
+		b := heartbeat.Budget(station, hbs)
+
+		if b.Negative || b.RuntimeHours != nil || b.RecommendedSleepInterval != nil {
+			t.Fatalf("expected a positive budget, got %+v", b)
+		}
+	}
+}
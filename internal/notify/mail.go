@@ -0,0 +1,104 @@
+package notify
+
+import (
+	// Core packages
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	// Third-party packages
+	"github.com/pkg/errors"
+)
+
+// SMTPConfig is what is required to send email through an SMTP server.
+// Messages are sent without authentication when Username is empty.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// Mailer sends email through an SMTP server.
+type Mailer struct {
+	cfg SMTPConfig
+}
+
+// NewMailer creates a Mailer for an SMTP server.
+func NewMailer(cfg SMTPConfig) *Mailer {
+	return &Mailer{cfg: cfg}
+}
+
+// Send emails a Message to an address. The message holds both the text and
+// the HTML body so mail clients can show either.
+func (m *Mailer) Send(to string, msg Message, now time.Time) error {
+	body, err := m.build(to, msg, now)
+	if err != nil {
+		return err
+	}
+
+	var a smtp.Auth
+	if m.cfg.Username != "" {
+		a = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	if err := smtp.SendMail(addr, a, m.cfg.From, []string{to}, body); err != nil {
+		return errors.Wrapf(err, "sending mail to %s", to)
+	}
+
+	return nil
+}
+
+// build writes a multipart/alternative message with a text and an HTML part.
+func (m *Mailer) build(to string, msg Message, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", m.cfg.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	}
+
+	for _, p := range parts {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "creating mail part")
+		}
+
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(p.body)); err != nil {
+			return nil, errors.Wrap(err, "writing mail part")
+		}
+		if err := qw.Close(); err != nil {
+			return nil, errors.Wrap(err, "writing mail part")
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, errors.Wrap(err, "closing mail")
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,88 @@
+package notify
+
+import (
+	// Core packages
+	"time"
+
+	// Third-party packages
+	"github.com/lib/pq"
+)
+
+// Channels a Notification is sent through.
+const (
+	ChannelEmail = "email"
+)
+
+// Statuses of a Notification.
+const (
+	StatusSent   = "sent"
+	StatusFailed = "failed"
+)
+
+// Deliveries of a Preference.
+const (
+	DeliveryImmediate = "immediate"
+	DeliveryDigest    = "digest"
+)
+
+// Preference is how an Account wants to be notified of Events. Events of the
+// EventTypes and at least MinSeverity are sent through the Channels to
+// Email. Accounts are only notified of Events of their own Stations unless
+// they are admins.
+//
+// With immediate Delivery warning and critical Events are sent as they are
+// raised while info Events are batched into a daily digest sent at DigestAt.
+// With digest Delivery every Event goes into the digest. Nothing but critical
+// Events is sent between QuietStart and QuietEnd. Times of day are "15:04"
+// clock times in Timezone.
+type Preference struct {
+	AccountId      string         `db:"account_id"       json:"account_id"`
+	Email          string         `db:"email"            json:"email"`
+	EventTypes     pq.StringArray `db:"event_types"      json:"event_types"`
+	Channels       pq.StringArray `db:"channels"         json:"channels"`
+	MinSeverity    string         `db:"min_severity"     json:"min_severity"`
+	QuietStart     string         `db:"quiet_start"      json:"quiet_start,omitempty"`
+	QuietEnd       string         `db:"quiet_end"        json:"quiet_end,omitempty"`
+	Timezone       string         `db:"timezone"         json:"timezone"`
+	Delivery       string         `db:"delivery"         json:"delivery"`
+	DigestAt       string         `db:"digest_at"        json:"digest_at"`
+	DateLastDigest *time.Time     `db:"date_last_digest" json:"date_last_digest,omitempty"`
+	DateCreated    time.Time      `db:"date_created"     json:"date_created"`
+	DateUpdated    time.Time      `db:"date_updated"     json:"date_updated"`
+}
+
+// UpdatePreference is what we require from clients when setting the
+// Preference of an Account. It replaces any Preference already set.
+//
+// Channels default to email when not given; an empty list turns
+// notifications off. MinSeverity defaults to info, Timezone to UTC, Delivery
+// to immediate and DigestAt to 08:00.
+type UpdatePreference struct {
+	Email       string   `json:"email" validate:"required,email"`
+	EventTypes  []string `json:"event_types" validate:"required,min=1"`
+	Channels    []string `json:"channels" validate:"dive,oneof=email"`
+	MinSeverity string   `json:"min_severity" validate:"omitempty,oneof=info warning critical"`
+	QuietStart  string   `json:"quiet_start" validate:"required_with=QuietEnd"`
+	QuietEnd    string   `json:"quiet_end" validate:"required_with=QuietStart"`
+	Timezone    string   `json:"timezone"`
+	Delivery    string   `json:"delivery" validate:"omitempty,oneof=immediate digest"`
+	DigestAt    string   `json:"digest_at"`
+}
+
+// Notification is an Event sent to an Account. Digest is set when the Event
+// was sent as part of a daily digest. A failed Notification is sent again at
+// DateNextAttempt, backing off exponentially, until it has been attempted
+// MaxAttempts times.
+type Notification struct {
+	Id              string     `db:"id"                json:"id"`
+	AccountId       string     `db:"account_id"        json:"account_id"`
+	EventId         string     `db:"event_id"          json:"event_id"`
+	Channel         string     `db:"channel"           json:"channel"`
+	Status          string     `db:"status"            json:"status"`
+	Digest          bool       `db:"digest"            json:"digest"`
+	Error           string     `db:"error"             json:"error,omitempty"`
+	Attempts        int        `db:"attempts"          json:"attempts"`
+	DateNextAttempt *time.Time `db:"date_next_attempt" json:"date_next_attempt,omitempty"`
+	DateSent        *time.Time `db:"date_sent"         json:"date_sent,omitempty"`
+	DateCreated     time.Time  `db:"date_created"      json:"date_created"`
+}
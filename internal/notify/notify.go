@@ -0,0 +1,415 @@
+package notify
+
+import (
+	// Core packages
+	"context"
+	"database/sql"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/event"
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/auth"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Predefined errors identify expected failure conditions.
+var (
+	// ErrNotFound is used when an Account has no Preference.
+	ErrNotFound = errors.New("notification preference not found")
+
+	// ErrInvalidID is used when an invalid UUID is provided.
+	ErrInvalidID = errors.New("ID is not in its proper UUID format")
+
+	// ErrInvalidPreference is used when a Preference has an unknown timezone or
+	// a time of day that is not formatted as 15:04.
+	ErrInvalidPreference = errors.New("timezone must be an IANA time zone and times of day formatted as 15:04")
+
+	// ErrInvalidEventType is used when a Preference names an Event type that
+	// is not in event.Types.
+	ErrInvalidEventType = errors.New("unknown event type")
+)
+
+const (
+	// DispatchWindow is how far back Dispatch looks for Events to send. It
+	// is longer than a day so Events held for a daily digest are still found.
+	DispatchWindow = 48 * time.Hour
+)
+
+// GetPreference finds the Preference of an Account.
+func GetPreference(ctx context.Context, db *sqlx.DB, accountID string) (*Preference, error) {
+
+	ctx, span := trace.StartSpan(ctx, "notify.GetPreference")
+	defer span.End()
+
+	if _, err := uuid.Parse(accountID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var p Preference
+
+	const q = `
+		SELECT
+			account_id, email, event_types, channels, min_severity, quiet_start, quiet_end, timezone,
+			delivery, digest_at, date_last_digest, date_created, date_updated
+		FROM notification_preference
+		WHERE account_id = $1`
+
+	if err := db.GetContext(ctx, &p, q, accountID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+
+		return nil, errors.Wrap(err, "selecting notification preference")
+	}
+
+	return &p, nil
+}
+
+// SetPreference sets the Preference of an Account, replacing any Preference
+// it had.
+func SetPreference(ctx context.Context, db *sqlx.DB, accountID string, up UpdatePreference, now time.Time) (*Preference, error) {
+
+	ctx, span := trace.StartSpan(ctx, "notify.SetPreference")
+	defer span.End()
+
+	if _, err := uuid.Parse(accountID); err != nil {
+		return nil, ErrInvalidID
+	}
+	if !event.ValidTypes(up.EventTypes) {
+		return nil, ErrInvalidEventType
+	}
+
+	p := Preference{
+		AccountId:   accountID,
+		Email:       up.Email,
+		EventTypes:  up.EventTypes,
+		Channels:    up.Channels,
+		MinSeverity: up.MinSeverity,
+		QuietStart:  up.QuietStart,
+		QuietEnd:    up.QuietEnd,
+		Timezone:    up.Timezone,
+		Delivery:    up.Delivery,
+		DigestAt:    up.DigestAt,
+		DateCreated: now.UTC(),
+		DateUpdated: now.UTC(),
+	}
+
+	if p.Channels == nil {
+		p.Channels = []string{ChannelEmail}
+	}
+	if p.MinSeverity == "" {
+		p.MinSeverity = event.SeverityInfo
+	}
+	if p.Timezone == "" {
+		p.Timezone = "UTC"
+	}
+	if p.Delivery == "" {
+		p.Delivery = DeliveryImmediate
+	}
+	if p.DigestAt == "" {
+		p.DigestAt = "08:00"
+	}
+
+	if _, err := time.LoadLocation(p.Timezone); err != nil {
+		return nil, ErrInvalidPreference
+	}
+	for _, c := range []string{p.QuietStart, p.QuietEnd, p.DigestAt} {
+		if c == "" {
+			continue
+		}
+		if _, err := clock(c); err != nil {
+			return nil, ErrInvalidPreference
+		}
+	}
+
+	// The creation date and last digest of an existing Preference are kept so
+	// Events raised since then are still sent.
+	const q = `
+		INSERT INTO notification_preference
+		  (account_id, email, event_types, channels, min_severity, quiet_start, quiet_end, timezone,
+		   delivery, digest_at, date_created, date_updated)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (account_id) DO UPDATE SET
+			email = EXCLUDED.email,
+			event_types = EXCLUDED.event_types,
+			channels = EXCLUDED.channels,
+			min_severity = EXCLUDED.min_severity,
+			quiet_start = EXCLUDED.quiet_start,
+			quiet_end = EXCLUDED.quiet_end,
+			timezone = EXCLUDED.timezone,
+			delivery = EXCLUDED.delivery,
+			digest_at = EXCLUDED.digest_at,
+			date_updated = EXCLUDED.date_updated
+		RETURNING date_created, date_last_digest`
+
+	row := db.QueryRowxContext(ctx, q,
+		p.AccountId,
+		p.Email,
+		p.EventTypes,
+		p.Channels,
+		p.MinSeverity,
+		p.QuietStart,
+		p.QuietEnd,
+		p.Timezone,
+		p.Delivery,
+		p.DigestAt,
+		p.DateCreated,
+		p.DateUpdated,
+	)
+	if err := row.Scan(&p.DateCreated, &p.DateLastDigest); err != nil {
+		return nil, errors.Wrap(err, "saving notification preference")
+	}
+
+	return &p, nil
+}
+
+// ListNotifications gets the Notifications sent to an Account, most recent
+// first.
+func ListNotifications(ctx context.Context, db *sqlx.DB, accountID string, limit int) ([]Notification, error) {
+
+	ctx, span := trace.StartSpan(ctx, "notify.ListNotifications")
+	defer span.End()
+
+	if _, err := uuid.Parse(accountID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	if limit <= 0 {
+		limit = 100
+	}
+
+	notifications := []Notification{}
+
+	const q = `
+		SELECT
+			id, account_id, event_id, channel, status, digest, error, attempts, date_next_attempt,
+			date_sent, date_created
+		FROM notification
+		WHERE account_id = $1
+		ORDER BY date_created DESC
+		LIMIT $2`
+
+	if err := db.SelectContext(ctx, &notifications, q, accountID, limit); err != nil {
+		return nil, errors.Wrap(err, "selecting notifications")
+	}
+
+	return notifications, nil
+}
+
+// Dispatch emails the Events raised in the last DispatchWindow to the
+// Accounts whose Preference includes their type and severity. Events of a
+// Station are only sent to the owner of the Station and to admins.
+//
+// Events are sent as they are raised or held for the daily digest of the
+// Account as its Preference asks. Events held back by quiet hours are sent
+// once they are over. Every Event is sent to an Account at most once; Events
+// that could not be sent are recorded as failed and sent again after Backoff
+// until they have been attempted MaxAttempts times. It returns the number of
+// Notifications sent.
+func Dispatch(ctx context.Context, db *sqlx.DB, m *Mailer, now time.Time) (int, error) {
+
+	ctx, span := trace.StartSpan(ctx, "notify.Dispatch")
+	defer span.End()
+
+	var prefs []Preference
+
+	const qp = `
+		SELECT
+			account_id, email, event_types, channels, min_severity, quiet_start, quiet_end, timezone,
+			delivery, digest_at, date_last_digest, date_created, date_updated
+		FROM notification_preference
+		WHERE $1 = ANY(channels)`
+
+	if err := db.SelectContext(ctx, &prefs, qp, ChannelEmail); err != nil {
+		return 0, errors.Wrap(err, "selecting notification preferences")
+	}
+
+	byAccount := make(map[string]Preference, len(prefs))
+	for _, p := range prefs {
+		byAccount[p.AccountId] = p
+	}
+
+	var due []struct {
+		EventId   string `db:"event_id"`
+		Severity  string `db:"severity"`
+		AccountId string `db:"account_id"`
+	}
+
+	const q = `
+		SELECT event.id AS event_id, event.severity, pref.account_id
+		FROM event
+		JOIN notification_preference AS pref
+			ON $1 = ANY(pref.channels) AND event.type = ANY(pref.event_types) AND event.date_created >= pref.date_created
+		JOIN account ON account.id = pref.account_id AND account.date_deleted IS NULL
+		LEFT JOIN station ON station.id = event.station_id
+		WHERE event.date_created >= $2
+			AND (event.station_id IS NULL OR station.account_id = pref.account_id OR $3 = ANY(account.roles))
+			AND NOT EXISTS (
+				SELECT 1 FROM notification
+				WHERE notification.account_id = pref.account_id AND notification.event_id = event.id
+					AND (notification.status <> $4 OR notification.attempts >= $5 OR notification.date_next_attempt > $6)
+			)
+		ORDER BY event.date_created`
+
+	err := db.SelectContext(ctx, &due, q,
+		ChannelEmail,
+		now.Add(-DispatchWindow).UTC(),
+		auth.RoleAdmin,
+		StatusFailed,
+		MaxAttempts,
+		now.UTC(),
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "selecting due notifications")
+	}
+
+	sent := 0
+	digests := make(map[string][]string)
+	for _, d := range due {
+		p, ok := byAccount[d.AccountId]
+		if !ok || event.Rank(d.Severity) < event.Rank(p.MinSeverity) {
+			continue
+		}
+
+		if p.Delivery == DeliveryDigest || d.Severity == event.SeverityInfo {
+			digests[d.AccountId] = append(digests[d.AccountId], d.EventId)
+			continue
+		}
+
+		if d.Severity != event.SeverityCritical && Quiet(p, now) {
+			continue
+		}
+
+		e, err := event.Get(ctx, db, d.EventId)
+		if err != nil {
+			return sent, errors.Wrapf(err, "getting event %s", d.EventId)
+		}
+
+		msg, sendErr := Render(*e)
+		if sendErr == nil {
+			sendErr = m.Send(p.Email, msg, now)
+		}
+		if _, err := record(ctx, db, p.AccountId, []string{e.Id}, false, sendErr, now); err != nil {
+			return sent, err
+		}
+		if sendErr == nil {
+			sent++
+		}
+	}
+
+	for accountID, ids := range digests {
+		p := byAccount[accountID]
+		if !DigestDue(p, now) {
+			continue
+		}
+
+		events := make([]event.Event, 0, len(ids))
+		for _, id := range ids {
+			e, err := event.Get(ctx, db, id)
+			if err != nil {
+				return sent, errors.Wrapf(err, "getting event %s", id)
+			}
+			events = append(events, *e)
+		}
+
+		msg, sendErr := RenderDigest(events, now.In(location(p)))
+		if sendErr == nil {
+			sendErr = m.Send(p.Email, msg, now)
+		}
+		retrying, err := record(ctx, db, accountID, ids, true, sendErr, now)
+		if err != nil {
+			return sent, err
+		}
+
+		// A digest that will be retried is still due.
+		if !retrying {
+			const q = `UPDATE notification_preference SET date_last_digest = $2 WHERE account_id = $1`
+			if _, err := db.ExecContext(ctx, q, accountID, now.UTC()); err != nil {
+				return sent, errors.Wrap(err, "updating last digest")
+			}
+		}
+
+		if sendErr == nil {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// record saves the Notifications of Events sent to an Account. They are
+// recorded as failed with the error sendErr when it is not nil, counting the
+// attempts of Events that failed before. It reports whether any of the failed
+// Notifications will be retried.
+func record(ctx context.Context, db *sqlx.DB, accountID string, eventIDs []string, digest bool, sendErr error, now time.Time) (bool, error) {
+	retrying := false
+
+	for _, id := range eventIDs {
+		n := Notification{
+			Id:          uuid.New().String(),
+			AccountId:   accountID,
+			EventId:     id,
+			Channel:     ChannelEmail,
+			Status:      StatusSent,
+			Digest:      digest,
+			Attempts:    1,
+			DateCreated: now.UTC(),
+		}
+
+		const qa = `SELECT attempts FROM notification WHERE account_id = $1 AND event_id = $2`
+		var attempts int
+		if err := db.GetContext(ctx, &attempts, qa, accountID, id); err != nil && err != sql.ErrNoRows {
+			return false, errors.Wrap(err, "selecting notification attempts")
+		}
+		n.Attempts += attempts
+
+		if sendErr != nil {
+			n.Status = StatusFailed
+			n.Error = sendErr.Error()
+			if n.Attempts < MaxAttempts {
+				next := now.Add(Backoff(n.Attempts)).UTC()
+				n.DateNextAttempt = &next
+				retrying = true
+			}
+		} else {
+			t := now.UTC()
+			n.DateSent = &t
+		}
+
+		const q = `
+			INSERT INTO notification
+			  (id, account_id, event_id, channel, status, digest, error, attempts, date_next_attempt, date_sent, date_created)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			ON CONFLICT (account_id, event_id) DO UPDATE SET
+				status = EXCLUDED.status,
+				digest = EXCLUDED.digest,
+				error = EXCLUDED.error,
+				attempts = EXCLUDED.attempts,
+				date_next_attempt = EXCLUDED.date_next_attempt,
+				date_sent = EXCLUDED.date_sent`
+
+		_, err := db.ExecContext(ctx, q,
+			n.Id,
+			n.AccountId,
+			n.EventId,
+			n.Channel,
+			n.Status,
+			n.Digest,
+			n.Error,
+			n.Attempts,
+			n.DateNextAttempt,
+			n.DateSent,
+			n.DateCreated,
+		)
+		if err != nil {
+			return false, errors.Wrap(err, "inserting notification")
+		}
+	}
+
+	return retrying, nil
+}
@@ -0,0 +1,206 @@
+package notify_test
+
+import (
+	// Core packages
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/event"
+	"github.com/deezone/HydroBytes-BaseStation/internal/notify"
+	"github.com/deezone/HydroBytes-BaseStation/internal/schema"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+)
+
+func TestRender(t *testing.T) {
+	e := event.Event{
+		Type:        "water.leak",
+		Severity:    event.SeverityCritical,
+		Message:     "water flowed through valve <zone-2> with no watering run open",
+		DateCreated: time.Date(2021, time.June, 2, 6, 0, 0, 0, time.UTC),
+	}
+
+	msg, err := notify.Render(e)
+	if err != nil {
+		t.Fatalf("rendering event: %s", err)
+	}
+
+	if exp, got := "[critical] Possible leak", msg.Subject; exp != got {
+		t.Fatalf("expected subject %q, got %q", exp, got)
+	}
+	if !strings.Contains(msg.Text, e.Message) {
+		t.Fatalf("text should hold the event message, got %q", msg.Text)
+	}
+	if !strings.Contains(msg.HTML, "valve &lt;zone-2&gt;") {
+		t.Fatalf("html should hold the escaped event message, got %q", msg.HTML)
+	}
+
+	// Event types without templates use the default ones.
+	e.Type = "station.created"
+	if msg, err = notify.Render(e); err != nil {
+		t.Fatalf("rendering event: %s", err)
+	}
+	if exp, got := "[critical] station.created", msg.Subject; exp != got {
+		t.Fatalf("expected subject %q, got %q", exp, got)
+	}
+}
+
+func TestMailer(t *testing.T) {
+	srv, stop := tests.NewSMTP(t)
+	defer stop()
+
+	m := notify.NewMailer(notify.SMTPConfig{Host: srv.Host, Port: srv.Port, From: "garden@example.com"})
+
+	msg := notify.Message{Subject: "Reservoir running dry", Text: "Plan a refill.", HTML: "<p>Plan a refill.</p>"}
+	if err := m.Send("owner@example.com", msg, time.Now()); err != nil {
+		t.Fatalf("sending mail: %s", err)
+	}
+
+	mail := srv.Mail()
+	if exp, got := 1, len(mail); exp != got {
+		t.Fatalf("expected %v mail, got %v", exp, got)
+	}
+	if exp, got := "garden@example.com", mail[0].From; exp != got {
+		t.Fatalf("expected mail from %q, got %q", exp, got)
+	}
+	for _, want := range []string{"Subject: Reservoir running dry", "text/plain", "text/html", "<p>Plan a refill.</p>"} {
+		if !strings.Contains(mail[0].Data, want) {
+			t.Fatalf("mail should contain %q, got:\n%s", want, mail[0].Data)
+		}
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	srv, stop := tests.NewSMTP(t)
+	defer stop()
+
+	m := notify.NewMailer(notify.SMTPConfig{Host: srv.Host, Port: srv.Port, From: "garden@example.com"})
+
+	ctx := context.Background()
+	now := time.Date(2021, time.June, 2, 6, 0, 0, 0, time.UTC)
+
+	// The regular account does not own the Water station so only the admin
+	// is told of its leak.
+	up := notify.UpdatePreference{Email: "admin@example.com", EventTypes: []string{"water.leak", "reservoir.dry"}}
+	if _, err := notify.SetPreference(ctx, db, tests.AdminId, up, now); err != nil {
+		t.Fatalf("setting admin preference: %s", err)
+	}
+	up.Email = "user@example.com"
+	if _, err := notify.SetPreference(ctx, db, tests.AccountOneId, up, now); err != nil {
+		t.Fatalf("setting user preference: %s", err)
+	}
+
+	// The leak is sent right away while the info event waits for the daily
+	// digest at 08:00.
+	water := "ee72a90c-590c-11eb-ae93-0242ac130002"
+	for _, ne := range []event.NewEvent{
+		{Type: "water.leak", Severity: event.SeverityCritical, StationId: &water, Message: "leak"},
+		{Type: "reservoir.dry", Severity: event.SeverityInfo, StationId: &water, Message: "reservoir low"},
+	} {
+		if _, err := event.Record(ctx, db, ne, now.Add(time.Minute)); err != nil {
+			t.Fatalf("recording event: %s", err)
+		}
+	}
+
+	for i, exp := range []int{1, 0} {
+		got, err := notify.Dispatch(ctx, db, m, now.Add(2*time.Minute))
+		if err != nil {
+			t.Fatalf("dispatching notifications: %s", err)
+		}
+		if exp != got {
+			t.Fatalf("dispatch %d: expected %v notifications, got %v", i, exp, got)
+		}
+	}
+
+	mail := srv.Mail()
+	if exp, got := 1, len(mail); exp != got {
+		t.Fatalf("expected %v mail, got %v", exp, got)
+	}
+	if exp, got := "admin@example.com", mail[0].To[0]; exp != got {
+		t.Fatalf("expected mail to %q, got %q", exp, got)
+	}
+
+	for i, exp := range []int{1, 0} {
+		got, err := notify.Dispatch(ctx, db, m, now.Add(150*time.Minute))
+		if err != nil {
+			t.Fatalf("dispatching digest: %s", err)
+		}
+		if exp != got {
+			t.Fatalf("digest %d: expected %v notifications, got %v", i, exp, got)
+		}
+	}
+
+	mail = srv.Mail()
+	if exp, got := 2, len(mail); exp != got {
+		t.Fatalf("expected %v mails, got %v", exp, got)
+	}
+	if !strings.Contains(mail[1].Data, "reservoir low") {
+		t.Fatalf("digest should list the info event, got:\n%s", mail[1].Data)
+	}
+}
+
+func TestDispatchRetry(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	srv, stop := tests.NewSMTP(t)
+	defer stop()
+
+	// Nothing listens on the port of a stopped stand-in so sending fails.
+	down, stopDown := tests.NewSMTP(t)
+	stopDown()
+
+	m := notify.NewMailer(notify.SMTPConfig{Host: srv.Host, Port: srv.Port, From: "garden@example.com"})
+	failing := notify.NewMailer(notify.SMTPConfig{Host: down.Host, Port: down.Port, From: "garden@example.com"})
+
+	ctx := context.Background()
+	now := time.Date(2021, time.June, 2, 6, 0, 0, 0, time.UTC)
+
+	up := notify.UpdatePreference{Email: "admin@example.com", EventTypes: []string{"water.leak"}}
+	if _, err := notify.SetPreference(ctx, db, tests.AdminId, up, now); err != nil {
+		t.Fatalf("setting admin preference: %s", err)
+	}
+
+	water := "ee72a90c-590c-11eb-ae93-0242ac130002"
+	ne := event.NewEvent{Type: "water.leak", Severity: event.SeverityCritical, StationId: &water, Message: "leak"}
+	if _, err := event.Record(ctx, db, ne, now); err != nil {
+		t.Fatalf("recording event: %s", err)
+	}
+
+	failed := now.Add(time.Minute)
+	if n, err := notify.Dispatch(ctx, db, failing, failed); err != nil || n != 0 {
+		t.Fatalf("dispatching while smtp is down: expected 0 notifications, got %v: %v", n, err)
+	}
+
+	// The failed notification waits for its backoff before it is sent again.
+	if n, err := notify.Dispatch(ctx, db, m, failed.Add(notify.Backoff(1)/2)); err != nil || n != 0 {
+		t.Fatalf("failed notification should not be retried before its backoff, got %v: %v", n, err)
+	}
+	if n, err := notify.Dispatch(ctx, db, m, failed.Add(notify.Backoff(1))); err != nil || n != 1 {
+		t.Fatalf("retried notification should be sent, got %v: %v", n, err)
+	}
+
+	list, err := notify.ListNotifications(ctx, db, tests.AdminId, 0)
+	if err != nil {
+		t.Fatalf("listing notifications: %s", err)
+	}
+	if exp, got := 1, len(list); exp != got {
+		t.Fatalf("expected %v notification, got %v", exp, got)
+	}
+	if list[0].Status != notify.StatusSent || list[0].Attempts != 2 {
+		t.Fatalf("expected the notification to be sent on the second attempt, got %+v", list[0])
+	}
+}
@@ -0,0 +1,100 @@
+package notify
+
+import (
+	// Core packages
+	"math"
+	"time"
+)
+
+const (
+	// MaxAttempts is how many times a Notification is attempted before it
+	// is given up on.
+	MaxAttempts = 5
+
+	// baseBackoff is how long the first retry of a Notification waits.
+	baseBackoff = time.Minute
+
+	// maxBackoff is the longest a retry of a Notification waits.
+	maxBackoff = 2 * time.Hour
+)
+
+// Backoff is how long to wait before sending a Notification again after the
+// given number of failed attempts. It doubles with every attempt.
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		return 0
+	}
+
+	d := time.Duration(float64(baseBackoff) * math.Pow(2, float64(attempts-1)))
+	if d > maxBackoff || d <= 0 {
+		return maxBackoff
+	}
+
+	return d
+}
+
+// clock parses a "15:04" time of day into minutes since midnight.
+func clock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// location loads the Timezone of a Preference. Timezones are checked when a
+// Preference is set; UTC is used should one no longer load.
+func location(p Preference) *time.Location {
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// Quiet reports whether now is within the quiet hours of a Preference. Quiet
+// hours may span midnight, for example from 22:00 to 07:00.
+func Quiet(p Preference, now time.Time) bool {
+	if p.QuietStart == "" || p.QuietEnd == "" {
+		return false
+	}
+
+	start, err := clock(p.QuietStart)
+	if err != nil {
+		return false
+	}
+	end, err := clock(p.QuietEnd)
+	if err != nil {
+		return false
+	}
+
+	local := now.In(location(p))
+	m := local.Hour()*60 + local.Minute()
+
+	if start <= end {
+		return m >= start && m < end
+	}
+
+	return m >= start || m < end
+}
+
+// DigestDue reports whether the daily digest of a Preference should be sent
+// at now: it is past DigestAt in the Timezone of the Preference, no digest
+// was sent since then and it is not quiet hours.
+func DigestDue(p Preference, now time.Time) bool {
+	at, err := clock(p.DigestAt)
+	if err != nil {
+		return false
+	}
+
+	local := now.In(location(p))
+	due := time.Date(local.Year(), local.Month(), local.Day(), at/60, at%60, 0, 0, local.Location())
+
+	if local.Before(due) || Quiet(p, now) {
+		return false
+	}
+
+	return p.DateLastDigest == nil || p.DateLastDigest.Before(due)
+}
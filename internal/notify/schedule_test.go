@@ -0,0 +1,84 @@
+package notify_test
+
+import (
+	// Core packages
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/notify"
+)
+
+func TestQuiet(t *testing.T) {
+	p := notify.Preference{QuietStart: "22:00", QuietEnd: "07:00", Timezone: "Europe/Amsterdam"}
+
+	// Amsterdam is two hours ahead of UTC in June.
+	tests := []struct {
+		utc   string
+		quiet bool
+	}{
+		{"2021-06-02T01:00:00Z", true},  // 03:00 local
+		{"2021-06-02T04:59:00Z", true},  // 06:59 local
+		{"2021-06-02T05:00:00Z", false}, // 07:00 local
+		{"2021-06-02T19:59:00Z", false}, // 21:59 local
+		{"2021-06-02T20:00:00Z", true},  // 22:00 local
+	}
+
+	for _, tt := range tests {
+		now, _ := time.Parse(time.RFC3339, tt.utc)
+		if got := notify.Quiet(p, now); got != tt.quiet {
+			t.Errorf("quiet at %s: expected %v, got %v", tt.utc, tt.quiet, got)
+		}
+	}
+
+	if notify.Quiet(notify.Preference{Timezone: "UTC"}, time.Now()) {
+		t.Error("a preference without quiet hours should never be quiet")
+	}
+}
+
+func TestDigestDue(t *testing.T) {
+	p := notify.Preference{DigestAt: "08:00", QuietStart: "22:00", QuietEnd: "07:00", Timezone: "UTC"}
+	day := time.Date(2021, time.June, 2, 0, 0, 0, 0, time.UTC)
+
+	if notify.DigestDue(p, day.Add(7*time.Hour)) {
+		t.Error("digest should not be due before its time")
+	}
+	if !notify.DigestDue(p, day.Add(9*time.Hour)) {
+		t.Error("digest should be due after its time")
+	}
+
+	sent := day.Add(8 * time.Hour)
+	p.DateLastDigest = &sent
+	if notify.DigestDue(p, day.Add(20*time.Hour)) {
+		t.Error("digest should not be due again the same day")
+	}
+	if !notify.DigestDue(p, day.Add(32*time.Hour)) {
+		t.Error("digest should be due again the next day")
+	}
+
+	// Digests are held back during quiet hours.
+	p.DigestAt = "06:00"
+	p.DateLastDigest = nil
+	if notify.DigestDue(p, day.Add(6*time.Hour)) {
+		t.Error("digest should not be due during quiet hours")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		exp      time.Duration
+	}{
+		{0, 0},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{20, 2 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := notify.Backoff(tt.attempts); got != tt.exp {
+			t.Errorf("expected a backoff of %v after %d attempts, got %v", tt.exp, tt.attempts, got)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package notify
+
+import (
+	// Core packages
+	"bytes"
+	htmltemplate "html/template"
+	"text/template"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/event"
+
+	// Third-party packages
+	"github.com/pkg/errors"
+)
+
+// Message is an Event rendered to be sent to an Account.
+type Message struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// layout wraps the HTML body of every Message.
+const layout = `{{define "layout"}}<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif">
+{{template "body" .}}
+<p style="color: #888; font-size: small">{{.Type}} &middot; {{.Severity}} &middot; {{.DateCreated.Format "2006-01-02 15:04 MST"}}</p>
+</body>
+</html>{{end}}`
+
+// eventTemplates are the subject, text and HTML body templates of each Event
+// type. Event types without templates use the "" templates. Templates are
+// executed with the Event.
+var eventTemplates = map[string]struct {
+	subject string
+	text    string
+	html    string
+}{
+	"": {
+		subject: `[{{.Severity}}] {{.Type}}`,
+		text:    "{{.Message}}\n",
+		html:    `<p>{{.Message}}</p>`,
+	},
+	"alert.firing": {
+		subject: `[{{.Severity}}] Alert firing: {{.Message}}`,
+		text:    "An alert is firing in the garden.\n\n{{.Message}}\n\nAcknowledge or silence it with the alert endpoints of the base station.\n",
+		html:    `<h2>Alert firing</h2><p>{{.Message}}</p><p>Acknowledge or silence it with the alert endpoints of the base station.</p>`,
+	},
+	"alert.resolved": {
+		subject: `Alert resolved: {{.Message}}`,
+		text:    "An alert in the garden has resolved.\n\n{{.Message}}\n",
+		html:    `<h2>Alert resolved</h2><p>{{.Message}}</p>`,
+	},
+	"water.leak": {
+		subject: `[{{.Severity}}] Possible leak`,
+		text:    "The base station suspects a leak.\n\n{{.Message}}\n\nCheck the valve and the pipes of the station.\n",
+		html:    `<h2>Possible leak</h2><p>{{.Message}}</p><p>Check the valve and the pipes of the station.</p>`,
+	},
+	"reservoir.dry": {
+		subject: `[{{.Severity}}] Reservoir running dry`,
+		text:    "A reservoir is about to run dry.\n\n{{.Message}}\n\nPlan a refill to keep the garden watered.\n",
+		html:    `<h2>Reservoir running dry</h2><p>{{.Message}}</p><p>Plan a refill to keep the garden watered.</p>`,
+	},
+	"frost.started": {
+		subject: `[{{.Severity}}] Frost in the garden`,
+		text:    "A station is below its frost limit.\n\n{{.Message}}\n\nCover tender plants and check exposed pipes.\n",
+		html:    `<h2>Frost in the garden</h2><p>{{.Message}}</p><p>Cover tender plants and check exposed pipes.</p>`,
+	},
+	"heat.started": {
+		subject: `[{{.Severity}}] Heat stress in the garden`,
+		text:    "A station is above its heat limit.\n\n{{.Message}}\n\nShade tender plants and check the reservoirs.\n",
+		html:    `<h2>Heat stress in the garden</h2><p>{{.Message}}</p><p>Shade tender plants and check the reservoirs.</p>`,
+	},
+	"command.failed": {
+		subject: `[{{.Severity}}] Station command failed`,
+		text:    "A station could not carry out a command.\n\n{{.Message}}\n",
+		html:    `<h2>Station command failed</h2><p>{{.Message}}</p>`,
+	},
+}
+
+// digestTemplates are the subject, text and HTML body templates of the daily
+// digest. They are executed with the Events of the digest and the Day.
+var digestTemplates = struct {
+	subject string
+	text    string
+	html    string
+}{
+	subject: `Garden digest for {{.Day.Format "Mon 2 Jan"}}: {{len .Events}} events`,
+	text: `{{range .Events}}- {{.DateCreated.Format "15:04"}} [{{.Severity}}] {{.Type}}: {{.Message}}
+{{end}}`,
+	html: `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif">
+<h2>Garden digest for {{.Day.Format "Monday 2 January"}}</h2>
+<ul>
+{{range .Events}}<li>{{.DateCreated.Format "15:04"}} <strong>{{.Severity}}</strong> {{.Type}}: {{.Message}}</li>
+{{end}}</ul>
+</body>
+</html>`,
+}
+
+// Render renders an Event with the templates of its type.
+func Render(e event.Event) (Message, error) {
+	tmpl, ok := eventTemplates[e.Type]
+	if !ok {
+		tmpl = eventTemplates[""]
+	}
+
+	var msg Message
+
+	var buf bytes.Buffer
+	for _, t := range []struct {
+		src string
+		dst *string
+	}{
+		{tmpl.subject, &msg.Subject},
+		{tmpl.text, &msg.Text},
+	} {
+		parsed, err := template.New(e.Type).Parse(t.src)
+		if err != nil {
+			return Message{}, errors.Wrapf(err, "parsing %s template", e.Type)
+		}
+		buf.Reset()
+		if err := parsed.Execute(&buf, e); err != nil {
+			return Message{}, errors.Wrapf(err, "rendering %s template", e.Type)
+		}
+		*t.dst = buf.String()
+	}
+
+	html, err := htmltemplate.New(e.Type).Parse(layout)
+	if err != nil {
+		return Message{}, errors.Wrap(err, "parsing layout template")
+	}
+	if _, err := html.New("body").Parse(tmpl.html); err != nil {
+		return Message{}, errors.Wrapf(err, "parsing %s html template", e.Type)
+	}
+	buf.Reset()
+	if err := html.ExecuteTemplate(&buf, "layout", e); err != nil {
+		return Message{}, errors.Wrapf(err, "rendering %s html template", e.Type)
+	}
+	msg.HTML = buf.String()
+
+	return msg, nil
+}
+
+// RenderDigest renders the daily digest of Events. Day is the day the digest
+// is sent on, in the timezone of the Account.
+func RenderDigest(events []event.Event, day time.Time) (Message, error) {
+	// Events are listed with the times of day of the Account.
+	local := make([]event.Event, len(events))
+	for i, e := range events {
+		e.DateCreated = e.DateCreated.In(day.Location())
+		local[i] = e
+	}
+
+	data := struct {
+		Day    time.Time
+		Events []event.Event
+	}{day, local}
+
+	var msg Message
+
+	var buf bytes.Buffer
+	for _, t := range []struct {
+		src string
+		dst *string
+	}{
+		{digestTemplates.subject, &msg.Subject},
+		{digestTemplates.text, &msg.Text},
+	} {
+		parsed, err := template.New("digest").Parse(t.src)
+		if err != nil {
+			return Message{}, errors.Wrap(err, "parsing digest template")
+		}
+		buf.Reset()
+		if err := parsed.Execute(&buf, data); err != nil {
+			return Message{}, errors.Wrap(err, "rendering digest template")
+		}
+		*t.dst = buf.String()
+	}
+
+	html, err := htmltemplate.New("digest").Parse(digestTemplates.html)
+	if err != nil {
+		return Message{}, errors.Wrap(err, "parsing digest html template")
+	}
+	buf.Reset()
+	if err := html.Execute(&buf, data); err != nil {
+		return Message{}, errors.Wrap(err, "rendering digest html template")
+	}
+	msg.HTML = buf.String()
+
+	return msg, nil
+}
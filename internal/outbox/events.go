@@ -0,0 +1,73 @@
+package outbox
+
+import (
+	// Core packages
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/event"
+
+	// Third-party packages
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// ConsumerEvents is the consumer that records Messages as Events.
+const ConsumerEvents = "events"
+
+// RecordEvents returns the Publisher that records Messages as info Events,
+// where webhooks and notifications pick them up. The Event takes the ID of
+// the Message so a Message relayed twice is recorded once. Events of Station
+// Messages reference their Station unless it has since been purged, in which
+// case its ID is only kept in the Data.
+func RecordEvents(db *sqlx.DB) Publisher {
+	return func(ctx context.Context, m Message) error {
+		ne := event.NewEvent{
+			Id:       m.Id,
+			Type:     m.Type,
+			Severity: event.SeverityInfo,
+			Message:  describe(m),
+			Data:     m.Payload,
+		}
+		if strings.HasPrefix(m.Type, "station.") {
+			var exists bool
+			const q = `SELECT EXISTS (SELECT 1 FROM station WHERE id = $1)`
+			if err := sqlx.GetContext(ctx, db, &exists, q, m.AggregateId); err != nil {
+				return errors.Wrapf(err, "checking station %s", m.AggregateId)
+			}
+			if exists {
+				ne.StationId = &m.AggregateId
+			}
+		}
+
+		_, err := event.Record(ctx, db, ne, m.DateCreated)
+		return err
+	}
+}
+
+// describe writes a Message as a sentence such as `station "Water Station
+// one" was created`. The name is left out when the payload has none.
+func describe(m Message) string {
+	parts := strings.SplitN(m.Type, ".", 2)
+	noun := strings.Replace(parts[0], "_", " ", -1)
+	verb := m.Type
+	if len(parts) == 2 {
+		verb = parts[1]
+	}
+
+	if m.AggregateId == "" {
+		return fmt.Sprintf("%ss were %s", noun, verb)
+	}
+
+	var payload struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(m.Payload, &payload); err == nil && payload.Name != "" {
+		return fmt.Sprintf("%s %q was %s", noun, payload.Name, verb)
+	}
+
+	return fmt.Sprintf("%s %s was %s", noun, m.AggregateId, verb)
+}
@@ -0,0 +1,29 @@
+package outbox
+
+import (
+	// Core packages
+	"time"
+
+	// Third-party packages
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Message is a domain event waiting in the outbox to be relayed. Seq orders
+// Messages in the order their transactions committed. AggregateId is the ID
+// of the record the Message is about.
+type Message struct {
+	Seq         int64          `db:"seq"          json:"seq"`
+	Id          string         `db:"id"           json:"id"`
+	Type        string         `db:"type"         json:"type"`
+	AggregateId string         `db:"aggregate_id" json:"aggregate_id"`
+	Payload     types.JSONText `db:"payload"      json:"payload"`
+	DateCreated time.Time      `db:"date_created" json:"date_created"`
+}
+
+// Consumer is the position of a relay consumer in the outbox. Every Message
+// up to and including Position has been published to it.
+type Consumer struct {
+	Name        string    `db:"name"         json:"name"`
+	Position    int64     `db:"position"     json:"position"`
+	DateUpdated time.Time `db:"date_updated" json:"date_updated"`
+}
@@ -0,0 +1,146 @@
+package outbox
+
+import (
+	// Core packages
+	"context"
+	"encoding/json"
+	"time"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// lockKey is the advisory lock that orders writers of the outbox. Holding
+// it until commit makes Messages commit in the order of their Seq, so a
+// relay never skips a Message that commits after a later one.
+const lockKey = 0x6f7574626f78 // "outbox"
+
+// batchSize is the most Messages published by one call to Relay.
+const batchSize = 100
+
+// Publisher publishes a Message. Messages are published at least once, so a
+// Publisher must cope with seeing a Message again.
+type Publisher func(ctx context.Context, m Message) error
+
+// Add stores a Message in the outbox in the transaction of the change the
+// Message is about. The Message is only kept when the change is, and is not
+// lost should the process stop before it is relayed.
+func Add(ctx context.Context, tx *sqlx.Tx, typ, aggregateID string, payload interface{}, now time.Time) error {
+
+	ctx, span := trace.StartSpan(ctx, "outbox.Add")
+	defer span.End()
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrapf(err, "encoding %s payload", typ)
+	}
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, lockKey); err != nil {
+		return errors.Wrap(err, "locking outbox")
+	}
+
+	const q = `
+		INSERT INTO outbox
+		  (id, type, aggregate_id, payload, date_created)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	_, err = tx.ExecContext(ctx, q,
+		uuid.New().String(),
+		typ,
+		aggregateID,
+		data,
+		now.UTC(),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "inserting %s message", typ)
+	}
+
+	return nil
+}
+
+// Relay publishes the Messages after the position of the named consumer in
+// order, moving the position past every Message that was published. It
+// stops at the first Message that fails to publish so it is retried first
+// on the next call. The consumer row is locked while relaying so running
+// Relay for the same consumer concurrently does not publish out of order.
+// It returns the number of Messages published.
+func Relay(ctx context.Context, db *sqlx.DB, consumer string, publish Publisher, now time.Time) (int, error) {
+
+	ctx, span := trace.StartSpan(ctx, "outbox.Relay")
+	defer span.End()
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	const insert = `
+		INSERT INTO outbox_consumer (name, position, date_updated)
+		VALUES ($1, 0, $2)
+		ON CONFLICT (name) DO NOTHING`
+	if _, err := tx.ExecContext(ctx, insert, consumer, now.UTC()); err != nil {
+		return 0, errors.Wrapf(err, "adding consumer %s", consumer)
+	}
+
+	var position int64
+	const lock = `SELECT position FROM outbox_consumer WHERE name = $1 FOR UPDATE`
+	if err := tx.GetContext(ctx, &position, lock, consumer); err != nil {
+		return 0, errors.Wrapf(err, "locking consumer %s", consumer)
+	}
+
+	var messages []Message
+	const q = `
+		SELECT seq, id, type, aggregate_id, payload, date_created
+		FROM outbox
+		WHERE seq > $1
+		ORDER BY seq
+		LIMIT $2`
+	if err := tx.SelectContext(ctx, &messages, q, position, batchSize); err != nil {
+		return 0, errors.Wrap(err, "selecting outbox messages")
+	}
+
+	published := 0
+	var publishErr error
+	for _, m := range messages {
+		if publishErr = publish(ctx, m); publishErr != nil {
+			publishErr = errors.Wrapf(publishErr, "publishing %s message %d", m.Type, m.Seq)
+			break
+		}
+		position = m.Seq
+		published++
+	}
+
+	if published > 0 {
+		const update = `UPDATE outbox_consumer SET position = $2, date_updated = $3 WHERE name = $1`
+		if _, err := tx.ExecContext(ctx, update, consumer, position, now.UTC()); err != nil {
+			return 0, errors.Wrapf(err, "moving consumer %s", consumer)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrapf(err, "committing consumer %s", consumer)
+	}
+
+	return published, publishErr
+}
+
+// Consumers gets the positions of all relay consumers.
+func Consumers(ctx context.Context, db *sqlx.DB) ([]Consumer, error) {
+
+	ctx, span := trace.StartSpan(ctx, "outbox.Consumers")
+	defer span.End()
+
+	consumers := []Consumer{}
+
+	const q = `SELECT name, position, date_updated FROM outbox_consumer ORDER BY name`
+
+	if err := db.SelectContext(ctx, &consumers, q); err != nil {
+		return nil, errors.Wrap(err, "selecting outbox consumers")
+	}
+
+	return consumers, nil
+}
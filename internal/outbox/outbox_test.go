@@ -0,0 +1,128 @@
+package outbox_test
+
+import (
+	// Core packages
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/event"
+	"github.com/deezone/HydroBytes-BaseStation/internal/outbox"
+	"github.com/deezone/HydroBytes-BaseStation/internal/platform/auth"
+	"github.com/deezone/HydroBytes-BaseStation/internal/schema"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+)
+
+func TestRelay(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	ctx := context.Background()
+	now := time.Date(2021, time.June, 2, 6, 0, 0, 0, time.UTC)
+
+	st, err := station_type.Create(ctx, db, station_type.NewStationType{Name: "Sensor"}, now)
+	if err != nil {
+		t.Fatalf("creating station type: %s", err)
+	}
+	name := "Soil sensor"
+	if err := station_type.Update(ctx, db, st.Id, station_type.UpdateStationType{Name: &name}, now); err != nil {
+		t.Fatalf("updating station type: %s", err)
+	}
+
+	// The second message fails to publish the first time so the relay stops
+	// there and picks it up again on the next call.
+	var published []string
+	fail := true
+	publish := func(ctx context.Context, m outbox.Message) error {
+		if m.Type == station_type.EventStationTypeUpdated && fail {
+			fail = false
+			return errors.New("broker unavailable")
+		}
+		published = append(published, m.Type)
+		return nil
+	}
+
+	if n, err := outbox.Relay(ctx, db, "test", publish, now); err == nil || n != 1 {
+		t.Fatalf("expected 1 message published before the failure, got %v: %v", n, err)
+	}
+	if n, err := outbox.Relay(ctx, db, "test", publish, now); err != nil || n != 1 {
+		t.Fatalf("expected the failed message published on retry, got %v: %v", n, err)
+	}
+	if n, err := outbox.Relay(ctx, db, "test", publish, now); err != nil || n != 0 {
+		t.Fatalf("expected nothing left to publish, got %v: %v", n, err)
+	}
+
+	exp := []string{station_type.EventStationTypeCreated, station_type.EventStationTypeUpdated}
+	if len(published) != len(exp) || published[0] != exp[0] || published[1] != exp[1] {
+		t.Fatalf("expected messages published in order %v, got %v", exp, published)
+	}
+
+	// Consumers keep their own position.
+	for i := 0; i < 2; i++ {
+		if _, err := outbox.Relay(ctx, db, outbox.ConsumerEvents, outbox.RecordEvents(db), now); err != nil {
+			t.Fatalf("relaying to events: %s", err)
+		}
+	}
+	events, err := event.List(ctx, db, event.Filter{Type: station_type.EventStationTypeUpdated})
+	if err != nil {
+		t.Fatalf("listing events: %s", err)
+	}
+	if exp, got := 1, len(events); exp != got {
+		t.Fatalf("expected %v event, got %v", exp, got)
+	}
+	if exp, got := `station type "Soil sensor" was updated`, events[0].Message; exp != got {
+		t.Fatalf("expected message %q, got %q", exp, got)
+	}
+}
+
+// TestRelayPurged ensures the Messages of a purged Station are still recorded
+// as Events rather than stopping the relay.
+func TestRelayPurged(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2021, time.June, 2, 6, 0, 0, 0, time.UTC)
+
+	claims := auth.NewClaims(tests.AdminId, []string{auth.RoleAdmin}, now, time.Hour)
+	ns := station_type.NewStation{Name: "Short lived", LocationX: 7, LocationY: 6}
+	s, err := station_type.AddStation(ctx, db, claims, ns, "5c86bbaa-4ef8-11eb-ae93-0242ac130002", now)
+	if err != nil {
+		t.Fatalf("adding station: %s", err)
+	}
+	if err := station_type.DeleteStation(ctx, db, s.Id, now); err != nil {
+		t.Fatalf("deleting station: %s", err)
+	}
+
+	// The created and deleted Messages are still waiting when the Station is
+	// purged.
+	if _, _, err := station_type.Purge(ctx, db, now.Add(time.Hour), now); err != nil {
+		t.Fatalf("purging: %s", err)
+	}
+
+	n, err := outbox.Relay(ctx, db, outbox.ConsumerEvents, outbox.RecordEvents(db), now)
+	if err != nil {
+		t.Fatalf("relaying to events: %s", err)
+	}
+	if exp := 3; n != exp {
+		t.Fatalf("expected %v messages relayed, got %v", exp, n)
+	}
+
+	events, err := event.List(ctx, db, event.Filter{Type: station_type.EventStationPurged})
+	if err != nil {
+		t.Fatalf("listing events: %s", err)
+	}
+	if exp, got := 1, len(events); exp != got {
+		t.Fatalf("expected %v event, got %v", exp, got)
+	}
+	if events[0].StationId != nil {
+		t.Fatalf("expected the event of a purged station to have no station, got %v", *events[0].StationId)
+	}
+}
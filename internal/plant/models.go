@@ -0,0 +1,85 @@
+package plant
+
+import (
+	// Core packages
+	"time"
+)
+
+// Light needs of a Plant.
+const (
+	LightFullSun      = "full_sun"
+	LightPartialShade = "partial_shade"
+	LightShade        = "shade"
+)
+
+// Plant is a species in the plant catalog. MoistureMin and MoistureMax are the
+// soil moisture range in percent the species grows best in and are the default
+// thresholds of any Station the species is planted at.
+type Plant struct {
+	Id             string    `db:"id"               json:"id"`
+	Species        string    `db:"species"          json:"species"`
+	CommonName     string    `db:"common_name"      json:"common_name"`
+	MoistureMin    int       `db:"moisture_min"     json:"moisture_min"`
+	MoistureMax    int       `db:"moisture_max"     json:"moisture_max"`
+	Light          string    `db:"light"            json:"light"`
+	DaysToMaturity int       `db:"days_to_maturity" json:"days_to_maturity"`
+	DateCreated    time.Time `db:"date_created"     json:"date_created"`
+	DateUpdated    time.Time `db:"date_updated"     json:"date_updated"`
+}
+
+// NewPlant is what we require from clients when adding a Plant.
+type NewPlant struct {
+	Species        string `json:"species" validate:"required"`
+	CommonName     string `json:"common_name"`
+	MoistureMin    int    `json:"moisture_min" validate:"gte=0,lte=100"`
+	MoistureMax    int    `json:"moisture_max" validate:"required,gtefield=MoistureMin,lte=100"`
+	Light          string `json:"light" validate:"required,oneof=full_sun partial_shade shade"`
+	DaysToMaturity int    `json:"days_to_maturity" validate:"gte=0"`
+}
+
+// UpdatePlant defines what information may be provided to modify an existing
+// Plant. All fields are optional so clients can send just the fields they want
+// changed.
+type UpdatePlant struct {
+	Species        *string `json:"species"`
+	CommonName     *string `json:"common_name"`
+	MoistureMin    *int    `json:"moisture_min" validate:"omitempty,gte=0,lte=100"`
+	MoistureMax    *int    `json:"moisture_max" validate:"omitempty,gte=0,lte=100"`
+	Light          *string `json:"light" validate:"omitempty,oneof=full_sun partial_shade shade"`
+	DaysToMaturity *int    `json:"days_to_maturity" validate:"omitempty,gte=0"`
+}
+
+// Planting records a Plant growing at a Station. A Planting is current until
+// DateRemoved is set. MoistureMin and MoistureMax override the moisture range
+// of the Plant for this Planting only.
+type Planting struct {
+	Id          string     `db:"id"           json:"id"`
+	PlantId     string     `db:"plant_id"     json:"plant_id"`
+	StationId   string     `db:"station_id"   json:"station_id"`
+	ZoneId      *string    `db:"zone_id"      json:"zone_id"`
+	MoistureMin *int       `db:"moisture_min" json:"moisture_min,omitempty"`
+	MoistureMax *int       `db:"moisture_max" json:"moisture_max,omitempty"`
+	DatePlanted time.Time  `db:"date_planted" json:"date_planted"`
+	DateRemoved *time.Time `db:"date_removed" json:"date_removed,omitempty"`
+}
+
+// NewPlanting is what we require from clients when planting at a Station. The
+// Planting is placed in the zone of the Station when ZoneId is not given and
+// is planted now when DatePlanted is not given.
+type NewPlanting struct {
+	PlantId     string     `json:"plant_id" validate:"required,uuid"`
+	ZoneId      *string    `json:"zone_id" validate:"omitempty,uuid"`
+	MoistureMin *int       `json:"moisture_min" validate:"omitempty,gte=0,lte=100"`
+	MoistureMax *int       `json:"moisture_max" validate:"omitempty,gte=0,lte=100"`
+	DatePlanted *time.Time `json:"date_planted"`
+}
+
+// Thresholds is the moisture range a Station should be kept in. PlantingId is
+// the current Planting the range comes from.
+type Thresholds struct {
+	StationId   string `db:"station_id"  json:"station_id"`
+	PlantingId  string `db:"planting_id" json:"planting_id"`
+	PlantId     string `db:"plant_id"    json:"plant_id"`
+	MoistureMin int    `db:"moisture_min" json:"moisture_min"`
+	MoistureMax int    `db:"moisture_max" json:"moisture_max"`
+}
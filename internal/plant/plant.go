@@ -0,0 +1,220 @@
+package plant
+
+import (
+	// Core packages
+	"context"
+	"database/sql"
+	"time"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Predefined errors identify expected failure conditions.
+var (
+	// ErrNotFound is used when a specific Plant is requested but does not exist.
+	ErrNotFound = errors.New("plant not found")
+
+	// ErrInvalidID is used when an invalid UUID is provided.
+	ErrInvalidID = errors.New("ID is not in its proper UUID format")
+
+	// ErrInvalidRange is used when a moisture range has its minimum above its
+	// maximum.
+	ErrInvalidRange = errors.New("moisture_min must not be above moisture_max")
+
+	// ErrHasPlantings is used when a Plant can not be deleted because it has
+	// been planted.
+	ErrHasPlantings = errors.New("plant has plantings")
+)
+
+// Create adds a Plant to the catalog. It returns the created Plant with fields
+// like ID and DateCreated populated.
+func Create(ctx context.Context, db *sqlx.DB, np NewPlant, now time.Time) (*Plant, error) {
+
+	ctx, span := trace.StartSpan(ctx, "plant.Create")
+	defer span.End()
+
+	p := Plant{
+		Id:             uuid.New().String(),
+		Species:        np.Species,
+		CommonName:     np.CommonName,
+		MoistureMin:    np.MoistureMin,
+		MoistureMax:    np.MoistureMax,
+		Light:          np.Light,
+		DaysToMaturity: np.DaysToMaturity,
+		DateCreated:    now.UTC(),
+		DateUpdated:    now.UTC(),
+	}
+
+	if p.MoistureMin > p.MoistureMax {
+		return nil, ErrInvalidRange
+	}
+
+	const q = `
+		INSERT INTO plant
+		  (id, species, common_name, moisture_min, moisture_max, light, days_to_maturity, date_created, date_updated)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := db.ExecContext(ctx, q,
+		p.Id,
+		p.Species,
+		p.CommonName,
+		p.MoistureMin,
+		p.MoistureMax,
+		p.Light,
+		p.DaysToMaturity,
+		p.DateCreated,
+		p.DateUpdated,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "inserting plant")
+	}
+
+	return &p, nil
+}
+
+// Delete removes the Plant identified by a given ID from the catalog. A Plant
+// that has ever been planted is kept so the planting history stays complete
+// and ErrHasPlantings is returned.
+func Delete(ctx context.Context, db *sqlx.DB, id string) error {
+
+	ctx, span := trace.StartSpan(ctx, "plant.Delete")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrInvalidID
+	}
+
+	var plantings int
+	const count = `SELECT COUNT(*) FROM planting WHERE plant_id = $1`
+	if err := db.GetContext(ctx, &plantings, count, id); err != nil {
+		return errors.Wrap(err, "counting plantings")
+	}
+	if plantings > 0 {
+		return ErrHasPlantings
+	}
+
+	const q = `DELETE FROM plant WHERE id = $1`
+
+	if _, err := db.ExecContext(ctx, q, id); err != nil {
+		return errors.Wrapf(err, "deleting plant %s", id)
+	}
+
+	return nil
+}
+
+// List gets all Plants in the catalog ordered by species.
+func List(ctx context.Context, db *sqlx.DB) ([]Plant, error) {
+
+	ctx, span := trace.StartSpan(ctx, "plant.List")
+	defer span.End()
+
+	plants := []Plant{}
+
+	const q = `
+		SELECT
+			id, species, common_name, moisture_min, moisture_max, light, days_to_maturity,
+			date_created, date_updated
+		FROM plant
+		ORDER BY species`
+
+	if err := db.SelectContext(ctx, &plants, q); err != nil {
+		return nil, errors.Wrap(err, "selecting plants")
+	}
+
+	return plants, nil
+}
+
+// Get finds the Plant identified by a given ID.
+func Get(ctx context.Context, db *sqlx.DB, id string) (*Plant, error) {
+
+	ctx, span := trace.StartSpan(ctx, "plant.Get")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var p Plant
+
+	const q = `
+		SELECT
+			id, species, common_name, moisture_min, moisture_max, light, days_to_maturity,
+			date_created, date_updated
+		FROM plant
+		WHERE id = $1`
+
+	if err := db.GetContext(ctx, &p, q, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+
+		return nil, errors.Wrap(err, "selecting single plant")
+	}
+
+	return &p, nil
+}
+
+// Update modifies data about a Plant. It will error if the specified ID is
+// invalid or does not reference an existing Plant.
+func Update(ctx context.Context, db *sqlx.DB, id string, update UpdatePlant, now time.Time) error {
+
+	ctx, span := trace.StartSpan(ctx, "plant.Update")
+	defer span.End()
+
+	p, err := Get(ctx, db, id)
+	if err != nil {
+		return err
+	}
+
+	if update.Species != nil {
+		p.Species = *update.Species
+	}
+	if update.CommonName != nil {
+		p.CommonName = *update.CommonName
+	}
+	if update.MoistureMin != nil {
+		p.MoistureMin = *update.MoistureMin
+	}
+	if update.MoistureMax != nil {
+		p.MoistureMax = *update.MoistureMax
+	}
+	if update.Light != nil {
+		p.Light = *update.Light
+	}
+	if update.DaysToMaturity != nil {
+		p.DaysToMaturity = *update.DaysToMaturity
+	}
+	p.DateUpdated = now
+
+	if p.MoistureMin > p.MoistureMax {
+		return ErrInvalidRange
+	}
+
+	const q = `UPDATE plant SET
+		"species" = $2,
+		"common_name" = $3,
+		"moisture_min" = $4,
+		"moisture_max" = $5,
+		"light" = $6,
+		"days_to_maturity" = $7,
+		"date_updated" = $8
+		WHERE id = $1`
+	_, err = db.ExecContext(ctx, q, id,
+		p.Species,
+		p.CommonName,
+		p.MoistureMin,
+		p.MoistureMax,
+		p.Light,
+		p.DaysToMaturity,
+		p.DateUpdated,
+	)
+	if err != nil {
+		return errors.Wrap(err, "updating plant")
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package plant_test
+
+import (
+	// Core packages
+	"context"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/plant"
+	"github.com/deezone/HydroBytes-BaseStation/internal/schema"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+
+	// Third-party packages
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPlant(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	np := plant.NewPlant{
+		Species:        "Capsicum annuum",
+		CommonName:     "Pepper",
+		MoistureMin:    45,
+		MoistureMax:    65,
+		Light:          plant.LightFullSun,
+		DaysToMaturity: 90,
+	}
+	now := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	p0, err := plant.Create(ctx, db, np, now)
+	if err != nil {
+		t.Fatalf("creating plant p0: %s", err)
+	}
+
+	p1, err := plant.Get(ctx, db, p0.Id)
+	if err != nil {
+		t.Fatalf("getting plant p0: %s", err)
+	}
+
+	if diff := cmp.Diff(p1, p0); diff != "" {
+		t.Fatalf("fetched != created:\n%s", diff)
+	}
+
+	update := plant.UpdatePlant{
+		MoistureMin: tests.IntPointer(50),
+		Light:       tests.StringPointer(plant.LightPartialShade),
+	}
+	updatedTime := time.Date(2019, time.January, 1, 1, 1, 1, 0, time.UTC)
+
+	if err := plant.Update(ctx, db, p0.Id, update, updatedTime); err != nil {
+		t.Fatalf("updating plant p0: %s", err)
+	}
+
+	saved, err := plant.Get(ctx, db, p0.Id)
+	if err != nil {
+		t.Fatalf("getting plant p0: %s", err)
+	}
+
+	want := *p0
+	want.MoistureMin = 50
+	want.Light = plant.LightPartialShade
+	want.DateUpdated = updatedTime
+
+	if diff := cmp.Diff(want, *saved); diff != "" {
+		t.Fatalf("updated record did not match:\n%s", diff)
+	}
+
+	// The range can not be inverted.
+	bad := plant.UpdatePlant{MoistureMax: tests.IntPointer(10)}
+	if err := plant.Update(ctx, db, p0.Id, bad, updatedTime); err != plant.ErrInvalidRange {
+		t.Fatalf("updating plant p0 with inverted range: expected %v, got %v", plant.ErrInvalidRange, err)
+	}
+
+	if err := plant.Delete(ctx, db, p0.Id); err != nil {
+		t.Fatalf("deleting plant p0: %s", err)
+	}
+
+	if _, err := plant.Get(ctx, db, p0.Id); err != plant.ErrNotFound {
+		t.Fatalf("getting deleted plant p0: expected %v, got %v", plant.ErrNotFound, err)
+	}
+}
+
+func TestPlanting(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2021, time.May, 1, 0, 0, 0, 0, time.UTC)
+
+	// Plant Station 0001 has tomatoes from the seed data.
+	station := "d58f6d32-6332-11eb-ae93-0242ac130002"
+	tomato := "6e3c1a9d-52b7-4f08-b1d4-7a2e9c0f5b36"
+	basil := "f1a7d3c5-0e94-4b62-8d3f-c59b2e7a1048"
+
+	th, err := plant.StationThresholds(ctx, db, station)
+	if err != nil {
+		t.Fatalf("getting thresholds: %s", err)
+	}
+	if exp, got := (plant.Thresholds{StationId: station, PlantingId: th.PlantingId, PlantId: tomato, MoistureMin: 40, MoistureMax: 70}), *th; exp != got {
+		t.Fatalf("expected thresholds %+v, got %+v", exp, got)
+	}
+
+	// The seeded tomatoes can not be taken out of the catalog.
+	if err := plant.Delete(ctx, db, tomato); err != plant.ErrHasPlantings {
+		t.Fatalf("deleting planted plant: expected %v, got %v", plant.ErrHasPlantings, err)
+	}
+
+	// Replace the tomatoes with basil kept a little wetter than usual.
+	if err := plant.RemovePlanting(ctx, db, th.PlantingId, now); err != nil {
+		t.Fatalf("removing planting: %s", err)
+	}
+	if err := plant.RemovePlanting(ctx, db, th.PlantingId, now); err != plant.ErrPlantingNotFound {
+		t.Fatalf("removing planting twice: expected %v, got %v", plant.ErrPlantingNotFound, err)
+	}
+
+	if _, err := plant.StationThresholds(ctx, db, station); err != plant.ErrPlantingNotFound {
+		t.Fatalf("getting thresholds of empty station: expected %v, got %v", plant.ErrPlantingNotFound, err)
+	}
+
+	np := plant.NewPlanting{PlantId: basil, MoistureMin: tests.IntPointer(60)}
+	pl, err := plant.AddPlanting(ctx, db, station, np, now)
+	if err != nil {
+		t.Fatalf("adding planting: %s", err)
+	}
+
+	// The planting is placed in the zone of the station.
+	if pl.ZoneId == nil || *pl.ZoneId != "8a1f5c2e-7d3b-4e0a-9c61-2b4d8e6f0a13" {
+		t.Fatalf("expected planting in the Tomato row zone, got %v", pl.ZoneId)
+	}
+
+	th, err = plant.StationThresholds(ctx, db, station)
+	if err != nil {
+		t.Fatalf("getting thresholds: %s", err)
+	}
+	if exp, got := (plant.Thresholds{StationId: station, PlantingId: pl.Id, PlantId: basil, MoistureMin: 60, MoistureMax: 70}), *th; exp != got {
+		t.Fatalf("expected thresholds %+v, got %+v", exp, got)
+	}
+
+	history, err := plant.ListPlantings(ctx, db, station)
+	if err != nil {
+		t.Fatalf("listing plantings: %s", err)
+	}
+	if exp, got := 2, len(history); exp != got {
+		t.Fatalf("expected %v plantings, got %v", exp, got)
+	}
+	if history[0].Id != pl.Id {
+		t.Fatalf("expected most recent planting first, got %v", history[0].Id)
+	}
+
+	bad := plant.NewPlanting{PlantId: basil, MoistureMax: tests.IntPointer(10)}
+	if _, err := plant.AddPlanting(ctx, db, station, bad, now); err != plant.ErrInvalidRange {
+		t.Fatalf("adding planting with inverted range: expected %v, got %v", plant.ErrInvalidRange, err)
+	}
+}
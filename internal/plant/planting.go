@@ -0,0 +1,208 @@
+package plant
+
+import (
+	// Core packages
+	"context"
+	"database/sql"
+	"time"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+var (
+	// ErrPlantingNotFound is used when a current Planting is requested but
+	// does not exist.
+	ErrPlantingNotFound = errors.New("planting not found")
+
+	// ErrStationNotFound is used when planting at a Station that does not exist.
+	ErrStationNotFound = errors.New("station not found")
+
+	// ErrZoneNotFound is used when a Planting is placed in a Zone that does not exist.
+	ErrZoneNotFound = errors.New("zone not found")
+)
+
+// AddPlanting plants a Plant at the Station identified by stationID.
+func AddPlanting(ctx context.Context, db *sqlx.DB, stationID string, np NewPlanting, now time.Time) (*Planting, error) {
+
+	ctx, span := trace.StartSpan(ctx, "plant.AddPlanting")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	p, err := Get(ctx, db, np.PlantId)
+	if err != nil {
+		return nil, err
+	}
+
+	// The Station's zone is used unless another one is given.
+	var zoneID *string
+	const station = `SELECT zone_id FROM station WHERE id = $1 AND date_deleted IS NULL`
+	if err := db.GetContext(ctx, &zoneID, station, stationID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrStationNotFound
+		}
+
+		return nil, errors.Wrapf(err, "selecting station %s", stationID)
+	}
+
+	if np.ZoneId != nil {
+		var exists bool
+		const q = `SELECT EXISTS (SELECT 1 FROM zone WHERE id = $1)`
+		if err := db.GetContext(ctx, &exists, q, *np.ZoneId); err != nil {
+			return nil, errors.Wrap(err, "checking zone")
+		}
+		if !exists {
+			return nil, ErrZoneNotFound
+		}
+		zoneID = np.ZoneId
+	}
+
+	pl := Planting{
+		Id:          uuid.New().String(),
+		PlantId:     p.Id,
+		StationId:   stationID,
+		ZoneId:      zoneID,
+		MoistureMin: np.MoistureMin,
+		MoistureMax: np.MoistureMax,
+		DatePlanted: now.UTC(),
+	}
+	if np.DatePlanted != nil {
+		pl.DatePlanted = np.DatePlanted.UTC()
+	}
+
+	if min, max := pl.moisture(*p); min > max {
+		return nil, ErrInvalidRange
+	}
+
+	const q = `
+		INSERT INTO planting
+		  (id, plant_id, station_id, zone_id, moisture_min, moisture_max, date_planted)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err = db.ExecContext(ctx, q,
+		pl.Id,
+		pl.PlantId,
+		pl.StationId,
+		pl.ZoneId,
+		pl.MoistureMin,
+		pl.MoistureMax,
+		pl.DatePlanted,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "inserting planting")
+	}
+
+	return &pl, nil
+}
+
+// RemovePlanting marks a current Planting as removed. The Planting is kept as
+// part of the planting history of its Station.
+func RemovePlanting(ctx context.Context, db *sqlx.DB, id string, now time.Time) error {
+
+	ctx, span := trace.StartSpan(ctx, "plant.RemovePlanting")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrInvalidID
+	}
+
+	const q = `UPDATE planting SET date_removed = $2 WHERE id = $1 AND date_removed IS NULL`
+
+	res, err := db.ExecContext(ctx, q, id, now.UTC())
+	if err != nil {
+		return errors.Wrapf(err, "removing planting %s", id)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "removing planting %s", id)
+	}
+	if n == 0 {
+		return ErrPlantingNotFound
+	}
+
+	return nil
+}
+
+// ListPlantings gets the planting history of a Station, most recently planted
+// first.
+func ListPlantings(ctx context.Context, db *sqlx.DB, stationID string) ([]Planting, error) {
+
+	ctx, span := trace.StartSpan(ctx, "plant.ListPlantings")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	plantings := []Planting{}
+
+	const q = `
+		SELECT
+			id, plant_id, station_id, zone_id, moisture_min, moisture_max, date_planted, date_removed
+		FROM planting
+		WHERE station_id = $1
+		ORDER BY date_planted DESC`
+
+	if err := db.SelectContext(ctx, &plantings, q, stationID); err != nil {
+		return nil, errors.Wrap(err, "selecting plantings")
+	}
+
+	return plantings, nil
+}
+
+// StationThresholds gets the moisture range of a Station. The range comes
+// from the most recently planted current Planting at the Station, falling
+// back to the range of its Plant for any bound the Planting does not override.
+// ErrPlantingNotFound is returned when nothing is planted at the Station.
+func StationThresholds(ctx context.Context, db *sqlx.DB, stationID string) (*Thresholds, error) {
+
+	ctx, span := trace.StartSpan(ctx, "plant.StationThresholds")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var t Thresholds
+
+	const q = `
+		SELECT
+			planting.station_id,
+			planting.id AS planting_id,
+			planting.plant_id,
+			COALESCE(planting.moisture_min, plant.moisture_min) AS moisture_min,
+			COALESCE(planting.moisture_max, plant.moisture_max) AS moisture_max
+		FROM planting
+		  JOIN plant ON plant.id = planting.plant_id
+		WHERE planting.station_id = $1 AND planting.date_removed IS NULL
+		ORDER BY planting.date_planted DESC
+		LIMIT 1`
+
+	if err := db.GetContext(ctx, &t, q, stationID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPlantingNotFound
+		}
+
+		return nil, errors.Wrapf(err, "selecting thresholds of station %s", stationID)
+	}
+
+	return &t, nil
+}
+
+// moisture is the moisture range of the Planting of Plant p.
+func (pl Planting) moisture(p Plant) (int, int) {
+	min, max := p.MoistureMin, p.MoistureMax
+	if pl.MoistureMin != nil {
+		min = *pl.MoistureMin
+	}
+	if pl.MoistureMax != nil {
+		max = *pl.MoistureMax
+	}
+	return min, max
+}
@@ -46,6 +46,28 @@ func Respond(ctx context.Context, w http.ResponseWriter, data interface{}, statu
 	return nil
 }
 
+// RespondContent sends a non JSON document such as an image or a calendar to
+// the client with the provided content type.
+func RespondContent(ctx context.Context, w http.ResponseWriter, content []byte, contentType string, statusCode int) error {
+
+	// Set the status code for the request logger middleware.
+	// If the context is missing this value, request the service
+	// to be shutdown gracefully.
+	v, ok := ctx.Value(KeyValues).(*Values)
+	if !ok {
+		return NewShutdownError("web value missing from context")
+	}
+	v.StatusCode = statusCode
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(statusCode)
+	if _, err := w.Write(content); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // RespondError sends an error reponse back to the client.
 func RespondError(ctx context.Context, w http.ResponseWriter, err error) error {
 
@@ -0,0 +1,342 @@
+package protection
+
+import (
+	// Core packages
+	"context"
+	"fmt"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/command"
+	"github.com/deezone/HydroBytes-BaseStation/internal/event"
+	"github.com/deezone/HydroBytes-BaseStation/internal/reading"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+const (
+	// StaleAfter is how old the latest temperature of a Station may be for it
+	// to be evaluated. Conditions of Stations without a recent temperature are
+	// left as they are.
+	StaleAfter = 3 * time.Hour
+
+	// WaterEvery is how often extra watering is queued while a Condition lasts.
+	WaterEvery = 24 * time.Hour
+)
+
+// candidate is a Station along with its latest temperature.
+type candidate struct {
+	StationId       string    `db:"station_id"`
+	Name            string    `db:"name"`
+	StationTypeId   string    `db:"station_type_id"`
+	StationTypeKind string    `db:"station_type_kind"`
+	Value           float64   `db:"value"`
+	DateRead        time.Time `db:"date_read"`
+}
+
+// target is a valve watering a Station. Valve is empty for every valve of the
+// Station.
+type target struct {
+	StationId string `db:"station_id"`
+	Valve     string `db:"valve"`
+}
+
+// Breached reports whether a temperature is past the limit of a kind of
+// Condition. An active Condition lasts until the temperature is back past the
+// limit by the hysteresis of the Settings.
+func Breached(s Settings, kind string, value float64, active bool) bool {
+	switch kind {
+	case KindFrost:
+		limit := s.FrostBelow
+		if active {
+			limit += s.Hysteresis
+		}
+		return value < limit
+	case KindHeat:
+		limit := s.HeatAbove
+		if active {
+			limit -= s.Hysteresis
+		}
+		return value > limit
+	}
+
+	return false
+}
+
+// response gets what the Settings ask to be done about a kind of Condition.
+func (s Settings) response(kind string) string {
+	if kind == KindFrost {
+		return s.FrostResponse
+	}
+	return s.HeatResponse
+}
+
+// Evaluate checks the latest temperature of every Station against the Settings
+// of its StationType and moves its frost and heat Conditions through pending,
+// active and ended. An Event is raised when a Condition becomes active or
+// ends, and the response of an active Condition is carried out. It returns
+// the Conditions that changed status.
+func Evaluate(ctx context.Context, db *sqlx.DB, now time.Time) ([]Condition, error) {
+
+	ctx, span := trace.StartSpan(ctx, "protection.Evaluate")
+	defer span.End()
+
+	now = now.UTC()
+
+	var stored []Settings
+	if err := db.SelectContext(ctx, &stored, selectSettings); err != nil {
+		return nil, errors.Wrap(err, "selecting protection settings")
+	}
+	settings := make(map[string]Settings, len(stored))
+	for _, s := range stored {
+		settings[s.StationTypeId] = s
+	}
+
+	const q = `
+		SELECT
+			station.id AS station_id,
+			station.name,
+			station.station_type_id,
+			station_type.kind AS station_type_kind,
+			latest.value,
+			latest.date_read
+		FROM station
+		JOIN station_type ON station_type.id = station.station_type_id
+		JOIN LATERAL (
+			SELECT value, date_read FROM reading
+			WHERE reading.station_id = station.id AND reading.sensor = $1
+			ORDER BY date_read DESC
+			LIMIT 1
+		) AS latest ON TRUE
+		WHERE station.date_deleted IS NULL AND latest.date_read >= $2
+		ORDER BY station.id`
+
+	var candidates []candidate
+	if err := db.SelectContext(ctx, &candidates, q, reading.SensorTemperature, now.Add(-StaleAfter)); err != nil {
+		return nil, errors.Wrap(err, "selecting station temperatures")
+	}
+
+	open, err := List(ctx, db, Filter{})
+	if err != nil {
+		return nil, err
+	}
+	conditions := make(map[[2]string]Condition, len(open))
+	for _, c := range open {
+		conditions[[2]string{c.StationId, c.Kind}] = c
+	}
+
+	changed := []Condition{}
+	for _, cand := range candidates {
+		s, ok := settings[cand.StationTypeId]
+		if !ok {
+			s = Default(cand.StationTypeId, cand.StationTypeKind)
+		}
+		hold := time.Duration(s.For) * time.Second
+
+		for _, kind := range []string{KindFrost, KindHeat} {
+			c, isOpen := conditions[[2]string{cand.StationId, kind}]
+			breach := Breached(s, kind, cand.Value, isOpen && c.Status == StatusActive)
+
+			from := c.Status
+			switch {
+			case !isOpen && breach:
+				c = Condition{
+					Id:          uuid.New().String(),
+					StationId:   cand.StationId,
+					Kind:        kind,
+					Status:      StatusPending,
+					Response:    s.response(kind),
+					DateStarted: cand.DateRead,
+				}
+			case isOpen && breach:
+				// The Condition goes on.
+			case isOpen && !breach:
+				if c.Status == StatusPending {
+					// The temperature did not stay past the limit for long
+					// enough so the Condition never happened.
+					if _, err := db.ExecContext(ctx, `DELETE FROM protection_condition WHERE id = $1`, c.Id); err != nil {
+						return nil, errors.Wrap(err, "deleting pending protection condition")
+					}
+					continue
+				}
+				c.Status = StatusEnded
+				c.DateEnded = &now
+			default:
+				continue
+			}
+
+			c.Value = cand.Value
+			c.DateUpdated = now
+			if c.Status == StatusPending && now.Sub(c.DateStarted) >= hold {
+				c.Status = StatusActive
+				c.DateActive = &now
+			}
+
+			if c.Status == StatusActive {
+				if err := respond(ctx, db, &c, s, now); err != nil {
+					return nil, errors.Wrapf(err, "responding to %s at station %s", kind, cand.StationId)
+				}
+			}
+
+			if err := save(ctx, db, c, isOpen); err != nil {
+				return nil, err
+			}
+
+			if c.Status != from && c.Status != StatusPending {
+				if err := raise(ctx, db, cand.Name, c, now); err != nil {
+					return nil, err
+				}
+				changed = append(changed, c)
+			}
+		}
+	}
+
+	return changed, nil
+}
+
+// respond carries out the response of an active Condition. Irrigation is
+// paused by cancelling the watering runs due at the Station and at the valves
+// irrigating it, on every evaluation while the Condition lasts; command.Due
+// holds them back in between. Extra watering
+// is queued on the valves irrigating the Station, or the Station itself when
+// nothing irrigates it, once every WaterEvery.
+func respond(ctx context.Context, db *sqlx.DB, c *Condition, s Settings, now time.Time) error {
+	if c.Response != ResponsePauseIrrigation && c.Response != ResponseExtraWatering {
+		return nil
+	}
+
+	const q = `
+		SELECT station_link.from_station_id AS station_id, station_link.valve
+		FROM station_link
+		JOIN station ON station.id = station_link.from_station_id
+		WHERE station_link.type = 'irrigates' AND station_link.to_station_id = $1 AND station.date_deleted IS NULL
+		ORDER BY station_link.from_station_id`
+
+	var targets []target
+	if err := db.SelectContext(ctx, &targets, q, c.StationId); err != nil {
+		return errors.Wrap(err, "selecting irrigating valves")
+	}
+
+	switch c.Response {
+	case ResponsePauseIrrigation:
+		for _, t := range append([]target{{StationId: c.StationId}}, targets...) {
+			queued, err := command.List(ctx, db, command.Filter{StationId: t.StationId, Status: command.StatusQueued})
+			if err != nil {
+				return err
+			}
+			for _, cmd := range queued {
+				if cmd.Kind != command.KindWater || cmd.DateScheduled.After(now) || (t.Valve != "" && cmd.Valve != t.Valve) {
+					continue
+				}
+				if _, err := command.Cancel(ctx, db, cmd.Id, now); err != nil && err != command.ErrInvalidTransition {
+					return errors.Wrapf(err, "cancelling watering run %s", cmd.Id)
+				}
+				c.DateResponded = &now
+			}
+		}
+
+	case ResponseExtraWatering:
+		if s.ExtraWatering <= 0 || (c.DateResponded != nil && now.Sub(*c.DateResponded) < WaterEvery) {
+			return nil
+		}
+		if len(targets) == 0 {
+			targets = []target{{StationId: c.StationId}}
+		}
+		for _, t := range targets {
+			nc := command.NewCommand{Kind: command.KindWater, Valve: t.Valve, Duration: s.ExtraWatering}
+			if _, err := command.Create(ctx, db, t.StationId, nc, nil, now); err != nil {
+				return errors.Wrap(err, "queueing extra watering run")
+			}
+		}
+		c.DateResponded = &now
+	}
+
+	return nil
+}
+
+// save stores a new Condition or the changes to an open one.
+func save(ctx context.Context, db *sqlx.DB, c Condition, isOpen bool) error {
+	if !isOpen {
+		const q = `
+			INSERT INTO protection_condition
+			  (id, station_id, kind, status, value, response, date_started, date_active, date_ended,
+			   date_responded, date_updated)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+		_, err := db.ExecContext(ctx, q,
+			c.Id,
+			c.StationId,
+			c.Kind,
+			c.Status,
+			c.Value,
+			c.Response,
+			c.DateStarted,
+			c.DateActive,
+			c.DateEnded,
+			c.DateResponded,
+			c.DateUpdated,
+		)
+		if err != nil {
+			return errors.Wrap(err, "inserting protection condition")
+		}
+
+		return nil
+	}
+
+	const q = `UPDATE protection_condition SET
+		"status" = $2,
+		"value" = $3,
+		"date_active" = $4,
+		"date_ended" = $5,
+		"date_responded" = $6,
+		"date_updated" = $7
+		WHERE id = $1`
+
+	_, err := db.ExecContext(ctx, q, c.Id, c.Status, c.Value, c.DateActive, c.DateEnded, c.DateResponded, c.DateUpdated)
+	if err != nil {
+		return errors.Wrap(err, "updating protection condition")
+	}
+
+	return nil
+}
+
+// raise records the Event of a Condition that became active or ended.
+func raise(ctx context.Context, db *sqlx.DB, station string, c Condition, now time.Time) error {
+	what := map[string]string{KindFrost: "Frost", KindHeat: "Heat stress"}[c.Kind]
+
+	var ne event.NewEvent
+	switch c.Status {
+	case StatusActive:
+		ne = event.NewEvent{
+			Type:     map[string]string{KindFrost: EventFrostStarted, KindHeat: EventHeatStarted}[c.Kind],
+			Severity: event.SeverityWarning,
+			Message:  fmt.Sprintf("%s at %s (%.1f°C)", what, station, c.Value),
+		}
+		switch c.Response {
+		case ResponsePauseIrrigation:
+			ne.Message += ", irrigation paused"
+		case ResponseExtraWatering:
+			ne.Message += ", extra watering queued"
+		}
+	case StatusEnded:
+		ne = event.NewEvent{
+			Type:     map[string]string{KindFrost: EventFrostEnded, KindHeat: EventHeatEnded}[c.Kind],
+			Severity: event.SeverityInfo,
+			Message:  fmt.Sprintf("%s at %s ended (%.1f°C)", what, station, c.Value),
+		}
+	default:
+		return nil
+	}
+	ne.StationId = &c.StationId
+	ne.Data = c
+
+	if _, err := event.Record(ctx, db, ne, now); err != nil {
+		return errors.Wrap(err, "recording protection event")
+	}
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package protection_test
+
+import (
+	// Core packages
+	"context"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/command"
+	"github.com/deezone/HydroBytes-BaseStation/internal/event"
+	"github.com/deezone/HydroBytes-BaseStation/internal/protection"
+	"github.com/deezone/HydroBytes-BaseStation/internal/reading"
+	"github.com/deezone/HydroBytes-BaseStation/internal/schema"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+
+	// Third-party packages
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	waterStation = "ee72a90c-590c-11eb-ae93-0242ac130002"
+	plantOne     = "d58f6d32-6332-11eb-ae93-0242ac130002"
+	plantTwo     = "27356858-6333-11eb-ae93-0242ac130002"
+	plantType    = "5c86bbaa-4ef8-11eb-ae93-0242ac130002"
+)
+
+func TestBreached(t *testing.T) {
+	s := protection.Settings{FrostBelow: 2, HeatAbove: 32, Hysteresis: 1}
+
+	tests := []struct {
+		name   string
+		kind   string
+		value  float64
+		active bool
+		exp    bool
+	}{
+		{"below frost limit", protection.KindFrost, 1.5, false, true},
+		{"at frost limit", protection.KindFrost, 2, false, false},
+		{"thawing within hysteresis", protection.KindFrost, 2.5, true, true},
+		{"thawed past hysteresis", protection.KindFrost, 3, true, false},
+		{"above heat limit", protection.KindHeat, 33, false, true},
+		{"cooling within hysteresis", protection.KindHeat, 31.5, true, true},
+		{"cooled past hysteresis", protection.KindHeat, 31, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := protection.Breached(s, tt.kind, tt.value, tt.active); tt.exp != got {
+				t.Fatalf("expected breached %v, got %v", tt.exp, got)
+			}
+		})
+	}
+}
+
+func TestDefault(t *testing.T) {
+	plant := protection.Default(plantType, station_type.KindPlant)
+	if plant.FrostResponse != protection.ResponsePauseIrrigation || plant.HeatResponse != protection.ResponseExtraWatering {
+		t.Errorf("expected plant stations to pause irrigation in frost and get extra water in heat, got %+v", plant)
+	}
+	if !plant.Default || plant.StationTypeId != plantType {
+		t.Errorf("expected default settings of the station type, got %+v", plant)
+	}
+
+	other := protection.Default(plantType, station_type.KindOther)
+	if other.FrostResponse != protection.ResponseNone || other.HeatResponse != protection.ResponseNone {
+		t.Errorf("expected unknown station types to only report, got %+v", other)
+	}
+}
+
+// temperatures records temperature readings of a Station read at the times.
+func temperatures(t *testing.T, db *sqlx.DB, station string, values map[time.Time]float64, now time.Time) {
+	t.Helper()
+
+	var nr reading.NewReadings
+	for read, v := range values {
+		read := read
+		nr.Readings = append(nr.Readings, reading.NewReading{Sensor: reading.SensorTemperature, Value: v, DateRead: &read})
+	}
+	if _, err := reading.Record(context.Background(), db, station, nr, now); err != nil {
+		t.Fatalf("recording temperatures: %s", err)
+	}
+}
+
+func TestEvaluateFrost(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2021, time.January, 12, 6, 0, 0, 0, time.UTC)
+
+	run, err := command.Create(ctx, db, waterStation, command.NewCommand{Kind: command.KindWater, Valve: "zone-2", Duration: 300}, nil, now)
+	if err != nil {
+		t.Fatalf("queueing watering run: %s", err)
+	}
+
+	// A short dip below the limit is pending only.
+	temperatures(t, db, plantOne, map[time.Time]float64{now.Add(-10 * time.Minute): 1.5}, now)
+
+	changed, err := protection.Evaluate(ctx, db, now)
+	if err != nil {
+		t.Fatalf("evaluating: %s", err)
+	}
+	if exp, got := 0, len(changed); exp != got {
+		t.Fatalf("expected %v changed conditions, got %v", exp, got)
+	}
+
+	later := now.Add(30 * time.Minute)
+	temperatures(t, db, plantOne, map[time.Time]float64{later: 0.5}, later)
+
+	changed, err = protection.Evaluate(ctx, db, later)
+	if err != nil {
+		t.Fatalf("evaluating: %s", err)
+	}
+	if exp, got := 1, len(changed); exp != got {
+		t.Fatalf("expected %v changed condition, got %v", exp, got)
+	}
+	if c := changed[0]; c.Kind != protection.KindFrost || c.Status != protection.StatusActive || c.StationId != plantOne {
+		t.Fatalf("expected frost at plant station one, got %+v", c)
+	}
+
+	// The watering run of the valve irrigating the station is cancelled.
+	cmd, err := command.Get(ctx, db, run.Id)
+	if err != nil {
+		t.Fatalf("getting watering run: %s", err)
+	}
+	if exp, got := command.StatusCancelled, cmd.Status; exp != got {
+		t.Fatalf("expected watering run %s, got %s", exp, got)
+	}
+
+	// Runs queued while the frost lasts are held back from the valve
+	// irrigating the station, other valves still water.
+	held, err := command.Create(ctx, db, waterStation, command.NewCommand{Kind: command.KindWater, Valve: "zone-2", Duration: 300}, nil, later)
+	if err != nil {
+		t.Fatalf("queueing watering run: %s", err)
+	}
+	other, err := command.Create(ctx, db, waterStation, command.NewCommand{Kind: command.KindWater, Valve: "zone-1", Duration: 300}, nil, later)
+	if err != nil {
+		t.Fatalf("queueing watering run: %s", err)
+	}
+	due, err := command.Due(ctx, db, waterStation, later)
+	if err != nil {
+		t.Fatalf("getting due commands: %s", err)
+	}
+	if len(due) != 1 || due[0].Id != other.Id {
+		t.Fatalf("expected only the zone-1 run %s to be due, got %+v", other.Id, due)
+	}
+
+	events, err := event.List(ctx, db, event.Filter{Type: protection.EventFrostStarted})
+	if err != nil {
+		t.Fatalf("listing events: %s", err)
+	}
+	if exp, got := 1, len(events); exp != got {
+		t.Fatalf("expected %v frost event, got %v", exp, got)
+	}
+
+	// Frost ends once the temperature is past the limit by the hysteresis.
+	thaw := later.Add(time.Hour)
+	temperatures(t, db, plantOne, map[time.Time]float64{thaw.Add(-time.Minute): 2.5, thaw: 3.5}, thaw)
+
+	changed, err = protection.Evaluate(ctx, db, thaw)
+	if err != nil {
+		t.Fatalf("evaluating: %s", err)
+	}
+	if len(changed) != 1 || changed[0].Status != protection.StatusEnded {
+		t.Fatalf("expected frost to end, got %+v", changed)
+	}
+
+	// The held run is due again once the frost has ended.
+	due, err = command.Due(ctx, db, waterStation, thaw)
+	if err != nil {
+		t.Fatalf("getting due commands: %s", err)
+	}
+	if len(due) != 2 || (due[0].Id != held.Id && due[1].Id != held.Id) {
+		t.Fatalf("expected the zone-2 run %s to be due after the frost, got %+v", held.Id, due)
+	}
+}
+
+func TestEvaluateHeat(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2021, time.July, 20, 14, 0, 0, 0, time.UTC)
+
+	temperatures(t, db, plantTwo, map[time.Time]float64{now.Add(-40 * time.Minute): 34}, now)
+
+	changed, err := protection.Evaluate(ctx, db, now)
+	if err != nil {
+		t.Fatalf("evaluating: %s", err)
+	}
+	if len(changed) != 1 || changed[0].Kind != protection.KindHeat {
+		t.Fatalf("expected heat at plant station two, got %+v", changed)
+	}
+
+	queued, err := command.List(ctx, db, command.Filter{StationId: waterStation, Status: command.StatusQueued})
+	if err != nil {
+		t.Fatalf("listing commands: %s", err)
+	}
+	if len(queued) != 1 || queued[0].Valve != "zone-2" || queued[0].Duration != 600 {
+		t.Fatalf("expected a 600 second extra watering run on zone-2, got %+v", queued)
+	}
+
+	// Extra watering is only queued once a day.
+	if _, err := protection.Evaluate(ctx, db, now.Add(time.Hour)); err != nil {
+		t.Fatalf("evaluating: %s", err)
+	}
+	queued, err = command.List(ctx, db, command.Filter{StationId: waterStation, Status: command.StatusQueued})
+	if err != nil {
+		t.Fatalf("listing commands: %s", err)
+	}
+	if exp, got := 1, len(queued); exp != got {
+		t.Fatalf("expected %v extra watering run, got %v", exp, got)
+	}
+
+	// The settings of the station type replace the defaults.
+	none := protection.ResponseNone
+	s, err := protection.Update(ctx, db, plantType, protection.UpdateSettings{HeatResponse: &none}, now)
+	if err != nil {
+		t.Fatalf("updating settings: %s", err)
+	}
+	if s.Default || s.HeatAbove != 32 {
+		t.Fatalf("expected stored settings keeping the default heat limit, got %+v", s)
+	}
+
+	tooHot := 1.0
+	if _, err := protection.Update(ctx, db, plantType, protection.UpdateSettings{HeatAbove: &tooHot}, now); err != protection.ErrInvalidSettings {
+		t.Fatalf("expected %v, got %v", protection.ErrInvalidSettings, err)
+	}
+}
@@ -0,0 +1,99 @@
+package protection
+
+import (
+	// Core packages
+	"time"
+)
+
+// Kinds of Condition.
+const (
+	KindFrost = "frost"
+	KindHeat  = "heat"
+)
+
+// Types of the Events raised as Conditions start and end.
+const (
+	EventFrostStarted = "frost.started"
+	EventFrostEnded   = "frost.ended"
+	EventHeatStarted  = "heat.started"
+	EventHeatEnded    = "heat.ended"
+)
+
+// Responses to a Condition. Besides raising its Events a Condition may pause
+// irrigation by cancelling the queued watering runs of the Station and of the
+// valves irrigating it, or queue extra watering runs.
+const (
+	ResponseNone            = "none"
+	ResponsePauseIrrigation = "pause_irrigation"
+	ResponseExtraWatering   = "extra_watering"
+)
+
+// Statuses of a Condition. A Condition is pending until the temperature has
+// been past its limit for long enough, then active until it ends.
+const (
+	StatusPending = "pending"
+	StatusActive  = "active"
+	StatusEnded   = "ended"
+)
+
+// Settings define when the Stations of a StationType are in frost or heat
+// stress and how the base station responds. Temperatures are in °C.
+//
+// Frost starts when the latest temperature of a Station has been below
+// FrostBelow for at least For seconds and ends once it is back above
+// FrostBelow by Hysteresis. Heat works the same way above HeatAbove.
+// ExtraWatering is how many seconds an extra watering run lasts. It is queued
+// once a day while the Condition lasts.
+//
+// Default is set when the StationType has no Settings of its own and the
+// defaults of its name are used.
+type Settings struct {
+	StationTypeId string     `db:"station_type_id" json:"station_type_id"`
+	FrostBelow    float64    `db:"frost_below"     json:"frost_below"`
+	FrostResponse string     `db:"frost_response"  json:"frost_response"`
+	HeatAbove     float64    `db:"heat_above"      json:"heat_above"`
+	HeatResponse  string     `db:"heat_response"   json:"heat_response"`
+	ExtraWatering int        `db:"extra_watering"  json:"extra_watering"`
+	For           int        `db:"for_seconds"     json:"for"`
+	Hysteresis    float64    `db:"hysteresis"      json:"hysteresis"`
+	Default       bool       `db:"-"               json:"default"`
+	DateUpdated   *time.Time `db:"date_updated"    json:"date_updated,omitempty"`
+}
+
+// UpdateSettings defines what information may be provided to modify the
+// Settings of a StationType. All fields are optional so clients can send just
+// the fields they want changed.
+type UpdateSettings struct {
+	FrostBelow    *float64 `json:"frost_below"`
+	FrostResponse *string  `json:"frost_response" validate:"omitempty,oneof=none pause_irrigation extra_watering"`
+	HeatAbove     *float64 `json:"heat_above"`
+	HeatResponse  *string  `json:"heat_response" validate:"omitempty,oneof=none pause_irrigation extra_watering"`
+	ExtraWatering *int     `json:"extra_watering" validate:"omitempty,gte=0"`
+	For           *int     `json:"for" validate:"omitempty,gte=0"`
+	Hysteresis    *float64 `json:"hysteresis" validate:"omitempty,gte=0"`
+}
+
+// Condition is frost or heat stress at a Station. Value is the latest
+// temperature of the Station. DateResponded is when the Response of the
+// Settings was last carried out. Only one pending or active Condition of each
+// kind exists per Station.
+type Condition struct {
+	Id            string     `db:"id"             json:"id"`
+	StationId     string     `db:"station_id"     json:"station_id"`
+	Kind          string     `db:"kind"           json:"kind"`
+	Status        string     `db:"status"         json:"status"`
+	Value         float64    `db:"value"          json:"value"`
+	Response      string     `db:"response"       json:"response"`
+	DateStarted   time.Time  `db:"date_started"   json:"date_started"`
+	DateActive    *time.Time `db:"date_active"    json:"date_active,omitempty"`
+	DateEnded     *time.Time `db:"date_ended"     json:"date_ended,omitempty"`
+	DateResponded *time.Time `db:"date_responded" json:"date_responded,omitempty"`
+	DateUpdated   time.Time  `db:"date_updated"   json:"date_updated"`
+}
+
+// Filter limits the Conditions returned by List. The zero value returns every
+// pending and active Condition.
+type Filter struct {
+	Status    string
+	StationId string
+}
@@ -0,0 +1,246 @@
+package protection
+
+import (
+	// Core packages
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Predefined errors identify expected failure conditions.
+var (
+	// ErrInvalidID is used when an invalid UUID is provided.
+	ErrInvalidID = errors.New("ID is not in its proper UUID format")
+
+	// ErrStationTypeNotFound is used when the Settings of a StationType that
+	// does not exist are requested.
+	ErrStationTypeNotFound = errors.New("station type not found")
+
+	// ErrInvalidSettings is used when frost would start above the temperature
+	// heat starts at.
+	ErrInvalidSettings = errors.New("frost_below must be lower than heat_above")
+)
+
+// Defaults are the Settings of the StationTypes without Settings of their own,
+// by the kind of station type. Plants are kept dry in frost and given extra
+// water in a heat wave, the pipes of water stations are kept empty in frost
+// and the base station only reports.
+var Defaults = map[string]Settings{
+	station_type.KindBase: {
+		FrostBelow:    -5,
+		FrostResponse: ResponseNone,
+		HeatAbove:     40,
+		HeatResponse:  ResponseNone,
+		For:           1800,
+		Hysteresis:    1,
+	},
+	station_type.KindWater: {
+		FrostBelow:    2,
+		FrostResponse: ResponsePauseIrrigation,
+		HeatAbove:     35,
+		HeatResponse:  ResponseNone,
+		For:           1800,
+		Hysteresis:    1,
+	},
+	station_type.KindPlant: {
+		FrostBelow:    2,
+		FrostResponse: ResponsePauseIrrigation,
+		HeatAbove:     32,
+		HeatResponse:  ResponseExtraWatering,
+		ExtraWatering: 600,
+		For:           1800,
+		Hysteresis:    1,
+	},
+}
+
+// DefaultSettings are the Settings of StationTypes without Settings of their
+// own and without Defaults for their kind.
+var DefaultSettings = Settings{
+	FrostBelow:    2,
+	FrostResponse: ResponseNone,
+	HeatAbove:     35,
+	HeatResponse:  ResponseNone,
+	For:           1800,
+	Hysteresis:    1,
+}
+
+// Default gets the default Settings of a StationType by its kind.
+func Default(stationTypeID, kind string) Settings {
+	s, ok := Defaults[kind]
+	if !ok {
+		s = DefaultSettings
+	}
+	s.StationTypeId = stationTypeID
+	s.Default = true
+
+	return s
+}
+
+// selectSettings selects the Settings of StationTypes.
+const selectSettings = `
+	SELECT
+		station_type_id, frost_below, frost_response, heat_above, heat_response, extra_watering,
+		for_seconds, hysteresis, date_updated
+	FROM protection_setting`
+
+// GetSettings gets the Settings of the StationType identified by a given ID,
+// or its defaults when it has no Settings of its own.
+func GetSettings(ctx context.Context, db *sqlx.DB, stationTypeID string) (*Settings, error) {
+
+	ctx, span := trace.StartSpan(ctx, "protection.GetSettings")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationTypeID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var kind string
+	if err := db.GetContext(ctx, &kind, `SELECT kind FROM station_type WHERE id = $1`, stationTypeID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrStationTypeNotFound
+		}
+
+		return nil, errors.Wrap(err, "selecting station type")
+	}
+
+	var s Settings
+	if err := db.GetContext(ctx, &s, selectSettings+` WHERE station_type_id = $1`, stationTypeID); err != nil {
+		if err == sql.ErrNoRows {
+			s = Default(stationTypeID, kind)
+			return &s, nil
+		}
+
+		return nil, errors.Wrap(err, "selecting protection settings")
+	}
+
+	return &s, nil
+}
+
+// Update modifies the Settings of the StationType identified by a given ID.
+// Fields that are not given keep their current, possibly default, value.
+func Update(ctx context.Context, db *sqlx.DB, stationTypeID string, us UpdateSettings, now time.Time) (*Settings, error) {
+
+	ctx, span := trace.StartSpan(ctx, "protection.Update")
+	defer span.End()
+
+	s, err := GetSettings(ctx, db, stationTypeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if us.FrostBelow != nil {
+		s.FrostBelow = *us.FrostBelow
+	}
+	if us.FrostResponse != nil {
+		s.FrostResponse = *us.FrostResponse
+	}
+	if us.HeatAbove != nil {
+		s.HeatAbove = *us.HeatAbove
+	}
+	if us.HeatResponse != nil {
+		s.HeatResponse = *us.HeatResponse
+	}
+	if us.ExtraWatering != nil {
+		s.ExtraWatering = *us.ExtraWatering
+	}
+	if us.For != nil {
+		s.For = *us.For
+	}
+	if us.Hysteresis != nil {
+		s.Hysteresis = *us.Hysteresis
+	}
+	if s.FrostBelow >= s.HeatAbove {
+		return nil, ErrInvalidSettings
+	}
+
+	updated := now.UTC()
+	s.Default = false
+	s.DateUpdated = &updated
+
+	const q = `
+		INSERT INTO protection_setting
+		  (station_type_id, frost_below, frost_response, heat_above, heat_response, extra_watering,
+		   for_seconds, hysteresis, date_updated)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (station_type_id) DO UPDATE SET
+			frost_below = EXCLUDED.frost_below,
+			frost_response = EXCLUDED.frost_response,
+			heat_above = EXCLUDED.heat_above,
+			heat_response = EXCLUDED.heat_response,
+			extra_watering = EXCLUDED.extra_watering,
+			for_seconds = EXCLUDED.for_seconds,
+			hysteresis = EXCLUDED.hysteresis,
+			date_updated = EXCLUDED.date_updated`
+
+	_, err = db.ExecContext(ctx, q,
+		s.StationTypeId,
+		s.FrostBelow,
+		s.FrostResponse,
+		s.HeatAbove,
+		s.HeatResponse,
+		s.ExtraWatering,
+		s.For,
+		s.Hysteresis,
+		s.DateUpdated,
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "saving protection settings of station type %s", stationTypeID)
+	}
+
+	return s, nil
+}
+
+// selectCondition selects Conditions.
+const selectCondition = `
+	SELECT
+		id, station_id, kind, status, value, response, date_started, date_active, date_ended,
+		date_responded, date_updated
+	FROM protection_condition`
+
+// List gets the Conditions matching the Filter, most recently started first.
+func List(ctx context.Context, db *sqlx.DB, filter Filter) ([]Condition, error) {
+
+	ctx, span := trace.StartSpan(ctx, "protection.List")
+	defer span.End()
+
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	var where []string
+	if filter.Status != "" {
+		where = append(where, "status = "+arg(filter.Status))
+	} else {
+		where = append(where, "status IN ('pending', 'active')")
+	}
+	if filter.StationId != "" {
+		if _, err := uuid.Parse(filter.StationId); err != nil {
+			return nil, ErrInvalidID
+		}
+		where = append(where, "station_id = "+arg(filter.StationId))
+	}
+
+	q := selectCondition + `
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY date_started DESC`
+
+	conditions := []Condition{}
+	if err := db.SelectContext(ctx, &conditions, q, args...); err != nil {
+		return nil, errors.Wrap(err, "selecting protection conditions")
+	}
+
+	return conditions, nil
+}
@@ -0,0 +1,58 @@
+package reading
+
+import (
+	// Core packages
+	"time"
+)
+
+// Sensors reported by the stations. Moisture and reservoir level are
+// percentages, temperature is in degrees Celsius and light in lux.
+const (
+	SensorMoisture       = "moisture"
+	SensorTemperature    = "temperature"
+	SensorLight          = "light"
+	SensorReservoirLevel = "reservoir_level"
+)
+
+// Reading is a value measured by a sensor of a Station.
+type Reading struct {
+	Id           string    `db:"id"            json:"id"`
+	StationId    string    `db:"station_id"    json:"station_id"`
+	Sensor       string    `db:"sensor"        json:"sensor"`
+	Value        float64   `db:"value"         json:"value"`
+	DateRead     time.Time `db:"date_read"     json:"date_read"`
+	DateReceived time.Time `db:"date_received" json:"date_received"`
+}
+
+// NewReading is a sensor value sent by a Station. DateRead defaults to the
+// time the reading is received.
+type NewReading struct {
+	Sensor   string     `json:"sensor" validate:"required"`
+	Value    float64    `json:"value"`
+	DateRead *time.Time `json:"date_read"`
+}
+
+// NewReadings is a batch of sensor values sent by a Station.
+type NewReadings struct {
+	Readings []NewReading `json:"readings" validate:"required,dive"`
+}
+
+// Query limits the Readings returned by List. Sensor returns Readings of that
+// sensor only. Since and Until limit the Readings to those read in the time
+// window.
+type Query struct {
+	Sensor string
+	Since  *time.Time
+	Until  *time.Time
+}
+
+// Aggregate summarizes the Readings of one sensor across the Stations of a
+// zone.
+type Aggregate struct {
+	Sensor   string  `db:"sensor"   json:"sensor"`
+	Stations int     `db:"stations" json:"stations"`
+	Count    int     `db:"count"    json:"count"`
+	Min      float64 `db:"min"      json:"min"`
+	Avg      float64 `db:"avg"      json:"avg"`
+	Max      float64 `db:"max"      json:"max"`
+}
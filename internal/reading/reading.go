@@ -0,0 +1,208 @@
+package reading
+
+import (
+	// Core packages
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Predefined errors identify expected failure conditions.
+var (
+	// ErrNotFound is used when the latest Reading of a sensor is requested but
+	// the sensor has not reported yet.
+	ErrNotFound = errors.New("reading not found")
+
+	// ErrInvalidID is used when an invalid UUID is provided.
+	ErrInvalidID = errors.New("ID is not in its proper UUID format")
+)
+
+// Record stores sensor values sent by a Station. It returns the stored
+// Readings.
+func Record(ctx context.Context, db *sqlx.DB, stationID string, nr NewReadings, now time.Time) ([]Reading, error) {
+
+	ctx, span := trace.StartSpan(ctx, "reading.Record")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	const q = `
+		INSERT INTO reading
+		  (id, station_id, sensor, value, date_read, date_received)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	readings := make([]Reading, 0, len(nr.Readings))
+	for _, r := range nr.Readings {
+		rd := Reading{
+			Id:           uuid.New().String(),
+			StationId:    stationID,
+			Sensor:       r.Sensor,
+			Value:        r.Value,
+			DateRead:     now.UTC(),
+			DateReceived: now.UTC(),
+		}
+		if r.DateRead != nil {
+			rd.DateRead = r.DateRead.UTC()
+		}
+
+		_, err := tx.ExecContext(ctx, q,
+			rd.Id,
+			rd.StationId,
+			rd.Sensor,
+			rd.Value,
+			rd.DateRead,
+			rd.DateReceived,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "inserting reading")
+		}
+
+		readings = append(readings, rd)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "committing readings")
+	}
+
+	return readings, nil
+}
+
+// List gets the Readings of a Station matching the Query, oldest first.
+func List(ctx context.Context, db *sqlx.DB, stationID string, q Query) ([]Reading, error) {
+
+	ctx, span := trace.StartSpan(ctx, "reading.List")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := []string{"station_id = " + arg(stationID)}
+	if q.Sensor != "" {
+		where = append(where, "sensor = "+arg(q.Sensor))
+	}
+	if q.Since != nil {
+		where = append(where, "date_read >= "+arg(q.Since.UTC()))
+	}
+	if q.Until != nil {
+		where = append(where, "date_read < "+arg(q.Until.UTC()))
+	}
+
+	readings := []Reading{}
+
+	query := `
+		SELECT id, station_id, sensor, value, date_read, date_received
+		FROM reading
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY date_read`
+
+	if err := db.SelectContext(ctx, &readings, query, args...); err != nil {
+		return nil, errors.Wrap(err, "selecting readings")
+	}
+
+	return readings, nil
+}
+
+// ZoneAggregates gives the minimum, average and maximum of each sensor over
+// the Readings of the active Stations in a zone matching the Query. Stations
+// belong to the zone through their zone_id.
+func ZoneAggregates(ctx context.Context, db *sqlx.DB, zoneID string, q Query) ([]Aggregate, error) {
+
+	ctx, span := trace.StartSpan(ctx, "reading.ZoneAggregates")
+	defer span.End()
+
+	if _, err := uuid.Parse(zoneID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := []string{"station.zone_id = " + arg(zoneID), "station.date_deleted IS NULL"}
+	if q.Sensor != "" {
+		where = append(where, "reading.sensor = "+arg(q.Sensor))
+	}
+	if q.Since != nil {
+		where = append(where, "reading.date_read >= "+arg(q.Since.UTC()))
+	}
+	if q.Until != nil {
+		where = append(where, "reading.date_read < "+arg(q.Until.UTC()))
+	}
+
+	aggregates := []Aggregate{}
+
+	query := `
+		SELECT
+			reading.sensor,
+			COUNT(DISTINCT reading.station_id) AS stations,
+			COUNT(*) AS count,
+			MIN(reading.value) AS min,
+			AVG(reading.value) AS avg,
+			MAX(reading.value) AS max
+		FROM reading
+		  JOIN station ON station.id = reading.station_id
+		WHERE ` + strings.Join(where, " AND ") + `
+		GROUP BY reading.sensor
+		ORDER BY reading.sensor`
+
+	if err := db.SelectContext(ctx, &aggregates, query, args...); err != nil {
+		return nil, errors.Wrap(err, "selecting zone reading aggregates")
+	}
+
+	return aggregates, nil
+}
+
+// Latest gets the most recent Reading of a sensor of a Station.
+func Latest(ctx context.Context, db *sqlx.DB, stationID, sensor string) (*Reading, error) {
+
+	ctx, span := trace.StartSpan(ctx, "reading.Latest")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var r Reading
+
+	const q = `
+		SELECT id, station_id, sensor, value, date_read, date_received
+		FROM reading
+		WHERE station_id = $1 AND sensor = $2
+		ORDER BY date_read DESC
+		LIMIT 1`
+
+	if err := db.GetContext(ctx, &r, q, stationID, sensor); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+
+		return nil, errors.Wrap(err, "selecting latest reading")
+	}
+
+	return &r, nil
+}
@@ -0,0 +1,72 @@
+package report
+
+import (
+	// Core packages
+	"time"
+)
+
+// Weekly is the report of the garden for the week starting on From, a Monday,
+// and ending before To. Week is the ISO 8601 week of the report such as
+// "2021-W23".
+type Weekly struct {
+	Week           string            `json:"week"`
+	From           time.Time         `json:"from"`
+	To             time.Time         `json:"to"`
+	Water          []ZoneWater       `json:"water"`
+	Moisture       []StationMoisture `json:"moisture"`
+	Alerts         []FiredAlert      `json:"alerts"`
+	Offline        []OfflineStation  `json:"offline"`
+	FailedCommands []FailedCommand   `json:"failed_commands"`
+	DateGenerated  time.Time         `json:"date_generated"`
+}
+
+// ZoneWater is the water used in a Zone. ZoneId is empty for the water that
+// was not counted towards a Zone.
+type ZoneWater struct {
+	ZoneId string  `json:"zone_id,omitempty"`
+	Zone   string  `json:"zone"`
+	Litres float64 `json:"litres"`
+}
+
+// StationMoisture is the lowest and highest moisture read at a plant Station.
+// Min and Max are nil when the Station sent no moisture readings.
+type StationMoisture struct {
+	StationId string   `db:"station_id" json:"station_id"`
+	Station   string   `db:"station"    json:"station"`
+	Min       *float64 `db:"min"        json:"min"`
+	Max       *float64 `db:"max"        json:"max"`
+	Readings  int      `db:"readings"   json:"readings"`
+}
+
+// FiredAlert is an Alert that fired during the week.
+type FiredAlert struct {
+	AlertId      string     `db:"alert_id"      json:"alert_id"`
+	Rule         string     `db:"rule"          json:"rule"`
+	Severity     string     `db:"severity"      json:"severity"`
+	StationId    string     `db:"station_id"    json:"station_id"`
+	Station      string     `db:"station"       json:"station"`
+	Value        float64    `db:"value"         json:"value"`
+	DateFiring   time.Time  `db:"date_firing"   json:"date_firing"`
+	DateResolved *time.Time `db:"date_resolved" json:"date_resolved,omitempty"`
+}
+
+// OfflineStation is a Station that offline alerts fired for during the week.
+// Times is how many alerts fired and Seconds how long the Station was offline
+// within the week.
+type OfflineStation struct {
+	StationId string  `db:"station_id" json:"station_id"`
+	Station   string  `db:"station"    json:"station"`
+	Times     int     `db:"times"      json:"times"`
+	Seconds   float64 `db:"seconds"    json:"seconds"`
+}
+
+// FailedCommand is a Command that a Station reported failed during the week.
+type FailedCommand struct {
+	CommandId    string    `db:"command_id"    json:"command_id"`
+	StationId    string    `db:"station_id"    json:"station_id"`
+	Station      string    `db:"station"       json:"station"`
+	Kind         string    `db:"kind"          json:"kind"`
+	Valve        string    `db:"valve"         json:"valve,omitempty"`
+	Error        string    `db:"error"         json:"error,omitempty"`
+	DateFinished time.Time `db:"date_finished" json:"date_finished"`
+}
@@ -0,0 +1,186 @@
+package report
+
+import (
+	// Core packages
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+	"time"
+
+	// Third-party packages
+	"github.com/pkg/errors"
+)
+
+// funcs are the functions shared by the Markdown and HTML templates.
+var funcs = map[string]interface{}{
+	"litres": func(l float64) string {
+		return fmt.Sprintf("%.1f", l)
+	},
+	"value": func(v *float64) string {
+		if v == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%.1f", *v)
+	},
+	"when": func(t time.Time) string {
+		return t.UTC().Format("Mon 2 Jan 15:04")
+	},
+	"resolved": func(t *time.Time) string {
+		if t == nil {
+			return "still firing"
+		}
+		return t.UTC().Format("Mon 2 Jan 15:04")
+	},
+	"duration": func(seconds float64) string {
+		return (time.Duration(seconds) * time.Second).Round(time.Minute).String()
+	},
+	"day": func(t time.Time) string {
+		return t.UTC().Format("Mon 2 Jan 2006")
+	},
+	"last": func(t time.Time) string {
+		return t.UTC().AddDate(0, 0, -1).Format("Mon 2 Jan 2006")
+	},
+}
+
+// cell escapes text for a Markdown table cell.
+var cell = strings.NewReplacer("|", `\|`, "\r", "", "\n", " ")
+
+const markdownReport = `# Garden report {{.Week}}
+
+{{day .From}} to {{last .To}}
+
+## Water used per zone
+{{if .Water}}
+| Zone | Litres |
+| --- | ---: |
+{{range .Water}}| {{cell .Zone}} | {{litres .Litres}} |
+{{end}}{{else}}
+No water was used.
+{{end}}
+## Moisture per plant station
+{{if .Moisture}}
+| Station | Min | Max | Readings |
+| --- | ---: | ---: | ---: |
+{{range .Moisture}}| {{cell .Station}} | {{value .Min}} | {{value .Max}} | {{.Readings}} |
+{{end}}{{else}}
+There are no plant stations.
+{{end}}
+## Alerts fired
+{{if .Alerts}}
+| Fired | Alert | Station | Severity | Value | Resolved |
+| --- | --- | --- | --- | ---: | --- |
+{{range .Alerts}}| {{when .DateFiring}} | {{cell .Rule}} | {{cell .Station}} | {{.Severity}} | {{litres .Value}} | {{resolved .DateResolved}} |
+{{end}}{{else}}
+No alerts fired.
+{{end}}
+## Stations offline
+{{if .Offline}}
+| Station | Times | Offline for |
+| --- | ---: | ---: |
+{{range .Offline}}| {{cell .Station}} | {{.Times}} | {{duration .Seconds}} |
+{{end}}{{else}}
+Every station stayed online.
+{{end}}
+## Failed commands
+{{if .FailedCommands}}
+| Failed | Station | Command | Valve | Error |
+| --- | --- | --- | --- | --- |
+{{range .FailedCommands}}| {{when .DateFinished}} | {{cell .Station}} | {{.Kind}} | {{cell .Valve}} | {{cell .Error}} |
+{{end}}{{else}}
+No commands failed.
+{{end}}
+_Generated {{when .DateGenerated}} UTC_
+`
+
+const htmlReport = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Garden report {{.Week}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+td.n { text-align: right; }
+</style>
+</head>
+<body>
+<h1>Garden report {{.Week}}</h1>
+<p>{{day .From}} to {{last .To}}</p>
+
+<h2>Water used per zone</h2>
+{{if .Water}}<table>
+<tr><th>Zone</th><th>Litres</th></tr>
+{{range .Water}}<tr><td>{{.Zone}}</td><td class="n">{{litres .Litres}}</td></tr>
+{{end}}</table>
+{{else}}<p>No water was used.</p>
+{{end}}
+<h2>Moisture per plant station</h2>
+{{if .Moisture}}<table>
+<tr><th>Station</th><th>Min</th><th>Max</th><th>Readings</th></tr>
+{{range .Moisture}}<tr><td>{{.Station}}</td><td class="n">{{value .Min}}</td><td class="n">{{value .Max}}</td><td class="n">{{.Readings}}</td></tr>
+{{end}}</table>
+{{else}}<p>There are no plant stations.</p>
+{{end}}
+<h2>Alerts fired</h2>
+{{if .Alerts}}<table>
+<tr><th>Fired</th><th>Alert</th><th>Station</th><th>Severity</th><th>Value</th><th>Resolved</th></tr>
+{{range .Alerts}}<tr><td>{{when .DateFiring}}</td><td>{{.Rule}}</td><td>{{.Station}}</td><td>{{.Severity}}</td><td class="n">{{litres .Value}}</td><td>{{resolved .DateResolved}}</td></tr>
+{{end}}</table>
+{{else}}<p>No alerts fired.</p>
+{{end}}
+<h2>Stations offline</h2>
+{{if .Offline}}<table>
+<tr><th>Station</th><th>Times</th><th>Offline for</th></tr>
+{{range .Offline}}<tr><td>{{.Station}}</td><td class="n">{{.Times}}</td><td class="n">{{duration .Seconds}}</td></tr>
+{{end}}</table>
+{{else}}<p>Every station stayed online.</p>
+{{end}}
+<h2>Failed commands</h2>
+{{if .FailedCommands}}<table>
+<tr><th>Failed</th><th>Station</th><th>Command</th><th>Valve</th><th>Error</th></tr>
+{{range .FailedCommands}}<tr><td>{{when .DateFinished}}</td><td>{{.Station}}</td><td>{{.Kind}}</td><td>{{.Valve}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>
+{{else}}<p>No commands failed.</p>
+{{end}}
+<p><em>Generated {{when .DateGenerated}} UTC</em></p>
+</body>
+</html>
+`
+
+// Markdown renders the Weekly report as a Markdown document.
+func (w Weekly) Markdown() ([]byte, error) {
+	fm := template.FuncMap{"cell": cell.Replace}
+	for name, fn := range funcs {
+		fm[name] = fn
+	}
+
+	t, err := template.New("markdown").Funcs(fm).Parse(markdownReport)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing markdown report template")
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, w); err != nil {
+		return nil, errors.Wrap(err, "rendering markdown report")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// HTML renders the Weekly report as a standalone HTML document.
+func (w Weekly) HTML() ([]byte, error) {
+	t, err := htmltemplate.New("html").Funcs(htmltemplate.FuncMap(funcs)).Parse(htmlReport)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing html report template")
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, w); err != nil {
+		return nil, errors.Wrap(err, "rendering html report")
+	}
+
+	return buf.Bytes(), nil
+}
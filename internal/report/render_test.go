@@ -0,0 +1,73 @@
+package report_test
+
+import (
+	// Core packages
+	"strings"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/report"
+)
+
+func weekly() report.Weekly {
+	from := time.Date(2021, time.June, 7, 0, 0, 0, 0, time.UTC)
+	min, max := 21.5, 64.0
+	resolved := from.Add(26 * time.Hour)
+
+	return report.Weekly{
+		Week:     "2021-W23",
+		From:     from,
+		To:       from.AddDate(0, 0, 7),
+		Water:    []report.ZoneWater{{ZoneId: "z1", Zone: "Tomato row", Litres: 123.45}, {Zone: "No zone", Litres: 2}},
+		Moisture: []report.StationMoisture{{StationId: "s1", Station: "Plant | <one>", Min: &min, Max: &max, Readings: 96}, {StationId: "s2", Station: "Plant two"}},
+		Alerts: []report.FiredAlert{
+			{AlertId: "a1", Rule: "Moisture low", Severity: "warning", Station: "Plant two", Value: 21.5, DateFiring: from.Add(25 * time.Hour), DateResolved: &resolved},
+		},
+		Offline:        []report.OfflineStation{{StationId: "s3", Station: "Water Station one", Times: 2, Seconds: 5400}},
+		FailedCommands: []report.FailedCommand{},
+		DateGenerated:  from.AddDate(0, 0, 7).Add(time.Hour),
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	md, err := weekly().Markdown()
+	if err != nil {
+		t.Fatalf("rendering markdown: %s", err)
+	}
+	doc := string(md)
+
+	for _, exp := range []string{
+		"# Garden report 2021-W23\n",
+		"Mon 7 Jun 2021 to Sun 13 Jun 2021",
+		"| Tomato row | 123.5 |\n",
+		`| Plant \| <one> | 21.5 | 64.0 | 96 |` + "\n",
+		"| Plant two | - | - | 0 |\n",
+		"| Tue 8 Jun 01:00 | Moisture low | Plant two | warning | 21.5 | Tue 8 Jun 02:00 |\n",
+		"| Water Station one | 2 | 1h30m0s |\n",
+		"No commands failed.",
+	} {
+		if !strings.Contains(doc, exp) {
+			t.Errorf("expected %q in:\n%s", exp, doc)
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	html, err := weekly().HTML()
+	if err != nil {
+		t.Fatalf("rendering html: %s", err)
+	}
+	doc := string(html)
+
+	for _, exp := range []string{
+		"<title>Garden report 2021-W23</title>",
+		"<td>Plant | &lt;one&gt;</td>",
+		`<td class="n">123.5</td>`,
+		"<p>No commands failed.</p>",
+	} {
+		if !strings.Contains(doc, exp) {
+			t.Errorf("expected %q in:\n%s", exp, doc)
+		}
+	}
+}
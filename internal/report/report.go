@@ -0,0 +1,191 @@
+package report
+
+import (
+	// Core packages
+	"context"
+	"fmt"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/water"
+	"github.com/deezone/HydroBytes-BaseStation/internal/zone"
+
+	// Third-party packages
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// ErrInvalidWeek is used when a week is not given as an ISO 8601 week such as
+// "2021-W23".
+var ErrInvalidWeek = errors.New("week must be an ISO 8601 week such as 2021-W23")
+
+// StartOfWeek gives the Monday midnight, in UTC, starting the ISO 8601 week t
+// is in.
+func StartOfWeek(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	// Sunday is the last day of an ISO week.
+	offset := (int(day.Weekday()) + 6) % 7
+
+	return day.AddDate(0, 0, -offset)
+}
+
+// LastWeek gives the start of the last full week before t. On a Monday that
+// is the week that just ended.
+func LastWeek(t time.Time) time.Time {
+	return StartOfWeek(t).AddDate(0, 0, -7)
+}
+
+// ParseWeek gives the start of an ISO 8601 week such as "2021-W23".
+func ParseWeek(s string) (time.Time, error) {
+	var year, week int
+	if n, err := fmt.Sscanf(s, "%4d-W%2d", &year, &week); err != nil || n != 2 || len(s) != 8 {
+		return time.Time{}, ErrInvalidWeek
+	}
+
+	// January 4th is always in the first week of its year.
+	start := StartOfWeek(time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)).AddDate(0, 0, 7*(week-1))
+
+	if y, w := start.ISOWeek(); y != year || w != week {
+		return time.Time{}, ErrInvalidWeek
+	}
+
+	return start, nil
+}
+
+// FormatWeek gives the ISO 8601 week t is in, such as "2021-W23".
+func FormatWeek(t time.Time) string {
+	year, week := t.UTC().ISOWeek()
+	return fmt.Sprintf("%04d-W%02d", year, week)
+}
+
+// Generate gathers the Weekly report of the week starting at from: the water
+// used per Zone, the lowest and highest moisture of every plant Station, the
+// Alerts that fired, the Stations that went offline and the Commands that
+// failed.
+func Generate(ctx context.Context, db *sqlx.DB, from time.Time, now time.Time) (*Weekly, error) {
+
+	ctx, span := trace.StartSpan(ctx, "report.Generate")
+	defer span.End()
+
+	from = StartOfWeek(from)
+	to := from.AddDate(0, 0, 7)
+
+	w := Weekly{
+		Week:           FormatWeek(from),
+		From:           from,
+		To:             to,
+		Water:          []ZoneWater{},
+		Moisture:       []StationMoisture{},
+		Alerts:         []FiredAlert{},
+		Offline:        []OfflineStation{},
+		FailedCommands: []FailedCommand{},
+		DateGenerated:  now.UTC(),
+	}
+
+	usage, err := water.ListUsage(ctx, db, water.UsageFilter{Period: water.PeriodWeek, GroupBy: water.GroupZone, From: &from, To: &to})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting water usage")
+	}
+	zones, err := zone.List(ctx, db)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting zone list")
+	}
+	names := make(map[string]string, len(zones))
+	for _, z := range zones {
+		names[z.Id] = z.Name
+	}
+	for _, u := range usage {
+		zw := ZoneWater{ZoneId: u.Key, Zone: names[u.Key], Litres: u.Litres}
+		if u.Key == "" {
+			zw.Zone = "No zone"
+		}
+		w.Water = append(w.Water, zw)
+	}
+
+	// Plant stations are matched by the kind of their station type.
+	const qm = `
+		SELECT
+			station.id AS station_id,
+			station.name AS station,
+			MIN(reading.value) AS min,
+			MAX(reading.value) AS max,
+			COUNT(reading.id) AS readings
+		FROM station
+		JOIN station_type ON station_type.id = station.station_type_id
+		LEFT JOIN reading ON reading.station_id = station.id
+			AND reading.sensor = 'moisture' AND reading.date_read >= $1 AND reading.date_read < $2
+		WHERE station_type.kind = 'plant' AND station.date_deleted IS NULL
+		GROUP BY station.id, station.name
+		ORDER BY station.name`
+
+	if err := db.SelectContext(ctx, &w.Moisture, qm, from, to); err != nil {
+		return nil, errors.Wrap(err, "selecting moisture of plant stations")
+	}
+
+	const qa = `
+		SELECT
+			alert.id AS alert_id,
+			alert_rule.name AS rule,
+			alert_rule.severity,
+			alert.station_id,
+			station.name AS station,
+			alert.value,
+			alert.date_firing,
+			alert.date_resolved
+		FROM alert
+		JOIN alert_rule ON alert_rule.id = alert.rule_id
+		JOIN station ON station.id = alert.station_id
+		WHERE alert.date_firing >= $1 AND alert.date_firing < $2
+		ORDER BY alert.date_firing`
+
+	if err := db.SelectContext(ctx, &w.Alerts, qa, from, to); err != nil {
+		return nil, errors.Wrap(err, "selecting fired alerts")
+	}
+
+	// Offline alerts count from when the station was last heard from, the
+	// start of the alert, and only the part within the week.
+	const qo = `
+		SELECT
+			alert.station_id,
+			station.name AS station,
+			COUNT(*) AS times,
+			SUM(EXTRACT(EPOCH FROM
+				LEAST(COALESCE(alert.date_resolved, $3), $2) - GREATEST(alert.date_started, $1)
+			)) AS seconds
+		FROM alert
+		JOIN alert_rule ON alert_rule.id = alert.rule_id
+		JOIN station ON station.id = alert.station_id
+		WHERE alert_rule.kind = 'offline'
+			AND alert.date_firing IS NOT NULL
+			AND alert.date_started < $2
+			AND COALESCE(alert.date_resolved, $3) > $1
+		GROUP BY alert.station_id, station.name
+		ORDER BY station.name`
+
+	if err := db.SelectContext(ctx, &w.Offline, qo, from, to, now.UTC()); err != nil {
+		return nil, errors.Wrap(err, "selecting offline stations")
+	}
+
+	const qc = `
+		SELECT
+			command.id AS command_id,
+			command.station_id,
+			station.name AS station,
+			command.kind,
+			command.valve,
+			command.error,
+			command.date_finished
+		FROM command
+		JOIN station ON station.id = command.station_id
+		WHERE command.status = 'failed' AND command.date_finished >= $1 AND command.date_finished < $2
+		ORDER BY command.date_finished`
+
+	if err := db.SelectContext(ctx, &w.FailedCommands, qc, from, to); err != nil {
+		return nil, errors.Wrap(err, "selecting failed commands")
+	}
+
+	return &w, nil
+}
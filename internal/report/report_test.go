@@ -0,0 +1,159 @@
+package report_test
+
+import (
+	// Core packages
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/command"
+	"github.com/deezone/HydroBytes-BaseStation/internal/reading"
+	"github.com/deezone/HydroBytes-BaseStation/internal/report"
+	"github.com/deezone/HydroBytes-BaseStation/internal/schema"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+)
+
+func TestWeeks(t *testing.T) {
+	monday := time.Date(2021, time.June, 7, 0, 0, 0, 0, time.UTC)
+
+	for _, day := range []time.Time{monday, monday.Add(36 * time.Hour), monday.AddDate(0, 0, 6).Add(23 * time.Hour)} {
+		if got := report.StartOfWeek(day); !got.Equal(monday) {
+			t.Errorf("expected the week of %s to start %s, got %s", day, monday, got)
+		}
+	}
+
+	if got, exp := report.LastWeek(monday.Add(9*time.Hour)), monday.AddDate(0, 0, -7); !got.Equal(exp) {
+		t.Errorf("expected last week to start %s, got %s", exp, got)
+	}
+
+	tests := []struct {
+		week  string
+		start time.Time
+		err   error
+	}{
+		{"2021-W23", monday, nil},
+		{"2021-W01", time.Date(2021, time.January, 4, 0, 0, 0, 0, time.UTC), nil},
+		{"2020-W53", time.Date(2020, time.December, 28, 0, 0, 0, 0, time.UTC), nil},
+		{"2021-W53", time.Time{}, report.ErrInvalidWeek},
+		{"2021-23", time.Time{}, report.ErrInvalidWeek},
+		{"2021-W2", time.Time{}, report.ErrInvalidWeek},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.week, func(t *testing.T) {
+			start, err := report.ParseWeek(tt.week)
+			if err != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if !start.Equal(tt.start) {
+				t.Fatalf("expected %s, got %s", tt.start, start)
+			}
+			if err == nil && report.FormatWeek(start) != tt.week {
+				t.Fatalf("expected %s to format as itself, got %s", tt.week, report.FormatWeek(start))
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	monday := time.Date(2021, time.June, 7, 0, 0, 0, 0, time.UTC)
+	plant := "d58f6d32-6332-11eb-ae93-0242ac130002"
+
+	var nr reading.NewReadings
+	for i, v := range []float64{30, 45, 22} {
+		read := monday.Add(time.Duration(i+1) * 24 * time.Hour)
+		nr.Readings = append(nr.Readings, reading.NewReading{Sensor: reading.SensorMoisture, Value: v, DateRead: &read})
+	}
+
+	// Readings of the week before are left out.
+	before := monday.Add(-time.Hour)
+	nr.Readings = append(nr.Readings, reading.NewReading{Sensor: reading.SensorMoisture, Value: 5, DateRead: &before})
+
+	if _, err := reading.Record(ctx, db, plant, nr, monday.AddDate(0, 0, 4)); err != nil {
+		t.Fatalf("recording readings: %s", err)
+	}
+
+	run, err := command.Create(ctx, db, "ee72a90c-590c-11eb-ae93-0242ac130002", command.NewCommand{Kind: command.KindWater, Valve: "zone-2", Duration: 60}, nil, monday.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("queueing watering run: %s", err)
+	}
+	if _, err := command.Update(ctx, db, run.Id, command.Report{Status: command.StatusFailed, Error: "valve stuck"}, monday.Add(2*time.Hour)); err != nil {
+		t.Fatalf("failing watering run: %s", err)
+	}
+
+	w, err := report.Generate(ctx, db, monday.Add(50*time.Hour), monday.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatalf("generating report: %s", err)
+	}
+
+	if exp, got := "2021-W23", w.Week; exp != got {
+		t.Fatalf("expected week %s, got %s", exp, got)
+	}
+	if exp, got := 3, len(w.Moisture); exp != got {
+		t.Fatalf("expected %v plant stations, got %v", exp, got)
+	}
+	for _, m := range w.Moisture {
+		if m.StationId != plant {
+			continue
+		}
+		if m.Min == nil || *m.Min != 22 || m.Max == nil || *m.Max != 45 || m.Readings != 3 {
+			t.Fatalf("expected moisture between 22 and 45 from 3 readings, got %+v", m)
+		}
+	}
+	if len(w.FailedCommands) != 1 || w.FailedCommands[0].Error != "valve stuck" {
+		t.Fatalf("expected the failed watering run, got %+v", w.FailedCommands)
+	}
+}
+
+func TestSave(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	now := time.Date(2021, time.June, 14, 1, 0, 0, 0, time.UTC)
+	from := report.LastWeek(now)
+
+	written, err := report.Save(ctx, db, dir, from, now)
+	if err != nil {
+		t.Fatalf("saving report: %s", err)
+	}
+	if !written {
+		t.Fatal("expected the report to be written")
+	}
+	for _, name := range []string{"2021-W23.md", "2021-W23.html"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("expected report %s: %s", name, err)
+		}
+	}
+
+	// A week is only written once.
+	written, err = report.Save(ctx, db, dir, from, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("saving report: %s", err)
+	}
+	if written {
+		t.Fatal("expected the report of the week to be written once")
+	}
+}
@@ -0,0 +1,55 @@
+package report
+
+import (
+	// Core packages
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	// Third-party packages
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Save writes the Markdown and HTML reports of the week from is in to dir as
+// files named after the week, such as 2021-W23.md and 2021-W23.html. A week
+// that already has both files is left as it is. It reports whether the files
+// were written.
+func Save(ctx context.Context, db *sqlx.DB, dir string, from, now time.Time) (bool, error) {
+
+	ctx, span := trace.StartSpan(ctx, "report.Save")
+	defer span.End()
+
+	base := filepath.Join(dir, FormatWeek(from))
+	md, html := base+".md", base+".html"
+
+	if exists(md) && exists(html) {
+		return false, nil
+	}
+
+	w, err := Generate(ctx, db, from, now)
+	if err != nil {
+		return false, err
+	}
+
+	for path, render := range map[string]func() ([]byte, error){md: w.Markdown, html: w.HTML} {
+		content, err := render()
+		if err != nil {
+			return false, err
+		}
+		if err := ioutil.WriteFile(path, content, 0644); err != nil {
+			return false, errors.Wrapf(err, "writing report %s", path)
+		}
+	}
+
+	return true, nil
+}
+
+// exists reports whether there is a file at path.
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
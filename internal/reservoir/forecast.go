@@ -0,0 +1,59 @@
+package reservoir
+
+import (
+	// Core packages
+	"sort"
+	"time"
+)
+
+// Used is the litres taken from a Reservoir going by successive levels in
+// litres. Rises in the level are refills and are not counted.
+func Used(levels []float64) float64 {
+	var used float64
+	for i := 1; i < len(levels); i++ {
+		if drop := levels[i-1] - levels[i]; drop > 0 {
+			used += drop
+		}
+	}
+
+	return used
+}
+
+// DateEmpty is when a Reservoir holding litres at the time from runs dry. It
+// loses baseline litres per day between the PlannedRuns plus the litres of
+// each run, and rate litres per day once the runs are over. It is nil when the
+// reservoir never runs dry at these rates.
+func DateEmpty(litres float64, from time.Time, baseline, rate float64, runs []PlannedRun) *time.Time {
+	if litres <= 0 {
+		return &from
+	}
+
+	sorted := make([]PlannedRun, len(runs))
+	copy(sorted, runs)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+
+	at := from
+	for _, r := range sorted {
+		if r.Date.After(at) {
+			days := r.Date.Sub(at).Hours() / 24
+			if baseline > 0 && litres-baseline*days <= 0 {
+				empty := at.Add(time.Duration(litres / baseline * 24 * float64(time.Hour)))
+				return &empty
+			}
+			litres -= baseline * days
+			at = r.Date
+		}
+
+		litres -= r.Litres
+		if litres <= 0 {
+			return &at
+		}
+	}
+
+	if rate <= 0 {
+		return nil
+	}
+
+	empty := at.Add(time.Duration(litres / rate * 24 * float64(time.Hour)))
+	return &empty
+}
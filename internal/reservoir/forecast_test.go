@@ -0,0 +1,64 @@
+package reservoir_test
+
+import (
+	// Core packages
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/reservoir"
+)
+
+func TestUsed(t *testing.T) {
+	// The reservoir is refilled between the third and fourth level.
+	levels := []float64{100, 90, 85, 180, 170}
+
+	if exp, got := 25.0, reservoir.Used(levels); exp != got {
+		t.Fatalf("expected %v litres used, got %v", exp, got)
+	}
+}
+
+func TestDateEmpty(t *testing.T) {
+	from := time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name     string
+		litres   float64
+		baseline float64
+		rate     float64
+		runs     []reservoir.PlannedRun
+		exp      *time.Duration
+	}{
+		{"no use", 100, 0, 0, nil, nil},
+		{"steady use", 100, 0, 10, nil, durationPtr(10 * day)},
+		{"run empties", 100, 5, 10, []reservoir.PlannedRun{{Date: from.Add(2 * day), Litres: 95}}, durationPtr(2 * day)},
+		{"dry before run", 100, 50, 10, []reservoir.PlannedRun{{Date: from.Add(5 * day), Litres: 10}}, durationPtr(2 * day)},
+		{"after runs", 100, 10, 20, []reservoir.PlannedRun{
+			{Date: from.Add(2 * day), Litres: 20},
+			{Date: from.Add(1 * day), Litres: 20},
+		}, durationPtr(4 * day)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reservoir.DateEmpty(tt.litres, from, tt.baseline, tt.rate, tt.runs)
+			if tt.exp == nil {
+				if got != nil {
+					t.Fatalf("expected the reservoir not to run dry, got %v", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("expected the reservoir to run dry after %v", *tt.exp)
+			}
+			if exp := from.Add(*tt.exp); !exp.Equal(*got) {
+				t.Fatalf("expected the reservoir to run dry at %v, got %v", exp, got)
+			}
+		})
+	}
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
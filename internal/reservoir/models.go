@@ -0,0 +1,66 @@
+package reservoir
+
+import (
+	// Core packages
+	"time"
+)
+
+// Reservoir is the water tank of a Water station. The station reports how
+// full the tank is as a percentage with the reservoir_level sensor.
+type Reservoir struct {
+	StationId      string    `db:"station_id"      json:"station_id"`
+	CapacityLitres float64   `db:"capacity_litres" json:"capacity_litres"`
+	DateCreated    time.Time `db:"date_created"    json:"date_created"`
+	DateUpdated    time.Time `db:"date_updated"    json:"date_updated"`
+}
+
+// UpdateReservoir is what we require from clients when setting up the
+// Reservoir of a Station.
+type UpdateReservoir struct {
+	CapacityLitres float64 `json:"capacity_litres" validate:"required,gt=0"`
+}
+
+// Refill is a planned refill of a Reservoir by hand.
+type Refill struct {
+	Id          string    `db:"id"           json:"id"`
+	StationId   string    `db:"station_id"   json:"station_id"`
+	DatePlanned time.Time `db:"date_planned" json:"date_planned"`
+	Note        string    `db:"note"         json:"note"`
+	DateCreated time.Time `db:"date_created" json:"date_created"`
+}
+
+// NewRefill is what we require from clients when planning a Refill.
+type NewRefill struct {
+	DatePlanned time.Time `json:"date_planned" validate:"required"`
+	Note        string    `json:"note"`
+}
+
+// PlannedRun is a watering run expected to take Litres from a Reservoir at
+// Date.
+type PlannedRun struct {
+	Date   time.Time
+	Litres float64
+}
+
+// Forecast is when a Reservoir is expected to run dry.
+//
+// Litres and Percent are the level last reported at DateRead. DailyUse is the
+// litres used per day over the last UsageWindow. ScheduledRuns are the queued
+// watering runs of the station and ScheduledLitres what they are expected to
+// use. DateEmpty is unset when the reservoir is not expected to run dry.
+// RunsDry is set when it runs dry before NextRefill, or within WarnAhead when
+// no refill is planned.
+type Forecast struct {
+	StationId       string     `json:"station_id"`
+	CapacityLitres  float64    `json:"capacity_litres"`
+	Litres          float64    `json:"litres"`
+	Percent         float64    `json:"percent"`
+	DateRead        *time.Time `json:"date_read"`
+	DailyUse        float64    `json:"daily_use"`
+	ScheduledRuns   int        `json:"scheduled_runs"`
+	ScheduledLitres float64    `json:"scheduled_litres"`
+	DaysToEmpty     *float64   `json:"days_to_empty"`
+	DateEmpty       *time.Time `json:"date_empty"`
+	NextRefill      *time.Time `json:"next_refill"`
+	RunsDry         bool       `json:"runs_dry"`
+}
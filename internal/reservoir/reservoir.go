@@ -0,0 +1,415 @@
+package reservoir
+
+import (
+	// Core packages
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/command"
+	"github.com/deezone/HydroBytes-BaseStation/internal/event"
+	"github.com/deezone/HydroBytes-BaseStation/internal/reading"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Predefined errors identify expected failure conditions.
+var (
+	// ErrNotFound is used when the Reservoir of a Station is requested but has
+	// not been set up.
+	ErrNotFound = errors.New("reservoir not found")
+
+	// ErrRefillNotFound is used when a specific Refill is requested but does not exist.
+	ErrRefillNotFound = errors.New("refill not found")
+
+	// ErrInvalidID is used when an invalid UUID is provided.
+	ErrInvalidID = errors.New("ID is not in its proper UUID format")
+
+	// ErrStationNotFound is used when a Reservoir is set up for a Station that does not exist.
+	ErrStationNotFound = errors.New("station not found")
+)
+
+const (
+	// EventDry is the type of the Event raised when a Reservoir is forecast to
+	// run dry.
+	EventDry = "reservoir.dry"
+
+	// UsageWindow is how far back reservoir levels and watering runs are used
+	// to work out the daily use of a Reservoir.
+	UsageWindow = 7 * 24 * time.Hour
+
+	// RateWindow is how far back watering runs are used to work out how many
+	// litres a planned run uses.
+	RateWindow = 30 * 24 * time.Hour
+
+	// WarnAhead is how far ahead a Reservoir running dry is warned about when
+	// no refill is planned.
+	WarnAhead = 3 * 24 * time.Hour
+
+	// warnEvery is how often the same Reservoir is warned about.
+	warnEvery = 24 * time.Hour
+)
+
+// Set sets up the Reservoir of a Station or changes its capacity.
+func Set(ctx context.Context, db *sqlx.DB, stationID string, ur UpdateReservoir, now time.Time) (*Reservoir, error) {
+
+	ctx, span := trace.StartSpan(ctx, "reservoir.Set")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var exists bool
+	const check = `SELECT EXISTS (SELECT 1 FROM station WHERE id = $1 AND date_deleted IS NULL)`
+	if err := db.GetContext(ctx, &exists, check, stationID); err != nil {
+		return nil, errors.Wrap(err, "checking station")
+	}
+	if !exists {
+		return nil, ErrStationNotFound
+	}
+
+	var r Reservoir
+
+	const q = `
+		INSERT INTO reservoir (station_id, capacity_litres, date_created, date_updated)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (station_id) DO UPDATE SET
+			capacity_litres = EXCLUDED.capacity_litres,
+			date_updated = EXCLUDED.date_updated
+		RETURNING station_id, capacity_litres, date_created, date_updated`
+
+	if err := db.GetContext(ctx, &r, q, stationID, ur.CapacityLitres, now.UTC()); err != nil {
+		return nil, errors.Wrap(err, "saving reservoir")
+	}
+
+	return &r, nil
+}
+
+// Get finds the Reservoir of a Station.
+func Get(ctx context.Context, db *sqlx.DB, stationID string) (*Reservoir, error) {
+
+	ctx, span := trace.StartSpan(ctx, "reservoir.Get")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var r Reservoir
+
+	const q = `
+		SELECT station_id, capacity_litres, date_created, date_updated
+		FROM reservoir
+		WHERE station_id = $1`
+
+	if err := db.GetContext(ctx, &r, q, stationID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+
+		return nil, errors.Wrap(err, "selecting reservoir")
+	}
+
+	return &r, nil
+}
+
+// AddRefill plans a Refill of the Reservoir of a Station.
+func AddRefill(ctx context.Context, db *sqlx.DB, stationID string, nr NewRefill, now time.Time) (*Refill, error) {
+
+	ctx, span := trace.StartSpan(ctx, "reservoir.AddRefill")
+	defer span.End()
+
+	if _, err := Get(ctx, db, stationID); err != nil {
+		return nil, err
+	}
+
+	r := Refill{
+		Id:          uuid.New().String(),
+		StationId:   stationID,
+		DatePlanned: nr.DatePlanned.UTC(),
+		Note:        nr.Note,
+		DateCreated: now.UTC(),
+	}
+
+	const q = `
+		INSERT INTO reservoir_refill
+		  (id, station_id, date_planned, note, date_created)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := db.ExecContext(ctx, q,
+		r.Id,
+		r.StationId,
+		r.DatePlanned,
+		r.Note,
+		r.DateCreated,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "inserting refill")
+	}
+
+	return &r, nil
+}
+
+// ListRefills gets the Refills planned from the given time on, soonest first.
+// An empty stationID lists the Refills of every Station.
+func ListRefills(ctx context.Context, db *sqlx.DB, stationID string, from time.Time) ([]Refill, error) {
+
+	ctx, span := trace.StartSpan(ctx, "reservoir.ListRefills")
+	defer span.End()
+
+	if stationID != "" {
+		if _, err := uuid.Parse(stationID); err != nil {
+			return nil, ErrInvalidID
+		}
+	}
+
+	refills := []Refill{}
+
+	const q = `
+		SELECT id, station_id, date_planned, note, date_created
+		FROM reservoir_refill
+		WHERE ($1 = '' OR station_id::TEXT = $1) AND date_planned >= $2
+		ORDER BY date_planned`
+
+	if err := db.SelectContext(ctx, &refills, q, stationID, from.UTC()); err != nil {
+		return nil, errors.Wrap(err, "selecting refills")
+	}
+
+	return refills, nil
+}
+
+// DeleteRefill removes a planned Refill.
+func DeleteRefill(ctx context.Context, db *sqlx.DB, id string) error {
+
+	ctx, span := trace.StartSpan(ctx, "reservoir.DeleteRefill")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrInvalidID
+	}
+
+	const q = `DELETE FROM reservoir_refill WHERE id = $1`
+
+	res, err := db.ExecContext(ctx, q, id)
+	if err != nil {
+		return errors.Wrapf(err, "deleting refill %s", id)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return errors.Wrapf(err, "deleting refill %s", id)
+	} else if n == 0 {
+		return ErrRefillNotFound
+	}
+
+	return nil
+}
+
+// StationForecast works out when the Reservoir of a Station runs dry from its
+// use over the last UsageWindow and the queued watering runs of the Station.
+//
+// The use not explained by watering runs, such as evaporation, continues
+// between the queued runs. Once they are over the reservoir is expected to be
+// used as much as it was recently.
+func StationForecast(ctx context.Context, db *sqlx.DB, stationID string, now time.Time) (*Forecast, error) {
+
+	ctx, span := trace.StartSpan(ctx, "reservoir.StationForecast")
+	defer span.End()
+
+	res, err := Get(ctx, db, stationID)
+	if err != nil {
+		return nil, err
+	}
+
+	f := Forecast{
+		StationId:      stationID,
+		CapacityLitres: res.CapacityLitres,
+	}
+
+	refills, err := ListRefills(ctx, db, stationID, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(refills) > 0 {
+		f.NextRefill = &refills[0].DatePlanned
+	}
+
+	latest, err := reading.Latest(ctx, db, stationID, reading.SensorReservoirLevel)
+	if err != nil {
+		if err == reading.ErrNotFound {
+			return &f, nil
+		}
+		return nil, errors.Wrap(err, "getting reservoir level")
+	}
+	f.Percent = latest.Value
+	f.Litres = res.CapacityLitres * latest.Value / 100
+	f.DateRead = &latest.DateRead
+
+	// Daily use from the drop in level over the usage window.
+	since := now.Add(-UsageWindow)
+	levels, err := reading.List(ctx, db, stationID, reading.Query{Sensor: reading.SensorReservoirLevel, Since: &since})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting reservoir levels")
+	}
+
+	var baseline float64
+	if len(levels) > 1 {
+		litres := make([]float64, len(levels))
+		for i, l := range levels {
+			litres[i] = res.CapacityLitres * l.Value / 100
+		}
+
+		first, last := levels[0].DateRead, levels[len(levels)-1].DateRead
+		if days := last.Sub(first).Hours() / 24; days > 0 {
+			f.DailyUse = Used(litres) / days
+
+			var watered float64
+			const q = `
+				SELECT COALESCE(SUM(flow_reading.litres), 0)
+				FROM flow_reading
+				JOIN flow_meter ON flow_meter.id = flow_reading.meter_id
+				WHERE flow_meter.station_id = $1
+				  AND flow_reading.command_id IS NOT NULL
+				  AND flow_reading.date_read >= $2 AND flow_reading.date_read <= $3`
+			if err := db.GetContext(ctx, &watered, q, stationID, first, last); err != nil {
+				return nil, errors.Wrap(err, "selecting watered litres")
+			}
+			baseline = math.Max(f.DailyUse-watered/days, 0)
+		}
+	}
+
+	// Litres per second the watering runs of the Station delivered recently.
+	var perSecond float64
+	const rate = `
+		SELECT COALESCE(SUM(runs.litres) / NULLIF(SUM(runs.duration), 0), 0)
+		FROM (
+			SELECT command.duration, (SELECT SUM(litres) FROM flow_reading WHERE command_id = command.id) AS litres
+			FROM command
+			WHERE command.station_id = $1
+			  AND command.kind = 'water'
+			  AND command.status = 'completed'
+			  AND command.date_finished >= $2
+		) AS runs
+		WHERE runs.litres IS NOT NULL`
+	if err := db.GetContext(ctx, &perSecond, rate, stationID, now.Add(-RateWindow).UTC()); err != nil {
+		return nil, errors.Wrap(err, "selecting watering rate")
+	}
+
+	queued, err := command.List(ctx, db, command.Filter{StationId: stationID, Status: command.StatusQueued})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting queued commands")
+	}
+
+	var runs []PlannedRun
+	for _, c := range queued {
+		if c.Kind != command.KindWater {
+			continue
+		}
+		r := PlannedRun{Date: c.DateScheduled, Litres: perSecond * float64(c.Duration)}
+		if r.Date.Before(now) {
+			r.Date = now
+		}
+		runs = append(runs, r)
+		f.ScheduledRuns++
+		f.ScheduledLitres += r.Litres
+	}
+
+	f.DateEmpty = DateEmpty(f.Litres, latest.DateRead, baseline, f.DailyUse, runs)
+	if f.DateEmpty != nil {
+		days := math.Max(f.DateEmpty.Sub(now).Hours()/24, 0)
+		f.DaysToEmpty = &days
+
+		warnBy := now.Add(WarnAhead)
+		if f.NextRefill != nil {
+			warnBy = *f.NextRefill
+		}
+		f.RunsDry = f.DateEmpty.Before(warnBy)
+	}
+
+	return &f, nil
+}
+
+// ListForecasts gets the Forecasts of every Reservoir.
+func ListForecasts(ctx context.Context, db *sqlx.DB, now time.Time) ([]Forecast, error) {
+
+	ctx, span := trace.StartSpan(ctx, "reservoir.ListForecasts")
+	defer span.End()
+
+	var stations []string
+	const q = `
+		SELECT reservoir.station_id
+		FROM reservoir
+		JOIN station ON station.id = reservoir.station_id
+		WHERE station.date_deleted IS NULL
+		ORDER BY station.name`
+	if err := db.SelectContext(ctx, &stations, q); err != nil {
+		return nil, errors.Wrap(err, "selecting reservoirs")
+	}
+
+	forecasts := make([]Forecast, 0, len(stations))
+	for _, id := range stations {
+		f, err := StationForecast(ctx, db, id, now)
+		if err != nil {
+			return nil, errors.Wrapf(err, "forecasting reservoir of station %s", id)
+		}
+		forecasts = append(forecasts, *f)
+	}
+
+	return forecasts, nil
+}
+
+// Warn raises a warning Event for every Reservoir forecast to run dry that was
+// not warned about in the last day. It returns the Forecasts warned about.
+func Warn(ctx context.Context, db *sqlx.DB, now time.Time) ([]Forecast, error) {
+
+	ctx, span := trace.StartSpan(ctx, "reservoir.Warn")
+	defer span.End()
+
+	forecasts, err := ListForecasts(ctx, db, now)
+	if err != nil {
+		return nil, err
+	}
+
+	warned := []Forecast{}
+	for _, f := range forecasts {
+		if !f.RunsDry {
+			continue
+		}
+
+		since := now.Add(-warnEvery)
+		recent, err := event.List(ctx, db, event.Filter{Type: EventDry, StationId: f.StationId, Since: &since, Limit: 1})
+		if err != nil {
+			return nil, errors.Wrap(err, "getting recent reservoir events")
+		}
+		if len(recent) > 0 {
+			continue
+		}
+
+		msg := fmt.Sprintf("reservoir is expected to run dry in %.1f days", *f.DaysToEmpty)
+		if f.NextRefill != nil {
+			msg += fmt.Sprintf(", before the refill planned for %s", f.NextRefill.Format("Mon Jan 2"))
+		}
+
+		stationID := f.StationId
+		ne := event.NewEvent{
+			Type:      EventDry,
+			Severity:  event.SeverityWarning,
+			StationId: &stationID,
+			Message:   msg,
+			Data:      f,
+		}
+		if _, err := event.Record(ctx, db, ne, now); err != nil {
+			return nil, errors.Wrap(err, "recording reservoir event")
+		}
+
+		warned = append(warned, f)
+	}
+
+	return warned, nil
+}
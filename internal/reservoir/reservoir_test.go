@@ -0,0 +1,86 @@
+package reservoir_test
+
+import (
+	// Core packages
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/reading"
+	"github.com/deezone/HydroBytes-BaseStation/internal/reservoir"
+	"github.com/deezone/HydroBytes-BaseStation/internal/schema"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+)
+
+func TestForecast(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2021, time.June, 3, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	station := "ee72a90c-590c-11eb-ae93-0242ac130002"
+
+	// The 200 litre reservoir drops 20 litres a day.
+	var nr reading.NewReadings
+	for i, percent := range []float64{80, 70, 60} {
+		read := now.Add(time.Duration(i-2) * day)
+		nr.Readings = append(nr.Readings, reading.NewReading{Sensor: reading.SensorReservoirLevel, Value: percent, DateRead: &read})
+	}
+	if _, err := reading.Record(ctx, db, station, nr, now); err != nil {
+		t.Fatalf("recording levels: %s", err)
+	}
+
+	refill, err := reservoir.AddRefill(ctx, db, station, reservoir.NewRefill{DatePlanned: now.Add(5 * day)}, now)
+	if err != nil {
+		t.Fatalf("planning refill: %s", err)
+	}
+
+	f, err := reservoir.StationForecast(ctx, db, station, now)
+	if err != nil {
+		t.Fatalf("forecasting reservoir: %s", err)
+	}
+	if exp, got := 120.0, f.Litres; exp != got {
+		t.Fatalf("expected %v litres, got %v", exp, got)
+	}
+	if exp, got := 20.0, f.DailyUse; math.Abs(exp-got) > 1e-9 {
+		t.Fatalf("expected %v litres daily use, got %v", exp, got)
+	}
+	if f.DaysToEmpty == nil || math.Abs(*f.DaysToEmpty-6) > 1e-6 {
+		t.Fatalf("expected the reservoir to run dry in 6 days, got %v", f.DaysToEmpty)
+	}
+	if f.RunsDry {
+		t.Fatal("expected the reservoir to be refilled before it runs dry")
+	}
+
+	// Without the refill the reservoir runs dry before the next one.
+	if err := reservoir.DeleteRefill(ctx, db, refill.Id); err != nil {
+		t.Fatalf("deleting refill: %s", err)
+	}
+	if _, err := reservoir.AddRefill(ctx, db, station, reservoir.NewRefill{DatePlanned: now.Add(10 * day)}, now); err != nil {
+		t.Fatalf("planning refill: %s", err)
+	}
+
+	warned, err := reservoir.Warn(ctx, db, now)
+	if err != nil {
+		t.Fatalf("warning about reservoirs: %s", err)
+	}
+	if exp, got := 1, len(warned); exp != got {
+		t.Fatalf("expected %v reservoir warned about, got %v", exp, got)
+	}
+
+	warned, err = reservoir.Warn(ctx, db, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("warning about reservoirs: %s", err)
+	}
+	if exp, got := 0, len(warned); exp != got {
+		t.Fatalf("expected the reservoir not to be warned about again, got %v", got)
+	}
+}
@@ -69,6 +69,734 @@ ALTER TABLE station
         FOREIGN KEY (account_id)
         REFERENCES account(id)
         ON DELETE CASCADE;
+`,
+	},
+	{
+		Version:     5,
+		Description: "Add zones",
+		Script: `
+CREATE TABLE zone (
+	id           UUID PRIMARY KEY,
+	name         TEXT,
+	description  TEXT,
+	soil_type    TEXT,
+	location_x   INT,
+	location_y   INT,
+	width        INT,
+	height       INT,
+	date_created TIMESTAMP,
+	date_updated TIMESTAMP
+);
+
+ALTER TABLE station
+	ADD COLUMN zone_id UUID,
+	ADD CONSTRAINT fk_zone_id
+		FOREIGN KEY (zone_id)
+		REFERENCES zone(id)
+		ON DELETE SET NULL;
+`,
+	},
+	{
+		Version:     6,
+		Description: "Add station location index",
+		Script: `
+CREATE INDEX idx_station_location ON station (location_x, location_y);
+`,
+	},
+	{
+		Version:     7,
+		Description: "Add station location history",
+		Script: `
+CREATE TABLE station_location (
+	id             UUID PRIMARY KEY,
+	station_id     UUID NOT NULL,
+	location_x     INT,
+	location_y     INT,
+	date_effective TIMESTAMP,
+
+	CONSTRAINT fk_station_id
+		FOREIGN KEY (station_id)
+		REFERENCES station(id)
+		ON DELETE CASCADE
+);
+
+CREATE INDEX idx_station_location_effective ON station_location (station_id, date_effective);
+
+INSERT INTO station_location (id, station_id, location_x, location_y, date_effective)
+	SELECT md5(random()::TEXT || id::TEXT)::UUID, id, location_x, location_y, date_created
+	FROM station;
+`,
+	},
+	{
+		Version:     8,
+		Description: "Restrict deleting station types and accounts with stations",
+		Script: `
+ALTER TABLE station
+	DROP CONSTRAINT fk_station_type_id,
+	ADD CONSTRAINT fk_station_type_id
+		FOREIGN KEY (station_type_id)
+		REFERENCES station_type(id)
+		ON DELETE RESTRICT,
+	DROP CONSTRAINT fk_account_id,
+	ADD CONSTRAINT fk_account_id
+		FOREIGN KEY (account_id)
+		REFERENCES account(id)
+		ON DELETE RESTRICT;
+`,
+	},
+	{
+		Version:     9,
+		Description: "Archive station types and stations instead of deleting",
+		Script: `
+ALTER TABLE station_type ADD COLUMN date_deleted TIMESTAMP;
+ALTER TABLE station ADD COLUMN date_deleted TIMESTAMP;
+`,
+	},
+	{
+		Version:     10,
+		Description: "Add station tags and groups",
+		Script: `
+ALTER TABLE station ADD COLUMN tags JSONB NOT NULL DEFAULT '{}';
+CREATE INDEX idx_station_tags ON station USING GIN (tags);
+
+CREATE TABLE station_group (
+	id           UUID PRIMARY KEY,
+	name         TEXT,
+	description  TEXT,
+	date_created TIMESTAMP,
+	date_updated TIMESTAMP
+);
+
+CREATE TABLE station_group_member (
+	group_id   UUID,
+	station_id UUID,
+
+	PRIMARY KEY (group_id, station_id),
+	CONSTRAINT fk_group_id
+		FOREIGN KEY (group_id)
+		REFERENCES station_group(id)
+		ON DELETE CASCADE,
+	CONSTRAINT fk_station_id
+		FOREIGN KEY (station_id)
+		REFERENCES station(id)
+		ON DELETE CASCADE
+);
+`,
+	},
+	{
+		Version:     11,
+		Description: "Add plant catalog and plantings",
+		Script: `
+CREATE TABLE plant (
+	id               UUID PRIMARY KEY,
+	species          TEXT,
+	common_name      TEXT,
+	moisture_min     INT,
+	moisture_max     INT,
+	light            TEXT,
+	days_to_maturity INT,
+	date_created     TIMESTAMP,
+	date_updated     TIMESTAMP
+);
+
+CREATE TABLE planting (
+	id           UUID PRIMARY KEY,
+	plant_id     UUID,
+	station_id   UUID,
+	zone_id      UUID,
+	moisture_min INT,
+	moisture_max INT,
+	date_planted TIMESTAMP,
+	date_removed TIMESTAMP,
+
+	CONSTRAINT fk_plant_id
+		FOREIGN KEY (plant_id)
+		REFERENCES plant(id)
+		ON DELETE RESTRICT,
+	CONSTRAINT fk_station_id
+		FOREIGN KEY (station_id)
+		REFERENCES station(id)
+		ON DELETE CASCADE,
+	CONSTRAINT fk_zone_id
+		FOREIGN KEY (zone_id)
+		REFERENCES zone(id)
+		ON DELETE SET NULL
+);
+
+CREATE INDEX idx_planting_station ON planting (station_id, date_planted);
+`,
+	},
+	{
+		Version:     12,
+		Description: "Add station links",
+		Script: `
+CREATE TABLE station_link (
+	id              UUID PRIMARY KEY,
+	type            TEXT,
+	from_station_id UUID,
+	to_station_id   UUID,
+	valve           TEXT,
+	channel         INT,
+	date_created    TIMESTAMP,
+
+	UNIQUE (type, from_station_id, to_station_id),
+	CONSTRAINT fk_from_station_id
+		FOREIGN KEY (from_station_id)
+		REFERENCES station(id)
+		ON DELETE CASCADE,
+	CONSTRAINT fk_to_station_id
+		FOREIGN KEY (to_station_id)
+		REFERENCES station(id)
+		ON DELETE CASCADE
+);
+
+CREATE INDEX idx_station_link_to ON station_link (to_station_id);
+`,
+	},
+	{
+		Version:     13,
+		Description: "Add station templates",
+		Script: `
+CREATE TABLE station_template (
+	id              UUID PRIMARY KEY,
+	station_type_id UUID,
+	name            TEXT,
+	description     TEXT,
+	zone_id         UUID,
+	tags            JSONB NOT NULL DEFAULT '{}',
+	date_created    TIMESTAMP,
+	date_updated    TIMESTAMP,
+
+	CONSTRAINT fk_station_type_id
+		FOREIGN KEY (station_type_id)
+		REFERENCES station_type(id)
+		ON DELETE CASCADE,
+	CONSTRAINT fk_zone_id
+		FOREIGN KEY (zone_id)
+		REFERENCES zone(id)
+		ON DELETE SET NULL
+);
+`,
+	},
+	{
+		Version:     14,
+		Description: "Add station device logs",
+		Script: `
+CREATE TABLE station_log (
+	id            BIGSERIAL PRIMARY KEY,
+	station_id    UUID,
+	level         TEXT,
+	module        TEXT,
+	message       TEXT,
+	date_logged   TIMESTAMP,
+	date_received TIMESTAMP,
+
+	CONSTRAINT fk_station_id
+		FOREIGN KEY (station_id)
+		REFERENCES station(id)
+		ON DELETE CASCADE
+);
+
+CREATE INDEX idx_station_log_station ON station_log (station_id, date_logged);
+CREATE INDEX idx_station_log_received ON station_log (date_received);
+
+CREATE TABLE station_log_retention (
+	station_id UUID PRIMARY KEY,
+	days       INT,
+
+	CONSTRAINT fk_station_id
+		FOREIGN KEY (station_id)
+		REFERENCES station(id)
+		ON DELETE CASCADE
+);
+`,
+	},
+	{
+		Version:     15,
+		Description: "Add station heartbeats",
+		Script: `
+CREATE TABLE heartbeat (
+	id              UUID PRIMARY KEY,
+	station_id      UUID,
+	battery_voltage DOUBLE PRECISION,
+	battery_current DOUBLE PRECISION,
+	solar_voltage   DOUBLE PRECISION,
+	sleep_interval  INT,
+	date_sent       TIMESTAMP,
+	date_received   TIMESTAMP,
+
+	CONSTRAINT fk_station_id
+		FOREIGN KEY (station_id)
+		REFERENCES station(id)
+		ON DELETE CASCADE
+);
+
+CREATE INDEX idx_heartbeat_station ON heartbeat (station_id, date_sent);
+`,
+	},
+	{
+		Version:     16,
+		Description: "Add commands, flow meters and flow readings",
+		Script: `
+CREATE TABLE flow_meter (
+	id           UUID PRIMARY KEY,
+	station_id   UUID,
+	name         TEXT,
+	valve        TEXT,
+	zone_id      UUID,
+	k_factor     DOUBLE PRECISION,
+	date_created TIMESTAMP,
+	date_updated TIMESTAMP,
+
+	CONSTRAINT fk_station_id
+		FOREIGN KEY (station_id)
+		REFERENCES station(id)
+		ON DELETE CASCADE,
+	CONSTRAINT fk_zone_id
+		FOREIGN KEY (zone_id)
+		REFERENCES zone(id)
+		ON DELETE SET NULL
+);
+
+CREATE TABLE command (
+	id             UUID PRIMARY KEY,
+	station_id     UUID,
+	kind           TEXT,
+	valve          TEXT,
+	meter_id       UUID,
+	duration       INT,
+	status         TEXT,
+	error          TEXT,
+	created_by     UUID,
+	date_scheduled TIMESTAMP,
+	date_started   TIMESTAMP,
+	date_finished  TIMESTAMP,
+	date_created   TIMESTAMP,
+
+	CONSTRAINT fk_station_id
+		FOREIGN KEY (station_id)
+		REFERENCES station(id)
+		ON DELETE CASCADE,
+	CONSTRAINT fk_meter_id
+		FOREIGN KEY (meter_id)
+		REFERENCES flow_meter(id)
+		ON DELETE SET NULL,
+	CONSTRAINT fk_created_by
+		FOREIGN KEY (created_by)
+		REFERENCES account(id)
+		ON DELETE SET NULL
+);
+
+CREATE INDEX idx_command_station ON command (station_id, status, date_scheduled);
+
+CREATE TABLE flow_reading (
+	id            UUID PRIMARY KEY,
+	meter_id      UUID,
+	command_id    UUID,
+	pulses        BIGINT,
+	litres        DOUBLE PRECISION,
+	date_read     TIMESTAMP,
+	date_received TIMESTAMP,
+
+	CONSTRAINT fk_meter_id
+		FOREIGN KEY (meter_id)
+		REFERENCES flow_meter(id)
+		ON DELETE CASCADE,
+	CONSTRAINT fk_command_id
+		FOREIGN KEY (command_id)
+		REFERENCES command(id)
+		ON DELETE SET NULL
+);
+
+CREATE INDEX idx_flow_reading_meter ON flow_reading (meter_id, date_read);
+CREATE INDEX idx_flow_reading_command ON flow_reading (command_id);
+`,
+	},
+	{
+		Version:     17,
+		Description: "Add events",
+		Script: `
+CREATE TABLE event (
+	id           UUID PRIMARY KEY,
+	type         TEXT,
+	severity     TEXT,
+	station_id   UUID,
+	message      TEXT,
+	data         JSONB,
+	date_created TIMESTAMP,
+
+	CONSTRAINT fk_station_id
+		FOREIGN KEY (station_id)
+		REFERENCES station(id)
+		ON DELETE CASCADE
+);
+
+CREATE INDEX idx_event_created ON event (date_created);
+CREATE INDEX idx_event_station ON event (station_id, date_created);
+`,
+	},
+	{
+		Version:     18,
+		Description: "Add sensor readings and reservoirs",
+		Script: `
+CREATE TABLE reading (
+	id            UUID PRIMARY KEY,
+	station_id    UUID,
+	sensor        TEXT,
+	value         DOUBLE PRECISION,
+	date_read     TIMESTAMP,
+	date_received TIMESTAMP,
+
+	CONSTRAINT fk_station_id
+		FOREIGN KEY (station_id)
+		REFERENCES station(id)
+		ON DELETE CASCADE
+);
+
+CREATE INDEX idx_reading_station_sensor ON reading (station_id, sensor, date_read);
+
+CREATE TABLE reservoir (
+	station_id      UUID PRIMARY KEY,
+	capacity_litres DOUBLE PRECISION,
+	date_created    TIMESTAMP,
+	date_updated    TIMESTAMP,
+
+	CONSTRAINT fk_station_id
+		FOREIGN KEY (station_id)
+		REFERENCES station(id)
+		ON DELETE CASCADE
+);
+
+CREATE TABLE reservoir_refill (
+	id           UUID PRIMARY KEY,
+	station_id   UUID,
+	date_planned TIMESTAMP,
+	note         TEXT,
+	date_created TIMESTAMP,
+
+	CONSTRAINT fk_station_id
+		FOREIGN KEY (station_id)
+		REFERENCES reservoir(station_id)
+		ON DELETE CASCADE
+);
+`,
+	},
+	{
+		Version:     19,
+		Description: "Add alert rules and alerts",
+		Script: `
+CREATE TABLE alert_rule (
+	id           UUID PRIMARY KEY,
+	name         TEXT,
+	kind         TEXT,
+	sensor       TEXT,
+	operator     TEXT,
+	threshold    DOUBLE PRECISION,
+	hysteresis   DOUBLE PRECISION,
+	for_seconds  INT,
+	severity     TEXT,
+	scope        TEXT,
+	scope_value  TEXT,
+	date_created TIMESTAMP,
+	date_updated TIMESTAMP
+);
+
+CREATE TABLE alert (
+	id                UUID PRIMARY KEY,
+	rule_id           UUID,
+	station_id        UUID,
+	status            TEXT,
+	value             DOUBLE PRECISION,
+	date_started      TIMESTAMP,
+	date_firing       TIMESTAMP,
+	date_resolved     TIMESTAMP,
+	acknowledged_by   UUID,
+	date_acknowledged TIMESTAMP,
+	silenced_until    TIMESTAMP,
+	date_updated      TIMESTAMP,
+
+	CONSTRAINT fk_rule_id
+		FOREIGN KEY (rule_id)
+		REFERENCES alert_rule(id)
+		ON DELETE CASCADE,
+	CONSTRAINT fk_station_id
+		FOREIGN KEY (station_id)
+		REFERENCES station(id)
+		ON DELETE CASCADE,
+	CONSTRAINT fk_acknowledged_by
+		FOREIGN KEY (acknowledged_by)
+		REFERENCES account(id)
+		ON DELETE SET NULL
+);
+
+-- Only one pending or firing alert per rule and station.
+CREATE UNIQUE INDEX idx_alert_open ON alert (rule_id, station_id) WHERE status IN ('pending', 'firing');
+`,
+	},
+	{
+		Version:     20,
+		Description: "Add webhook subscriptions and deliveries",
+		Script: `
+CREATE TABLE webhook_subscription (
+	id           UUID PRIMARY KEY,
+	url          TEXT,
+	secret       TEXT,
+	event_types  TEXT[],
+	date_created TIMESTAMP,
+	date_updated TIMESTAMP
+);
+
+CREATE TABLE webhook_delivery (
+	id                UUID PRIMARY KEY,
+	subscription_id   UUID,
+	event_id          UUID,
+	event_type        TEXT,
+	status            TEXT,
+	attempts          INT,
+	response_status   INT,
+	last_error        TEXT,
+	date_next_attempt TIMESTAMP,
+	date_delivered    TIMESTAMP,
+	date_created      TIMESTAMP,
+
+	UNIQUE (subscription_id, event_id),
+
+	CONSTRAINT fk_subscription_id
+		FOREIGN KEY (subscription_id)
+		REFERENCES webhook_subscription(id)
+		ON DELETE CASCADE,
+	CONSTRAINT fk_event_id
+		FOREIGN KEY (event_id)
+		REFERENCES event(id)
+		ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_delivery_due ON webhook_delivery (status, date_next_attempt);
+`,
+	},
+	{
+		Version:     21,
+		Description: "Add notification preferences and notifications",
+		Script: `
+CREATE TABLE notification_preference (
+	account_id   UUID PRIMARY KEY,
+	email        TEXT,
+	event_types  TEXT[],
+	enabled      BOOLEAN,
+	date_created TIMESTAMP,
+	date_updated TIMESTAMP,
+
+	CONSTRAINT fk_account_id
+		FOREIGN KEY (account_id)
+		REFERENCES account(id)
+		ON DELETE CASCADE
+);
+
+CREATE TABLE notification (
+	id           UUID PRIMARY KEY,
+	account_id   UUID,
+	event_id     UUID,
+	channel      TEXT,
+	status       TEXT,
+	error        TEXT,
+	date_sent    TIMESTAMP,
+	date_created TIMESTAMP,
+
+	UNIQUE (account_id, event_id),
+
+	CONSTRAINT fk_account_id
+		FOREIGN KEY (account_id)
+		REFERENCES account(id)
+		ON DELETE CASCADE,
+	CONSTRAINT fk_event_id
+		FOREIGN KEY (event_id)
+		REFERENCES event(id)
+		ON DELETE CASCADE
+);
+`,
+	},
+	{
+		Version:     22,
+		Description: "Add notification channels, quiet hours and digests",
+		Script: `
+ALTER TABLE notification_preference
+	ADD COLUMN channels         TEXT[] NOT NULL DEFAULT '{email}',
+	ADD COLUMN min_severity     TEXT NOT NULL DEFAULT 'info',
+	ADD COLUMN quiet_start      TEXT NOT NULL DEFAULT '',
+	ADD COLUMN quiet_end        TEXT NOT NULL DEFAULT '',
+	ADD COLUMN timezone         TEXT NOT NULL DEFAULT 'UTC',
+	ADD COLUMN delivery         TEXT NOT NULL DEFAULT 'immediate',
+	ADD COLUMN digest_at        TEXT NOT NULL DEFAULT '08:00',
+	ADD COLUMN date_last_digest TIMESTAMP;
+
+-- Disabled preferences no longer notify through any channel.
+UPDATE notification_preference SET channels = '{}' WHERE NOT enabled;
+
+ALTER TABLE notification_preference DROP COLUMN enabled;
+
+ALTER TABLE notification ADD COLUMN digest BOOLEAN NOT NULL DEFAULT FALSE;
+`,
+	},
+	{
+		Version:     23,
+		Description: "Add audit trail",
+		Script: `
+-- Entries outlive the accounts and records they refer to so there are no
+-- foreign keys.
+CREATE TABLE audit (
+	id           UUID PRIMARY KEY,
+	actor_id     UUID,
+	action       TEXT,
+	target_id    TEXT,
+	before       JSONB,
+	after        JSONB,
+	trace_id     TEXT,
+	date_created TIMESTAMP
+);
+
+CREATE INDEX idx_audit_date_created ON audit (date_created);
+CREATE INDEX idx_audit_target_id ON audit (target_id);
+`,
+	},
+	{
+		Version:     24,
+		Description: "Add transactional outbox",
+		Script: `
+CREATE TABLE outbox (
+	seq          BIGSERIAL PRIMARY KEY,
+	id           UUID UNIQUE,
+	type         TEXT,
+	aggregate_id TEXT,
+	payload      JSONB,
+	date_created TIMESTAMP
+);
+
+CREATE TABLE outbox_consumer (
+	name         TEXT PRIMARY KEY,
+	position     BIGINT,
+	date_updated TIMESTAMP
+);
+`,
+	},
+	{
+		Version:     25,
+		Description: "Add sensor state for anomaly detection",
+		Script: `
+CREATE TABLE sensor_state (
+	station_id         UUID,
+	sensor             TEXT,
+	mean               DOUBLE PRECISION,
+	variance           DOUBLE PRECISION,
+	count              INT,
+	flat_run           INT,
+	"window"           JSONB,
+	date_last_received TIMESTAMP,
+	date_drift         TIMESTAMP,
+	date_updated       TIMESTAMP,
+
+	PRIMARY KEY (station_id, sensor),
+
+	CONSTRAINT fk_station_id
+		FOREIGN KEY (station_id)
+		REFERENCES station(id)
+		ON DELETE CASCADE
+);
+
+CREATE INDEX idx_reading_date_received ON reading (date_received);
+`,
+	},
+	{
+		Version:     26,
+		Description: "Add frost and heat protection",
+		Script: `
+CREATE TABLE protection_setting (
+	station_type_id UUID PRIMARY KEY,
+	frost_below     DOUBLE PRECISION,
+	frost_response  TEXT,
+	heat_above      DOUBLE PRECISION,
+	heat_response   TEXT,
+	extra_watering  INT,
+	for_seconds     INT,
+	hysteresis      DOUBLE PRECISION,
+	date_updated    TIMESTAMP,
+
+	CONSTRAINT fk_station_type_id
+		FOREIGN KEY (station_type_id)
+		REFERENCES station_type(id)
+		ON DELETE CASCADE
+);
+
+CREATE TABLE protection_condition (
+	id             UUID PRIMARY KEY,
+	station_id     UUID,
+	kind           TEXT,
+	status         TEXT,
+	value          DOUBLE PRECISION,
+	response       TEXT,
+	date_started   TIMESTAMP,
+	date_active    TIMESTAMP,
+	date_ended     TIMESTAMP,
+	date_responded TIMESTAMP,
+	date_updated   TIMESTAMP,
+
+	CONSTRAINT fk_station_id
+		FOREIGN KEY (station_id)
+		REFERENCES station(id)
+		ON DELETE CASCADE
+);
+
+-- Only one pending or active condition of each kind per station.
+CREATE UNIQUE INDEX idx_protection_condition_open ON protection_condition (station_id, kind) WHERE status IN ('pending', 'active');
+`,
+	},
+	{
+		Version:     27,
+		Description: "Add calendar feed tokens",
+		Script: `
+-- Only a SHA-256 hash of each token is stored.
+CREATE TABLE calendar_feed_token (
+	account_id   UUID PRIMARY KEY,
+	token_hash   TEXT UNIQUE,
+	date_created TIMESTAMP,
+
+	CONSTRAINT fk_account_id
+		FOREIGN KEY (account_id)
+		REFERENCES account(id)
+		ON DELETE CASCADE
+);
+`,
+	},
+	{
+		Version:     28,
+		Description: "Add retries of failed notifications",
+		Script: `
+ALTER TABLE notification
+	ADD COLUMN attempts          INT NOT NULL DEFAULT 1,
+	ADD COLUMN date_next_attempt TIMESTAMP;
+`,
+	},
+	{
+		Version:     29,
+		Description: "Add archiving of accounts",
+		Script: `
+-- Accounts deleted along with their stations are archived so the stations can
+-- be restored or purged like those of an archived station type.
+ALTER TABLE account ADD COLUMN date_deleted TIMESTAMP;
+`,
+	},
+	{
+		Version:     30,
+		Description: "Add kind of station type",
+		Script: `
+-- The kind decides what the stations of a type do so renaming a station type
+-- does not change it. Existing station types get their kind from their name
+-- once.
+ALTER TABLE station_type ADD COLUMN kind TEXT NOT NULL DEFAULT 'other'
+	CHECK (kind IN ('base', 'water', 'plant', 'other'));
+
+UPDATE station_type SET kind = 'base' WHERE name = 'Base';
+UPDATE station_type SET kind = 'water' WHERE name = 'Water';
+UPDATE station_type SET kind = 'plant' WHERE name = 'Plant';
 `,
 	},
 }
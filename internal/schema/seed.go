@@ -18,7 +18,13 @@ import (
 // may need to be broken up.
 const seeds = `
 -- Reset tables
+DELETE FROM alert_rule;
+DELETE FROM planting;
+DELETE FROM plant;
+DELETE FROM station_group;
+DELETE FROM station_template;
 DELETE FROM station;
+DELETE FROM zone;
 DELETE FROM station_type;
 DELETE FROM account;
 
@@ -49,67 +55,217 @@ INSERT INTO account
 
 INSERT INTO station_type
     (
-         id, name,
+         id, name, kind,
          description,
          date_created, date_updated
     )
     VALUES
 	(
-        'a2b0639f-2cc6-44b8-b97b-15d69dbb511e', 'Base',
+        'a2b0639f-2cc6-44b8-b97b-15d69dbb511e', 'Base', 'base',
         'Coordinator for all station types - monitor, command and control. Access point to public Internet.',
         '2021-01-01 00:00:01.000001+00', '2021-01-01 00:00:01.000001+00'
 	),
 	(
-        '72f8b983-3eb4-48db-9ed0-e45cc6bd716b', 'Water',
+        '72f8b983-3eb4-48db-9ed0-e45cc6bd716b', 'Water', 'water',
         'Management of water resources. Controls water levels in reservoir and implements irrigation.',
         '2021-01-01 00:00:02.000001+00', '2021-01-01 00:00:02.000001+00'
 	),
 	(
-        '5c86bbaa-4ef8-11eb-ae93-0242ac130002', 'Plant',
+        '5c86bbaa-4ef8-11eb-ae93-0242ac130002', 'Plant', 'plant',
         'Monitors and reports plant health.',
         '2021-01-01 00:00:03.000001+00', '2021-01-01 00:00:03.000001+00'
 	)
 	ON CONFLICT DO NOTHING;
 
+INSERT INTO zone
+    (
+         id, name,
+         description, soil_type,
+         location_x, location_y, width, height,
+         date_created, date_updated
+    )
+    VALUES
+	(
+        '8a1f5c2e-7d3b-4e0a-9c61-2b4d8e6f0a13', 'Tomato row',
+        'Raised bed along the south railing.', 'Loam',
+        3, 2, 4, 2,
+        '2021-01-01 00:00:01.000001+00', '2021-01-01 00:00:01.000001+00'
+	),
+	(
+        'c4e9b7a0-1f62-4d85-a3e7-5b0c9d2f8e41', 'Herb box',
+        'Planter box next to the kitchen door.', 'Potting mix',
+        0, 4, 2, 1,
+        '2021-01-01 00:00:02.000001+00', '2021-01-01 00:00:02.000001+00'
+	)
+	ON CONFLICT DO NOTHING;
+
 INSERT INTO station
     (
          id, station_type_id,
-         account_id, name,
+         account_id, zone_id, name,
          description, location_x, location_y,
          date_created, date_updated
     )
     VALUES
     (
         'ddd3f222-590c-11eb-ae93-0242ac130002', 'a2b0639f-2cc6-44b8-b97b-15d69dbb511e', 
-        '5cf37266-3473-4006-984f-9325122678b7', 'Base Station one',
+        '5cf37266-3473-4006-984f-9325122678b7', NULL, 'Base Station one',
         'Some description of Base Station One', 1, 1,
         '2021-01-01 00:00:01.000001+00', '2021-01-01 00:00:01.000001+00'
     ),
     (
         'ee72a90c-590c-11eb-ae93-0242ac130002', '72f8b983-3eb4-48db-9ed0-e45cc6bd716b',
-        '5cf37266-3473-4006-984f-9325122678b7', 'Water Station one',
+        '5cf37266-3473-4006-984f-9325122678b7', NULL, 'Water Station one',
         'Some description of Water Station One', 2, 2,
         '2021-01-01 00:00:02.000001+00', '2021-01-01 00:00:02.000001+00'
     ),
     (
         'd58f6d32-6332-11eb-ae93-0242ac130002', '5c86bbaa-4ef8-11eb-ae93-0242ac130002',
-        '45b5fbd3-755f-4379-8f07-a58d4a30fa2f', 'Plant Station 0001',
+        '45b5fbd3-755f-4379-8f07-a58d4a30fa2f', '8a1f5c2e-7d3b-4e0a-9c61-2b4d8e6f0a13', 'Plant Station 0001',
         'Some description of Plant Station one', 3, 3,
         '2021-01-01 00:00:03.000001+00', '2021-01-01 00:00:03.000001+00'
     ),
     (
         '27356858-6333-11eb-ae93-0242ac130002', '5c86bbaa-4ef8-11eb-ae93-0242ac130002',
-        'afb7c618-6332-11eb-ae93-0242ac130002', 'Plant Station 0002',
+        'afb7c618-6332-11eb-ae93-0242ac130002', '8a1f5c2e-7d3b-4e0a-9c61-2b4d8e6f0a13', 'Plant Station 0002',
         'Some description of Plant Station two', 4, 3,
         '2021-01-01 00:00:04.000001+00', '2021-01-01 00:00:04.000001+00'
     ),
     (
         '342c0d0a-6333-11eb-ae93-0242ac130002', '5c86bbaa-4ef8-11eb-ae93-0242ac130002',
-        'c08cfdf0-6332-11eb-ae93-0242ac130002', 'Plant Station 0003',
+        'c08cfdf0-6332-11eb-ae93-0242ac130002', '8a1f5c2e-7d3b-4e0a-9c61-2b4d8e6f0a13', 'Plant Station 0003',
         'Some description of Plant Station three', 5, 3,
         '2021-01-01 00:00:05.000001+00', '2021-01-01 00:00:05.000001+00'
     )
 	ON CONFLICT DO NOTHING;
+
+INSERT INTO station_group
+    (
+         id, name,
+         description,
+         date_created, date_updated
+    )
+    VALUES
+	(
+        '0b6f2a4e-93d1-4c58-8e27-6d1a5f3c9b70', 'Seedlings',
+        'Young plants that need watering more often.',
+        '2021-01-01 00:00:01.000001+00', '2021-01-01 00:00:01.000001+00'
+	)
+	ON CONFLICT DO NOTHING;
+
+INSERT INTO station_group_member (group_id, station_id)
+    VALUES
+	('0b6f2a4e-93d1-4c58-8e27-6d1a5f3c9b70', 'd58f6d32-6332-11eb-ae93-0242ac130002'),
+	('0b6f2a4e-93d1-4c58-8e27-6d1a5f3c9b70', '27356858-6333-11eb-ae93-0242ac130002')
+	ON CONFLICT DO NOTHING;
+
+INSERT INTO plant
+    (
+         id, species, common_name,
+         moisture_min, moisture_max, light, days_to_maturity,
+         date_created, date_updated
+    )
+    VALUES
+	(
+        '6e3c1a9d-52b7-4f08-b1d4-7a2e9c0f5b36', 'Solanum lycopersicum', 'Tomato',
+        40, 70, 'full_sun', 75,
+        '2021-01-01 00:00:01.000001+00', '2021-01-01 00:00:01.000001+00'
+	),
+	(
+        'f1a7d3c5-0e94-4b62-8d3f-c59b2e7a1048', 'Ocimum basilicum', 'Basil',
+        50, 70, 'full_sun', 60,
+        '2021-01-01 00:00:02.000001+00', '2021-01-01 00:00:02.000001+00'
+	)
+	ON CONFLICT DO NOTHING;
+
+-- Tomatoes are planted at the plant stations of the Tomato row.
+INSERT INTO planting (id, plant_id, station_id, zone_id, date_planted)
+	SELECT md5(random()::TEXT || id::TEXT)::UUID, '6e3c1a9d-52b7-4f08-b1d4-7a2e9c0f5b36', id, zone_id, date_created
+	FROM station
+	WHERE station_type_id = '5c86bbaa-4ef8-11eb-ae93-0242ac130002';
+
+INSERT INTO station_template
+    (
+         id, station_type_id, name,
+         description, zone_id,
+         date_created, date_updated
+    )
+    VALUES
+	(
+        '9a4e2c7f-3b18-4d65-a0f9-e6c2b8d4f173', '5c86bbaa-4ef8-11eb-ae93-0242ac130002', 'Tomato row plant station',
+        'Soil moisture and light monitoring for a tomato plant.', '8a1f5c2e-7d3b-4e0a-9c61-2b4d8e6f0a13',
+        '2021-01-01 00:00:01.000001+00', '2021-01-01 00:00:01.000001+00'
+	)
+	ON CONFLICT DO NOTHING;
+
+-- The zone-2 valve of Water Station one irrigates the plant stations.
+INSERT INTO station_link
+    (
+         id, type, from_station_id, to_station_id,
+         valve, channel, date_created
+    )
+    VALUES
+	(
+        '1c7e4b2a-8f53-4d90-a6e1-3b9d5c2f7e04', 'irrigates', 'ee72a90c-590c-11eb-ae93-0242ac130002', 'd58f6d32-6332-11eb-ae93-0242ac130002',
+        'zone-2', 1, '2021-01-01 00:00:05.000001+00'
+	),
+	(
+        '2d8f5c3b-9064-4ea1-b7f2-4c0e6d3a8f15', 'irrigates', 'ee72a90c-590c-11eb-ae93-0242ac130002', '27356858-6333-11eb-ae93-0242ac130002',
+        'zone-2', 2, '2021-01-01 00:00:05.000001+00'
+	),
+	(
+        '3e906d4c-a175-4fb2-88a3-5d1f7e4b9026', 'irrigates', 'ee72a90c-590c-11eb-ae93-0242ac130002', '342c0d0a-6333-11eb-ae93-0242ac130002',
+        'zone-2', 3, '2021-01-01 00:00:05.000001+00'
+	)
+	ON CONFLICT DO NOTHING;
+
+-- The zone-2 valve of Water Station one has a flow meter giving 450 pulses per litre.
+INSERT INTO flow_meter
+    (
+         id, station_id, name,
+         valve, zone_id, k_factor,
+         date_created, date_updated
+    )
+    VALUES
+	(
+        '7b2d9e4f-6a13-4c85-9f70-1e8c3a5d2b46', 'ee72a90c-590c-11eb-ae93-0242ac130002', 'Zone 2 meter',
+        'zone-2', '8a1f5c2e-7d3b-4e0a-9c61-2b4d8e6f0a13', 450,
+        '2021-01-01 00:00:02.000001+00', '2021-01-01 00:00:02.000001+00'
+	)
+	ON CONFLICT DO NOTHING;
+
+-- Water Station one has a 200 litre reservoir.
+INSERT INTO reservoir (station_id, capacity_litres, date_created, date_updated)
+    VALUES ('ee72a90c-590c-11eb-ae93-0242ac130002', 200, '2021-01-01 00:00:02.000001+00', '2021-01-01 00:00:02.000001+00')
+	ON CONFLICT DO NOTHING;
+
+-- Alert when the tomatoes are thirsty or a station stops reporting.
+INSERT INTO alert_rule
+    (
+         id, name, kind,
+         sensor, operator, threshold, hysteresis, for_seconds, severity,
+         scope, scope_value,
+         date_created, date_updated
+    )
+    VALUES
+	(
+        '4c8e2a6f-1d93-4b57-a0e8-7f3b5d9c1e24', 'Moisture low', 'threshold',
+        'moisture', 'below', 25, 5, 1200, 'warning',
+        'zone', '8a1f5c2e-7d3b-4e0a-9c61-2b4d8e6f0a13',
+        '2021-01-01 00:00:01.000001+00', '2021-01-01 00:00:01.000001+00'
+	),
+	(
+        'd1f7b3e9-5a26-4c80-9e4d-2b8a6c0f7d35', 'Station offline', 'offline',
+        '', '', 0, 0, 3600, 'critical',
+        '', '',
+        '2021-01-01 00:00:02.000001+00', '2021-01-01 00:00:02.000001+00'
+	)
+	ON CONFLICT DO NOTHING;
+
+-- Every station starts its location history where it was added.
+INSERT INTO station_location (id, station_id, location_x, location_y, date_effective)
+	SELECT md5(random()::TEXT || id::TEXT)::UUID, id, location_x, location_y, date_created
+	FROM station;
 `
 
 // Seed runs the set of seed-data queries against db. The queries are ran in a
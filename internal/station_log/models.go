@@ -0,0 +1,73 @@
+package station_log
+
+import (
+	// Core packages
+	"time"
+)
+
+// Log levels from least to most severe.
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+// levels lists the log levels from least to most severe.
+var levels = []string{LevelDebug, LevelInfo, LevelWarn, LevelError}
+
+// DefaultRetentionDays is how long log lines of a Station are kept when no
+// Retention has been set for it.
+const DefaultRetentionDays = 14
+
+// LogLine is a line of a Station's device log. Id increases with every line
+// received and is used as the cursor when tailing a log.
+type LogLine struct {
+	Id           int64     `db:"id"            json:"id"`
+	StationId    string    `db:"station_id"    json:"station_id"`
+	Level        string    `db:"level"         json:"level"`
+	Module       string    `db:"module"        json:"module"`
+	Message      string    `db:"message"       json:"message"`
+	DateLogged   time.Time `db:"date_logged"   json:"date_logged"`
+	DateReceived time.Time `db:"date_received" json:"date_received"`
+}
+
+// NewLogLine is a log line uploaded by a Station. DateLogged is the time
+// according to the clock of the Station.
+type NewLogLine struct {
+	Level      string    `json:"level" validate:"required,oneof=debug info warn error"`
+	Module     string    `json:"module"`
+	Message    string    `json:"message" validate:"required"`
+	DateLogged time.Time `json:"date_logged" validate:"required"`
+}
+
+// NewLogLines is a batch of log lines uploaded by a Station.
+type NewLogLines struct {
+	Lines []NewLogLine `json:"lines" validate:"required,dive"`
+}
+
+// Query limits the log lines returned by Search. The zero value returns the
+// most recent lines of every level.
+//
+// Text matches lines containing the text in their message or module, ignoring
+// case. Level returns lines of that level or more severe. Since and Until
+// limit the lines to those logged in the time window.
+type Query struct {
+	Text  string
+	Level string
+	Since *time.Time
+	Until *time.Time
+	Limit int
+}
+
+// Retention is how many days log lines of a Station are kept.
+type Retention struct {
+	StationId string `db:"station_id" json:"station_id"`
+	Days      int    `db:"days"       json:"days"`
+}
+
+// UpdateRetention is what we require from clients when changing the Retention
+// of a Station.
+type UpdateRetention struct {
+	Days int `json:"days" validate:"required,gt=0"`
+}
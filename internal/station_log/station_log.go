@@ -0,0 +1,266 @@
+package station_log
+
+import (
+	// Core packages
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Predefined errors identify expected failure conditions.
+var (
+	// ErrInvalidID is used when an invalid UUID is provided.
+	ErrInvalidID = errors.New("ID is not in its proper UUID format")
+
+	// ErrInvalidLevel is used when searching for an unknown log level.
+	ErrInvalidLevel = errors.New("level must be one of debug, info, warn or error")
+)
+
+const (
+	// maxLimit is the most log lines returned by one Search or Tail.
+	maxLimit = 1000
+
+	// tailPoll is how often Tail checks for new log lines while waiting.
+	tailPoll = 500 * time.Millisecond
+)
+
+// Ingest stores log lines uploaded by a Station. It returns the stored lines.
+func Ingest(ctx context.Context, db *sqlx.DB, stationID string, lines []NewLogLine, now time.Time) ([]LogLine, error) {
+
+	ctx, span := trace.StartSpan(ctx, "station_log.Ingest")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	const q = `
+		INSERT INTO station_log
+		  (station_id, level, module, message, date_logged, date_received)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+
+	stored := make([]LogLine, 0, len(lines))
+	for _, nl := range lines {
+		l := LogLine{
+			StationId:    stationID,
+			Level:        nl.Level,
+			Module:       nl.Module,
+			Message:      nl.Message,
+			DateLogged:   nl.DateLogged.UTC(),
+			DateReceived: now.UTC(),
+		}
+		if err := tx.GetContext(ctx, &l.Id, q, l.StationId, l.Level, l.Module, l.Message, l.DateLogged, l.DateReceived); err != nil {
+			return nil, errors.Wrap(err, "inserting log line")
+		}
+		stored = append(stored, l)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "committing log lines")
+	}
+
+	return stored, nil
+}
+
+// Search finds log lines of a Station matching the Query, most recently logged
+// first.
+func Search(ctx context.Context, db *sqlx.DB, stationID string, query Query) ([]LogLine, error) {
+
+	ctx, span := trace.StartSpan(ctx, "station_log.Search")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	args := []interface{}{stationID}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := []string{"station_id = $1"}
+
+	if query.Level != "" {
+		severe, err := atLeast(query.Level)
+		if err != nil {
+			return nil, err
+		}
+		where = append(where, "level = ANY("+arg(pq.Array(severe))+")")
+	}
+	if query.Text != "" {
+		p := arg("%" + escapeLike(query.Text) + "%")
+		where = append(where, fmt.Sprintf("(message ILIKE %s OR module ILIKE %s)", p, p))
+	}
+	if query.Since != nil {
+		where = append(where, "date_logged >= "+arg(query.Since.UTC()))
+	}
+	if query.Until != nil {
+		where = append(where, "date_logged < "+arg(query.Until.UTC()))
+	}
+
+	q := `
+		SELECT id, station_id, level, module, message, date_logged, date_received
+		FROM station_log
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY date_logged DESC, id DESC
+		LIMIT ` + arg(limit(query.Limit))
+
+	lines := []LogLine{}
+	if err := db.SelectContext(ctx, &lines, q, args...); err != nil {
+		return nil, errors.Wrap(err, "selecting log lines")
+	}
+
+	return lines, nil
+}
+
+// Tail gets the log lines of a Station received after the line with the id
+// after, oldest first. When there are none yet it waits up to wait for new
+// lines to arrive so clients can follow a log by calling Tail again with the
+// id of the last line returned.
+func Tail(ctx context.Context, db *sqlx.DB, stationID string, after int64, wait time.Duration) ([]LogLine, error) {
+
+	ctx, span := trace.StartSpan(ctx, "station_log.Tail")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	const q = `
+		SELECT id, station_id, level, module, message, date_logged, date_received
+		FROM station_log
+		WHERE station_id = $1 AND id > $2
+		ORDER BY id
+		LIMIT $3`
+
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+
+	for {
+		lines := []LogLine{}
+		if err := db.SelectContext(ctx, &lines, q, stationID, after, maxLimit); err != nil {
+			return nil, errors.Wrap(err, "selecting log lines")
+		}
+		if len(lines) > 0 {
+			return lines, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline.C:
+			return lines, nil
+		case <-time.After(tailPoll):
+		}
+	}
+}
+
+// GetRetention gets how many days log lines of a Station are kept.
+func GetRetention(ctx context.Context, db *sqlx.DB, stationID string) (*Retention, error) {
+
+	ctx, span := trace.StartSpan(ctx, "station_log.GetRetention")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	r := Retention{StationId: stationID, Days: DefaultRetentionDays}
+
+	const q = `SELECT COALESCE((SELECT days FROM station_log_retention WHERE station_id = $1), $2)`
+	if err := db.GetContext(ctx, &r.Days, q, stationID, DefaultRetentionDays); err != nil {
+		return nil, errors.Wrap(err, "selecting log retention")
+	}
+
+	return &r, nil
+}
+
+// SetRetention changes how many days log lines of a Station are kept.
+func SetRetention(ctx context.Context, db *sqlx.DB, stationID string, days int) error {
+
+	ctx, span := trace.StartSpan(ctx, "station_log.SetRetention")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return ErrInvalidID
+	}
+
+	const q = `
+		INSERT INTO station_log_retention (station_id, days)
+		VALUES ($1, $2)
+		ON CONFLICT (station_id) DO UPDATE SET days = EXCLUDED.days`
+
+	if _, err := db.ExecContext(ctx, q, stationID, days); err != nil {
+		return errors.Wrapf(err, "setting log retention of station %s", stationID)
+	}
+
+	return nil
+}
+
+// Prune removes log lines received longer ago than the Retention of their
+// Station. It returns the number of lines removed.
+func Prune(ctx context.Context, db *sqlx.DB, now time.Time) (int64, error) {
+
+	ctx, span := trace.StartSpan(ctx, "station_log.Prune")
+	defer span.End()
+
+	const q = `
+		DELETE FROM station_log
+		WHERE date_received < $1::TIMESTAMP - make_interval(days => COALESCE(
+			(SELECT days FROM station_log_retention WHERE station_log_retention.station_id = station_log.station_id),
+			$2::INT))`
+
+	res, err := db.ExecContext(ctx, q, now.UTC(), DefaultRetentionDays)
+	if err != nil {
+		return 0, errors.Wrap(err, "pruning log lines")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "counting pruned log lines")
+	}
+
+	return n, nil
+}
+
+// atLeast gives the log levels at least as severe as level.
+func atLeast(level string) ([]string, error) {
+	for i, l := range levels {
+		if l == level {
+			return levels[i:], nil
+		}
+	}
+	return nil, ErrInvalidLevel
+}
+
+// escapeLike escapes the LIKE pattern characters in s so it is matched as is.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// limit caps the number of log lines requested, defaulting to 100.
+func limit(n int) int {
+	switch {
+	case n <= 0:
+		return 100
+	case n > maxLimit:
+		return maxLimit
+	}
+	return n
+}
@@ -0,0 +1,110 @@
+package station_log_test
+
+import (
+	// Core packages
+	"context"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/schema"
+	"github.com/deezone/HydroBytes-BaseStation/internal/station_log"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+)
+
+func TestStationLog(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2021, time.May, 1, 12, 0, 0, 0, time.UTC)
+	station := "d58f6d32-6332-11eb-ae93-0242ac130002"
+
+	lines := []station_log.NewLogLine{
+		{Level: station_log.LevelInfo, Module: "wifi", Message: "connected to garden-ap", DateLogged: now.Add(-3 * time.Minute)},
+		{Level: station_log.LevelWarn, Module: "sensor", Message: "moisture reading out of range", DateLogged: now.Add(-2 * time.Minute)},
+		{Level: station_log.LevelError, Module: "wifi", Message: "connection lost", DateLogged: now.Add(-1 * time.Minute)},
+	}
+
+	stored, err := station_log.Ingest(ctx, db, station, lines, now)
+	if err != nil {
+		t.Fatalf("ingesting log lines: %s", err)
+	}
+	if exp, got := 3, len(stored); exp != got {
+		t.Fatalf("expected %v stored lines, got %v", exp, got)
+	}
+
+	since := now.Add(-150 * time.Second)
+	tt := []struct {
+		name  string
+		query station_log.Query
+		want  int
+	}{
+		{"everything", station_log.Query{}, 3},
+		{"text in module", station_log.Query{Text: "WIFI"}, 2},
+		{"text in message", station_log.Query{Text: "range"}, 1},
+		{"level and above", station_log.Query{Level: station_log.LevelWarn}, 2},
+		{"time window", station_log.Query{Since: &since}, 2},
+		{"limit", station_log.Query{Limit: 1}, 1},
+		{"like characters are literal", station_log.Query{Text: "%"}, 0},
+	}
+
+	for _, tc := range tt {
+		found, err := station_log.Search(ctx, db, station, tc.query)
+		if err != nil {
+			t.Fatalf("%s: searching: %s", tc.name, err)
+		}
+		if got := len(found); got != tc.want {
+			t.Errorf("%s: expected %v lines, got %v", tc.name, tc.want, got)
+		}
+	}
+
+	if _, err := station_log.Search(ctx, db, station, station_log.Query{Level: "fatal"}); err != station_log.ErrInvalidLevel {
+		t.Fatalf("searching unknown level: expected %v, got %v", station_log.ErrInvalidLevel, err)
+	}
+
+	// Tailing after the first line gives the other two, oldest first.
+	tail, err := station_log.Tail(ctx, db, station, stored[0].Id, 0)
+	if err != nil {
+		t.Fatalf("tailing: %s", err)
+	}
+	if exp, got := 2, len(tail); exp != got {
+		t.Fatalf("expected %v tailed lines, got %v", exp, got)
+	}
+	if tail[0].Id != stored[1].Id {
+		t.Fatalf("expected tail to start at line %v, got %v", stored[1].Id, tail[0].Id)
+	}
+
+	// Nothing new arrives while waiting.
+	tail, err = station_log.Tail(ctx, db, station, stored[2].Id, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("tailing: %s", err)
+	}
+	if exp, got := 0, len(tail); exp != got {
+		t.Fatalf("expected %v tailed lines, got %v", exp, got)
+	}
+
+	// Keep the log for one day and prune two days later.
+	if err := station_log.SetRetention(ctx, db, station, 1); err != nil {
+		t.Fatalf("setting retention: %s", err)
+	}
+	r, err := station_log.GetRetention(ctx, db, station)
+	if err != nil {
+		t.Fatalf("getting retention: %s", err)
+	}
+	if exp, got := 1, r.Days; exp != got {
+		t.Fatalf("expected retention of %v days, got %v", exp, got)
+	}
+
+	pruned, err := station_log.Prune(ctx, db, now.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatalf("pruning: %s", err)
+	}
+	if exp, got := int64(3), pruned; exp != got {
+		t.Fatalf("expected %v pruned lines, got %v", exp, got)
+	}
+}
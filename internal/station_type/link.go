@@ -0,0 +1,217 @@
+package station_type
+
+import (
+	// Core packages
+	"context"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/audit"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+var (
+	// ErrLinkNotAllowed is used when the station types of the linked Stations
+	// do not fit the type of link.
+	ErrLinkNotAllowed = errors.New("station link type not allowed between these station types")
+
+	// ErrLinkExists is used when the same link between two Stations is added twice.
+	ErrLinkExists = errors.New("station link already exists")
+)
+
+// Link directions for ListStationLinks.
+const (
+	LinksFrom = "from"
+	LinksTo   = "to"
+)
+
+// linkAllowed reports whether a link of type linkType may go from a Station of
+// station type kind from to a Station of station type kind to.
+//
+//	irrigates   a water station irrigates a plant station
+//	relays_for  a base station relays for a station of any other kind
+//	backup_of   a station is the backup of another station of the same kind
+func linkAllowed(linkType, from, to string) bool {
+	switch linkType {
+	case LinkIrrigates:
+		return from == KindWater && to == KindPlant
+	case LinkRelaysFor:
+		return from == KindBase && to != KindBase
+	case LinkBackupOf:
+		return from == to
+	}
+	return false
+}
+
+// AddStationLink links the Station identified by fromID to another Station.
+// The station types of both Stations must fit the type of link.
+func AddStationLink(ctx context.Context, db *sqlx.DB, fromID string, nl NewStationLink, now time.Time) (*StationLink, error) {
+
+	ctx, span := trace.StartSpan(ctx, "station.AddStationLink")
+	defer span.End()
+
+	if _, err := uuid.Parse(fromID); err != nil {
+		return nil, ErrInvalidID
+	}
+	if _, err := uuid.Parse(nl.ToStationId); err != nil {
+		return nil, ErrInvalidID
+	}
+	if fromID == nl.ToStationId {
+		return nil, ErrLinkNotAllowed
+	}
+
+	var types []struct {
+		Id   string `db:"id"`
+		Kind string `db:"kind"`
+	}
+	const q = `
+		SELECT station.id, station_type.kind
+		FROM station
+		  JOIN station_type ON station_type.id = station.station_type_id
+		WHERE station.id IN ($1, $2) AND station.date_deleted IS NULL`
+	if err := db.SelectContext(ctx, &types, q, fromID, nl.ToStationId); err != nil {
+		return nil, errors.Wrap(err, "selecting station types of link")
+	}
+	if len(types) != 2 {
+		return nil, ErrStationNotFound
+	}
+
+	kinds := make(map[string]string, 2)
+	for _, t := range types {
+		kinds[t.Id] = t.Kind
+	}
+	if !linkAllowed(nl.Type, kinds[fromID], kinds[nl.ToStationId]) {
+		return nil, ErrLinkNotAllowed
+	}
+
+	l := StationLink{
+		Id:            uuid.New().String(),
+		Type:          nl.Type,
+		FromStationId: fromID,
+		ToStationId:   nl.ToStationId,
+		Valve:         nl.Valve,
+		Channel:       nl.Channel,
+		DateCreated:   now.UTC(),
+	}
+
+	const insert = `
+		INSERT INTO station_link
+		  (id, type, from_station_id, to_station_id, valve, channel, date_created)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (type, from_station_id, to_station_id) DO NOTHING`
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, insert,
+		l.Id,
+		l.Type,
+		l.FromStationId,
+		l.ToStationId,
+		l.Valve,
+		l.Channel,
+		l.DateCreated,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "inserting station link")
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, errors.Wrap(err, "inserting station link")
+	} else if n == 0 {
+		return nil, ErrLinkExists
+	}
+
+	if err := audit.Write(ctx, tx, "station_link.create", l.Id, nil, l, now); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "committing station link")
+	}
+
+	return &l, nil
+}
+
+// DeleteStationLink removes the StationLink identified by a given ID.
+func DeleteStationLink(ctx context.Context, db *sqlx.DB, id string, now time.Time) error {
+
+	ctx, span := trace.StartSpan(ctx, "station.DeleteStationLink")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrInvalidID
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	var before []StationLink
+	const q = `
+		DELETE FROM station_link WHERE id = $1
+		RETURNING id, type, from_station_id, to_station_id, valve, channel, date_created`
+	if err := sqlx.SelectContext(ctx, tx, &before, q, id); err != nil {
+		return errors.Wrapf(err, "deleting station link %s", id)
+	}
+
+	if len(before) > 0 {
+		if err := audit.Write(ctx, tx, "station_link.delete", id, before[0], nil, now); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrapf(err, "committing delete of station link %s", id)
+	}
+
+	return nil
+}
+
+// ListStationLinks gives the links of a Station. With direction LinksFrom only
+// the links from the Station are returned, with LinksTo only the links to the
+// Station and otherwise both. Links to or from archived Stations are left out.
+// When linkType is set only links of that type are returned.
+func ListStationLinks(ctx context.Context, db *sqlx.DB, stationID, direction, linkType string) ([]StationLink, error) {
+
+	ctx, span := trace.StartSpan(ctx, "station.ListStationLinks")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	links := []StationLink{}
+
+	const q = `
+		SELECT
+			station_link.id,
+			station_link.type,
+			station_link.from_station_id,
+			station_link.to_station_id,
+			station_link.valve,
+			station_link.channel,
+			station_link.date_created
+		FROM station_link
+		  JOIN station AS from_station ON from_station.id = station_link.from_station_id
+		  JOIN station AS to_station ON to_station.id = station_link.to_station_id
+		WHERE from_station.date_deleted IS NULL AND to_station.date_deleted IS NULL
+		  AND (($2 <> 'to' AND station_link.from_station_id = $1) OR ($2 <> 'from' AND station_link.to_station_id = $1))
+		  AND ($3 = '' OR station_link.type = $3)
+		ORDER BY station_link.type, station_link.channel, station_link.date_created`
+
+	if err := db.SelectContext(ctx, &links, q, stationID, direction, linkType); err != nil {
+		return nil, errors.Wrap(err, "selecting station links")
+	}
+
+	return links, nil
+}
@@ -0,0 +1,100 @@
+package station_type
+
+import (
+	// Core packages
+	"context"
+	"database/sql"
+	"time"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// ErrLocationNotFound is used when the location of a Station is requested for a
+// time before the Station was added.
+var ErrLocationNotFound = errors.New("station location not found")
+
+// recordLocation adds an entry to the location history of a Station. The entry
+// is effective from the given time until the next entry.
+func recordLocation(ctx context.Context, tx *sqlx.Tx, stationID string, x, y int, effective time.Time) error {
+
+	const q = `INSERT INTO station_location
+		(id, station_id, location_x, location_y, date_effective)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	if _, err := tx.ExecContext(ctx, q, uuid.New().String(), stationID, x, y, effective.UTC()); err != nil {
+		return errors.Wrap(err, "inserting station location")
+	}
+
+	return nil
+}
+
+// ListStationLocations gives the location history of a Station, oldest first.
+func ListStationLocations(ctx context.Context, db *sqlx.DB, stationID string) ([]StationLocation, error) {
+
+	ctx, span := trace.StartSpan(ctx, "station.ListStationLocations")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	locations := []StationLocation{}
+
+	const q = `
+      SELECT
+        id,
+        station_id,
+        location_x,
+        location_y,
+        date_effective
+      FROM station_location
+      WHERE station_id = $1
+      ORDER BY date_effective`
+
+	if err := db.SelectContext(ctx, &locations, q, stationID); err != nil {
+		return nil, errors.Wrap(err, "selecting station locations")
+	}
+
+	return locations, nil
+}
+
+// StationLocationAt finds where a Station was at a given time. This is the
+// location to attribute a reading to rather than the current location of the
+// Station, which may have moved since.
+func StationLocationAt(ctx context.Context, db *sqlx.DB, stationID string, at time.Time) (*StationLocation, error) {
+
+	ctx, span := trace.StartSpan(ctx, "station.StationLocationAt")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var l StationLocation
+
+	const q = `
+      SELECT
+        id,
+        station_id,
+        location_x,
+        location_y,
+        date_effective
+      FROM station_location
+      WHERE station_id = $1 AND date_effective <= $2
+      ORDER BY date_effective DESC
+      LIMIT 1`
+
+	if err := db.GetContext(ctx, &l, q, stationID, at.UTC()); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrLocationNotFound
+		}
+
+		return nil, errors.Wrap(err, "selecting station location")
+	}
+
+	return &l, nil
+}
@@ -2,7 +2,12 @@ package station_type
 
 import (
 	// Core packages
+	"database/sql/driver"
+	"encoding/json"
 	"time"
+
+	// Third-party packages
+	"github.com/pkg/errors"
 )
 
 /**
@@ -15,17 +20,21 @@ import (
  * Note: the use of db:"id" allows renaming to map to the column used in the database
  */
 type StationType struct {
-	Id          string    `db:"id"           json:"id"`
-	Name        string    `db:"name"         json:"name"`
-	Description string    `db:"description"  json:"description"`
-	Stations    int       `db:"stations"     json:"stations"`
-	DateCreated time.Time `db:"date_created" json:"date_created"`
-	DateUpdated time.Time `db:"date_updated" json:"date_updated"`
+	Id          string     `db:"id"           json:"id"`
+	Name        string     `db:"name"         json:"name"`
+	Kind        string     `db:"kind"         json:"kind"`
+	Description string     `db:"description"  json:"description"`
+	Stations    int        `db:"stations"     json:"stations"`
+	DateCreated time.Time  `db:"date_created" json:"date_created"`
+	DateUpdated time.Time  `db:"date_updated" json:"date_updated"`
+	DateDeleted *time.Time `db:"date_deleted" json:"date_deleted,omitempty"`
 }
 
 // NewStationType is what we require from clients when adding a StationType.
+// Kind is KindOther when not given and can not be changed afterwards.
 type NewStationType struct {
 	Name        string    `json:"name" validate:"required"`
+	Kind        string    `json:"kind" validate:"omitempty,oneof=base water plant other"`
 	Description string    `json:"description"`
 }
 
@@ -40,25 +49,77 @@ type UpdateStationType struct {
 	Description  *string `json:"description"`
 }
 
+// DeleteStationType defines what happens to the Stations of a StationType that
+// is deleted. Without either option a StationType with Stations is not deleted.
+type DeleteStationType struct {
+	ReassignTo string // ID of the StationType the Stations are moved to
+	Cascade    bool   // Stations are deleted along with the StationType
+}
+
 // Station is a station that is defined as one of the station types in StationType.
 type Station struct {
+	Id            string     `db:"id"              json:"id"`
+	StationTypeId string     `db:"station_type_id" json:"station_type_id"`
+	AccountId     string     `db:"account_id"      json:"account_id"`
+	ZoneId        *string    `db:"zone_id"         json:"zone_id"`
+	Name          string     `db:"name"            json:"name"`
+	Description   string     `db:"description"     json:"description"`
+	LocationX     int        `db:"location_x"      json:"location_x"`
+	LocationY     int        `db:"location_y"      json:"location_y"`
+	Tags          Tags       `db:"tags"            json:"tags,omitempty"`
+	DateCreated   time.Time  `db:"date_created"    json:"date_created"`
+	DateUpdated   time.Time  `db:"date_updated"    json:"date_updated"`
+	DateDeleted   *time.Time `db:"date_deleted"    json:"date_deleted,omitempty"`
+
+	// Links are only loaded when a single Station is requested.
+	Links []StationLink `db:"-" json:"links,omitempty"`
+}
+
+// NewStation is a what we require from clients when adding a Station.
+//
+// When TemplateId is set the Name, Description, ZoneId and Tags of the
+// StationTemplate are used for any of them that are not given. Tags given
+// along with a template are added to the tags of the template.
+type NewStation struct {
+	Name          string    `db:"name"            json:"name" validate:"required_without=TemplateId"`
+	Description   string    `db:"description"     json:"description"`
+	LocationX     int       `db:"location_x"      json:"location_x" validate:"required,gte=0"`
+	LocationY     int       `db:"location_y"      json:"location_y" validate:"required,gte=0"`
+	ZoneId        *string   `db:"zone_id"         json:"zone_id" validate:"omitempty,uuid"`
+	Tags          Tags      `db:"tags"            json:"tags"`
+	TemplateId    *string   `db:"-"               json:"template_id" validate:"omitempty,uuid"`
+}
+
+// NewStationClone is what we require from clients when cloning a Station. The
+// clone is placed in the Zone of the original Station unless ZoneId is given.
+type NewStationClone struct {
+	Name      string  `json:"name" validate:"required"`
+	LocationX int     `json:"location_x" validate:"required,gte=0"`
+	LocationY int     `json:"location_y" validate:"required,gte=0"`
+	ZoneId    *string `json:"zone_id" validate:"omitempty,uuid"`
+}
+
+// StationTemplate is a reusable Station configuration of a StationType.
+// Stations are added from a template by setting NewStation.TemplateId.
+type StationTemplate struct {
 	Id            string    `db:"id"              json:"id"`
 	StationTypeId string    `db:"station_type_id" json:"station_type_id"`
-	AccountId     string    `db:"account_id"      json:"account_id"`
 	Name          string    `db:"name"            json:"name"`
 	Description   string    `db:"description"     json:"description"`
-	LocationX     int       `db:"location_x"      json:"location_x"`
-	LocationY     int       `db:"location_y"      json:"location_y"`
+	ZoneId        *string   `db:"zone_id"         json:"zone_id"`
+	Tags          Tags      `db:"tags"            json:"tags,omitempty"`
 	DateCreated   time.Time `db:"date_created"    json:"date_created"`
 	DateUpdated   time.Time `db:"date_updated"    json:"date_updated"`
 }
 
-// NewStation is a what we require from clients when adding a Station.
-type NewStation struct {
-	Name          string    `db:"name"            json:"name" validate:"required"`
-	Description   string    `db:"description"     json:"description"`
-	LocationX     int       `db:"location_x"      json:"location_x" validate:"required,gte=0"`
-	LocationY     int       `db:"location_y"      json:"location_y" validate:"required,gte=0"`
+// NewStationTemplate is what we require from clients when adding a
+// StationTemplate.
+type NewStationTemplate struct {
+	StationTypeId string  `json:"station_type_id" validate:"required,uuid"`
+	Name          string  `json:"name" validate:"required"`
+	Description   string  `json:"description"`
+	ZoneId        *string `json:"zone_id" validate:"omitempty,uuid"`
+	Tags          Tags    `json:"tags"`
 }
 
 // UpdateStation defines what information may be provided to modify an
@@ -67,9 +128,135 @@ type NewStation struct {
 // between a field that were not provided and a field that was provided as
 // explicitly blank. Normally we do not want to use pointers to basic types but
 // we make exceptions around marshalling/unmarshalling.
+//
+// A ZoneId of "" removes the Station from its current Zone. Tags replace all of
+// the existing tags of the Station.
 type UpdateStation struct {
 	Name         *string `json:"name"`
 	Description  *string `json:"description"`
 	LocationX    *int    `json:"location_x" validate:"omitempty,gte=0"`
 	LocationY    *int    `json:"location_y" validate:"omitempty,gte=0"`
+	ZoneId       *string `json:"zone_id" validate:"omitempty,uuid"`
+	Tags         *Tags   `json:"tags"`
+}
+
+// Point is a position on the station location grid.
+type Point struct {
+	X int
+	Y int
+}
+
+// BBox is a rectangle on the station location grid. Both corners are inclusive.
+type BBox struct {
+	MinX int
+	MinY int
+	MaxX int
+	MaxY int
+}
+
+// MaxRadius is the largest Radius of a StationFilter, in grid units. It keeps
+// the squared distance of a radius search well within range of an integer.
+const MaxRadius = 10000
+
+// StationFilter limits the Stations returned by ListAllStations. The zero value
+// matches every Station.
+//
+// When Near is set only Stations within Radius grid units of the Point are
+// returned, ordered by distance. When BBox is set only Stations inside the box
+// are returned. Both may be combined. Only Stations matching every TagFilter and
+// belonging to the group GroupId are returned when those are set. Archived
+// Stations are only returned when IncludeArchived is set.
+type StationFilter struct {
+	Near            *Point
+	Radius          int
+	BBox            *BBox
+	Tags            []TagFilter
+	GroupId         string
+	IncludeArchived bool
+}
+
+// TagFilter matches Stations with the tag Key. When Value is set the tag must
+// also have that value.
+type TagFilter struct {
+	Key   string
+	Value *string
+}
+
+// StationLocation is an entry in the location history of a Station. The
+// Station was at LocationX, LocationY from DateEffective until the next entry.
+type StationLocation struct {
+	Id            string    `db:"id"             json:"id"`
+	StationId     string    `db:"station_id"     json:"station_id"`
+	LocationX     int       `db:"location_x"     json:"location_x"`
+	LocationY     int       `db:"location_y"     json:"location_y"`
+	DateEffective time.Time `db:"date_effective" json:"date_effective"`
+}
+
+// Station link types.
+const (
+	LinkIrrigates = "irrigates"
+	LinkRelaysFor = "relays_for"
+	LinkBackupOf  = "backup_of"
+)
+
+// StationLink is a typed relationship from one Station to another, for example
+// a Water station that irrigates a Plant station. Valve and Channel identify
+// the part of the from Station that serves the to Station.
+type StationLink struct {
+	Id            string    `db:"id"              json:"id"`
+	Type          string    `db:"type"            json:"type"`
+	FromStationId string    `db:"from_station_id" json:"from_station_id"`
+	ToStationId   string    `db:"to_station_id"   json:"to_station_id"`
+	Valve         string    `db:"valve"           json:"valve,omitempty"`
+	Channel       *int      `db:"channel"         json:"channel,omitempty"`
+	DateCreated   time.Time `db:"date_created"    json:"date_created"`
+}
+
+// NewStationLink is what we require from clients when linking a Station to
+// another Station.
+type NewStationLink struct {
+	Type        string `json:"type" validate:"required,oneof=irrigates relays_for backup_of"`
+	ToStationId string `json:"to_station_id" validate:"required,uuid"`
+	Valve       string `json:"valve"`
+	Channel     *int   `json:"channel" validate:"omitempty,gte=0"`
+}
+
+// Tags are free form key/value labels such as "seedlings" or "hardware":
+// "prototype". They are stored as a JSON object in the tags column.
+type Tags map[string]string
+
+// Scan implements the sql.Scanner interface to read Tags from the database.
+func (t *Tags) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		*t = nil
+		return nil
+	default:
+		return errors.Errorf("unsupported type %T for tags", src)
+	}
+
+	if err := json.Unmarshal(data, t); err != nil {
+		return err
+	}
+
+	// Stations without tags are read as nil so they match a new Station.
+	if len(*t) == 0 {
+		*t = nil
+	}
+
+	return nil
+}
+
+// Value implements the driver.Valuer interface to write Tags to the database.
+func (t Tags) Value() (driver.Value, error) {
+	if t == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(t)
 }
@@ -4,9 +4,14 @@ import (
 	// Core packages
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/audit"
+	"github.com/deezone/HydroBytes-BaseStation/internal/outbox"
 	"github.com/deezone/HydroBytes-BaseStation/internal/platform/auth"
 
 	// Third-party packages
@@ -16,6 +21,20 @@ import (
 	"go.opencensus.io/trace"
 )
 
+// Types of the outbox Messages of Station changes.
+const (
+	EventStationCreated  = "station.created"
+	EventStationUpdated  = "station.updated"
+	EventStationDeleted  = "station.deleted"
+	EventStationRestored = "station.restored"
+	EventStationPurged   = "station.purged"
+)
+
+// StationColumns are the columns of a Station, for statements that return the
+// Stations they change such as those run when a station type or an account is
+// deleted.
+const StationColumns = `id, station_type_id, account_id, zone_id, name, description, location_x, location_y, tags, date_created, date_updated, date_deleted`
+
 // Predefined errors identify expected failure conditions.
 var (
 	// ErrStationNotFound is used when a specific Station is requested but does not exist.
@@ -24,6 +43,20 @@ var (
 	// ErrForbidden occurs when an account tries to do something that is forbidden to
 	// it according to our access control policies.
 	ErrForbidden = errors.New("Attempted action is not allowed")
+
+	// ErrZoneNotFound is used when a Station is assigned to a Zone that does not exist.
+	ErrZoneNotFound = errors.New("zone not found")
+
+	// ErrStationTypeArchived is used when a Station is restored while its
+	// StationType is still archived.
+	ErrStationTypeArchived = errors.New("station type of the station is archived")
+
+	// ErrAccountArchived is used when a Station is restored while its account
+	// is archived.
+	ErrAccountArchived = errors.New("account of the station is archived")
+
+	// ErrInvalidTag is used when a tag filter is not given as key or key:value.
+	ErrInvalidTag = errors.New("tag must be key or key:value")
 )
 
 // AddStation adds a station of a specific StationType.
@@ -32,38 +65,166 @@ func AddStation(ctx context.Context, db *sqlx.DB, account auth.Claims, ns NewSta
 	ctx, span := trace.StartSpan(ctx, "station.AddStation")
 	defer span.End()
 
+	if ns.TemplateId != nil {
+		if err := applyTemplate(ctx, db, &ns, stationTypeID); err != nil {
+			return nil, err
+		}
+	}
+
+	if ns.ZoneId != nil {
+		if err := checkZone(ctx, db, *ns.ZoneId); err != nil {
+			return nil, err
+		}
+	}
+
 	s := Station{
 		Id:            uuid.New().String(),
 		StationTypeId: stationTypeID,
 		AccountId:     account.Subject,
+		ZoneId:        ns.ZoneId,
 		Name:          ns.Name,
 		Description:   ns.Description,
 		LocationX:     ns.LocationX,
 		LocationY:     ns.LocationY,
+		Tags:          ns.Tags,
 		DateCreated:   now.UTC(),
 		DateUpdated:   now.UTC(),
 	}
 
+	// The station and the first entry of its location history are saved together.
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	if err := insertStation(ctx, tx, s); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "committing station")
+	}
+
+	return &s, nil
+}
+
+// CloneStation adds a copy of the Station identified by id at a new location.
+// The description, tags, zone and group memberships of the Station are copied
+// along with its settings: log retention, reservoir capacity and flow meters,
+// which get IDs of their own. Links to other Stations, plantings and alert
+// rules scoped to the Station are not copied as they depend on where the
+// clone is placed, and neither is its history of locations, readings,
+// heartbeats, logs, commands and refills.
+func CloneStation(ctx context.Context, db *sqlx.DB, account auth.Claims, id string, cs NewStationClone, now time.Time) (*Station, error) {
+
+	ctx, span := trace.StartSpan(ctx, "station.CloneStation")
+	defer span.End()
+
+	orig, err := GetStation(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s := Station{
+		Id:            uuid.New().String(),
+		StationTypeId: orig.StationTypeId,
+		AccountId:     account.Subject,
+		ZoneId:        orig.ZoneId,
+		Name:          cs.Name,
+		Description:   orig.Description,
+		LocationX:     cs.LocationX,
+		LocationY:     cs.LocationY,
+		Tags:          orig.Tags,
+		DateCreated:   now.UTC(),
+		DateUpdated:   now.UTC(),
+	}
+
+	if cs.ZoneId != nil {
+		if err := checkZone(ctx, db, *cs.ZoneId); err != nil {
+			return nil, err
+		}
+		s.ZoneId = cs.ZoneId
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	if err := insertStation(ctx, tx, s); err != nil {
+		return nil, err
+	}
+
+	const q = `
+		INSERT INTO station_group_member (group_id, station_id)
+		SELECT group_id, $2 FROM station_group_member WHERE station_id = $1`
+	if _, err := tx.ExecContext(ctx, q, id, s.Id); err != nil {
+		return nil, errors.Wrapf(err, "copying groups of station %s", id)
+	}
+
+	settings := []struct {
+		name, q string
+	}{
+		{"log retention", `
+			INSERT INTO station_log_retention (station_id, days)
+			SELECT $2, days FROM station_log_retention WHERE station_id = $1`},
+		{"reservoir", `
+			INSERT INTO reservoir (station_id, capacity_litres, date_created, date_updated)
+			SELECT $2, capacity_litres, $3, $3 FROM reservoir WHERE station_id = $1`},
+		{"flow meters", `
+			INSERT INTO flow_meter (id, station_id, name, valve, zone_id, k_factor, date_created, date_updated)
+			SELECT md5(random()::TEXT || id::TEXT)::UUID, $2, name, valve, zone_id, k_factor, $3, $3
+			FROM flow_meter WHERE station_id = $1`},
+	}
+	for _, st := range settings {
+		if _, err := tx.ExecContext(ctx, st.q, id, s.Id, s.DateCreated); err != nil {
+			return nil, errors.Wrapf(err, "copying %s of station %s", st.name, id)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "committing station")
+	}
+
+	return &s, nil
+}
+
+// insertStation saves a new Station along with the first entry of its
+// location history, its audit Entry and the outbox Message of its creation.
+func insertStation(ctx context.Context, tx *sqlx.Tx, s Station) error {
+
 	const q = `INSERT INTO station
-		(id, station_type_id, account_id, name, description, location_x, location_y, date_created, date_updated)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+		(id, station_type_id, account_id, zone_id, name, description, location_x, location_y, tags, date_created, date_updated)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
 
-	_, err := db.ExecContext(ctx, q,
+	_, err := tx.ExecContext(ctx, q,
 		s.Id,
 		s.StationTypeId,
 		s.AccountId,
+		s.ZoneId,
 		s.Name,
 		s.Description,
 		s.LocationX,
 		s.LocationY,
+		s.Tags,
 		s.DateCreated,
 		s.DateUpdated,
 	)
 	if err != nil {
-		return nil, errors.Wrap(err, "inserting station")
+		return errors.Wrap(err, "inserting station")
 	}
 
-	return &s, nil
+	if err := audit.Write(ctx, tx, "station.create", s.Id, nil, s, s.DateCreated); err != nil {
+		return err
+	}
+
+	if err := outbox.Add(ctx, tx, EventStationCreated, s.Id, s, s.DateCreated); err != nil {
+		return err
+	}
+
+	return recordLocation(ctx, tx, s.Id, s.LocationX, s.LocationY, s.DateCreated)
 }
 
 // AdjustStation modifies data about a Station. It will error if the specified ID is
@@ -84,6 +245,10 @@ func AdjustStation(ctx context.Context, db *sqlx.DB, account auth.Claims, id str
 	if !account.HasRole(auth.RoleAdmin) && s.AccountId != account.Subject {
 		return ErrForbidden
 	}
+	before := *s
+
+	moved := (update.LocationX != nil && *update.LocationX != s.LocationX) ||
+		(update.LocationY != nil && *update.LocationY != s.LocationY)
 
 	if update.Name != nil {
 		s.Name = *update.Name
@@ -97,6 +262,18 @@ func AdjustStation(ctx context.Context, db *sqlx.DB, account auth.Claims, id str
 	if update.LocationY != nil {
 		s.LocationY = *update.LocationY
 	}
+	if update.Tags != nil {
+		s.Tags = *update.Tags
+	}
+	if update.ZoneId != nil {
+		s.ZoneId = nil
+		if *update.ZoneId != "" {
+			if err := checkZone(ctx, db, *update.ZoneId); err != nil {
+				return err
+			}
+			s.ZoneId = update.ZoneId
+		}
+	}
 	s.DateUpdated = now
 
 	const q = `UPDATE station SET
@@ -104,44 +281,167 @@ func AdjustStation(ctx context.Context, db *sqlx.DB, account auth.Claims, id str
 		"description" = $3,
         "location_x" = $4,
         "location_y" = $5,
-        "date_updated" = $6
+        "zone_id" = $6,
+        "tags" = $7,
+        "date_updated" = $8
 		WHERE id = $1`
-	_, err = db.ExecContext(ctx, q, id,
+
+	// A move is saved together with the new entry in the location history.
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, q, id,
 		s.Name,
 		s.Description,
 		s.LocationX,
 		s.LocationY,
+		s.ZoneId,
+		s.Tags,
 		s.DateUpdated,
 	)
 	if err != nil {
 		return errors.Wrap(err, "updating station")
 	}
 
+	if moved {
+		if err := recordLocation(ctx, tx, s.Id, s.LocationX, s.LocationY, now); err != nil {
+			return err
+		}
+	}
+
+	if err := audit.Write(ctx, tx, "station.update", id, before, s, now); err != nil {
+		return err
+	}
+
+	if err := outbox.Add(ctx, tx, EventStationUpdated, id, s, now); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "committing station update")
+	}
+
 	return nil
 }
 
-// DeleteStation removes the station identified by a given ID.
-func DeleteStation(ctx context.Context, db *sqlx.DB, id string) error {
+// DeleteStation archives the station identified by a given ID. The Station and
+// its history are kept but it is hidden until it is restored with
+// RestoreStation or permanently removed by Purge.
+func DeleteStation(ctx context.Context, db *sqlx.DB, id string, now time.Time) error {
 
 	ctx, span := trace.StartSpan(ctx, "station.DeleteStation")
 	defer span.End()
 
-	// Validate id is a valid uuid
+	before, err := GetStation(ctx, db, id)
+	if err != nil {
+		switch err {
+		case ErrStationNotFound:
+			return nil
+		default:
+			return err
+		}
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	const q = `UPDATE station SET date_deleted = $2 WHERE id = $1 AND date_deleted IS NULL`
+
+	if _, err := tx.ExecContext(ctx, q, id, now.UTC()); err != nil {
+		return errors.Wrapf(err, "archiving station %s", id)
+	}
+
+	if err := audit.Write(ctx, tx, "station.delete", id, before, nil, now); err != nil {
+		return err
+	}
+
+	if err := outbox.Add(ctx, tx, EventStationDeleted, id, before, now); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrapf(err, "committing delete of station %s", id)
+	}
+
+	return nil
+}
+
+// RestoreStation brings back an archived Station. A Station can not be restored
+// while its StationType or its account is archived.
+func RestoreStation(ctx context.Context, db *sqlx.DB, id string, now time.Time) error {
+
+	ctx, span := trace.StartSpan(ctx, "station.RestoreStation")
+	defer span.End()
+
 	if _, err := uuid.Parse(id); err != nil {
 		return ErrInvalidID
 	}
 
-	const q = `DELETE FROM station WHERE id = $1`
+	var archived struct {
+		Date            time.Time `db:"date_deleted"`
+		TypeArchived    bool      `db:"type_archived"`
+		AccountArchived bool      `db:"account_archived"`
+	}
+
+	const q = `
+        SELECT
+            station.date_deleted,
+            station_type.date_deleted IS NOT NULL AS type_archived,
+            account.date_deleted IS NOT NULL AS account_archived
+        FROM station
+          JOIN station_type ON station_type.id = station.station_type_id
+          JOIN account ON account.id = station.account_id
+        WHERE station.id = $1 AND station.date_deleted IS NOT NULL`
+
+	if err := db.GetContext(ctx, &archived, q, id); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrStationNotFound
+		}
 
-	if _, err := db.ExecContext(ctx, q, id); err != nil {
-		return errors.Wrapf(err, "deleting station %s", id)
+		return errors.Wrapf(err, "selecting archived station %s", id)
+	}
+	if archived.TypeArchived {
+		return ErrStationTypeArchived
+	}
+	if archived.AccountArchived {
+		return ErrAccountArchived
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE station SET date_deleted = NULL WHERE id = $1`, id); err != nil {
+		return errors.Wrapf(err, "restoring station %s", id)
+	}
+
+	before := map[string]interface{}{"date_deleted": archived.Date}
+	if err := audit.Write(ctx, tx, "station.restore", id, before, nil, now); err != nil {
+		return err
+	}
+
+	if err := outbox.Add(ctx, tx, EventStationRestored, id, map[string]string{"id": id}, now); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrapf(err, "committing restore of station %s", id)
 	}
 
 	return nil
 }
 
-// ListStations gives all Stations for a StationType.
-func ListStations(ctx context.Context, db *sqlx.DB, stationTypeID string) ([]Station, error) {
+// ListStations gives all Stations for a StationType. Archived Stations are only
+// included when includeArchived is set.
+func ListStations(ctx context.Context, db *sqlx.DB, stationTypeID string, includeArchived bool) ([]Station, error) {
 
 	ctx, span := trace.StartSpan(ctx, "station.ListStations")
 	defer span.End()
@@ -153,21 +453,270 @@ func ListStations(ctx context.Context, db *sqlx.DB, stationTypeID string) ([]Sta
         id,
         station_type_id,
         account_id,
+        zone_id,
+        name, description,
+        location_x,
+        location_y,
+        tags,
+        date_created,
+        date_updated,
+        date_deleted
+      FROM station
+      WHERE station_type_id = $1 AND ($2 OR date_deleted IS NULL)`
+
+	if err := db.SelectContext(ctx, &stations, q, stationTypeID, includeArchived); err != nil {
+		return nil, errors.Wrap(err, "selecting stations")
+	}
+
+	return stations, nil
+}
+
+// ListAccountStations gives all Stations that belong to an account, including
+// archived Stations as they still reference the account.
+func ListAccountStations(ctx context.Context, db *sqlx.DB, accountID string) ([]Station, error) {
+
+	ctx, span := trace.StartSpan(ctx, "station.ListAccountStations")
+	defer span.End()
+
+	if _, err := uuid.Parse(accountID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	stations := []Station{}
+
+	const q = `
+      SELECT
+        id,
+        station_type_id,
+        account_id,
+        zone_id,
         name, description,
         location_x,
         location_y,
+        tags,
         date_created,
-        date_updated
+        date_updated,
+        date_deleted
       FROM station
-      WHERE station_type_id = $1`
+      WHERE account_id = $1`
+
+	if err := db.SelectContext(ctx, &stations, q, accountID); err != nil {
+		return nil, errors.Wrap(err, "selecting account stations")
+	}
+
+	return stations, nil
+}
+
+// ListZoneStations gives all Stations assigned to a Zone.
+func ListZoneStations(ctx context.Context, db *sqlx.DB, zoneID string) ([]Station, error) {
+
+	ctx, span := trace.StartSpan(ctx, "station.ListZoneStations")
+	defer span.End()
+
+	if _, err := uuid.Parse(zoneID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	stations := []Station{}
 
-	if err := db.SelectContext(ctx, &stations, q, stationTypeID); err != nil {
+	const q = `
+      SELECT
+        id,
+        station_type_id,
+        account_id,
+        zone_id,
+        name, description,
+        location_x,
+        location_y,
+        tags,
+        date_created,
+        date_updated,
+        date_deleted
+      FROM station
+      WHERE zone_id = $1 AND date_deleted IS NULL`
+
+	if err := db.SelectContext(ctx, &stations, q, zoneID); err != nil {
+		return nil, errors.Wrap(err, "selecting zone stations")
+	}
+
+	return stations, nil
+}
+
+// ListAllStations gives all Stations matching the filter regardless of
+// StationType. Filtering on location uses the location index on the station
+// table: a radius search is first narrowed to the bounding square of the circle
+// and then to the exact distance.
+func ListAllStations(ctx context.Context, db *sqlx.DB, filter StationFilter) ([]Station, error) {
+
+	ctx, span := trace.StartSpan(ctx, "station.ListAllStations")
+	defer span.End()
+
+	var args []interface{}
+
+	// arg adds a query argument and returns its placeholder.
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where, err := filter.Where(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	order := "date_created"
+	if filter.Near != nil {
+		x, y := arg(filter.Near.X), arg(filter.Near.Y)
+		order = fmt.Sprintf("(location_x - %[1]s) * (location_x - %[1]s) + (location_y - %[2]s) * (location_y - %[2]s), date_created", x, y)
+	}
+
+	q := `
+      SELECT
+        id,
+        station_type_id,
+        account_id,
+        zone_id,
+        name, description,
+        location_x,
+        location_y,
+        tags,
+        date_created,
+        date_updated,
+        date_deleted
+      FROM station`
+	if len(where) > 0 {
+		q += "\n      WHERE " + strings.Join(where, "\n        AND ")
+	}
+	q += "\n      ORDER BY " + order
+
+	stations := []Station{}
+	if err := db.SelectContext(ctx, &stations, q, args...); err != nil {
 		return nil, errors.Wrap(err, "selecting stations")
 	}
 
 	return stations, nil
 }
 
+// Where gives the conditions on the station table matching the filter. arg
+// adds a query argument and returns its placeholder so the conditions can be
+// used in queries of other packages, such as alert rules scoped to a tag or
+// group.
+func (f StationFilter) Where(arg func(v interface{}) string) ([]string, error) {
+	var where []string
+
+	if f.GroupId != "" {
+		if _, err := uuid.Parse(f.GroupId); err != nil {
+			return nil, ErrInvalidID
+		}
+	}
+
+	if !f.IncludeArchived {
+		where = append(where, "station.date_deleted IS NULL")
+	}
+
+	for _, tag := range f.Tags {
+		if tag.Value == nil {
+			where = append(where, fmt.Sprintf("station.tags ? %s", arg(tag.Key)))
+			continue
+		}
+		match, err := json.Marshal(map[string]string{tag.Key: *tag.Value})
+		if err != nil {
+			return nil, errors.Wrap(err, "encoding tag filter")
+		}
+		where = append(where, fmt.Sprintf("station.tags @> %s", arg(string(match))))
+	}
+
+	if f.GroupId != "" {
+		where = append(where, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM station_group_member WHERE station_group_member.station_id = station.id AND station_group_member.group_id = %s)",
+			arg(f.GroupId)))
+	}
+
+	if f.BBox != nil {
+		where = append(where, fmt.Sprintf("station.location_x BETWEEN %s AND %s AND station.location_y BETWEEN %s AND %s",
+			arg(f.BBox.MinX), arg(f.BBox.MaxX), arg(f.BBox.MinY), arg(f.BBox.MaxY)))
+	}
+
+	if f.Near != nil {
+		n, r := f.Near, f.Radius
+		where = append(where, fmt.Sprintf("station.location_x BETWEEN %s AND %s AND station.location_y BETWEEN %s AND %s",
+			arg(n.X-r), arg(n.X+r), arg(n.Y-r), arg(n.Y+r)))
+
+		x, y := arg(n.X), arg(n.Y)
+		where = append(where, fmt.Sprintf(
+			"(station.location_x - %[1]s) * (station.location_x - %[1]s) + (station.location_y - %[2]s) * (station.location_y - %[2]s) <= %[3]s",
+			x, y, arg(r*r)))
+	}
+
+	return where, nil
+}
+
+// ParseTagFilter parses a tag given as key or key:value.
+func ParseTagFilter(v string) (TagFilter, error) {
+	parts := strings.SplitN(v, ":", 2)
+	if parts[0] == "" {
+		return TagFilter{}, ErrInvalidTag
+	}
+
+	tf := TagFilter{Key: parts[0]}
+	if len(parts) == 2 {
+		tf.Value = &parts[1]
+	}
+
+	return tf, nil
+}
+
+// NearestStation finds the Station of the given StationType closest to the
+// Station identified by id, for example the Water station nearest to a Plant
+// station. Ties are broken by the oldest Station.
+func NearestStation(ctx context.Context, db *sqlx.DB, id, stationTypeID string) (*Station, error) {
+
+	ctx, span := trace.StartSpan(ctx, "station.NearestStation")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationTypeID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	from, err := GetStation(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Station
+
+	const q = `
+        SELECT
+            id,
+            station_type_id,
+            account_id,
+            zone_id,
+            name,
+            description,
+            location_x,
+            location_y,
+            tags,
+            date_created,
+            date_updated,
+            date_deleted
+        FROM station
+        WHERE station_type_id = $1 AND id <> $2 AND date_deleted IS NULL
+        ORDER BY
+            (location_x - $3) * (location_x - $3) + (location_y - $4) * (location_y - $4),
+            date_created
+        LIMIT 1`
+
+	if err := db.GetContext(ctx, &s, q, stationTypeID, from.Id, from.LocationX, from.LocationY); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrStationNotFound
+		}
+
+		return nil, errors.Wrap(err, "selecting nearest station")
+	}
+
+	return &s, nil
+}
+
 // GetStation gets a specific Station from the database.
 func GetStation(ctx context.Context, db *sqlx.DB, id string) (*Station, error) {
 
@@ -185,14 +734,17 @@ func GetStation(ctx context.Context, db *sqlx.DB, id string) (*Station, error) {
             id,
             station_type_id,
             account_id,
+            zone_id,
             name,
             description,
             location_x,
             location_y,
+            tags,
             date_created,
-            date_updated
+            date_updated,
+            date_deleted
         FROM station
-        WHERE id = $1`
+        WHERE id = $1 AND date_deleted IS NULL`
 
     if err := db.GetContext(ctx, &s, q, id); err != nil {
         if err == sql.ErrNoRows {
@@ -204,3 +756,20 @@ func GetStation(ctx context.Context, db *sqlx.DB, id string) (*Station, error) {
 
     return &s, nil
 }
+
+// checkZone confirms the Zone a Station is being assigned to exists.
+func checkZone(ctx context.Context, db *sqlx.DB, zoneID string) error {
+
+	var exists bool
+
+	const q = `SELECT EXISTS (SELECT 1 FROM zone WHERE id = $1)`
+
+	if err := db.GetContext(ctx, &exists, q, zoneID); err != nil {
+		return errors.Wrap(err, "checking zone")
+	}
+	if !exists {
+		return ErrZoneNotFound
+	}
+
+	return nil
+}
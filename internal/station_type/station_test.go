@@ -10,8 +10,10 @@ import (
 	// Internal packages
 	"github.com/deezone/HydroBytes-BaseStation/internal/account"
 	"github.com/deezone/HydroBytes-BaseStation/internal/platform/auth"
+	"github.com/deezone/HydroBytes-BaseStation/internal/schema"
 	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
 	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+	"github.com/deezone/HydroBytes-BaseStation/internal/water"
 )
 
 func TestStation(t *testing.T) {
@@ -91,6 +93,38 @@ func TestStation(t *testing.T) {
 			t.Fatalf("updating station: %s", err)
 		}
 
+		// The move is recorded in the location history.
+		locations, err := station_type.ListStationLocations(ctx, db, s.Id)
+		if err != nil {
+			t.Fatalf("listing station locations: %s", err)
+		}
+		if exp, got := 2, len(locations); exp != got {
+			t.Fatalf("expected location history size %v, got %v", exp, got)
+		}
+
+		tt := []struct {
+			at   time.Time
+			x, y int
+		}{
+			{now, 7, 6},
+			{updatedTime.Add(-time.Second), 7, 6},
+			{updatedTime, 25, 45},
+			{updatedTime.Add(time.Hour), 25, 45},
+		}
+		for _, tc := range tt {
+			l, err := station_type.StationLocationAt(ctx, db, s.Id, tc.at)
+			if err != nil {
+				t.Fatalf("getting station location at %v: %s", tc.at, err)
+			}
+			if l.LocationX != tc.x || l.LocationY != tc.y {
+				t.Fatalf("expected station at %v,%v at %v, got %v,%v", tc.x, tc.y, tc.at, l.LocationX, l.LocationY)
+			}
+		}
+
+		if _, err := station_type.StationLocationAt(ctx, db, s.Id, now.Add(-time.Second)); err != station_type.ErrLocationNotFound {
+			t.Fatalf("getting station location before it was added: expected %v, got %v", station_type.ErrLocationNotFound, err)
+		}
+
 		// Invalid uuid
 		_, err = station_type.GetStation(ctx, db, "123abc")
 		if err == nil {
@@ -118,7 +152,7 @@ func TestStation(t *testing.T) {
 		}
 
 		// StationTypeOne should show the 1 station.
-		stations, err := station_type.ListStations(ctx, db, stationTypeOne.Id)
+		stations, err := station_type.ListStations(ctx, db, stationTypeOne.Id, false)
 		if err != nil {
 			t.Fatalf("listing stations: %s", err)
 		}
@@ -131,7 +165,7 @@ func TestStation(t *testing.T) {
 		}
 
 		// StationTypeTwo should have 0 stations.
-		stations, err = station_type.ListStations(ctx, db, stationTypeTwo.Id)
+		stations, err = station_type.ListStations(ctx, db, stationTypeTwo.Id, false)
 		if err != nil {
 			t.Fatalf("listing stations: %s", err)
 		}
@@ -140,24 +174,361 @@ func TestStation(t *testing.T) {
 		}
 
 		// Delete invalid Station, should not return error
-		err = station_type.DeleteStation(ctx, db, "123456")
+		err = station_type.DeleteStation(ctx, db, "123456", now)
 		if err == nil {
 			t.Fatalf("delete station should have failed: %s", err)
 		}
 
 		// Delete Station 0
-		err = station_type.DeleteStation(ctx, db, s.Id)
+		err = station_type.DeleteStation(ctx, db, s.Id, updatedTime)
 		if err != nil {
 			t.Fatalf("delete station: %s", err)
 		}
 
 		// StationTypeOne should show the 0 stations.
-		stations, err = station_type.ListStations(ctx, db, stationTypeOne.Id)
+		stations, err = station_type.ListStations(ctx, db, stationTypeOne.Id, false)
 		if err != nil {
 			t.Fatalf("listing stations: %s", err)
 		}
 		if exp, got := 0, len(stations); exp != got {
 			t.Fatalf("expected station list size %v, got %v", exp, got)
 		}
+
+		// The deleted station is archived rather than removed.
+		if _, err := station_type.GetStation(ctx, db, s.Id); err != station_type.ErrStationNotFound {
+			t.Fatalf("getting archived station: expected %v, got %v", station_type.ErrStationNotFound, err)
+		}
+
+		stations, err = station_type.ListStations(ctx, db, stationTypeOne.Id, true)
+		if err != nil {
+			t.Fatalf("listing archived stations: %s", err)
+		}
+		if exp, got := 1, len(stations); exp != got {
+			t.Fatalf("expected archived station list size %v, got %v", exp, got)
+		}
+		if stations[0].DateDeleted == nil || !stations[0].DateDeleted.Equal(updatedTime) {
+			t.Fatalf("expected station archived at %v, got %v", updatedTime, stations[0].DateDeleted)
+		}
+
+		if err := station_type.RestoreStation(ctx, db, s.Id, updatedTime); err != nil {
+			t.Fatalf("restoring station: %s", err)
+		}
+		if _, err := station_type.GetStation(ctx, db, s.Id); err != nil {
+			t.Fatalf("getting restored station: %s", err)
+		}
+
+		// Archived records are purged once they are older than the cutoff.
+		if err := station_type.DeleteStation(ctx, db, s.Id, updatedTime); err != nil {
+			t.Fatalf("delete station: %s", err)
+		}
+
+		purged, _, err := station_type.Purge(ctx, db, updatedTime, updatedTime)
+		if err != nil {
+			t.Fatalf("purging: %s", err)
+		}
+		if purged != 0 {
+			t.Fatalf("expected no stations purged before %v, got %v", updatedTime, purged)
+		}
+
+		purged, _, err = station_type.Purge(ctx, db, updatedTime.Add(time.Hour), updatedTime)
+		if err != nil {
+			t.Fatalf("purging: %s", err)
+		}
+		if purged != 1 {
+			t.Fatalf("expected 1 station purged, got %v", purged)
+		}
+	}
+}
+
+func TestStationSpatial(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	{ // Radius search is ordered by distance.
+		filter := station_type.StationFilter{
+			Near:   &station_type.Point{X: 3, Y: 3},
+			Radius: 1,
+		}
+		stations, err := station_type.ListAllStations(ctx, db, filter)
+		if err != nil {
+			t.Fatalf("listing stations near 3,3: %s", err)
+		}
+
+		var names []string
+		for _, s := range stations {
+			names = append(names, s.Name)
+		}
+		if diff := cmp.Diff([]string{"Plant Station 0001", "Plant Station 0002"}, names); diff != "" {
+			t.Fatalf("stations near 3,3 did not match:\n%s", diff)
+		}
+	}
+
+	{ // Bounding box search.
+		filter := station_type.StationFilter{
+			BBox: &station_type.BBox{MinX: 0, MinY: 0, MaxX: 2, MaxY: 2},
+		}
+		stations, err := station_type.ListAllStations(ctx, db, filter)
+		if err != nil {
+			t.Fatalf("listing stations in bbox: %s", err)
+		}
+		if exp, got := 2, len(stations); exp != got {
+			t.Fatalf("expected station list size %v, got %v", exp, got)
+		}
+	}
+
+	{ // The Water station closest to Plant Station 0003.
+		s, err := station_type.NearestStation(ctx, db, "342c0d0a-6333-11eb-ae93-0242ac130002", "72f8b983-3eb4-48db-9ed0-e45cc6bd716b")
+		if err != nil {
+			t.Fatalf("getting nearest water station: %s", err)
+		}
+		if exp, got := "ee72a90c-590c-11eb-ae93-0242ac130002", s.Id; exp != got {
+			t.Fatalf("expected nearest station %v, got %v", exp, got)
+		}
+	}
+}
+
+func TestStationTags(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+	claims := auth.NewClaims("5cf37266-3473-4006-984f-9325122678b7", []string{auth.RoleAdmin}, now, time.Hour)
+
+	tagged := map[string]station_type.Tags{
+		"d58f6d32-6332-11eb-ae93-0242ac130002": {"hardware": "v2", "seedlings": ""},
+		"27356858-6333-11eb-ae93-0242ac130002": {"hardware": "v1"},
+	}
+	for id, tags := range tagged {
+		tags := tags
+		if err := station_type.AdjustStation(ctx, db, claims, id, station_type.UpdateStation{Tags: &tags}, now); err != nil {
+			t.Fatalf("tagging station %s: %s", id, err)
+		}
+	}
+
+	saved, err := station_type.GetStation(ctx, db, "d58f6d32-6332-11eb-ae93-0242ac130002")
+	if err != nil {
+		t.Fatalf("getting tagged station: %s", err)
+	}
+	if diff := cmp.Diff(tagged["d58f6d32-6332-11eb-ae93-0242ac130002"], saved.Tags); diff != "" {
+		t.Fatalf("saved tags did not match:\n%s", diff)
+	}
+
+	v2 := "v2"
+	tt := []struct {
+		name string
+		tags []station_type.TagFilter
+		want int
+	}{
+		{"key only", []station_type.TagFilter{{Key: "hardware"}}, 2},
+		{"key and value", []station_type.TagFilter{{Key: "hardware", Value: &v2}}, 1},
+		{"all must match", []station_type.TagFilter{{Key: "hardware"}, {Key: "seedlings"}}, 1},
+		{"no match", []station_type.TagFilter{{Key: "solar"}}, 0},
+	}
+
+	for _, tc := range tt {
+		stations, err := station_type.ListAllStations(ctx, db, station_type.StationFilter{Tags: tc.tags})
+		if err != nil {
+			t.Fatalf("%s: listing stations: %s", tc.name, err)
+		}
+		if got := len(stations); got != tc.want {
+			t.Errorf("%s: expected %v stations, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestStationLinks(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2021, time.May, 1, 0, 0, 0, 0, time.UTC)
+
+	base := "ddd3f222-590c-11eb-ae93-0242ac130002"
+	water := "ee72a90c-590c-11eb-ae93-0242ac130002"
+	plant := "d58f6d32-6332-11eb-ae93-0242ac130002"
+
+	// The seeded zone-2 valve irrigates all three plant stations.
+	links, err := station_type.ListStationLinks(ctx, db, water, station_type.LinksFrom, station_type.LinkIrrigates)
+	if err != nil {
+		t.Fatalf("listing links from water station: %s", err)
+	}
+	if exp, got := 3, len(links); exp != got {
+		t.Fatalf("expected %v links, got %v", exp, got)
+	}
+
+	links, err = station_type.ListStationLinks(ctx, db, plant, station_type.LinksTo, "")
+	if err != nil {
+		t.Fatalf("listing links to plant station: %s", err)
+	}
+	if exp, got := 1, len(links); exp != got {
+		t.Fatalf("expected %v links, got %v", exp, got)
+	}
+	if links[0].FromStationId != water || links[0].Valve != "zone-2" {
+		t.Fatalf("expected link from the zone-2 valve of %v, got %+v", water, links[0])
+	}
+
+	// A Plant station can not irrigate anything.
+	nl := station_type.NewStationLink{Type: station_type.LinkIrrigates, ToStationId: water}
+	if _, err := station_type.AddStationLink(ctx, db, plant, nl, now); err != station_type.ErrLinkNotAllowed {
+		t.Fatalf("linking plant to water station: expected %v, got %v", station_type.ErrLinkNotAllowed, err)
+	}
+
+	// Links follow the kind of a station type, so renaming the Base station
+	// type does not stop it relaying.
+	name := "Coordinator"
+	if err := station_type.Update(ctx, db, "a2b0639f-2cc6-44b8-b97b-15d69dbb511e", station_type.UpdateStationType{Name: &name}, now); err != nil {
+		t.Fatalf("renaming base station type: %s", err)
+	}
+
+	nl = station_type.NewStationLink{Type: station_type.LinkRelaysFor, ToStationId: water}
+	l, err := station_type.AddStationLink(ctx, db, base, nl, now)
+	if err != nil {
+		t.Fatalf("linking base to water station: %s", err)
+	}
+	if _, err := station_type.AddStationLink(ctx, db, base, nl, now); err != station_type.ErrLinkExists {
+		t.Fatalf("linking base to water station twice: expected %v, got %v", station_type.ErrLinkExists, err)
+	}
+
+	// Both directions are listed by default.
+	links, err = station_type.ListStationLinks(ctx, db, water, "", "")
+	if err != nil {
+		t.Fatalf("listing links of water station: %s", err)
+	}
+	if exp, got := 4, len(links); exp != got {
+		t.Fatalf("expected %v links, got %v", exp, got)
+	}
+
+	if err := station_type.DeleteStationLink(ctx, db, l.Id, now); err != nil {
+		t.Fatalf("deleting link: %s", err)
+	}
+
+	links, err = station_type.ListStationLinks(ctx, db, base, "", "")
+	if err != nil {
+		t.Fatalf("listing links of base station: %s", err)
+	}
+	if exp, got := 0, len(links); exp != got {
+		t.Fatalf("expected %v links, got %v", exp, got)
+	}
+}
+
+func TestStationTemplatesAndClone(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2021, time.May, 1, 0, 0, 0, 0, time.UTC)
+	claims := auth.NewClaims("5cf37266-3473-4006-984f-9325122678b7", []string{auth.RoleAdmin}, now, time.Hour)
+
+	plantType := "5c86bbaa-4ef8-11eb-ae93-0242ac130002"
+	tomatoRow := "8a1f5c2e-7d3b-4e0a-9c61-2b4d8e6f0a13"
+
+	nt := station_type.NewStationTemplate{
+		StationTypeId: plantType,
+		Name:          "Tomato bed",
+		Description:   "Plant station for a tomato bed.",
+		ZoneId:        &tomatoRow,
+		Tags:          station_type.Tags{"hardware": "v2"},
+	}
+	tmpl, err := station_type.CreateTemplate(ctx, db, nt, now)
+	if err != nil {
+		t.Fatalf("creating template: %s", err)
+	}
+
+	{ // Add a station from the template, giving only a location and an extra tag.
+		ns := station_type.NewStation{
+			TemplateId: &tmpl.Id,
+			LocationX:  6,
+			LocationY:  2,
+			Tags:       station_type.Tags{"seedlings": ""},
+		}
+		s, err := station_type.AddStation(ctx, db, claims, ns, plantType, now)
+		if err != nil {
+			t.Fatalf("adding station from template: %s", err)
+		}
+
+		want := station_type.Station{
+			Id:            s.Id,
+			StationTypeId: plantType,
+			AccountId:     claims.Subject,
+			ZoneId:        &tomatoRow,
+			Name:          "Tomato bed",
+			Description:   "Plant station for a tomato bed.",
+			LocationX:     6,
+			LocationY:     2,
+			Tags:          station_type.Tags{"hardware": "v2", "seedlings": ""},
+			DateCreated:   now,
+			DateUpdated:   now,
+		}
+		if diff := cmp.Diff(want, *s); diff != "" {
+			t.Fatalf("station from template did not match:\n%s", diff)
+		}
+
+		// The template is only for Plant stations.
+		if _, err := station_type.AddStation(ctx, db, claims, ns, "72f8b983-3eb4-48db-9ed0-e45cc6bd716b", now); err != station_type.ErrTemplateMismatch {
+			t.Fatalf("adding water station from plant template: expected %v, got %v", station_type.ErrTemplateMismatch, err)
+		}
+	}
+
+	{ // Clone Plant Station 0001, a member of the seeded Seedlings group.
+		nc := station_type.NewStationClone{Name: "Plant Station 0004", LocationX: 6, LocationY: 3}
+		s, err := station_type.CloneStation(ctx, db, claims, "d58f6d32-6332-11eb-ae93-0242ac130002", nc, now)
+		if err != nil {
+			t.Fatalf("cloning station: %s", err)
+		}
+
+		if s.StationTypeId != plantType || s.ZoneId == nil || *s.ZoneId != tomatoRow {
+			t.Fatalf("clone did not keep the station type and zone: %+v", s)
+		}
+
+		stations, err := station_type.ListAllStations(ctx, db, station_type.StationFilter{GroupId: "0b6f2a4e-93d1-4c58-8e27-6d1a5f3c9b70"})
+		if err != nil {
+			t.Fatalf("listing stations in group: %s", err)
+		}
+		if exp, got := 3, len(stations); exp != got {
+			t.Fatalf("expected %v stations in group after clone, got %v", exp, got)
+		}
+	}
+
+	{ // Clone Water Station one, which has a reservoir and a flow meter.
+		nc := station_type.NewStationClone{Name: "Water Station 0002", LocationX: 7, LocationY: 3}
+		s, err := station_type.CloneStation(ctx, db, claims, "ee72a90c-590c-11eb-ae93-0242ac130002", nc, now)
+		if err != nil {
+			t.Fatalf("cloning water station: %s", err)
+		}
+
+		var capacity float64
+		if err := db.GetContext(ctx, &capacity, "SELECT capacity_litres FROM reservoir WHERE station_id = $1", s.Id); err != nil {
+			t.Fatalf("getting reservoir of clone: %s", err)
+		}
+		if capacity != 200 {
+			t.Fatalf("expected reservoir capacity of clone to be 200, got %v", capacity)
+		}
+
+		meters, err := water.ListMeters(ctx, db, s.Id)
+		if err != nil {
+			t.Fatalf("listing flow meters of clone: %s", err)
+		}
+		if len(meters) != 1 || meters[0].Name != "Zone 2 meter" || meters[0].Id == "7b2d9e4f-6a13-4c85-9f70-1e8c3a5d2b46" {
+			t.Fatalf("expected a copy of the flow meter with an ID of its own, got %+v", meters)
+		}
 	}
 }
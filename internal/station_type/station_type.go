@@ -6,6 +6,10 @@ import (
 	"database/sql"
 	"time"
 
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/audit"
+	"github.com/deezone/HydroBytes-BaseStation/internal/outbox"
+
 	// Third-party packages
 	"github.com/pkg/errors"
 	"github.com/google/uuid"
@@ -13,6 +17,25 @@ import (
 	"go.opencensus.io/trace"
 )
 
+// Types of the outbox Messages of StationType changes.
+const (
+	EventStationTypeCreated  = "station_type.created"
+	EventStationTypeUpdated  = "station_type.updated"
+	EventStationTypeDeleted  = "station_type.deleted"
+	EventStationTypeRestored = "station_type.restored"
+	EventStationTypePurged   = "station_type.purged"
+)
+
+// Kinds of StationType. Unlike the name, which is free to change, the kind
+// decides what the Stations of a StationType do, such as the links they may
+// take part in.
+const (
+	KindBase  = "base"
+	KindWater = "water"
+	KindPlant = "plant"
+	KindOther = "other"
+)
+
 // Predefined errors identify expected failure conditions.
 var (
 	// ErrNotFound is used when a specific StationType is requested but does not exist.
@@ -20,6 +43,18 @@ var (
 
 	// ErrInvalidID is used when an invalid UUID is provided.
 	ErrInvalidID = errors.New("ID is not in its proper UUID format")
+
+	// ErrHasStations is used when a StationType can not be deleted because
+	// Stations of the type still exist.
+	ErrHasStations = errors.New("station type has stations")
+
+	// ErrReassignNotFound is used when the StationType that Stations should be
+	// moved to before a delete does not exist.
+	ErrReassignNotFound = errors.New("station type to reassign stations to not found")
+
+	// ErrReassignToSelf is used when the Stations of a StationType are to be
+	// moved to the StationType being deleted.
+	ErrReassignToSelf = errors.New("stations can not be reassigned to the station type being deleted")
 )
 
 // Create adds a StationType to the database. It returns the created StationType with
@@ -32,19 +67,30 @@ func Create(ctx context.Context, db *sqlx.DB, nst NewStationType, now time.Time)
 	st := StationType{
 		Id:          uuid.New().String(),
 		Name:        nst.Name,
+		Kind:        nst.Kind,
 		Description: nst.Description,
 		DateCreated: now.UTC(),
 		DateUpdated: now.UTC(),
 	}
+	if st.Kind == "" {
+		st.Kind = KindOther
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
 
 	const q = `
 		INSERT INTO station_type
-		  (id, name, description, date_created, date_updated)
-		VALUES ($1, $2, $3, $4, $5)`
+		  (id, name, kind, description, date_created, date_updated)
+		VALUES ($1, $2, $3, $4, $5, $6)`
 
-	_, err := db.ExecContext(ctx, q,
+	_, err = tx.ExecContext(ctx, q,
 		st.Id,
 		st.Name,
+		st.Kind,
 		st.Description,
 		st.DateCreated,
 		st.DateUpdated,
@@ -54,11 +100,30 @@ func Create(ctx context.Context, db *sqlx.DB, nst NewStationType, now time.Time)
 		return nil, errors.Wrap(err, "inserting station tyoe")
 	}
 
+	if err := audit.Write(ctx, tx, "station_type.create", st.Id, nil, st, now); err != nil {
+		return nil, err
+	}
+
+	if err := outbox.Add(ctx, tx, EventStationTypeCreated, st.Id, st, now); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "committing station type")
+	}
+
 	return &st, nil
 }
 
-// Delete removes the station type identified by a given ID.
-func Delete(ctx context.Context, db *sqlx.DB, id string) error {
+// Delete archives the station type identified by a given ID. A station type
+// that still has active Stations is only archived when the Stations are either
+// moved to another station type with DeleteStationType.ReassignTo or archived
+// along with it with DeleteStationType.Cascade. Otherwise ErrHasStations is
+// returned.
+//
+// Archived records are kept until they are restored with Restore or
+// permanently removed by Purge.
+func Delete(ctx context.Context, db *sqlx.DB, id string, d DeleteStationType, now time.Time) error {
 
 	ctx, span := trace.StartSpan(ctx, "station_type.Delete")
 	defer span.End()
@@ -67,18 +132,236 @@ func Delete(ctx context.Context, db *sqlx.DB, id string) error {
 	if _, err := uuid.Parse(id); err != nil {
 		return ErrInvalidID
 	}
+	if d.ReassignTo != "" {
+		if _, err := uuid.Parse(d.ReassignTo); err != nil {
+			return ErrInvalidID
+		}
+		if d.ReassignTo == id {
+			return ErrReassignToSelf
+		}
+	}
+
+	before, err := Get(ctx, db, id)
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	var stations int
+	const count = `SELECT COUNT(*) FROM station WHERE station_type_id = $1 AND date_deleted IS NULL`
+	if err := tx.GetContext(ctx, &stations, count, id); err != nil {
+		return errors.Wrap(err, "counting stations")
+	}
+
+	if stations > 0 {
+		switch {
+		case d.ReassignTo != "":
+			var exists bool
+			const q = `SELECT EXISTS (SELECT 1 FROM station_type WHERE id = $1 AND date_deleted IS NULL)`
+			if err := tx.GetContext(ctx, &exists, q, d.ReassignTo); err != nil {
+				return errors.Wrap(err, "checking station type")
+			}
+			if !exists {
+				return ErrReassignNotFound
+			}
+
+			// Archived stations are moved as well so nothing is left behind
+			// referencing the archived station type.
+			var moved []Station
+			const u = `UPDATE station SET station_type_id = $2 WHERE station_type_id = $1 RETURNING ` + StationColumns
+			if err := sqlx.SelectContext(ctx, tx, &moved, u, id, d.ReassignTo); err != nil {
+				return errors.Wrapf(err, "reassigning stations of station type %s", id)
+			}
+
+			for _, s := range moved {
+				from := s
+				from.StationTypeId = id
+				if err := audit.Write(ctx, tx, "station.update", s.Id, from, s, now); err != nil {
+					return err
+				}
+				if err := outbox.Add(ctx, tx, EventStationUpdated, s.Id, s, now); err != nil {
+					return err
+				}
+			}
+
+		case d.Cascade:
+			var archived []Station
+			const q = `UPDATE station SET date_deleted = $2 WHERE station_type_id = $1 AND date_deleted IS NULL RETURNING ` + StationColumns
+			if err := sqlx.SelectContext(ctx, tx, &archived, q, id, now.UTC()); err != nil {
+				return errors.Wrapf(err, "archiving stations of station type %s", id)
+			}
+
+			for _, s := range archived {
+				s.DateDeleted = nil
+				if err := audit.Write(ctx, tx, "station.delete", s.Id, s, nil, now); err != nil {
+					return err
+				}
+				if err := outbox.Add(ctx, tx, EventStationDeleted, s.Id, s, now); err != nil {
+					return err
+				}
+			}
+
+		default:
+			return ErrHasStations
+		}
+	}
+
+	const q = `UPDATE station_type SET date_deleted = $2 WHERE id = $1 AND date_deleted IS NULL`
+
+	if _, err := tx.ExecContext(ctx, q, id, now.UTC()); err != nil {
+		return errors.Wrapf(err, "archiving station type %s", id)
+	}
+
+	if before != nil {
+		if err := audit.Write(ctx, tx, "station_type.delete", id, before, nil, now); err != nil {
+			return err
+		}
+
+		if err := outbox.Add(ctx, tx, EventStationTypeDeleted, id, before, now); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrapf(err, "committing delete of station type %s", id)
+	}
+
+	return nil
+}
+
+// Restore brings back an archived station type. Stations that were archived
+// along with it by a cascading Delete are restored as well, unless their
+// account has since been archived.
+func Restore(ctx context.Context, db *sqlx.DB, id string, now time.Time) error {
+
+	ctx, span := trace.StartSpan(ctx, "station_type.Restore")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrInvalidID
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	var archived time.Time
+	const q = `SELECT date_deleted FROM station_type WHERE id = $1 AND date_deleted IS NOT NULL`
+	if err := tx.GetContext(ctx, &archived, q, id); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+
+		return errors.Wrapf(err, "selecting archived station type %s", id)
+	}
+
+	const stations = `
+		UPDATE station SET date_deleted = NULL
+		WHERE station_type_id = $1 AND date_deleted = $2
+		  AND NOT EXISTS (SELECT 1 FROM account WHERE account.id = station.account_id AND account.date_deleted IS NOT NULL)
+		RETURNING id`
+	var restored []string
+	if err := sqlx.SelectContext(ctx, tx, &restored, stations, id, archived); err != nil {
+		return errors.Wrapf(err, "restoring stations of station type %s", id)
+	}
 
-	const q = `DELETE FROM station_type WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, `UPDATE station_type SET date_deleted = NULL WHERE id = $1`, id); err != nil {
+		return errors.Wrapf(err, "restoring station type %s", id)
+	}
 
-	if _, err := db.ExecContext(ctx, q, id); err != nil {
-		return errors.Wrapf(err, "deleting station type %s", id)
+	before := map[string]interface{}{"date_deleted": archived}
+	for _, sid := range restored {
+		if err := audit.Write(ctx, tx, "station.restore", sid, before, nil, now); err != nil {
+			return err
+		}
+		if err := outbox.Add(ctx, tx, EventStationRestored, sid, map[string]string{"id": sid}, now); err != nil {
+			return err
+		}
+	}
+
+	if err := audit.Write(ctx, tx, "station_type.restore", id, before, nil, now); err != nil {
+		return err
+	}
+
+	if err := outbox.Add(ctx, tx, EventStationTypeRestored, id, map[string]string{"id": id}, now); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrapf(err, "committing restore of station type %s", id)
 	}
 
 	return nil
 }
 
-// List gets all StationType from the database.
-func List(ctx context.Context, db *sqlx.DB) ([]StationType, error) {
+// Purge permanently removes Stations and station types that were archived
+// before the given time. An archived station type that still has Stations,
+// archived or not, is kept. It returns the number of Stations and station
+// types removed.
+func Purge(ctx context.Context, db *sqlx.DB, before, now time.Time) (int64, int64, error) {
+
+	ctx, span := trace.StartSpan(ctx, "station_type.Purge")
+	defer span.End()
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	var stations []Station
+	const ps = `DELETE FROM station WHERE date_deleted < $1 RETURNING ` + StationColumns
+	if err := sqlx.SelectContext(ctx, tx, &stations, ps, before.UTC()); err != nil {
+		return 0, 0, errors.Wrap(err, "purging stations")
+	}
+
+	for _, s := range stations {
+		if err := audit.Write(ctx, tx, "station.purge", s.Id, s, nil, now); err != nil {
+			return 0, 0, err
+		}
+		if err := outbox.Add(ctx, tx, EventStationPurged, s.Id, s, now); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	var types []StationType
+	const q = `
+		DELETE FROM station_type
+		WHERE date_deleted < $1
+		  AND NOT EXISTS (SELECT 1 FROM station WHERE station.station_type_id = station_type.id)
+		RETURNING id, name, kind, description, date_created, date_updated, date_deleted`
+
+	if err := sqlx.SelectContext(ctx, tx, &types, q, before.UTC()); err != nil {
+		return 0, 0, errors.Wrap(err, "purging station types")
+	}
+
+	for _, st := range types {
+		if err := audit.Write(ctx, tx, "station_type.purge", st.Id, st, nil, now); err != nil {
+			return 0, 0, err
+		}
+		if err := outbox.Add(ctx, tx, EventStationTypePurged, st.Id, st, now); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, errors.Wrap(err, "committing purge")
+	}
+
+	return int64(len(stations)), int64(len(types)), nil
+}
+
+// List gets all StationType from the database. Archived station types are only
+// included when includeArchived is set. The station count of each station type
+// only includes active Stations.
+func List(ctx context.Context, db *sqlx.DB, includeArchived bool) ([]StationType, error) {
 
 	ctx, span := trace.StartSpan(ctx, "station_type.List")
 	defer span.End()
@@ -89,15 +372,18 @@ func List(ctx context.Context, db *sqlx.DB) ([]StationType, error) {
 		SELECT
 			station_type.id,
 			station_type.name,
+			station_type.kind,
 			station_type.description,
 			COUNT(station.id) AS stations,
 			station_type.date_created,
-			station_type.date_updated
+			station_type.date_updated,
+			station_type.date_deleted
 		FROM station_type
-		  LEFT JOIN station ON station_type.id = station.station_type_id
+		  LEFT JOIN station ON station_type.id = station.station_type_id AND station.date_deleted IS NULL
+		WHERE $1 OR station_type.date_deleted IS NULL
 		GROUP BY station_type.id`
 
-	if err := db.SelectContext(ctx, &station_type, q); err != nil {
+	if err := db.SelectContext(ctx, &station_type, q, includeArchived); err != nil {
 		return nil, errors.Wrap(err, "selecting station types")
 	}
 
@@ -120,13 +406,15 @@ func Get(ctx context.Context, db *sqlx.DB, id string) (*StationType, error) {
 		SELECT
 			station_type.id,
 			station_type.name,
+			station_type.kind,
 			station_type.description,
 			COUNT(station.id) AS stations,
 			station_type.date_created,
-			station_type.date_updated
+			station_type.date_updated,
+			station_type.date_deleted
 		FROM station_type
-		  LEFT JOIN station ON station_type.id = station.station_type_id
-		WHERE station_type.id = $1
+		  LEFT JOIN station ON station_type.id = station.station_type_id AND station.date_deleted IS NULL
+		WHERE station_type.id = $1 AND station_type.date_deleted IS NULL
 		GROUP BY station_type.id`
 
 	if err := db.GetContext(ctx, &st, q, id); err != nil {
@@ -151,6 +439,7 @@ func Update(ctx context.Context, db *sqlx.DB, id string, update UpdateStationTyp
 	if err != nil {
 		return err
 	}
+	before := *st
 
 	if update.Name != nil {
 		st.Name = *update.Name
@@ -160,12 +449,18 @@ func Update(ctx context.Context, db *sqlx.DB, id string, update UpdateStationTyp
 	}
 	st.DateUpdated = now
 
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
 	const q = `UPDATE station_type SET
 		"name" = $2,
 		"description" = $3,
 		"date_updated" = $4
 		WHERE id = $1`
-	_, err = db.ExecContext(ctx, q, id,
+	_, err = tx.ExecContext(ctx, q, id,
 		st.Name,
 		st.Description,
 		st.DateUpdated,
@@ -174,5 +469,17 @@ func Update(ctx context.Context, db *sqlx.DB, id string, update UpdateStationTyp
 		return errors.Wrap(err, "updating station tyoe")
 	}
 
+	if err := audit.Write(ctx, tx, "station_type.update", id, before, st, now); err != nil {
+		return err
+	}
+
+	if err := outbox.Add(ctx, tx, EventStationTypeUpdated, id, st, now); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "committing station type update")
+	}
+
 	return nil
 }
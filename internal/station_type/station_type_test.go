@@ -7,12 +7,15 @@ import (
 	"time"
 
 	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/audit"
+	"github.com/deezone/HydroBytes-BaseStation/internal/outbox"
 	"github.com/deezone/HydroBytes-BaseStation/internal/station_type"
 	"github.com/deezone/HydroBytes-BaseStation/internal/schema"
 	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
 
 	// Third-party packages
 	"github.com/google/go-cmp/cmp"
+	"github.com/jmoiron/sqlx"
 )
 
 func TestStationType(t *testing.T) {
@@ -78,13 +81,13 @@ func TestStationType(t *testing.T) {
 	}
 
 	// Delete invalid Station Type, should not return error
-	err = station_type.Delete(ctx, db, "123456")
+	err = station_type.Delete(ctx, db, "123456", station_type.DeleteStationType{}, now)
 	if err == nil {
 		t.Fatalf("delete invalid uuid station type should have not failed: %s", err)
 	}
 
 	// Delete invalid Station Type, should not return error
-	err = station_type.Delete(ctx, db, st0.Id)
+	err = station_type.Delete(ctx, db, st0.Id, station_type.DeleteStationType{}, now)
 	if err != nil {
 		t.Fatalf("delete station type should have not failed: %s", err)
 	}
@@ -94,6 +97,14 @@ func TestStationType(t *testing.T) {
 	if err == nil {
 		t.Fatalf("getting deleted station type st0: %s", err)
 	}
+
+	// Deleted station types are archived and can be restored.
+	if err := station_type.Restore(ctx, db, st0.Id, now); err != nil {
+		t.Fatalf("restoring station type st0: %s", err)
+	}
+	if _, err := station_type.Get(ctx, db, st0.Id); err != nil {
+		t.Fatalf("getting restored station type st0: %s", err)
+	}
 }
 
 func TestStationTypeList(t *testing.T) {
@@ -106,7 +117,7 @@ func TestStationTypeList(t *testing.T) {
 
 	ctx := context.Background()
 
-	sts, err := station_type.List(ctx, db)
+	sts, err := station_type.List(ctx, db, false)
 	if err != nil {
 		t.Fatalf("listing station types: %s", err)
 	}
@@ -114,3 +125,120 @@ func TestStationTypeList(t *testing.T) {
 		t.Fatalf("expected station type list size %v, got %v", exp, got)
 	}
 }
+
+func TestStationTypeDeleteWithStations(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	const (
+		base  = "a2b0639f-2cc6-44b8-b97b-15d69dbb511e"
+		water = "72f8b983-3eb4-48db-9ed0-e45cc6bd716b"
+		plant = "5c86bbaa-4ef8-11eb-ae93-0242ac130002"
+	)
+
+	// The Plant station type has three stations in the seed data.
+	if err := station_type.Delete(ctx, db, plant, station_type.DeleteStationType{}, now); err != station_type.ErrHasStations {
+		t.Fatalf("deleting station type with stations: expected %v, got %v", station_type.ErrHasStations, err)
+	}
+	if _, err := station_type.Get(ctx, db, plant); err != nil {
+		t.Fatalf("refused delete should keep the station type: %s", err)
+	}
+
+	// Reassigning to an unknown station type is refused.
+	d := station_type.DeleteStationType{ReassignTo: "0b6e4d2c-3f8a-4c59-9e21-7d5a6b8c9f01"}
+	if err := station_type.Delete(ctx, db, plant, d, now); err != station_type.ErrReassignNotFound {
+		t.Fatalf("reassigning to unknown station type: expected %v, got %v", station_type.ErrReassignNotFound, err)
+	}
+
+	// Reassigning to the station type being deleted is refused.
+	d = station_type.DeleteStationType{ReassignTo: plant}
+	if err := station_type.Delete(ctx, db, plant, d, now); err != station_type.ErrReassignToSelf {
+		t.Fatalf("reassigning to itself: expected %v, got %v", station_type.ErrReassignToSelf, err)
+	}
+
+	// Reassign the Plant stations to the Water station type.
+	d = station_type.DeleteStationType{ReassignTo: water}
+	if err := station_type.Delete(ctx, db, plant, d, now); err != nil {
+		t.Fatalf("deleting station type with reassign: %s", err)
+	}
+
+	stations, err := station_type.ListStations(ctx, db, water, false)
+	if err != nil {
+		t.Fatalf("listing stations: %s", err)
+	}
+	if exp, got := 4, len(stations); exp != got {
+		t.Fatalf("expected station list size %v, got %v", exp, got)
+	}
+	checkAudit(t, db, "station.update", 3)
+
+	// Cascade archives the stations along with the station type.
+	if err := station_type.Delete(ctx, db, base, station_type.DeleteStationType{Cascade: true}, now); err != nil {
+		t.Fatalf("deleting station type with cascade: %s", err)
+	}
+
+	stations, err = station_type.ListStations(ctx, db, base, false)
+	if err != nil {
+		t.Fatalf("listing stations: %s", err)
+	}
+	if exp, got := 0, len(stations); exp != got {
+		t.Fatalf("expected station list size %v, got %v", exp, got)
+	}
+	checkAudit(t, db, "station.delete", 1)
+
+	// Restoring the station type brings back the stations archived with it.
+	if err := station_type.Restore(ctx, db, base, now); err != nil {
+		t.Fatalf("restoring station type: %s", err)
+	}
+
+	stations, err = station_type.ListStations(ctx, db, base, false)
+	if err != nil {
+		t.Fatalf("listing stations: %s", err)
+	}
+	if exp, got := 1, len(stations); exp != got {
+		t.Fatalf("expected station list size %v, got %v", exp, got)
+	}
+	checkAudit(t, db, "station.restore", 1)
+
+	// Each Station changed along with a station type has its own outbox
+	// Message as well.
+	messages := map[string]int{}
+	publish := func(ctx context.Context, m outbox.Message) error {
+		messages[m.Type]++
+		return nil
+	}
+	if _, err := outbox.Relay(ctx, db, "test", publish, now); err != nil {
+		t.Fatalf("relaying outbox: %s", err)
+	}
+
+	exp := map[string]int{
+		station_type.EventStationUpdated:      3,
+		station_type.EventStationDeleted:      1,
+		station_type.EventStationRestored:     1,
+		station_type.EventStationTypeDeleted:  2,
+		station_type.EventStationTypeRestored: 1,
+	}
+	if diff := cmp.Diff(exp, messages); diff != "" {
+		t.Fatalf("outbox messages did not match:\n%s", diff)
+	}
+}
+
+// checkAudit ensures each Station changed along with a station type has its
+// own audit entry.
+func checkAudit(t *testing.T, db *sqlx.DB, action string, exp int) {
+	t.Helper()
+
+	entries, err := audit.List(context.Background(), db, audit.Filter{Action: action})
+	if err != nil {
+		t.Fatalf("listing %s audit entries: %s", action, err)
+	}
+	if got := len(entries); exp != got {
+		t.Fatalf("expected %v %s audit entries, got %v", exp, action, got)
+	}
+}
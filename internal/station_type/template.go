@@ -0,0 +1,211 @@
+package station_type
+
+import (
+	// Core packages
+	"context"
+	"database/sql"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/audit"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+var (
+	// ErrTemplateNotFound is used when a specific StationTemplate is requested
+	// but does not exist.
+	ErrTemplateNotFound = errors.New("station template not found")
+
+	// ErrTemplateMismatch is used when a Station is added from a
+	// StationTemplate of another StationType.
+	ErrTemplateMismatch = errors.New("station template is for another station type")
+)
+
+// CreateTemplate adds a StationTemplate to the database.
+func CreateTemplate(ctx context.Context, db *sqlx.DB, nt NewStationTemplate, now time.Time) (*StationTemplate, error) {
+
+	ctx, span := trace.StartSpan(ctx, "station.CreateTemplate")
+	defer span.End()
+
+	if _, err := Get(ctx, db, nt.StationTypeId); err != nil {
+		return nil, err
+	}
+	if nt.ZoneId != nil {
+		if err := checkZone(ctx, db, *nt.ZoneId); err != nil {
+			return nil, err
+		}
+	}
+
+	t := StationTemplate{
+		Id:            uuid.New().String(),
+		StationTypeId: nt.StationTypeId,
+		Name:          nt.Name,
+		Description:   nt.Description,
+		ZoneId:        nt.ZoneId,
+		Tags:          nt.Tags,
+		DateCreated:   now.UTC(),
+		DateUpdated:   now.UTC(),
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	const q = `
+		INSERT INTO station_template
+		  (id, station_type_id, name, description, zone_id, tags, date_created, date_updated)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err = tx.ExecContext(ctx, q,
+		t.Id,
+		t.StationTypeId,
+		t.Name,
+		t.Description,
+		t.ZoneId,
+		t.Tags,
+		t.DateCreated,
+		t.DateUpdated,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "inserting station template")
+	}
+
+	if err := audit.Write(ctx, tx, "station_template.create", t.Id, nil, t, now); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "committing station template")
+	}
+
+	return &t, nil
+}
+
+// DeleteTemplate removes the StationTemplate identified by a given ID.
+// Stations added from the template are not changed.
+func DeleteTemplate(ctx context.Context, db *sqlx.DB, id string, now time.Time) error {
+
+	ctx, span := trace.StartSpan(ctx, "station.DeleteTemplate")
+	defer span.End()
+
+	before, err := GetTemplate(ctx, db, id)
+	if err != nil {
+		switch err {
+		case ErrTemplateNotFound:
+			return nil
+		default:
+			return err
+		}
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM station_template WHERE id = $1`, id); err != nil {
+		return errors.Wrapf(err, "deleting station template %s", id)
+	}
+
+	if err := audit.Write(ctx, tx, "station_template.delete", id, before, nil, now); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrapf(err, "committing delete of station template %s", id)
+	}
+
+	return nil
+}
+
+// ListTemplates gets all StationTemplates ordered by name.
+func ListTemplates(ctx context.Context, db *sqlx.DB) ([]StationTemplate, error) {
+
+	ctx, span := trace.StartSpan(ctx, "station.ListTemplates")
+	defer span.End()
+
+	templates := []StationTemplate{}
+
+	const q = `
+		SELECT id, station_type_id, name, description, zone_id, tags, date_created, date_updated
+		FROM station_template
+		ORDER BY name`
+
+	if err := db.SelectContext(ctx, &templates, q); err != nil {
+		return nil, errors.Wrap(err, "selecting station templates")
+	}
+
+	return templates, nil
+}
+
+// GetTemplate finds the StationTemplate identified by a given ID.
+func GetTemplate(ctx context.Context, db *sqlx.DB, id string) (*StationTemplate, error) {
+
+	ctx, span := trace.StartSpan(ctx, "station.GetTemplate")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var t StationTemplate
+
+	const q = `
+		SELECT id, station_type_id, name, description, zone_id, tags, date_created, date_updated
+		FROM station_template
+		WHERE id = $1`
+
+	if err := db.GetContext(ctx, &t, q, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTemplateNotFound
+		}
+
+		return nil, errors.Wrap(err, "selecting single station template")
+	}
+
+	return &t, nil
+}
+
+// applyTemplate fills in the fields of ns that were not given from the
+// StationTemplate ns.TemplateId.
+func applyTemplate(ctx context.Context, db *sqlx.DB, ns *NewStation, stationTypeID string) error {
+
+	t, err := GetTemplate(ctx, db, *ns.TemplateId)
+	if err != nil {
+		return err
+	}
+	if t.StationTypeId != stationTypeID {
+		return ErrTemplateMismatch
+	}
+
+	if ns.Name == "" {
+		ns.Name = t.Name
+	}
+	if ns.Description == "" {
+		ns.Description = t.Description
+	}
+	if ns.ZoneId == nil {
+		ns.ZoneId = t.ZoneId
+	}
+
+	if len(t.Tags) > 0 {
+		tags := make(Tags, len(t.Tags)+len(ns.Tags))
+		for k, v := range t.Tags {
+			tags[k] = v
+		}
+		for k, v := range ns.Tags {
+			tags[k] = v
+		}
+		ns.Tags = tags
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	// Core packages
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Mail is a message received by an SMTP stand-in.
+type Mail struct {
+	From string
+	To   []string
+	Data string
+}
+
+// SMTP is an in-process SMTP server that accepts every message sent to it
+// and keeps it in memory. It speaks just enough of the protocol for
+// net/smtp.SendMail and does not support TLS or authentication.
+type SMTP struct {
+	Host string
+	Port int
+
+	ln   net.Listener
+	mu   sync.Mutex
+	mail []Mail
+}
+
+// NewSMTP starts an SMTP stand-in on a random local port. It calls Fatal on
+// the provided testing.T if the server cannot be started.
+//
+// It returns the server as well as a function to call at the end of the test.
+func NewSMTP(t *testing.T) (*SMTP, func()) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("starting smtp stand-in: %v", err)
+	}
+
+	s := SMTP{
+		Host: "127.0.0.1",
+		Port: ln.Addr().(*net.TCPAddr).Port,
+		ln:   ln,
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return &s, func() { ln.Close() }
+}
+
+// Mail returns the messages received so far.
+func (s *SMTP) Mail() []Mail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Mail(nil), s.mail...)
+}
+
+// serve handles the commands of a single connection until the client quits.
+func (s *SMTP) serve(conn net.Conn) {
+	c := textproto.NewConn(conn)
+	defer c.Close()
+
+	var m Mail
+	c.PrintfLine("220 localhost SMTP stand-in")
+
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO", "HELO":
+			c.PrintfLine("250 localhost")
+		case "MAIL":
+			m = Mail{From: address(line)}
+			c.PrintfLine("250 OK")
+		case "RCPT":
+			m.To = append(m.To, address(line))
+			c.PrintfLine("250 OK")
+		case "DATA":
+			c.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+			data, err := c.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			m.Data = string(data)
+			s.mu.Lock()
+			s.mail = append(s.mail, m)
+			s.mu.Unlock()
+			c.PrintfLine("250 OK")
+		case "RSET", "NOOP":
+			c.PrintfLine("250 OK")
+		case "QUIT":
+			c.PrintfLine("221 bye")
+			return
+		default:
+			c.PrintfLine("502 command not implemented")
+		}
+	}
+}
+
+// address gets the address between angle brackets of a MAIL or RCPT command.
+func address(line string) string {
+	start := strings.Index(line, "<")
+	end := strings.LastIndex(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+
+	return line[start+1 : end]
+}
@@ -0,0 +1,224 @@
+package water
+
+import (
+	// Core packages
+	"context"
+	"fmt"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/command"
+	"github.com/deezone/HydroBytes-BaseStation/internal/event"
+
+	// Third-party packages
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// EventLeak is the type of the Event raised for a Leak.
+const EventLeak = "water.leak"
+
+// Kinds of Leak.
+const (
+	LeakUncommandedFlow = "uncommanded_flow"
+	LeakOverDelivery    = "over_delivery"
+)
+
+// leakEvery is how often the same FlowMeter is warned about water flowing
+// without a watering run while it keeps flowing.
+const leakEvery = time.Hour
+
+// LeakOptions tune DetectLeaks.
+type LeakOptions struct {
+	MinLitres  float64 // Flow without a watering run below this is noise
+	OverFactor float64 // A run delivering this many times its usual litres is a leak
+	MinRuns    int     // Earlier runs of the same duration needed to know the usual litres
+	CloseValve bool    // Queue a close_valve Command for the valve of every Leak
+}
+
+// DefaultLeakOptions are the LeakOptions used by the API.
+var DefaultLeakOptions = LeakOptions{
+	MinLitres:  0.5,
+	OverFactor: 1.5,
+	MinRuns:    3,
+}
+
+// Leak is water that flowed through a FlowMeter when it should not have:
+// either while no watering run was open on the meter, or far more than a run
+// of the same duration usually delivers. Expected is the usual litres of the
+// run and CommandId the run for over deliveries.
+type Leak struct {
+	Kind      string  `db:"kind"       json:"kind"`
+	MeterId   string  `db:"meter_id"   json:"meter_id"`
+	StationId string  `db:"station_id" json:"station_id"`
+	Valve     string  `db:"valve"      json:"valve"`
+	CommandId *string `db:"command_id" json:"command_id,omitempty"`
+	Litres    float64 `db:"litres"     json:"litres"`
+	Expected  float64 `db:"expected"   json:"expected,omitempty"`
+}
+
+// DetectLeaks correlates the FlowReadings received and the watering runs that
+// finished between from and to with the command log. Every Leak found is
+// raised as a critical Event, and the valve is closed when the LeakOptions ask
+// for it. Flow without a run on a FlowMeter already raised within leakEvery is
+// not raised again.
+//
+// Runs are checked CommandWindow late so the readings sent just after a run
+// finished are counted towards it.
+func DetectLeaks(ctx context.Context, db *sqlx.DB, from, to time.Time, opts LeakOptions, now time.Time) ([]Leak, error) {
+
+	ctx, span := trace.StartSpan(ctx, "water.DetectLeaks")
+	defer span.End()
+
+	// Readings may be received before their station reports the run started,
+	// so they are matched to the command log again before looking for flow
+	// without a run.
+	q := `
+		UPDATE flow_reading SET command_id = ` + matchCommand("flow_meter.id", "flow_meter.station_id", "flow_meter.valve", "flow_reading.date_read") + `
+		FROM flow_meter
+		WHERE flow_meter.id = flow_reading.meter_id
+		  AND flow_reading.command_id IS NULL
+		  AND flow_reading.date_received >= $1 AND flow_reading.date_received < $2`
+
+	if _, err := db.ExecContext(ctx, q, from.UTC(), to.UTC()); err != nil {
+		return nil, errors.Wrap(err, "matching flow readings to commands")
+	}
+
+	leaks := []Leak{}
+
+	const uncommanded = `
+		SELECT
+			'uncommanded_flow' AS kind,
+			flow_meter.id AS meter_id,
+			flow_meter.station_id,
+			flow_meter.valve,
+			SUM(flow_reading.litres) AS litres
+		FROM flow_reading
+		JOIN flow_meter ON flow_meter.id = flow_reading.meter_id
+		WHERE flow_reading.command_id IS NULL
+		  AND flow_reading.date_received >= $1 AND flow_reading.date_received < $2
+		GROUP BY flow_meter.id, flow_meter.station_id, flow_meter.valve
+		HAVING SUM(flow_reading.litres) > $3
+		ORDER BY flow_meter.id`
+
+	if err := db.SelectContext(ctx, &leaks, uncommanded, from.UTC(), to.UTC(), opts.MinLitres); err != nil {
+		return nil, errors.Wrap(err, "selecting uncommanded flow")
+	}
+
+	// The usual litres of a run are the average of the last ten completed
+	// runs of the same duration on the same valve.
+	const over = `
+		SELECT 'over_delivery' AS kind, meter_id, station_id, valve, command_id, litres, expected
+		FROM (
+			SELECT
+				flow_meter.id AS meter_id,
+				command.station_id,
+				command.valve,
+				command.id AS command_id,
+				(SELECT SUM(litres) FROM flow_reading WHERE command_id = command.id) AS litres,
+				history.expected,
+				history.runs
+			FROM command
+			JOIN flow_meter ON flow_meter.station_id = command.station_id
+			  AND (flow_meter.id = command.meter_id OR (command.meter_id IS NULL AND flow_meter.valve = command.valve))
+			CROSS JOIN LATERAL (
+				SELECT AVG(earlier.litres) AS expected, COUNT(*) AS runs
+				FROM (
+					SELECT (SELECT SUM(litres) FROM flow_reading WHERE command_id = previous.id) AS litres
+					FROM command AS previous
+					WHERE previous.station_id = command.station_id
+					  AND previous.valve = command.valve
+					  AND previous.kind = 'water'
+					  AND previous.status = 'completed'
+					  AND previous.duration = command.duration
+					  AND previous.date_finished < command.date_started
+					ORDER BY previous.date_finished DESC
+					LIMIT 10
+				) AS earlier
+				WHERE earlier.litres IS NOT NULL
+			) AS history
+			WHERE command.kind = 'water'
+			  AND command.status = 'completed'
+			  AND command.date_finished >= $1 AND command.date_finished < $2
+		) AS candidate
+		WHERE litres IS NOT NULL AND runs >= $3 AND litres > expected * $4
+		ORDER BY command_id`
+
+	var overs []Leak
+	err := db.SelectContext(ctx, &overs, over,
+		from.Add(-CommandWindow).UTC(),
+		to.Add(-CommandWindow).UTC(),
+		opts.MinRuns,
+		opts.OverFactor,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "selecting over delivering runs")
+	}
+	leaks = append(leaks, overs...)
+
+	for _, l := range leaks {
+		if err := raiseLeak(ctx, db, l, opts.CloseValve, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return leaks, nil
+}
+
+// raiseLeak records the Event of a Leak and queues a close_valve Command for
+// it unless one is already queued for the valve.
+func raiseLeak(ctx context.Context, db *sqlx.DB, l Leak, closeValve bool, now time.Time) error {
+	msg := fmt.Sprintf("%.1f litres flowed through valve %q while no watering run was open", l.Litres, l.Valve)
+	if l.Kind == LeakOverDelivery {
+		msg = fmt.Sprintf("watering run on valve %q delivered %.1f litres, %.1f are usual", l.Valve, l.Litres, l.Expected)
+	}
+
+	// Over deliveries belong to a single run so only flow without a run can
+	// repeat.
+	recent := false
+	if l.Kind == LeakUncommandedFlow {
+		const q = `
+			SELECT EXISTS (
+				SELECT 1 FROM event
+				WHERE type = $1 AND data->>'kind' = $2 AND data->>'meter_id' = $3 AND date_created >= $4
+			)`
+		if err := db.GetContext(ctx, &recent, q, EventLeak, l.Kind, l.MeterId, now.Add(-leakEvery).UTC()); err != nil {
+			return errors.Wrap(err, "getting recent leak events")
+		}
+	}
+
+	if !recent {
+		ne := event.NewEvent{
+			Type:      EventLeak,
+			Severity:  event.SeverityCritical,
+			StationId: &l.StationId,
+			Message:   msg,
+			Data:      l,
+		}
+		if _, err := event.Record(ctx, db, ne, now); err != nil {
+			return errors.Wrap(err, "recording leak event")
+		}
+	}
+
+	if !closeValve {
+		return nil
+	}
+
+	queued, err := command.List(ctx, db, command.Filter{StationId: l.StationId, Status: command.StatusQueued})
+	if err != nil {
+		return errors.Wrap(err, "getting queued commands")
+	}
+	for _, c := range queued {
+		if c.Kind == command.KindCloseValve && c.Valve == l.Valve {
+			return nil
+		}
+	}
+
+	nc := command.NewCommand{Kind: command.KindCloseValve, Valve: l.Valve, MeterId: &l.MeterId}
+	if _, err := command.Create(ctx, db, l.StationId, nc, nil, now); err != nil {
+		return errors.Wrap(err, "queueing close valve command")
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package water_test
+
+import (
+	// Core packages
+	"context"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/command"
+	"github.com/deezone/HydroBytes-BaseStation/internal/event"
+	"github.com/deezone/HydroBytes-BaseStation/internal/schema"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+	"github.com/deezone/HydroBytes-BaseStation/internal/water"
+)
+
+func TestDetectLeaks(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	start := time.Date(2021, time.June, 1, 6, 0, 0, 0, time.UTC)
+
+	station := "ee72a90c-590c-11eb-ae93-0242ac130002"
+	meter := "7b2d9e4f-6a13-4c85-9f70-1e8c3a5d2b46"
+
+	// run waters for 4 minutes at the given time and sends the pulses counted
+	// half way through and at the end.
+	run := func(at time.Time, pulses int64) *command.Command {
+		c, err := command.Create(ctx, db, station, command.NewCommand{Kind: command.KindWater, Valve: "zone-2", Duration: 240}, nil, at)
+		if err != nil {
+			t.Fatalf("creating command: %s", err)
+		}
+		if _, err := command.Update(ctx, db, c.Id, command.Report{Status: command.StatusRunning}, at); err != nil {
+			t.Fatalf("starting command: %s", err)
+		}
+		for _, d := range []time.Duration{2 * time.Minute, 4 * time.Minute} {
+			read := at.Add(d)
+			nr := water.NewFlowReadings{Readings: []water.NewFlowReading{{Pulses: pulses / 2, DateRead: &read}}}
+			if _, err := water.RecordFlow(ctx, db, meter, nr, read); err != nil {
+				t.Fatalf("recording flow: %s", err)
+			}
+		}
+		if _, err := command.Update(ctx, db, c.Id, command.Report{Status: command.StatusCompleted}, at.Add(4*time.Minute)); err != nil {
+			t.Fatalf("completing command: %s", err)
+		}
+		return c
+	}
+
+	// Three days of 4 litre runs set what is usual for the valve.
+	for day := 0; day < 3; day++ {
+		run(start.Add(time.Duration(day)*24*time.Hour), 1800)
+	}
+
+	opts := water.DefaultLeakOptions
+	opts.CloseValve = true
+
+	leaks, err := water.DetectLeaks(ctx, db, start, start.Add(72*time.Hour), opts, start.Add(72*time.Hour))
+	if err != nil {
+		t.Fatalf("detecting leaks: %s", err)
+	}
+	if exp, got := 0, len(leaks); exp != got {
+		t.Fatalf("expected %v leaks in usual runs, got %v: %+v", exp, got, leaks)
+	}
+
+	// The fourth run delivers 10 litres and water keeps flowing afterwards.
+	day4 := start.Add(72 * time.Hour)
+	over := run(day4, 4500)
+
+	after := day4.Add(30 * time.Minute)
+	nr := water.NewFlowReadings{Readings: []water.NewFlowReading{{Pulses: 900, DateRead: &after}}}
+	if _, err := water.RecordFlow(ctx, db, meter, nr, after); err != nil {
+		t.Fatalf("recording flow: %s", err)
+	}
+
+	leaks, err = water.DetectLeaks(ctx, db, day4, day4.Add(time.Hour), opts, day4.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("detecting leaks: %s", err)
+	}
+	if exp, got := 2, len(leaks); exp != got {
+		t.Fatalf("expected %v leaks, got %v: %+v", exp, got, leaks)
+	}
+	if exp, got := water.LeakUncommandedFlow, leaks[0].Kind; exp != got {
+		t.Fatalf("expected a %v leak, got %v", exp, got)
+	}
+	if exp, got := 2.0, leaks[0].Litres; exp != got {
+		t.Fatalf("expected %v litres without a run, got %v", exp, got)
+	}
+	if exp, got := water.LeakOverDelivery, leaks[1].Kind; exp != got {
+		t.Fatalf("expected a %v leak, got %v", exp, got)
+	}
+	if leaks[1].CommandId == nil || *leaks[1].CommandId != over.Id {
+		t.Fatalf("expected the over delivery of run %v, got %v", over.Id, leaks[1].CommandId)
+	}
+
+	events, err := event.List(ctx, db, event.Filter{Type: water.EventLeak, Severity: event.SeverityCritical})
+	if err != nil {
+		t.Fatalf("listing events: %s", err)
+	}
+	if exp, got := 2, len(events); exp != got {
+		t.Fatalf("expected %v leak events, got %v", exp, got)
+	}
+
+	// Water still flowing a few minutes later is not raised again.
+	later := day4.Add(70 * time.Minute)
+	nr = water.NewFlowReadings{Readings: []water.NewFlowReading{{Pulses: 900, DateRead: &later}}}
+	if _, err := water.RecordFlow(ctx, db, meter, nr, later); err != nil {
+		t.Fatalf("recording flow: %s", err)
+	}
+	leaks, err = water.DetectLeaks(ctx, db, day4.Add(time.Hour), later.Add(time.Minute), opts, later.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("detecting leaks: %s", err)
+	}
+	if exp, got := 1, len(leaks); exp != got {
+		t.Fatalf("expected %v ongoing leak, got %v: %+v", exp, got, leaks)
+	}
+	events, err = event.List(ctx, db, event.Filter{Type: water.EventLeak, Severity: event.SeverityCritical})
+	if err != nil {
+		t.Fatalf("listing events: %s", err)
+	}
+	if exp, got := 2, len(events); exp != got {
+		t.Fatalf("expected the ongoing leak not to raise another event, got %v events", got)
+	}
+
+	queued, err := command.List(ctx, db, command.Filter{StationId: station, Status: command.StatusQueued})
+	if err != nil {
+		t.Fatalf("listing commands: %s", err)
+	}
+	if exp, got := 1, len(queued); exp != got {
+		t.Fatalf("expected %v queued close valve command, got %v", exp, got)
+	}
+	if exp, got := command.KindCloseValve, queued[0].Kind; exp != got {
+		t.Fatalf("expected a %v command, got %v", exp, got)
+	}
+}
@@ -0,0 +1,101 @@
+package water
+
+import (
+	// Core packages
+	"time"
+)
+
+// Usage periods.
+const (
+	PeriodDay    = "day"
+	PeriodWeek   = "week"
+	PeriodSeason = "season"
+)
+
+// Usage groupings.
+const (
+	GroupStation = "station"
+	GroupZone    = "zone"
+	GroupMeter   = "meter"
+)
+
+// FlowMeter is a pulse output flow meter of a Station. KFactor is the number of
+// pulses the meter gives per litre. The water is counted towards ZoneId, or
+// the Zone of the Station when it is not set.
+type FlowMeter struct {
+	Id          string    `db:"id"           json:"id"`
+	StationId   string    `db:"station_id"   json:"station_id"`
+	Name        string    `db:"name"         json:"name"`
+	Valve       string    `db:"valve"        json:"valve"`
+	ZoneId      *string   `db:"zone_id"      json:"zone_id"`
+	KFactor     float64   `db:"k_factor"     json:"k_factor"`
+	DateCreated time.Time `db:"date_created" json:"date_created"`
+	DateUpdated time.Time `db:"date_updated" json:"date_updated"`
+}
+
+// NewFlowMeter is what we require from clients when adding a FlowMeter.
+type NewFlowMeter struct {
+	Name    string  `json:"name" validate:"required"`
+	Valve   string  `json:"valve"`
+	ZoneId  *string `json:"zone_id" validate:"omitempty,uuid"`
+	KFactor float64 `json:"k_factor" validate:"required,gt=0"`
+}
+
+// UpdateFlowMeter defines what information may be provided to modify an
+// existing FlowMeter. All fields are optional so clients can send just the
+// fields they want changed. A ZoneId of "" counts the water towards the Zone
+// of the Station again.
+type UpdateFlowMeter struct {
+	Name    *string  `json:"name"`
+	Valve   *string  `json:"valve"`
+	ZoneId  *string  `json:"zone_id" validate:"omitempty,uuid"`
+	KFactor *float64 `json:"k_factor" validate:"omitempty,gt=0"`
+}
+
+// FlowReading is the number of pulses a FlowMeter counted since its previous
+// reading, converted to litres with the KFactor of the meter. CommandId is
+// the watering run that was open on the meter's valve at the time.
+type FlowReading struct {
+	Id           string    `db:"id"            json:"id"`
+	MeterId      string    `db:"meter_id"      json:"meter_id"`
+	CommandId    *string   `db:"command_id"    json:"command_id,omitempty"`
+	Pulses       int64     `db:"pulses"        json:"pulses"`
+	Litres       float64   `db:"litres"        json:"litres"`
+	DateRead     time.Time `db:"date_read"     json:"date_read"`
+	DateReceived time.Time `db:"date_received" json:"date_received"`
+}
+
+// NewFlowReading is a pulse count sent by a Station. DateRead defaults to the
+// time the reading is received.
+type NewFlowReading struct {
+	Pulses   int64      `json:"pulses" validate:"gte=0"`
+	DateRead *time.Time `json:"date_read"`
+}
+
+// NewFlowReadings is a batch of pulse counts sent by a Station.
+type NewFlowReadings struct {
+	Readings []NewFlowReading `json:"readings" validate:"required,dive"`
+}
+
+// Usage is the water used in the period starting at Period. Key is the id of
+// the station, zone or meter the usage is grouped by and is empty when the
+// usage is not grouped or the water was not counted towards a zone.
+type Usage struct {
+	Period time.Time `db:"period" json:"period"`
+	Key    string    `db:"key"    json:"key,omitempty"`
+	Litres float64   `db:"litres" json:"litres"`
+}
+
+// UsageFilter selects the water usage returned by ListUsage. Period is one of
+// day, week or season (meteorological seasons starting in March, June,
+// September and December). GroupBy is empty for totals or one of station, zone
+// or meter. StationId and ZoneId limit the usage to a station or zone, From and
+// To to a time window.
+type UsageFilter struct {
+	Period    string
+	GroupBy   string
+	StationId string
+	ZoneId    string
+	From      *time.Time
+	To        *time.Time
+}
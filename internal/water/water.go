@@ -0,0 +1,433 @@
+package water
+
+import (
+	// Core packages
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Predefined errors identify expected failure conditions.
+var (
+	// ErrNotFound is used when a specific FlowMeter is requested but does not exist.
+	ErrNotFound = errors.New("flow meter not found")
+
+	// ErrInvalidID is used when an invalid UUID is provided.
+	ErrInvalidID = errors.New("ID is not in its proper UUID format")
+
+	// ErrStationNotFound is used when a FlowMeter is added to a Station that does not exist.
+	ErrStationNotFound = errors.New("station not found")
+
+	// ErrZoneNotFound is used when a FlowMeter is assigned to a Zone that does not exist.
+	ErrZoneNotFound = errors.New("zone not found")
+
+	// ErrInvalidUsage is used when water usage is requested with an unknown
+	// period or grouping.
+	ErrInvalidUsage = errors.New("period must be day, week or season and group must be station, zone or meter")
+)
+
+// CommandWindow is how long after a watering run finished a FlowReading is
+// still counted towards it. Stations report the pulses counted up to the
+// moment the valve closed a little after closing it.
+const CommandWindow = time.Minute
+
+// periods are the SQL expressions for the start of each usage period.
+// Meteorological seasons start on the first of March, June, September and
+// December.
+var periods = map[string]string{
+	PeriodDay:    `date_trunc('day', flow_reading.date_read)`,
+	PeriodWeek:   `date_trunc('week', flow_reading.date_read)`,
+	PeriodSeason: `date_trunc('quarter', flow_reading.date_read - INTERVAL '2 months') + INTERVAL '2 months'`,
+}
+
+// groups are the SQL expressions for the key of each usage grouping.
+var groups = map[string]string{
+	"":           `''`,
+	GroupStation: `flow_meter.station_id::TEXT`,
+	GroupZone:    `COALESCE(COALESCE(flow_meter.zone_id, station.zone_id)::TEXT, '')`,
+	GroupMeter:   `flow_meter.id::TEXT`,
+}
+
+// AddMeter adds a FlowMeter to a Station.
+func AddMeter(ctx context.Context, db *sqlx.DB, stationID string, nm NewFlowMeter, now time.Time) (*FlowMeter, error) {
+
+	ctx, span := trace.StartSpan(ctx, "water.AddMeter")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var exists bool
+	const check = `SELECT EXISTS (SELECT 1 FROM station WHERE id = $1 AND date_deleted IS NULL)`
+	if err := db.GetContext(ctx, &exists, check, stationID); err != nil {
+		return nil, errors.Wrap(err, "checking station")
+	}
+	if !exists {
+		return nil, ErrStationNotFound
+	}
+
+	if err := checkZone(ctx, db, nm.ZoneId); err != nil {
+		return nil, err
+	}
+
+	m := FlowMeter{
+		Id:          uuid.New().String(),
+		StationId:   stationID,
+		Name:        nm.Name,
+		Valve:       nm.Valve,
+		ZoneId:      nm.ZoneId,
+		KFactor:     nm.KFactor,
+		DateCreated: now.UTC(),
+		DateUpdated: now.UTC(),
+	}
+
+	const q = `
+		INSERT INTO flow_meter
+		  (id, station_id, name, valve, zone_id, k_factor, date_created, date_updated)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := db.ExecContext(ctx, q,
+		m.Id,
+		m.StationId,
+		m.Name,
+		m.Valve,
+		m.ZoneId,
+		m.KFactor,
+		m.DateCreated,
+		m.DateUpdated,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "inserting flow meter")
+	}
+
+	return &m, nil
+}
+
+// ListMeters gets the FlowMeters of a Station.
+func ListMeters(ctx context.Context, db *sqlx.DB, stationID string) ([]FlowMeter, error) {
+
+	ctx, span := trace.StartSpan(ctx, "water.ListMeters")
+	defer span.End()
+
+	if _, err := uuid.Parse(stationID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	meters := []FlowMeter{}
+
+	const q = `
+		SELECT id, station_id, name, valve, zone_id, k_factor, date_created, date_updated
+		FROM flow_meter
+		WHERE station_id = $1
+		ORDER BY name`
+
+	if err := db.SelectContext(ctx, &meters, q, stationID); err != nil {
+		return nil, errors.Wrap(err, "selecting flow meters")
+	}
+
+	return meters, nil
+}
+
+// GetMeter finds the FlowMeter identified by a given ID.
+func GetMeter(ctx context.Context, db *sqlx.DB, id string) (*FlowMeter, error) {
+
+	ctx, span := trace.StartSpan(ctx, "water.GetMeter")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var m FlowMeter
+
+	const q = `
+		SELECT id, station_id, name, valve, zone_id, k_factor, date_created, date_updated
+		FROM flow_meter
+		WHERE id = $1`
+
+	if err := db.GetContext(ctx, &m, q, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+
+		return nil, errors.Wrap(err, "selecting single flow meter")
+	}
+
+	return &m, nil
+}
+
+// UpdateMeter modifies data about a FlowMeter. Changing the KFactor does not
+// change the litres of FlowReadings already recorded.
+func UpdateMeter(ctx context.Context, db *sqlx.DB, id string, um UpdateFlowMeter, now time.Time) error {
+
+	ctx, span := trace.StartSpan(ctx, "water.UpdateMeter")
+	defer span.End()
+
+	m, err := GetMeter(ctx, db, id)
+	if err != nil {
+		return err
+	}
+
+	if um.Name != nil {
+		m.Name = *um.Name
+	}
+	if um.Valve != nil {
+		m.Valve = *um.Valve
+	}
+	if um.ZoneId != nil {
+		if *um.ZoneId == "" {
+			m.ZoneId = nil
+		} else {
+			if err := checkZone(ctx, db, um.ZoneId); err != nil {
+				return err
+			}
+			m.ZoneId = um.ZoneId
+		}
+	}
+	if um.KFactor != nil {
+		m.KFactor = *um.KFactor
+	}
+	m.DateUpdated = now.UTC()
+
+	const q = `UPDATE flow_meter SET
+		"name" = $2,
+		"valve" = $3,
+		"zone_id" = $4,
+		"k_factor" = $5,
+		"date_updated" = $6
+		WHERE id = $1`
+	_, err = db.ExecContext(ctx, q, id, m.Name, m.Valve, m.ZoneId, m.KFactor, m.DateUpdated)
+	if err != nil {
+		return errors.Wrap(err, "updating flow meter")
+	}
+
+	return nil
+}
+
+// DeleteMeter removes a FlowMeter along with its FlowReadings.
+func DeleteMeter(ctx context.Context, db *sqlx.DB, id string) error {
+
+	ctx, span := trace.StartSpan(ctx, "water.DeleteMeter")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrInvalidID
+	}
+
+	const q = `DELETE FROM flow_meter WHERE id = $1`
+
+	res, err := db.ExecContext(ctx, q, id)
+	if err != nil {
+		return errors.Wrapf(err, "deleting flow meter %s", id)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return errors.Wrapf(err, "deleting flow meter %s", id)
+	} else if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// RecordFlow stores pulse counts of a FlowMeter as litres. Each FlowReading is
+// counted towards the watering run that was open on the meter, or on the
+// meter's valve, when it was read.
+func RecordFlow(ctx context.Context, db *sqlx.DB, meterID string, nr NewFlowReadings, now time.Time) ([]FlowReading, error) {
+
+	ctx, span := trace.StartSpan(ctx, "water.RecordFlow")
+	defer span.End()
+
+	m, err := GetMeter(ctx, db, meterID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "starting transaction")
+	}
+	defer tx.Rollback()
+
+	q := `
+		INSERT INTO flow_reading
+		  (id, meter_id, command_id, pulses, litres, date_read, date_received)
+		VALUES ($1, $2, ` + matchCommand("$2", "$7", "$8", "$6") + `, $3, $4, $6, $5)
+		RETURNING command_id`
+
+	readings := make([]FlowReading, 0, len(nr.Readings))
+	for _, r := range nr.Readings {
+		fr := FlowReading{
+			Id:           uuid.New().String(),
+			MeterId:      m.Id,
+			Pulses:       r.Pulses,
+			Litres:       Litres(r.Pulses, m.KFactor),
+			DateRead:     now.UTC(),
+			DateReceived: now.UTC(),
+		}
+		if r.DateRead != nil {
+			fr.DateRead = r.DateRead.UTC()
+		}
+
+		err := tx.GetContext(ctx, &fr.CommandId, q,
+			fr.Id,
+			fr.MeterId,
+			fr.Pulses,
+			fr.Litres,
+			fr.DateReceived,
+			fr.DateRead,
+			m.StationId,
+			m.Valve,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "inserting flow reading")
+		}
+
+		readings = append(readings, fr)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "committing flow readings")
+	}
+
+	return readings, nil
+}
+
+// ListReadings gets the FlowReadings of a FlowMeter read since the given time,
+// oldest first.
+func ListReadings(ctx context.Context, db *sqlx.DB, meterID string, since time.Time) ([]FlowReading, error) {
+
+	ctx, span := trace.StartSpan(ctx, "water.ListReadings")
+	defer span.End()
+
+	if _, err := uuid.Parse(meterID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	readings := []FlowReading{}
+
+	const q = `
+		SELECT id, meter_id, command_id, pulses, litres, date_read, date_received
+		FROM flow_reading
+		WHERE meter_id = $1 AND date_read >= $2
+		ORDER BY date_read`
+
+	if err := db.SelectContext(ctx, &readings, q, meterID, since.UTC()); err != nil {
+		return nil, errors.Wrap(err, "selecting flow readings")
+	}
+
+	return readings, nil
+}
+
+// ListUsage totals the litres of FlowReadings per period, and per station, zone
+// or meter when the UsageFilter groups them.
+func ListUsage(ctx context.Context, db *sqlx.DB, filter UsageFilter) ([]Usage, error) {
+
+	ctx, span := trace.StartSpan(ctx, "water.ListUsage")
+	defer span.End()
+
+	period, ok := periods[filter.Period]
+	if !ok {
+		return nil, ErrInvalidUsage
+	}
+	key, ok := groups[filter.GroupBy]
+	if !ok {
+		return nil, ErrInvalidUsage
+	}
+
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := []string{"TRUE"}
+	if filter.StationId != "" {
+		if _, err := uuid.Parse(filter.StationId); err != nil {
+			return nil, ErrInvalidID
+		}
+		where = append(where, "flow_meter.station_id = "+arg(filter.StationId))
+	}
+	if filter.ZoneId != "" {
+		if _, err := uuid.Parse(filter.ZoneId); err != nil {
+			return nil, ErrInvalidID
+		}
+		where = append(where, "COALESCE(flow_meter.zone_id, station.zone_id) = "+arg(filter.ZoneId))
+	}
+	if filter.From != nil {
+		where = append(where, "flow_reading.date_read >= "+arg(filter.From.UTC()))
+	}
+	if filter.To != nil {
+		where = append(where, "flow_reading.date_read < "+arg(filter.To.UTC()))
+	}
+
+	usage := []Usage{}
+
+	q := `
+		SELECT ` + period + ` AS period, ` + key + ` AS key, SUM(flow_reading.litres) AS litres
+		FROM flow_reading
+		JOIN flow_meter ON flow_meter.id = flow_reading.meter_id
+		JOIN station ON station.id = flow_meter.station_id
+		WHERE ` + strings.Join(where, " AND ") + `
+		GROUP BY 1, 2
+		ORDER BY 1, 2`
+
+	if err := db.SelectContext(ctx, &usage, q, args...); err != nil {
+		return nil, errors.Wrap(err, "selecting water usage")
+	}
+
+	return usage, nil
+}
+
+// Litres converts a pulse count of a meter with the given K-factor to litres.
+func Litres(pulses int64, kFactor float64) float64 {
+	if kFactor <= 0 {
+		return 0
+	}
+
+	return float64(pulses) / kFactor
+}
+
+// matchCommand is a subquery finding the watering run a FlowReading counts
+// towards: the latest water Command on the meter, or on the valve of the meter,
+// started before the reading that had not finished more than CommandWindow
+// before it. The arguments are the SQL expressions for the meter, its station
+// and valve and the time of the reading.
+func matchCommand(meter, station, valve, read string) string {
+	return fmt.Sprintf(`(
+			SELECT command.id FROM command
+			WHERE command.station_id = %[2]s
+			  AND command.kind = 'water'
+			  AND (command.meter_id = %[1]s OR (command.meter_id IS NULL AND command.valve = %[3]s))
+			  AND command.date_started <= %[4]s
+			  AND (command.date_finished IS NULL OR command.date_finished >= %[4]s - INTERVAL '%[5]d seconds')
+			ORDER BY command.date_started DESC
+			LIMIT 1
+		)`, meter, station, valve, read, int(CommandWindow.Seconds()))
+}
+
+// checkZone makes sure the Zone a FlowMeter is assigned to exists.
+func checkZone(ctx context.Context, db *sqlx.DB, zoneID *string) error {
+	if zoneID == nil {
+		return nil
+	}
+
+	var exists bool
+	const q = `SELECT EXISTS (SELECT 1 FROM zone WHERE id = $1)`
+	if err := db.GetContext(ctx, &exists, q, *zoneID); err != nil {
+		return errors.Wrap(err, "checking zone")
+	}
+	if !exists {
+		return ErrZoneNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package water_test
+
+import (
+	// Core packages
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/command"
+	"github.com/deezone/HydroBytes-BaseStation/internal/schema"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+	"github.com/deezone/HydroBytes-BaseStation/internal/water"
+)
+
+func TestFlow(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2021, time.June, 2, 6, 0, 0, 0, time.UTC)
+
+	station := "ee72a90c-590c-11eb-ae93-0242ac130002"
+	meter := "7b2d9e4f-6a13-4c85-9f70-1e8c3a5d2b46"
+	zone := "8a1f5c2e-7d3b-4e0a-9c61-2b4d8e6f0a13"
+
+	// A 4 minute watering run on the valve of the meter.
+	run, err := command.Create(ctx, db, station, command.NewCommand{Kind: command.KindWater, Valve: "zone-2", Duration: 240}, nil, now)
+	if err != nil {
+		t.Fatalf("creating command: %s", err)
+	}
+	if _, err := command.Update(ctx, db, run.Id, command.Report{Status: command.StatusRunning}, now); err != nil {
+		t.Fatalf("starting command: %s", err)
+	}
+	if _, err := command.Update(ctx, db, run.Id, command.Report{Status: command.StatusCompleted}, now.Add(4*time.Minute)); err != nil {
+		t.Fatalf("completing command: %s", err)
+	}
+
+	read := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+	nr := water.NewFlowReadings{Readings: []water.NewFlowReading{
+		{Pulses: 900, DateRead: read(2 * time.Minute)},
+		{Pulses: 900, DateRead: read(4*time.Minute + 10*time.Second)},
+		{Pulses: 450, DateRead: read(time.Hour)},
+		{Pulses: 4500, DateRead: read(-30 * 24 * time.Hour)},
+	}}
+	readings, err := water.RecordFlow(ctx, db, meter, nr, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("recording flow: %s", err)
+	}
+	if exp, got := 2.0, readings[0].Litres; exp != got {
+		t.Fatalf("expected %v litres, got %v", exp, got)
+	}
+	if readings[1].CommandId == nil || *readings[1].CommandId != run.Id {
+		t.Fatalf("expected the reading just after the run to count towards it, got %v", readings[1].CommandId)
+	}
+	if readings[2].CommandId != nil {
+		t.Fatalf("expected the reading after the run not to count towards a command, got %v", *readings[2].CommandId)
+	}
+
+	delivered, err := command.Get(ctx, db, run.Id)
+	if err != nil {
+		t.Fatalf("getting command: %s", err)
+	}
+	if delivered.Litres == nil || math.Abs(*delivered.Litres-4) > 1e-9 {
+		t.Fatalf("expected the run to deliver 4 litres, got %v", delivered.Litres)
+	}
+
+	daily, err := water.ListUsage(ctx, db, water.UsageFilter{Period: water.PeriodDay, GroupBy: water.GroupZone, ZoneId: zone})
+	if err != nil {
+		t.Fatalf("listing daily usage: %s", err)
+	}
+	if exp, got := 2, len(daily); exp != got {
+		t.Fatalf("expected %v days of usage, got %v", exp, got)
+	}
+	if exp, got := 5.0, daily[1].Litres; math.Abs(exp-got) > 1e-9 {
+		t.Fatalf("expected %v litres on the day of the run, got %v", exp, got)
+	}
+	if exp, got := zone, daily[1].Key; exp != got {
+		t.Fatalf("expected usage of zone %v, got %v", exp, got)
+	}
+
+	// May 3rd is in spring and June 2nd in summer.
+	seasons, err := water.ListUsage(ctx, db, water.UsageFilter{Period: water.PeriodSeason})
+	if err != nil {
+		t.Fatalf("listing seasonal usage: %s", err)
+	}
+	if exp, got := 2, len(seasons); exp != got {
+		t.Fatalf("expected %v seasons of usage, got %v", exp, got)
+	}
+	if exp, got := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC), seasons[0].Period; !exp.Equal(got) {
+		t.Fatalf("expected the spring season to start %v, got %v", exp, got)
+	}
+	if exp, got := time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC), seasons[1].Period; !exp.Equal(got) {
+		t.Fatalf("expected the summer season to start %v, got %v", exp, got)
+	}
+
+	if _, err := water.ListUsage(ctx, db, water.UsageFilter{Period: "month"}); err != water.ErrInvalidUsage {
+		t.Fatalf("expected %v for an unknown period, got %v", water.ErrInvalidUsage, err)
+	}
+}
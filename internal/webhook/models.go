@@ -0,0 +1,53 @@
+package webhook
+
+import (
+	// Core packages
+	"time"
+
+	// Third-party packages
+	"github.com/lib/pq"
+)
+
+// Statuses of a Delivery.
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// Subscription is a URL that Events of the EventTypes are posted to. Every
+// post is signed with Secret, which is only returned when the Subscription is
+// created.
+type Subscription struct {
+	Id          string         `db:"id"           json:"id"`
+	Url         string         `db:"url"          json:"url"`
+	Secret      string         `db:"secret"       json:"secret,omitempty"`
+	EventTypes  pq.StringArray `db:"event_types"  json:"event_types"`
+	DateCreated time.Time      `db:"date_created" json:"date_created"`
+	DateUpdated time.Time      `db:"date_updated" json:"date_updated"`
+}
+
+// NewSubscription is what we require from clients when adding a Subscription.
+// A Secret is generated when none is given.
+type NewSubscription struct {
+	Url        string   `json:"url" validate:"required,url"`
+	Secret     string   `json:"secret" validate:"omitempty,min=16"`
+	EventTypes []string `json:"event_types" validate:"required,min=1"`
+}
+
+// Delivery is an Event queued to be posted to a Subscription. A Delivery that
+// fails is retried at DateNextAttempt, backing off exponentially, until it
+// has been attempted MaxAttempts times.
+type Delivery struct {
+	Id              string     `db:"id"                json:"id"`
+	SubscriptionId  string     `db:"subscription_id"   json:"subscription_id"`
+	EventId         string     `db:"event_id"          json:"event_id"`
+	EventType       string     `db:"event_type"        json:"event_type"`
+	Status          string     `db:"status"            json:"status"`
+	Attempts        int        `db:"attempts"          json:"attempts"`
+	ResponseStatus  *int       `db:"response_status"   json:"response_status,omitempty"`
+	LastError       string     `db:"last_error"        json:"last_error,omitempty"`
+	DateNextAttempt time.Time  `db:"date_next_attempt" json:"date_next_attempt"`
+	DateDelivered   *time.Time `db:"date_delivered"    json:"date_delivered,omitempty"`
+	DateCreated     time.Time  `db:"date_created"      json:"date_created"`
+}
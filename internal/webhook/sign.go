@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	// Core packages
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Headers sent with every webhook post.
+const (
+	HeaderEvent     = "X-HydroBytes-Event"
+	HeaderDelivery  = "X-HydroBytes-Delivery"
+	HeaderTimestamp = "X-HydroBytes-Timestamp"
+	HeaderSignature = "X-HydroBytes-Signature"
+)
+
+const (
+	// MaxAttempts is how many times a Delivery is attempted before it fails.
+	MaxAttempts = 8
+
+	// baseBackoff is how long the first retry of a Delivery waits.
+	baseBackoff = 30 * time.Second
+
+	// maxBackoff is the longest a retry of a Delivery waits.
+	maxBackoff = 6 * time.Hour
+)
+
+// Sign is the signature of a webhook post sent in HeaderSignature. It is the
+// hex encoded HMAC-SHA256 of the timestamp in HeaderTimestamp, a dot and the
+// body, keyed with the secret of the Subscription. Receivers compute the same
+// and reject posts with old timestamps to guard against replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff is how long to wait before attempting a Delivery again after the
+// given number of failed attempts. It doubles with every attempt.
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		return 0
+	}
+
+	d := time.Duration(float64(baseBackoff) * math.Pow(2, float64(attempts-1)))
+	if d > maxBackoff || d <= 0 {
+		return maxBackoff
+	}
+
+	return d
+}
@@ -0,0 +1,46 @@
+package webhook_test
+
+import (
+	// Core packages
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/webhook"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"type":"alert.firing"}`)
+
+	// Computed with: printf '1622548800.{"type":"alert.firing"}' | openssl dgst -sha256 -hmac gardensecret
+	exp := "sha256=96150875f49517e3c93533def80ce31335a6498c1a1e815447479e60582da10f"
+	got := webhook.Sign("gardensecret", 1622548800, body)
+	if exp != got {
+		t.Fatalf("expected signature %q, got %q", exp, got)
+	}
+
+	if got == webhook.Sign("othersecret", 1622548800, body) {
+		t.Fatal("expected a different signature for another secret")
+	}
+	if got == webhook.Sign("gardensecret", 1622548801, body) {
+		t.Fatal("expected a different signature for another timestamp")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		exp      time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{5, 8 * time.Minute},
+		{20, 6 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := webhook.Backoff(tt.attempts); tt.exp != got {
+			t.Fatalf("expected a backoff of %v after %d attempts, got %v", tt.exp, tt.attempts, got)
+		}
+	}
+}
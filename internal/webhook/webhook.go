@@ -0,0 +1,363 @@
+package webhook
+
+import (
+	// Core packages
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/event"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Predefined errors identify expected failure conditions.
+var (
+	// ErrNotFound is used when a specific Subscription is requested but does not exist.
+	ErrNotFound = errors.New("webhook subscription not found")
+
+	// ErrInvalidID is used when an invalid UUID is provided.
+	ErrInvalidID = errors.New("ID is not in its proper UUID format")
+
+	// ErrInvalidEventType is used when a Subscription names an Event type
+	// that is not in event.Types.
+	ErrInvalidEventType = errors.New("unknown event type")
+)
+
+const (
+	// EnqueueWindow is how far back Enqueue looks for Events to deliver.
+	EnqueueWindow = 24 * time.Hour
+
+	// batchSize is the most Deliveries attempted by one call to Deliver.
+	batchSize = 50
+)
+
+// Create adds a Subscription to the database. The returned Subscription
+// holds the secret posts are signed with.
+func Create(ctx context.Context, db *sqlx.DB, ns NewSubscription, now time.Time) (*Subscription, error) {
+
+	ctx, span := trace.StartSpan(ctx, "webhook.Create")
+	defer span.End()
+
+	if !event.ValidTypes(ns.EventTypes) {
+		return nil, ErrInvalidEventType
+	}
+
+	s := Subscription{
+		Id:          uuid.New().String(),
+		Url:         ns.Url,
+		Secret:      ns.Secret,
+		EventTypes:  ns.EventTypes,
+		DateCreated: now.UTC(),
+		DateUpdated: now.UTC(),
+	}
+
+	if s.Secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, errors.Wrap(err, "generating secret")
+		}
+		s.Secret = hex.EncodeToString(b)
+	}
+
+	const q = `
+		INSERT INTO webhook_subscription
+		  (id, url, secret, event_types, date_created, date_updated)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := db.ExecContext(ctx, q,
+		s.Id,
+		s.Url,
+		s.Secret,
+		s.EventTypes,
+		s.DateCreated,
+		s.DateUpdated,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "inserting webhook subscription")
+	}
+
+	return &s, nil
+}
+
+// List gets all Subscriptions without their secrets.
+func List(ctx context.Context, db *sqlx.DB) ([]Subscription, error) {
+
+	ctx, span := trace.StartSpan(ctx, "webhook.List")
+	defer span.End()
+
+	subs := []Subscription{}
+
+	const q = `
+		SELECT id, url, event_types, date_created, date_updated
+		FROM webhook_subscription
+		ORDER BY date_created`
+
+	if err := db.SelectContext(ctx, &subs, q); err != nil {
+		return nil, errors.Wrap(err, "selecting webhook subscriptions")
+	}
+
+	return subs, nil
+}
+
+// Get finds the Subscription identified by a given ID. The secret of the
+// Subscription is not returned.
+func Get(ctx context.Context, db *sqlx.DB, id string) (*Subscription, error) {
+
+	ctx, span := trace.StartSpan(ctx, "webhook.Get")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var s Subscription
+
+	const q = `
+		SELECT id, url, event_types, date_created, date_updated
+		FROM webhook_subscription
+		WHERE id = $1`
+
+	if err := db.GetContext(ctx, &s, q, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+
+		return nil, errors.Wrap(err, "selecting single webhook subscription")
+	}
+
+	return &s, nil
+}
+
+// Delete removes a Subscription along with its Deliveries.
+func Delete(ctx context.Context, db *sqlx.DB, id string) error {
+
+	ctx, span := trace.StartSpan(ctx, "webhook.Delete")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrInvalidID
+	}
+
+	const q = `DELETE FROM webhook_subscription WHERE id = $1`
+
+	res, err := db.ExecContext(ctx, q, id)
+	if err != nil {
+		return errors.Wrapf(err, "deleting webhook subscription %s", id)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return errors.Wrapf(err, "deleting webhook subscription %s", id)
+	} else if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// ListDeliveries gets the Deliveries of a Subscription, most recent first.
+func ListDeliveries(ctx context.Context, db *sqlx.DB, id string, limit int) ([]Delivery, error) {
+
+	ctx, span := trace.StartSpan(ctx, "webhook.ListDeliveries")
+	defer span.End()
+
+	if _, err := Get(ctx, db, id); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = 100
+	}
+
+	deliveries := []Delivery{}
+
+	const q = `
+		SELECT
+			id, subscription_id, event_id, event_type, status, attempts, response_status, last_error,
+			date_next_attempt, date_delivered, date_created
+		FROM webhook_delivery
+		WHERE subscription_id = $1
+		ORDER BY date_created DESC
+		LIMIT $2`
+
+	if err := db.SelectContext(ctx, &deliveries, q, id, limit); err != nil {
+		return nil, errors.Wrap(err, "selecting webhook deliveries")
+	}
+
+	return deliveries, nil
+}
+
+// Enqueue queues a Delivery of every Event raised in the last EnqueueWindow
+// to every Subscription of its type created before the Event. Events already
+// queued for a Subscription are skipped, so Enqueue can be called as often as
+// needed. It returns the number of Deliveries queued.
+func Enqueue(ctx context.Context, db *sqlx.DB, now time.Time) (int64, error) {
+
+	ctx, span := trace.StartSpan(ctx, "webhook.Enqueue")
+	defer span.End()
+
+	const q = `
+		INSERT INTO webhook_delivery
+		  (id, subscription_id, event_id, event_type, status, attempts, last_error, date_next_attempt, date_created)
+		SELECT
+			md5(random()::TEXT || event.id::TEXT || webhook_subscription.id::TEXT)::UUID,
+			webhook_subscription.id, event.id, event.type, 'pending', 0, '', $1, $1
+		FROM event
+		JOIN webhook_subscription ON event.type = ANY(webhook_subscription.event_types)
+		WHERE event.date_created >= webhook_subscription.date_created AND event.date_created >= $2
+		ORDER BY event.date_created
+		ON CONFLICT (subscription_id, event_id) DO NOTHING`
+
+	res, err := db.ExecContext(ctx, q, now.UTC(), now.Add(-EnqueueWindow).UTC())
+	if err != nil {
+		return 0, errors.Wrap(err, "queueing webhook deliveries")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "queueing webhook deliveries")
+	}
+
+	return n, nil
+}
+
+// Deliver posts the queued Deliveries that are due, oldest first. Deliveries
+// answered with a 2xx status are done. Others are retried with an exponential
+// Backoff until they have been attempted MaxAttempts times. A Subscription
+// that gives no response at all, such as when the post times out, is skipped
+// for the rest of the call so one slow endpoint does not hold up the others;
+// its remaining Deliveries are left due without using up an attempt. It
+// returns the number of Deliveries that were delivered.
+func Deliver(ctx context.Context, db *sqlx.DB, client *http.Client, now time.Time) (int, error) {
+
+	ctx, span := trace.StartSpan(ctx, "webhook.Deliver")
+	defer span.End()
+
+	var due []struct {
+		Id             string `db:"id"`
+		SubscriptionId string `db:"subscription_id"`
+		EventId        string `db:"event_id"`
+		Attempts       int    `db:"attempts"`
+		Url            string `db:"url"`
+		Secret         string `db:"secret"`
+	}
+
+	const q = `
+		SELECT webhook_delivery.id, webhook_delivery.subscription_id, webhook_delivery.event_id, webhook_delivery.attempts,
+			webhook_subscription.url, webhook_subscription.secret
+		FROM webhook_delivery
+		JOIN webhook_subscription ON webhook_subscription.id = webhook_delivery.subscription_id
+		WHERE webhook_delivery.status = 'pending' AND webhook_delivery.date_next_attempt <= $1
+		ORDER BY webhook_delivery.date_created
+		LIMIT $2`
+
+	if err := db.SelectContext(ctx, &due, q, now.UTC(), batchSize); err != nil {
+		return 0, errors.Wrap(err, "selecting due webhook deliveries")
+	}
+
+	delivered := 0
+	unresponsive := map[string]bool{}
+	for _, d := range due {
+		if unresponsive[d.SubscriptionId] {
+			continue
+		}
+
+		e, err := event.Get(ctx, db, d.EventId)
+		if err != nil {
+			return delivered, errors.Wrapf(err, "getting event %s", d.EventId)
+		}
+
+		status, postErr := post(ctx, client, d.Url, d.Secret, d.Id, e, now)
+		if postErr != nil && status == nil {
+			unresponsive[d.SubscriptionId] = true
+		}
+
+		attempts := d.Attempts + 1
+		var deliveredAt *time.Time
+		var lastError string
+		next := now.UTC()
+		result := StatusPending
+
+		switch {
+		case postErr == nil:
+			result = StatusDelivered
+			t := now.UTC()
+			deliveredAt = &t
+		case attempts >= MaxAttempts:
+			result = StatusFailed
+			lastError = postErr.Error()
+		default:
+			lastError = postErr.Error()
+			next = now.Add(Backoff(attempts)).UTC()
+		}
+
+		const update = `UPDATE webhook_delivery SET
+			"status" = $2,
+			"attempts" = $3,
+			"response_status" = $4,
+			"last_error" = $5,
+			"date_next_attempt" = $6,
+			"date_delivered" = $7
+			WHERE id = $1`
+		if _, err := db.ExecContext(ctx, update, d.Id, result, attempts, status, lastError, next, deliveredAt); err != nil {
+			return delivered, errors.Wrap(err, "updating webhook delivery")
+		}
+
+		if result == StatusDelivered {
+			delivered++
+		}
+	}
+
+	return delivered, nil
+}
+
+// post sends an Event to the URL of a Subscription. It returns the status of
+// the response when there was one.
+func post(ctx context.Context, client *http.Client, url, secret, deliveryID string, e *event.Event, now time.Time) (*int, error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding event")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+	req = req.WithContext(ctx)
+
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, e.Type)
+	req.Header.Set(HeaderDelivery, deliveryID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Drain the body so the connection can be reused.
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	status := resp.StatusCode
+	if status < 200 || status > 299 {
+		return &status, fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+
+	return &status, nil
+}
@@ -0,0 +1,164 @@
+package webhook_test
+
+import (
+	// Core packages
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/event"
+	"github.com/deezone/HydroBytes-BaseStation/internal/schema"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+	"github.com/deezone/HydroBytes-BaseStation/internal/webhook"
+)
+
+func TestWebhook(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2021, time.June, 2, 6, 0, 0, 0, time.UTC)
+
+	// The receiver fails the first post and accepts the rest as long as they
+	// are signed with the secret of the subscription.
+	const secret = "0123456789abcdef0123456789abcdef"
+	posts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		body, _ := ioutil.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		if r.Header.Get(webhook.HeaderSignature) != webhook.Sign(secret, ts, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if posts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sub, err := webhook.Create(ctx, db, webhook.NewSubscription{Url: srv.URL, Secret: secret, EventTypes: []string{"water.leak"}}, now)
+	if err != nil {
+		t.Fatalf("creating subscription: %s", err)
+	}
+
+	water := "ee72a90c-590c-11eb-ae93-0242ac130002"
+	for _, typ := range []string{"water.leak", "reservoir.dry"} {
+		ne := event.NewEvent{Type: typ, Severity: event.SeverityWarning, StationId: &water, Message: typ}
+		if _, err := event.Record(ctx, db, ne, now.Add(time.Minute)); err != nil {
+			t.Fatalf("recording event: %s", err)
+		}
+	}
+
+	at := now.Add(2 * time.Minute)
+	for i, exp := range []int64{1, 0} {
+		got, err := webhook.Enqueue(ctx, db, at)
+		if err != nil {
+			t.Fatalf("queueing deliveries: %s", err)
+		}
+		if exp != got {
+			t.Fatalf("queueing %d: expected %v deliveries, got %v", i, exp, got)
+		}
+	}
+
+	if n, err := webhook.Deliver(ctx, db, srv.Client(), at); err != nil || n != 0 {
+		t.Fatalf("first delivery should fail, got %v delivered: %v", n, err)
+	}
+	if n, err := webhook.Deliver(ctx, db, srv.Client(), at.Add(time.Second)); err != nil || n != 0 {
+		t.Fatalf("failed delivery should not be retried before its backoff, got %v delivered: %v", n, err)
+	}
+	if n, err := webhook.Deliver(ctx, db, srv.Client(), at.Add(webhook.Backoff(1))); err != nil || n != 1 {
+		t.Fatalf("retried delivery should succeed, got %v delivered: %v", n, err)
+	}
+
+	deliveries, err := webhook.ListDeliveries(ctx, db, sub.Id, 0)
+	if err != nil {
+		t.Fatalf("listing deliveries: %s", err)
+	}
+	if exp, got := 1, len(deliveries); exp != got {
+		t.Fatalf("expected %v delivery, got %v", exp, got)
+	}
+	if d := deliveries[0]; d.Status != webhook.StatusDelivered || d.Attempts != 2 {
+		t.Fatalf("expected delivered after 2 attempts, got %s after %d", d.Status, d.Attempts)
+	}
+}
+
+// TestDeliverUnresponsive ensures a Subscription that times out is skipped for
+// the rest of a Deliver call while other Subscriptions are still posted to.
+func TestDeliverUnresponsive(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2021, time.June, 2, 6, 0, 0, 0, time.UTC)
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer slow.Close()
+
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer fast.Close()
+
+	const secret = "0123456789abcdef0123456789abcdef"
+	subs := map[string]string{}
+	for name, url := range map[string]string{"slow": slow.URL, "fast": fast.URL} {
+		sub, err := webhook.Create(ctx, db, webhook.NewSubscription{Url: url, Secret: secret, EventTypes: []string{"water.leak"}}, now)
+		if err != nil {
+			t.Fatalf("creating subscription: %s", err)
+		}
+		subs[name] = sub.Id
+	}
+
+	water := "ee72a90c-590c-11eb-ae93-0242ac130002"
+	for i := 0; i < 2; i++ {
+		ne := event.NewEvent{Type: "water.leak", Severity: event.SeverityWarning, StationId: &water, Message: "water.leak"}
+		if _, err := event.Record(ctx, db, ne, now.Add(time.Minute)); err != nil {
+			t.Fatalf("recording event: %s", err)
+		}
+	}
+
+	at := now.Add(2 * time.Minute)
+	if _, err := webhook.Enqueue(ctx, db, at); err != nil {
+		t.Fatalf("queueing deliveries: %s", err)
+	}
+
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+	if n, err := webhook.Deliver(ctx, db, client, at); err != nil || n != 2 {
+		t.Fatalf("expected both deliveries of the fast subscription, got %v delivered: %v", n, err)
+	}
+
+	// Only the first delivery of the slow subscription was attempted.
+	deliveries, err := webhook.ListDeliveries(ctx, db, subs["slow"], 0)
+	if err != nil {
+		t.Fatalf("listing deliveries: %s", err)
+	}
+	attempts := 0
+	for _, d := range deliveries {
+		if d.Status != webhook.StatusPending {
+			t.Fatalf("expected slow deliveries to stay pending, got %s", d.Status)
+		}
+		attempts += d.Attempts
+	}
+	if exp := 1; attempts != exp {
+		t.Fatalf("expected %v attempt at the slow subscription, got %v", exp, attempts)
+	}
+}
@@ -0,0 +1,56 @@
+package zone
+
+import (
+	// Core packages
+	"time"
+)
+
+/**
+ * Zone is a named area of the garden such as a bed or a planter box. Zones are rectangles laid out on the same
+ * integer grid as the location_x / location_y of stations. LocationX and LocationY define the lower left corner of
+ * the zone and Width and Height define its size in grid units.
+ */
+type Zone struct {
+	Id          string    `db:"id"           json:"id"`
+	Name        string    `db:"name"         json:"name"`
+	Description string    `db:"description"  json:"description"`
+	SoilType    string    `db:"soil_type"    json:"soil_type"`
+	LocationX   int       `db:"location_x"   json:"location_x"`
+	LocationY   int       `db:"location_y"   json:"location_y"`
+	Width       int       `db:"width"        json:"width"`
+	Height      int       `db:"height"       json:"height"`
+	Stations    int       `db:"stations"     json:"stations"`
+	DateCreated time.Time `db:"date_created" json:"date_created"`
+	DateUpdated time.Time `db:"date_updated" json:"date_updated"`
+}
+
+// NewZone is what we require from clients when adding a Zone.
+type NewZone struct {
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description"`
+	SoilType    string `json:"soil_type"`
+	LocationX   int    `json:"location_x" validate:"gte=0"`
+	LocationY   int    `json:"location_y" validate:"gte=0"`
+	Width       int    `json:"width" validate:"required,gt=0"`
+	Height      int    `json:"height" validate:"required,gt=0"`
+}
+
+// UpdateZone defines what information may be provided to modify an existing
+// Zone. All fields are optional so clients can send just the fields they want
+// changed. It uses pointer fields so we can differentiate between a field that
+// was not provided and a field that was provided as explicitly blank.
+type UpdateZone struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	SoilType    *string `json:"soil_type"`
+	LocationX   *int    `json:"location_x" validate:"omitempty,gte=0"`
+	LocationY   *int    `json:"location_y" validate:"omitempty,gte=0"`
+	Width       *int    `json:"width" validate:"omitempty,gt=0"`
+	Height      *int    `json:"height" validate:"omitempty,gt=0"`
+}
+
+// Contains reports if the grid point x, y falls within the bounds of the Zone.
+func (z Zone) Contains(x, y int) bool {
+	return x >= z.LocationX && x < z.LocationX+z.Width &&
+		y >= z.LocationY && y < z.LocationY+z.Height
+}
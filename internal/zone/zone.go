@@ -0,0 +1,224 @@
+package zone
+
+import (
+	// Core packages
+	"context"
+	"database/sql"
+	"time"
+
+	// Third-party packages
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+// Predefined errors identify expected failure conditions.
+var (
+	// ErrNotFound is used when a specific Zone is requested but does not exist.
+	ErrNotFound = errors.New("zone not found")
+
+	// ErrInvalidID is used when an invalid UUID is provided.
+	ErrInvalidID = errors.New("ID is not in its proper UUID format")
+)
+
+// Create adds a Zone to the database. It returns the created Zone with fields
+// like ID and DateCreated populated.
+func Create(ctx context.Context, db *sqlx.DB, nz NewZone, now time.Time) (*Zone, error) {
+
+	ctx, span := trace.StartSpan(ctx, "zone.Create")
+	defer span.End()
+
+	z := Zone{
+		Id:          uuid.New().String(),
+		Name:        nz.Name,
+		Description: nz.Description,
+		SoilType:    nz.SoilType,
+		LocationX:   nz.LocationX,
+		LocationY:   nz.LocationY,
+		Width:       nz.Width,
+		Height:      nz.Height,
+		DateCreated: now.UTC(),
+		DateUpdated: now.UTC(),
+	}
+
+	const q = `
+		INSERT INTO zone
+		  (id, name, description, soil_type, location_x, location_y, width, height, date_created, date_updated)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := db.ExecContext(ctx, q,
+		z.Id,
+		z.Name,
+		z.Description,
+		z.SoilType,
+		z.LocationX,
+		z.LocationY,
+		z.Width,
+		z.Height,
+		z.DateCreated,
+		z.DateUpdated,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "inserting zone")
+	}
+
+	return &z, nil
+}
+
+// Delete removes the zone identified by a given ID. Stations assigned to the
+// zone are kept but no longer belong to a zone.
+func Delete(ctx context.Context, db *sqlx.DB, id string) error {
+
+	ctx, span := trace.StartSpan(ctx, "zone.Delete")
+	defer span.End()
+
+	// Validate id is a valid uuid
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrInvalidID
+	}
+
+	const q = `DELETE FROM zone WHERE id = $1`
+
+	if _, err := db.ExecContext(ctx, q, id); err != nil {
+		return errors.Wrapf(err, "deleting zone %s", id)
+	}
+
+	return nil
+}
+
+// List gets all Zones from the database.
+func List(ctx context.Context, db *sqlx.DB) ([]Zone, error) {
+
+	ctx, span := trace.StartSpan(ctx, "zone.List")
+	defer span.End()
+
+	zones := []Zone{}
+
+	const q = `
+		SELECT
+			zone.id,
+			zone.name,
+			zone.description,
+			zone.soil_type,
+			zone.location_x,
+			zone.location_y,
+			zone.width,
+			zone.height,
+			COUNT(station.id) AS stations,
+			zone.date_created,
+			zone.date_updated
+		FROM zone
+		  LEFT JOIN station ON zone.id = station.zone_id AND station.date_deleted IS NULL
+		GROUP BY zone.id
+		ORDER BY zone.name`
+
+	if err := db.SelectContext(ctx, &zones, q); err != nil {
+		return nil, errors.Wrap(err, "selecting zones")
+	}
+
+	return zones, nil
+}
+
+// Get finds the Zone identified by a given ID.
+func Get(ctx context.Context, db *sqlx.DB, id string) (*Zone, error) {
+
+	ctx, span := trace.StartSpan(ctx, "zone.Get")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	var z Zone
+
+	const q = `
+		SELECT
+			zone.id,
+			zone.name,
+			zone.description,
+			zone.soil_type,
+			zone.location_x,
+			zone.location_y,
+			zone.width,
+			zone.height,
+			COUNT(station.id) AS stations,
+			zone.date_created,
+			zone.date_updated
+		FROM zone
+		  LEFT JOIN station ON zone.id = station.zone_id AND station.date_deleted IS NULL
+		WHERE zone.id = $1
+		GROUP BY zone.id`
+
+	if err := db.GetContext(ctx, &z, q, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+
+		return nil, errors.Wrap(err, "selecting single zone")
+	}
+
+	return &z, nil
+}
+
+// Update modifies data about a Zone. It will error if the specified ID is
+// invalid or does not reference an existing Zone.
+func Update(ctx context.Context, db *sqlx.DB, id string, update UpdateZone, now time.Time) error {
+
+	ctx, span := trace.StartSpan(ctx, "zone.Update")
+	defer span.End()
+
+	z, err := Get(ctx, db, id)
+	if err != nil {
+		return err
+	}
+
+	if update.Name != nil {
+		z.Name = *update.Name
+	}
+	if update.Description != nil {
+		z.Description = *update.Description
+	}
+	if update.SoilType != nil {
+		z.SoilType = *update.SoilType
+	}
+	if update.LocationX != nil {
+		z.LocationX = *update.LocationX
+	}
+	if update.LocationY != nil {
+		z.LocationY = *update.LocationY
+	}
+	if update.Width != nil {
+		z.Width = *update.Width
+	}
+	if update.Height != nil {
+		z.Height = *update.Height
+	}
+	z.DateUpdated = now
+
+	const q = `UPDATE zone SET
+		"name" = $2,
+		"description" = $3,
+		"soil_type" = $4,
+		"location_x" = $5,
+		"location_y" = $6,
+		"width" = $7,
+		"height" = $8,
+		"date_updated" = $9
+		WHERE id = $1`
+	_, err = db.ExecContext(ctx, q, id,
+		z.Name,
+		z.Description,
+		z.SoilType,
+		z.LocationX,
+		z.LocationY,
+		z.Width,
+		z.Height,
+		z.DateUpdated,
+	)
+	if err != nil {
+		return errors.Wrap(err, "updating zone")
+	}
+
+	return nil
+}
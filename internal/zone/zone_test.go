@@ -0,0 +1,131 @@
+package zone_test
+
+import (
+	// Core packages
+	"context"
+	"testing"
+	"time"
+
+	// Internal packages
+	"github.com/deezone/HydroBytes-BaseStation/internal/schema"
+	"github.com/deezone/HydroBytes-BaseStation/internal/tests"
+	"github.com/deezone/HydroBytes-BaseStation/internal/zone"
+
+	// Third-party packages
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestZone(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	nz := zone.NewZone{
+		Name:        "Tomato row",
+		Description: "Raised bed along the south railing.",
+		SoilType:    "Loam",
+		LocationX:   3,
+		LocationY:   2,
+		Width:       4,
+		Height:      2,
+	}
+	now := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	z0, err := zone.Create(ctx, db, nz, now)
+	if err != nil {
+		t.Fatalf("creating zone z0: %s", err)
+	}
+
+	// Invalid uuid
+	if _, err := zone.Get(ctx, db, "abc123"); err == nil {
+		t.Fatalf("getting invalid uuid zone abc123: %s", err)
+	}
+
+	z1, err := zone.Get(ctx, db, z0.Id)
+	if err != nil {
+		t.Fatalf("getting zone z0: %s", err)
+	}
+
+	if diff := cmp.Diff(z1, z0); diff != "" {
+		t.Fatalf("fetched != created:\n%s", diff)
+	}
+
+	update := zone.UpdateZone{
+		Name:     tests.StringPointer("Herb box"),
+		SoilType: tests.StringPointer("Potting mix"),
+		Width:    tests.IntPointer(2),
+	}
+	updatedTime := time.Date(2019, time.January, 1, 1, 1, 1, 0, time.UTC)
+
+	if err := zone.Update(ctx, db, z0.Id, update, updatedTime); err != nil {
+		t.Fatalf("updating zone z0: %s", err)
+	}
+
+	saved, err := zone.Get(ctx, db, z0.Id)
+	if err != nil {
+		t.Fatalf("getting zone z0: %s", err)
+	}
+
+	// Check specified fields were updated. Make a copy of the original zone
+	// and change just the fields we expect then diff it with what was saved.
+	want := *z0
+	want.Name = "Herb box"
+	want.SoilType = "Potting mix"
+	want.Width = 2
+	want.DateUpdated = updatedTime
+
+	if diff := cmp.Diff(want, *saved); diff != "" {
+		t.Fatalf("updated record did not match:\n%s", diff)
+	}
+
+	if err := zone.Delete(ctx, db, z0.Id); err != nil {
+		t.Fatalf("deleting zone z0: %s", err)
+	}
+
+	if _, err := zone.Get(ctx, db, z0.Id); err != zone.ErrNotFound {
+		t.Fatalf("getting deleted zone z0: expected %v, got %v", zone.ErrNotFound, err)
+	}
+}
+
+func TestZoneList(t *testing.T) {
+	db, teardown := tests.NewUnit(t)
+	defer teardown()
+
+	if err := schema.Seed(db); err != nil {
+		t.Fatal(err)
+	}
+
+	zones, err := zone.List(context.Background(), db)
+	if err != nil {
+		t.Fatalf("listing zones: %s", err)
+	}
+	if exp, got := 2, len(zones); exp != got {
+		t.Fatalf("expected zone list size %v, got %v", exp, got)
+	}
+
+	// Zones are sorted by name, the "Tomato row" has the three seeded plant stations.
+	if exp, got := 3, zones[1].Stations; exp != got {
+		t.Fatalf("expected %v stations in %q, got %v", exp, zones[1].Name, got)
+	}
+}
+
+func TestZoneContains(t *testing.T) {
+	z := zone.Zone{LocationX: 3, LocationY: 2, Width: 4, Height: 2}
+
+	tt := []struct {
+		x, y int
+		want bool
+	}{
+		{3, 2, true},
+		{6, 3, true},
+		{7, 3, false},
+		{5, 4, false},
+		{2, 2, false},
+	}
+
+	for _, tc := range tt {
+		if got := z.Contains(tc.x, tc.y); got != tc.want {
+			t.Errorf("Contains(%d, %d): expected %v, got %v", tc.x, tc.y, tc.want, got)
+		}
+	}
+}